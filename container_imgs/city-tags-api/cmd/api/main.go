@@ -1,9 +1,18 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
+	"time"
+	// Timezones of the cities are validated against the IANA database, which
+	// is not installed in the runtime image.
+	_ "time/tzdata"
 
 	"city-tags-api/internal/server"
 
@@ -37,9 +46,25 @@ func main() {
 		log.Fatalf("SERVER_PORT env variable error when parsing to integer %s", err.Error())
 	}
 
-	cityTApi := server.NewServer(port)
+	// Cloud Run sends SIGTERM before stopping an instance.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	cityTApi := server.NewServer(ctx, port)
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := cityTApi.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Error shutting down the server: %s", err.Error())
+		}
+	}()
+
 	log.Printf("Running server on %s", cityTApi.Addr)
-	if err := cityTApi.ListenAndServe(); err != nil {
+	if err := cityTApi.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
 		log.Fatal(err)
 	}
+	<-stopped
 }
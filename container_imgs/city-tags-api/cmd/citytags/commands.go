@@ -0,0 +1,399 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"city-tags-api/internal/vocabulary"
+	"city-tags-api/pkg/client"
+)
+
+// listFlags are the flags of the cities listings.
+type listFlags struct {
+	tags      map[string]*string
+	month     *int
+	season    *string
+	bbox      *string
+	continent *string
+	country   *string
+	offset    *int
+	limit     *int
+	pageSize  *int
+}
+
+func newListFlags(flags *flag.FlagSet) *listFlags {
+	list := &listFlags{tags: map[string]*string{}}
+	for _, category := range vocabulary.Categories {
+		list.tags[category.Name] = flags.String(category.Name, "", fmt.Sprintf("Only cities with this %s tag: %s", category.Name, strings.Join(category.Values, ", ")))
+	}
+	list.month = flags.Int("month", 0, "Month (1 to 12) the tag filters apply to")
+	list.season = flags.String("season", "", "Season (winter, spring, summer, autumn) the tag filters apply to")
+	list.bbox = flags.String("bbox", "", "Only cities inside the box minLon,minLat,maxLon,maxLat")
+	list.continent = flags.String("continent", "", "Only cities of this continent")
+	list.country = flags.String("country", "", "Only cities of this ISO 3166-1 alpha-3 country code")
+	list.offset = flags.Int("offset", 0, "Number of cities to skip")
+	list.limit = flags.Int("limit", 0, "Maximum number of cities to print, every page is fetched if 0")
+	list.pageSize = flags.Int("page-size", 0, "Number of cities fetched per request, 100 by default")
+	return list
+}
+
+func (list *listFlags) query() client.CitiesQuery {
+	query := client.CitiesQuery{
+		Tags:         map[string]string{},
+		Month:        *list.month,
+		Season:       *list.season,
+		BBox:         *list.bbox,
+		Continent:    *list.continent,
+		Country3Code: *list.country,
+		Offset:       *list.offset,
+		PageSize:     *list.pageSize,
+	}
+	for category, value := range list.tags {
+		if *value != "" {
+			query.Tags[category] = *value
+		}
+	}
+	if query.PageSize == 0 && *list.limit > 0 {
+		query.PageSize = min(*list.limit, 100)
+	}
+	return query
+}
+
+// printAll prints the items of the listing matching match, if not nil, until
+// limit items are printed if it is greater than 0.
+func printAll[T any](items *client.Iterator[T], p *printer, limit int, match func(T) bool, row func(T) []string) error {
+	for (limit <= 0 || p.count < limit) && items.Next() {
+		item := items.Value()
+		if match != nil && !match(item) {
+			continue
+		}
+		if err := p.print(item, row(item)); err != nil {
+			return err
+		}
+	}
+	if err := p.close(); err != nil {
+		return err
+	}
+	return items.Err()
+}
+
+func parseId(value string) (int, error) {
+	id, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, usageError{fmt.Sprintf("invalid city id %s", value)}
+	}
+	return id, nil
+}
+
+func runCities(ctx context.Context, args []string) error {
+	flags, opts := newFlagSet("cities")
+	list := newListFlags(flags)
+	if _, err := parse(flags, args, 0); err != nil {
+		return err
+	}
+	apiClient, p, err := opts.session(cityColumns, true)
+	if err != nil {
+		return err
+	}
+	return printAll(apiClient.Cities(ctx, list.query()), p, *list.limit, nil, cityRow)
+}
+
+// runSearch walks through the cities matching the filters and prints the
+// ones whose name contains the text, the API has no search by name.
+func runSearch(ctx context.Context, args []string) error {
+	flags, opts := newFlagSet("search")
+	list := newListFlags(flags)
+	positional, err := parse(flags, args, 1)
+	if err != nil {
+		return err
+	}
+	apiClient, p, err := opts.session(cityColumns, true)
+	if err != nil {
+		return err
+	}
+
+	text := strings.ToLower(positional[0])
+	matches := func(cityData client.CityData) bool {
+		for _, name := range append([]string{cityData.CityName}, cityData.AlternateNames...) {
+			if strings.Contains(strings.ToLower(name), text) {
+				return true
+			}
+		}
+		return false
+	}
+	query := list.query()
+	if *list.pageSize == 0 {
+		query.PageSize = 1000
+	}
+	return printAll(apiClient.Cities(ctx, query), p, *list.limit, matches, cityRow)
+}
+
+func runCity(ctx context.Context, args []string) error {
+	flags, opts := newFlagSet("city")
+	positional, err := parse(flags, args, 1)
+	if err != nil {
+		return err
+	}
+	cityId, err := parseId(positional[0])
+	if err != nil {
+		return err
+	}
+	apiClient, p, err := opts.session(cityColumns, false)
+	if err != nil {
+		return err
+	}
+
+	cityData, err := apiClient.GetCity(ctx, cityId)
+	if err != nil {
+		return err
+	}
+	if err := p.print(cityData, cityRow(cityData)); err != nil {
+		return err
+	}
+	return p.close()
+}
+
+func runTags(ctx context.Context, args []string) error {
+	flags, opts := newFlagSet("tags")
+	month := flags.Int("month", 0, "Month (1 to 12) to get the tags of")
+	season := flags.String("season", "", "Season (winter, spring, summer, autumn) to get the tags of")
+	asOf := flags.String("as-of", "", "Date (2006-01-02) or RFC 3339 timestamp to get the tags valid at that moment")
+	positional, err := parse(flags, args, 1)
+	if err != nil {
+		return err
+	}
+	cityId, err := parseId(positional[0])
+	if err != nil {
+		return err
+	}
+
+	query := client.TagsQuery{Month: *month, Season: *season}
+	if *asOf != "" {
+		if query.AsOf, err = time.Parse(time.DateOnly, *asOf); err != nil {
+			if query.AsOf, err = time.Parse(time.RFC3339, *asOf); err != nil {
+				return usageError{fmt.Sprintf("invalid -as-of %s", *asOf)}
+			}
+		}
+	}
+	apiClient, p, err := opts.session(tagsColumns(), false)
+	if err != nil {
+		return err
+	}
+
+	tagsData, err := apiClient.GetTags(ctx, cityId, query)
+	if err != nil {
+		return err
+	}
+	if err := p.print(tagsData, tagsRow(tagsData)); err != nil {
+		return err
+	}
+	return p.close()
+}
+
+func runHistory(ctx context.Context, args []string) error {
+	flags, opts := newFlagSet("history")
+	positional, err := parse(flags, args, 1)
+	if err != nil {
+		return err
+	}
+	cityId, err := parseId(positional[0])
+	if err != nil {
+		return err
+	}
+	apiClient, p, err := opts.session(append([]string{"valid_from", "valid_to"}, tagsColumns()...), true)
+	if err != nil {
+		return err
+	}
+	return printAll(apiClient.TagsHistory(ctx, cityId), p, 0, nil, versionRow)
+}
+
+func runNearby(ctx context.Context, args []string) error {
+	flags, opts := newFlagSet("nearby")
+	lat := flags.Float64("lat", 0, "Latitude of the center")
+	lon := flags.Float64("lon", 0, "Longitude of the center")
+	radius := flags.Float64("radius", 0, "Radius in km, 50 by default")
+	limit := flags.Int("limit", 0, "Maximum number of cities, 100 by default")
+	if _, err := parse(flags, args, 0); err != nil {
+		return err
+	}
+	set := map[string]bool{}
+	flags.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	if !set["lat"] || !set["lon"] {
+		return usageError{"-lat and -lon are required"}
+	}
+	apiClient, p, err := opts.session(append(cityColumns[:len(cityColumns):len(cityColumns)], "distance_km"), true)
+	if err != nil {
+		return err
+	}
+
+	cities, err := apiClient.NearbyCities(ctx, client.NearbyQuery{Lat: *lat, Lon: *lon, RadiusKm: *radius, Limit: *limit})
+	if err != nil {
+		return err
+	}
+	for _, city := range cities {
+		if err := p.print(city, append(cityRow(city.CityData), formatFloat(city.DistanceKm))); err != nil {
+			return err
+		}
+	}
+	return p.close()
+}
+
+// parseWeights reads weights like "temperature:2,humidity:0.5".
+func parseWeights(value string) (map[string]float64, error) {
+	weights := map[string]float64{}
+	if value == "" {
+		return weights, nil
+	}
+	for _, pair := range strings.Split(value, ",") {
+		category, weightParam, found := strings.Cut(pair, ":")
+		weight, err := strconv.ParseFloat(weightParam, 64)
+		if !found || err != nil {
+			return nil, usageError{fmt.Sprintf("invalid weight %s, must be category:weight", pair)}
+		}
+		weights[category] = weight
+	}
+	return weights, nil
+}
+
+func runSimilar(ctx context.Context, args []string) error {
+	flags, opts := newFlagSet("similar")
+	limit := flags.Int("limit", 0, "Number of cities, 10 by default")
+	continent := flags.String("continent", "", "Only cities of this continent")
+	country := flags.String("country", "", "Only cities of this ISO 3166-1 alpha-3 country code")
+	weightsParam := flags.String("weights", "", "Weight of each tag category, e.g. temperature:2,humidity:0.5")
+	positional, err := parse(flags, args, 1)
+	if err != nil {
+		return err
+	}
+	cityId, err := parseId(positional[0])
+	if err != nil {
+		return err
+	}
+	weights, err := parseWeights(*weightsParam)
+	if err != nil {
+		return err
+	}
+	apiClient, p, err := opts.session([]string{"city_id", "city_name", "continent", "country_3_code", "score"}, true)
+	if err != nil {
+		return err
+	}
+
+	similar, err := apiClient.SimilarCities(ctx, cityId, client.SimilarQuery{
+		Limit:        *limit,
+		Continent:    *continent,
+		Country3Code: *country,
+		Weights:      weights,
+	})
+	if err != nil {
+		return err
+	}
+	for _, city := range similar {
+		row := append(cityRow(city.CityData)[:4], formatFloat(city.Score))
+		if err := p.print(city, row); err != nil {
+			return err
+		}
+	}
+	return p.close()
+}
+
+func runCountries(ctx context.Context, args []string) error {
+	flags, opts := newFlagSet("countries")
+	continent := flags.String("continent", "", "Only countries of this continent")
+	if _, err := parse(flags, args, 0); err != nil {
+		return err
+	}
+	apiClient, p, err := opts.session(countryColumns, true)
+	if err != nil {
+		return err
+	}
+	return printAll(apiClient.Countries(ctx, *continent), p, 0, nil, countryRow)
+}
+
+func runCountry(ctx context.Context, args []string) error {
+	flags, opts := newFlagSet("country")
+	positional, err := parse(flags, args, 1)
+	if err != nil {
+		return err
+	}
+	apiClient, p, err := opts.session(countryColumns, false)
+	if err != nil {
+		return err
+	}
+
+	country, err := apiClient.GetCountry(ctx, positional[0])
+	if err != nil {
+		return err
+	}
+	if err := p.print(country, countryRow(country.CountryData)); err != nil {
+		return err
+	}
+	return p.close()
+}
+
+func runVocabulary(ctx context.Context, args []string) error {
+	flags, opts := newFlagSet("vocabulary")
+	if _, err := parse(flags, args, 0); err != nil {
+		return err
+	}
+	apiClient, p, err := opts.session([]string{"category", "value", "rank", "label"}, true)
+	if err != nil {
+		return err
+	}
+
+	categories, err := apiClient.Vocabulary(ctx)
+	if err != nil {
+		return err
+	}
+	for _, category := range categories {
+		rows := make([][]string, 0, len(category.Values))
+		for _, value := range category.Values {
+			rows = append(rows, []string{category.Category, value.Value, strconv.Itoa(value.Rank), value.Label})
+		}
+		if err := p.print(category, rows...); err != nil {
+			return err
+		}
+	}
+	return p.close()
+}
+
+// runProfiles lists the profiles of the config without their tokens.
+func runProfiles(ctx context.Context, args []string) error {
+	flags, opts := newFlagSet("profiles")
+	if _, err := parse(flags, args, 0); err != nil {
+		return err
+	}
+	config, err := loadConfig(*opts.config)
+	if err != nil {
+		return err
+	}
+
+	out := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(out, "PROFILE\tENDPOINT\tTOKEN\tDEFAULT")
+	for _, name := range sortedKeys(config.Profiles) {
+		profile := config.Profiles[name]
+		isDefault := ""
+		if name == config.DefaultProfile || (config.DefaultProfile == "" && name == defaultProfile) {
+			isDefault = "*"
+		}
+		fmt.Fprintf(out, "%s\t%s\t%t\t%s\n", name, profile.Endpoint, profile.Token != "", isDefault)
+	}
+	return out.Flush()
+}
+
+func sortedKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
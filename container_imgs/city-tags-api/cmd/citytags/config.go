@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+const defaultProfile = "default"
+
+// Profile is the API a command talks to.
+type Profile struct {
+	Endpoint string `yaml:"endpoint"`
+	Token    string `yaml:"token"`
+	Language string `yaml:"language"`
+}
+
+// Config is the file with the profiles, e.g.
+//
+//	default_profile: prod
+//	profiles:
+//	  prod:
+//	    endpoint: https://city-tags-api.com
+//	    token: eyJhbGciOi...
+//	  local:
+//	    endpoint: http://localhost:8080
+type Config struct {
+	DefaultProfile string             `yaml:"default_profile"`
+	Profiles       map[string]Profile `yaml:"profiles"`
+}
+
+// defaultConfigPath returns the config file of the user, citytags/config.yaml
+// in the user config directory.
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "citytags", "config.yaml")
+}
+
+// loadConfig reads the config file at path, a missing file is an empty
+// config so the profile can come from the environment alone.
+func loadConfig(path string) (*Config, error) {
+	config := &Config{Profiles: map[string]Profile{}}
+	if path == "" {
+		return config, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return config, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read config: %v", err)
+	}
+	if err := yaml.Unmarshal(content, config); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %v", path, err)
+	}
+	if config.Profiles == nil {
+		config.Profiles = map[string]Profile{}
+	}
+	return config, nil
+}
+
+// resolveProfile returns the profile named name, the CITYTAGS_PROFILE
+// environment variable, the default profile of the config or "default", in
+// that order. CITYTAGS_ENDPOINT, CITYTAGS_TOKEN and CITYTAGS_LANGUAGE override
+// the values of the profile.
+func (config *Config) resolveProfile(name string, getenv func(string) string) (string, Profile, error) {
+	explicit := name != "" || getenv("CITYTAGS_PROFILE") != ""
+	if name == "" {
+		name = getenv("CITYTAGS_PROFILE")
+	}
+	if name == "" {
+		name = config.DefaultProfile
+		explicit = name != ""
+	}
+	if name == "" {
+		name = defaultProfile
+	}
+
+	profile, ok := config.Profiles[name]
+	if !ok && explicit {
+		return name, Profile{}, fmt.Errorf("profile %s not found in the config", name)
+	}
+
+	overrides := map[string]*string{
+		"CITYTAGS_ENDPOINT": &profile.Endpoint,
+		"CITYTAGS_TOKEN":    &profile.Token,
+		"CITYTAGS_LANGUAGE": &profile.Language,
+	}
+	for variable, value := range overrides {
+		if override := getenv(variable); override != "" {
+			*value = override
+		}
+	}
+
+	if profile.Endpoint == "" {
+		return name, Profile{}, fmt.Errorf("no endpoint for profile %s, set it in the config or in CITYTAGS_ENDPOINT", name)
+	}
+	return name, profile, nil
+}
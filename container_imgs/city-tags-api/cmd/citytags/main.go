@@ -0,0 +1,178 @@
+// Command citytags queries the city tags API from a terminal. The endpoint and
+// token come from a profile of the config file, see Config, and the results
+// are printed as a table, JSON or CSV, fetching every page of the listings.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+
+	"city-tags-api/pkg/client"
+)
+
+type command struct {
+	args    string
+	summary string
+	run     func(ctx context.Context, args []string) error
+}
+
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"cities":     {"[filters]", "List the cities matching the filters", runCities},
+		"search":     {"[filters] <name>", "Search the cities whose name or alternate names contain name", runSearch},
+		"city":       {"<city id>", "Get a city", runCity},
+		"tags":       {"<city id>", "Get the tags of a city", runTags},
+		"history":    {"<city id>", "List every version of the tags of a city", runHistory},
+		"nearby":     {"-lat <lat> -lon <lon>", "List the cities around a point, closest first", runNearby},
+		"similar":    {"<city id>", "List the cities with the most similar tags", runSimilar},
+		"countries":  {"", "List the countries", runCountries},
+		"country":    {"<code>", "Get a country by its alpha-2 or alpha-3 code", runCountry},
+		"vocabulary": {"", "List the tag categories and their values", runVocabulary},
+		"profiles":   {"", "List the profiles of the config", runProfiles},
+	}
+}
+
+func usage(out io.Writer) {
+	fmt.Fprintf(out, "Usage: citytags <command> [flags] [args]\n\nCommands:\n")
+	for _, name := range sortedKeys(commands) {
+		fmt.Fprintf(out, "  %-11s %s\n", name, commands[name].summary)
+	}
+	fmt.Fprintf(out, "\nRun citytags <command> -h for the flags of a command.\n")
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage(os.Stderr)
+		os.Exit(2)
+	}
+	name := os.Args[1]
+	if name == "-h" || name == "-help" || name == "help" {
+		usage(os.Stdout)
+		return
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "citytags: unknown command %s\n\n", name)
+		usage(os.Stderr)
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	err := cmd.run(ctx, os.Args[2:])
+	stop()
+
+	var usageErr usageError
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+	case errors.As(err, &usageErr):
+		fmt.Fprintf(os.Stderr, "citytags %s: %v\n", name, err)
+		os.Exit(2)
+	default:
+		fmt.Fprintf(os.Stderr, "citytags %s: %s\n", name, describe(err))
+		os.Exit(1)
+	}
+}
+
+// usageError is returned for invalid flags or arguments.
+type usageError struct {
+	message string
+}
+
+func (err usageError) Error() string {
+	return err.message
+}
+
+// describe formats the errors of the API with their status code and invalid
+// parameters.
+func describe(err error) string {
+	var clientErr *client.Error
+	if !errors.As(err, &clientErr) {
+		return err.Error()
+	}
+
+	description := fmt.Sprintf("%d %s", clientErr.HttpCode, clientErr.Message)
+	params := make([]string, 0, len(clientErr.Errors))
+	for param, reason := range clientErr.Errors {
+		params = append(params, fmt.Sprintf("\n  %s: %s", param, reason))
+	}
+	sort.Strings(params)
+	return description + strings.Join(params, "")
+}
+
+// options are the flags shared by every command.
+type options struct {
+	config   *string
+	profile  *string
+	output   *string
+	language *string
+}
+
+func newFlagSet(name string) (*flag.FlagSet, *options) {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	opts := &options{
+		config:   flags.String("config", defaultConfigPath(), "Config file with the profiles"),
+		profile:  flags.String("profile", "", "Profile to use, CITYTAGS_PROFILE or the default profile of the config if empty"),
+		output:   flags.String("o", "table", fmt.Sprintf("Output format, one of: %s", strings.Join(formats, ", "))),
+		language: flags.String("lang", "", "Language of names and labels (en, es, de), the one of the profile if empty"),
+	}
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: citytags %s [flags] %s\n\n%s.\n\nFlags:\n", name, commands[name].args, commands[name].summary)
+		flags.PrintDefaults()
+	}
+	return flags, opts
+}
+
+// parse parses the flags and checks the number of positional arguments.
+func parse(flags *flag.FlagSet, args []string, positional int) ([]string, error) {
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	if flags.NArg() != positional {
+		return nil, usageError{fmt.Sprintf("expected %d arguments, got %d", positional, flags.NArg())}
+	}
+	return flags.Args(), nil
+}
+
+// client returns the client of the selected profile.
+func (opts *options) client() (*client.Client, error) {
+	config, err := loadConfig(*opts.config)
+	if err != nil {
+		return nil, err
+	}
+	_, profile, err := config.resolveProfile(*opts.profile, os.Getenv)
+	if err != nil {
+		return nil, err
+	}
+
+	clientOptions := []client.Option{client.WithToken(profile.Token)}
+	language := profile.Language
+	if *opts.language != "" {
+		language = *opts.language
+	}
+	if language != "" {
+		clientOptions = append(clientOptions, client.WithLanguage(language))
+	}
+	return client.New(profile.Endpoint, clientOptions...), nil
+}
+
+// session returns the client of the profile and a printer of the columns.
+func (opts *options) session(columns []string, list bool) (*client.Client, *printer, error) {
+	apiClient, err := opts.client()
+	if err != nil {
+		return nil, nil, err
+	}
+	p, err := newPrinter(*opts.output, os.Stdout, columns, list)
+	if err != nil {
+		return nil, nil, usageError{err.Error()}
+	}
+	return apiClient, p, nil
+}
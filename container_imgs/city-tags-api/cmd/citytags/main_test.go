@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"city-tags-api/pkg/client"
+)
+
+func TestResolveProfile(t *testing.T) {
+	config := &Config{
+		DefaultProfile: "prod",
+		Profiles: map[string]Profile{
+			"prod":  {Endpoint: "https://city-tags-api.com", Token: "prod_token"},
+			"local": {Endpoint: "http://localhost:8080"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		profile  string
+		env      map[string]string
+		expected Profile
+		isError  bool
+	}{
+		{"default profile", "", nil, config.Profiles["prod"], false},
+		{"flag", "local", map[string]string{"CITYTAGS_PROFILE": "prod"}, config.Profiles["local"], false},
+		{"environment", "", map[string]string{"CITYTAGS_PROFILE": "local"}, config.Profiles["local"], false},
+		{
+			"overrides",
+			"local",
+			map[string]string{"CITYTAGS_TOKEN": "env_token", "CITYTAGS_LANGUAGE": "es"},
+			Profile{Endpoint: "http://localhost:8080", Token: "env_token", Language: "es"},
+			false,
+		},
+		{"unknown profile", "staging", nil, Profile{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, profile, err := config.resolveProfile(tt.profile, func(key string) string { return tt.env[key] })
+			if (err != nil) != tt.isError || profile != tt.expected {
+				t.Errorf("resolveProfile(%s) = %v, %v; want %v", tt.profile, profile, err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestResolveProfile_environmentOnly(t *testing.T) {
+	config := &Config{}
+	env := map[string]string{"CITYTAGS_ENDPOINT": "http://localhost:8080"}
+
+	name, profile, err := config.resolveProfile("", func(key string) string { return env[key] })
+	if err != nil || name != defaultProfile || profile.Endpoint != env["CITYTAGS_ENDPOINT"] {
+		t.Errorf("resolveProfile without config = %s, %v, %v; want the endpoint of the environment", name, profile, err)
+	}
+
+	if _, _, err := config.resolveProfile("", func(string) string { return "" }); err == nil {
+		t.Errorf("resolveProfile without config nor environment returned no error")
+	}
+}
+
+func TestPrinter(t *testing.T) {
+	cities := []client.CityData{
+		{CityId: 1, CityName: "Río Gallegos", Continent: "South America", Country3Code: "ARG"},
+		{CityId: 2, CityName: "Luján, BA", Continent: "South America", Country3Code: "ARG"},
+	}
+	columns := []string{"city_id", "city_name"}
+
+	tests := []struct {
+		format   string
+		cities   []client.CityData
+		expected string
+	}{
+		{"table", cities, "CITY_ID  CITY_NAME\n1        Río Gallegos\n2        Luján, BA\n"},
+		{"csv", cities, "city_id,city_name\n1,Río Gallegos\n2,\"Luján, BA\"\n"},
+		{"json", cities[:1], "[\n  {\n    \"city_id\": 1,\n    \"city_name\": \"Río Gallegos\",\n    \"continent\": \"South America\",\n    \"country_3_code\": \"ARG\",\n    \"latitude\": null,\n    \"longitude\": null,\n    \"timezone\": null,\n    \"population\": null,\n    \"alternate_names\": null\n  }\n]\n"},
+		{"json", nil, "[]\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			p, err := newPrinter(tt.format, out, columns, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, city := range tt.cities {
+				if err := p.print(city, cityRow(city)[:2]); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := p.close(); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != tt.expected {
+				t.Errorf("%s printer wrote %q; want %q", tt.format, out.String(), tt.expected)
+			}
+		})
+	}
+}
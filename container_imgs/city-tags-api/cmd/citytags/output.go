@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"city-tags-api/internal/vocabulary"
+	"city-tags-api/pkg/client"
+)
+
+var formats = []string{"table", "json", "csv"}
+
+// printer writes the results of a command as they are fetched, as an aligned
+// table, a JSON value or CSV with a header.
+type printer struct {
+	format  string
+	list    bool
+	count   int
+	out     io.Writer
+	table   *tabwriter.Writer
+	csv     *csv.Writer
+	encoder *json.Encoder
+}
+
+// newPrinter returns a printer of rows with the given columns. JSON lists are
+// written as an array, unless list is false and a single value is printed.
+func newPrinter(format string, out io.Writer, columns []string, list bool) (*printer, error) {
+	p := &printer{format: format, list: list, out: out}
+	switch format {
+	case "table":
+		p.table = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(p.table, strings.ToUpper(strings.Join(columns, "\t")))
+	case "csv":
+		p.csv = csv.NewWriter(out)
+		if err := p.csv.Write(columns); err != nil {
+			return nil, err
+		}
+	case "json":
+		p.encoder = json.NewEncoder(out)
+		p.encoder.SetIndent("", "  ")
+	default:
+		return nil, fmt.Errorf("unknown output %s, must be one of: %s", format, strings.Join(formats, ", "))
+	}
+	return p, nil
+}
+
+// print writes value, as is in JSON and as the cells of its rows otherwise.
+func (p *printer) print(value any, rows ...[]string) error {
+	p.count++
+	switch p.format {
+	case "table":
+		for _, row := range rows {
+			fmt.Fprintln(p.table, strings.Join(row, "\t"))
+		}
+	case "csv":
+		return p.csv.WriteAll(rows)
+	case "json":
+		if !p.list {
+			return p.encoder.Encode(value)
+		}
+		separator := ",\n"
+		if p.count == 1 {
+			separator = "[\n"
+		}
+		if _, err := io.WriteString(p.out, separator); err != nil {
+			return err
+		}
+		encoded, err := json.MarshalIndent(value, "  ", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(p.out, "  %s", encoded)
+		return err
+	}
+	return nil
+}
+
+// close flushes the output, it must be called even if nothing was printed.
+func (p *printer) close() error {
+	switch p.format {
+	case "table":
+		return p.table.Flush()
+	case "csv":
+		p.csv.Flush()
+		return p.csv.Error()
+	case "json":
+		if !p.list {
+			return nil
+		}
+		closing := "\n]\n"
+		if p.count == 0 {
+			closing = "[]\n"
+		}
+		_, err := io.WriteString(p.out, closing)
+		return err
+	}
+	return nil
+}
+
+var cityColumns = []string{"city_id", "city_name", "continent", "country_3_code", "latitude", "longitude", "timezone", "population"}
+
+func optional[T any](value *T) string {
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprint(*value)
+}
+
+func cityRow(cityData client.CityData) []string {
+	return []string{
+		strconv.Itoa(cityData.CityId),
+		cityData.CityName,
+		cityData.Continent,
+		cityData.Country3Code,
+		optional(cityData.Latitude),
+		optional(cityData.Longitude),
+		optional(cityData.Timezone),
+		optional(cityData.Population),
+	}
+}
+
+func tagsColumns() []string {
+	columns := []string{"city_id"}
+	for _, category := range vocabulary.Categories {
+		columns = append(columns, category.Name)
+	}
+	return columns
+}
+
+func tagsRow(tagsData client.TagsData) []string {
+	values := map[string]string{
+		"cloud_coverage": tagsData.CloudCoverage,
+		"humidity":       tagsData.Humidity,
+		"temperature":    tagsData.Temp,
+		"precipitation":  tagsData.Precipitation,
+		"air_quality":    tagsData.AirQuality,
+		"daylight_hours": tagsData.DaylightHours,
+		"city_size":      tagsData.CitySize,
+	}
+	row := []string{strconv.Itoa(tagsData.CityId)}
+	for _, category := range vocabulary.Categories {
+		row = append(row, values[category.Name])
+	}
+	return row
+}
+
+func versionRow(version client.TagsVersion) []string {
+	validTo := ""
+	if version.ValidTo != nil {
+		validTo = version.ValidTo.Format(time.RFC3339)
+	}
+	return append([]string{version.ValidFrom.Format(time.RFC3339), validTo}, tagsRow(version.TagsData)...)
+}
+
+var countryColumns = []string{"alpha_3_code", "alpha_2_code", "name", "continent", "cities"}
+
+func countryRow(countryData client.CountryData) []string {
+	return []string{
+		countryData.Alpha3Code,
+		countryData.Alpha2Code,
+		countryData.Name,
+		countryData.Continent,
+		strconv.Itoa(countryData.Cities),
+	}
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"city-tags-api/internal/database"
+	"city-tags-api/internal/importer"
+
+	_ "github.com/joho/godotenv/autoload"
+)
+
+func main() {
+	citiesPath := flag.String("cities", "", "CSV file with the cities, same header as cities_table.csv")
+	tagsPath := flag.String("tags", "", "CSV file with the tags, same header as city_tags.csv")
+	batchSize := flag.Int("batch-size", 1000, "Number of rows upserted per transaction")
+	dryRun := flag.Bool("dry-run", false, "Validate the files without writing to the database")
+	reportPath := flag.String("report", "import_report.json", "File where the import report is written")
+	flag.Parse()
+
+	if *citiesPath == "" && *tagsPath == "" {
+		log.Fatal("At least one of -cities or -tags must be set")
+	}
+	if *batchSize <= 0 {
+		log.Fatal("-batch-size must be a positive integer")
+	}
+
+	db := database.New()
+	defer db.Close()
+
+	report, runErr := importer.New(db, *batchSize, *dryRun).Run(*citiesPath, *tagsPath)
+	if report != nil {
+		writeReport(*reportPath, report)
+		log.Printf(
+			"Cities: %d accepted, %d rejected, %d upserted. Tags: %d accepted, %d rejected, %d upserted. Report written to %s",
+			report.Cities.Accepted, report.Cities.Rejected, report.Cities.Upserted,
+			report.Tags.Accepted, report.Tags.Rejected, report.Tags.Upserted,
+			*reportPath,
+		)
+	}
+	if runErr != nil {
+		log.Fatalf("Import failed: %v", runErr)
+	}
+}
+
+func writeReport(path string, report *importer.Report) {
+	jsonReport, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		log.Fatalf("Error when marshaling report: %v", err)
+	}
+	if err := os.WriteFile(path, jsonReport, 0644); err != nil {
+		log.Fatalf("Unable to write report: %v", err)
+	}
+}
@@ -1,33 +1,231 @@
+// Command infinite_query load tests the city tags API. Workers send requests
+// picked from a weighted mix of scenarios, at an optional total rate, until the
+// duration or the number of requests is reached, then the latency percentiles,
+// throughput and status codes of every scenario are printed.
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"flag"
 	"fmt"
+	"io"
+	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"city-tags-api/pkg/client"
 )
 
 func main() {
-	apiEndpoint := "https://city-tags-api.com/v0/cities?limit=10&offset=200"
+	targetParam := flag.String("target", "http://localhost:8080", "URL of the API")
+	version := flag.String("version", "v1", "Version of the API (v0, v1)")
+	token := flag.String("token", os.Getenv("JWT"), "Bearer token, JWT by default")
+	mixParam := flag.String("mix", defaultMix, "Weight of each scenario (city, tags, cities, search)")
+	concurrency := flag.Int("concurrency", 10, "Number of concurrent workers")
+	rate := flag.Float64("rate", 0, "Total requests per second, unlimited if 0")
+	duration := flag.Duration("duration", 30*time.Second, "Duration of the test")
+	maxRequests := flag.Int("requests", 0, "Maximum number of requests, unlimited if 0")
+	timeout := flag.Duration("timeout", 10*time.Second, "Timeout of each request")
+	discover := flag.Int("discover", 1000, "Number of city ids fetched before the test to build the requests")
+	cityIdsParam := flag.String("city-ids", "", "Comma separated city ids to use instead of discovering them")
+	jsonPath := flag.String("json", "", "File to write the summary as JSON to, - for stdout")
+	flag.Parse()
+
+	if *concurrency < 1 || *rate < 0 || *duration <= 0 {
+		log.Fatal("-concurrency and -duration must be positive and -rate not negative")
+	}
+	scenarioMix, err := parseMix(*mixParam)
+	if err != nil {
+		log.Fatal(err)
+	}
+	target := strings.TrimSuffix(*targetParam, "/")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	cityIds, err := parseCityIds(*cityIdsParam)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(cityIds) == 0 {
+		if cityIds, err = discoverCityIds(ctx, target, *token, *discover); err != nil {
+			log.Fatalf("failed to discover city ids: %v", err)
+		}
+	}
+	if len(cityIds) == 0 {
+		log.Fatal("no city to query")
+	}
+
+	httpClient := &http.Client{
+		Timeout:   *timeout,
+		Transport: &http.Transport{MaxIdleConnsPerHost: *concurrency},
+	}
+	l := &loader{
+		httpClient:  httpClient,
+		baseUrl:     target + "/" + *version,
+		token:       *token,
+		mix:         scenarioMix,
+		cityIds:     cityIds,
+		maxRequests: int64(*maxRequests),
+		recorder:    newRecorder(),
+	}
+
+	log.Printf("Running %s against %s with %d workers for %s", *mixParam, l.baseUrl, *concurrency, *duration)
+	startedAt := time.Now()
+	l.run(ctx, *concurrency, *rate, *duration)
+	elapsed := time.Since(startedAt)
+
+	total, scenarioSummaries := l.recorder.summary(elapsed)
+	summary := &Summary{
+		Target:      l.baseUrl,
+		StartedAt:   startedAt.UTC(),
+		DurationSec: elapsed.Seconds(),
+		Concurrency: *concurrency,
+		Rate:        *rate,
+		Mix:         scenarioMix.weightsByName(),
+		Total:       total,
+		Scenarios:   scenarioSummaries,
+	}
+	if *jsonPath != "-" {
+		if err := summary.print(os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *jsonPath != "" {
+		if err := writeJson(*jsonPath, summary); err != nil {
+			log.Fatalf("failed to write the summary: %v", err)
+		}
+	}
+}
 
-	for {
-		err := makeRequest(apiEndpoint)
+func parseCityIds(value string) ([]int, error) {
+	var cityIds []int
+	if value == "" {
+		return cityIds, nil
+	}
+	for _, param := range strings.Split(value, ",") {
+		cityId, err := strconv.Atoi(strings.TrimSpace(param))
 		if err != nil {
-			fmt.Printf("Error making request: %v\n", err)
+			return nil, fmt.Errorf("invalid city id %s", param)
 		}
+		cityIds = append(cityIds, cityId)
 	}
+	return cityIds, nil
 }
 
-func makeRequest(url string) error {
-	resp, err := http.Get(url)
-	if err != nil {
-		return fmt.Errorf("failed to make GET request: %v", err)
+// discoverCityIds returns the ids of the first count cities of the API.
+func discoverCityIds(ctx context.Context, target, token string, count int) ([]int, error) {
+	apiClient := client.New(target, client.WithToken(token))
+	cities := apiClient.Cities(ctx, client.CitiesQuery{PageSize: min(count, 1000)})
+	var cityIds []int
+	for len(cityIds) < count && cities.Next() {
+		cityIds = append(cityIds, cities.Value().CityId)
+	}
+	return cityIds, cities.Err()
+}
+
+func writeJson(path string, summary *Summary) error {
+	out := io.Writer(os.Stdout)
+	if path != "-" {
+		file, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(summary)
+}
+
+// loader sends the requests of the test and records their results.
+type loader struct {
+	httpClient  *http.Client
+	baseUrl     string
+	token       string
+	mix         *mix
+	cityIds     []int
+	maxRequests int64
+	sent        atomic.Int64
+	recorder    *recorder
+}
+
+// run starts the workers and waits for them to stop, after duration, when the
+// maximum number of requests is sent or when ctx is cancelled.
+func (l *loader) run(ctx context.Context, concurrency int, rate float64, duration time.Duration) {
+	ctx, cancel := context.WithTimeout(ctx, duration)
+	defer cancel()
+
+	// Without rate the workers send requests as fast as they get responses,
+	// otherwise each request waits for a tick shared by every worker.
+	var ticks <-chan time.Time
+	if rate > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / rate))
+		defer ticker.Stop()
+		ticks = ticker.C
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	var wg sync.WaitGroup
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				if ticks != nil {
+					select {
+					case <-ctx.Done():
+						return
+					case <-ticks:
+					}
+				}
+				if ctx.Err() != nil || !l.reserve() {
+					return
+				}
+				l.send(ctx, l.mix.pick())
+			}
+		}()
 	}
+	wg.Wait()
+}
 
-	fmt.Println("Request successful")
+// reserve returns false once the maximum number of requests is sent.
+func (l *loader) reserve() bool {
+	return l.maxRequests <= 0 || l.sent.Add(1) <= l.maxRequests
+}
 
-	return nil
+func (l *loader) send(ctx context.Context, scenario string) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, l.baseUrl+scenarios[scenario](l.cityIds), nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if l.token != "" {
+		req.Header.Set("Authorization", "Bearer "+l.token)
+	}
+
+	start := time.Now()
+	resp, err := l.httpClient.Do(req)
+	if err == nil {
+		// The body is read so that the connection is reused and the latency
+		// includes the transfer of the response.
+		_, err = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+	latency := time.Since(start)
+	if ctx.Err() != nil {
+		// Requests interrupted by the end of the test are not counted.
+		return
+	}
+	statusCode := 0
+	if resp != nil {
+		statusCode = resp.StatusCode
+	}
+	l.recorder.record(scenario, latency, statusCode, err)
 }
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"city-tags-api/internal/vocabulary"
+)
+
+const defaultMix = "city=4,tags=4,cities=1,search=1"
+
+// scenarios build the path of a request, relative to the API version, for a
+// random city of cityIds.
+var scenarios = map[string]func(cityIds []int) string{
+	"city": func(cityIds []int) string {
+		return fmt.Sprintf("/cities/%d", cityIds[rand.IntN(len(cityIds))])
+	},
+	"tags": func(cityIds []int) string {
+		return fmt.Sprintf("/cities/%d/tags", cityIds[rand.IntN(len(cityIds))])
+	},
+	"cities": func(cityIds []int) string {
+		return fmt.Sprintf("/cities?offset=%d&limit=100", rand.IntN(len(cityIds)))
+	},
+	// search filters the cities by a random tag, a third of them by the tag of
+	// a random month.
+	"search": func(cityIds []int) string {
+		category := vocabulary.Categories[rand.IntN(len(vocabulary.Categories))]
+		query := url.Values{}
+		query.Set(category.Name, category.Values[rand.IntN(len(category.Values))])
+		if rand.IntN(3) == 0 {
+			query.Set("month", strconv.Itoa(1+rand.IntN(12)))
+		}
+		return "/cities?" + query.Encode()
+	},
+}
+
+// mix picks the scenarios at random in proportion to their weights.
+type mix struct {
+	names   []string
+	weights []int
+	total   int
+}
+
+// parseMix reads weights like "city=4,tags=4,cities=1,search=1", the
+// scenarios not listed are never run.
+func parseMix(value string) (*mix, error) {
+	weights := map[string]int{}
+	for _, pair := range strings.Split(value, ",") {
+		name, weightParam, found := strings.Cut(strings.TrimSpace(pair), "=")
+		weight, err := strconv.Atoi(weightParam)
+		if !found || err != nil || weight < 0 {
+			return nil, fmt.Errorf("invalid scenario weight %s, must be scenario=weight", pair)
+		}
+		if _, ok := scenarios[name]; !ok {
+			return nil, fmt.Errorf("unknown scenario %s", name)
+		}
+		weights[name] += weight
+	}
+
+	m := &mix{}
+	for name, weight := range weights {
+		if weight > 0 {
+			m.names = append(m.names, name)
+		}
+	}
+	if len(m.names) == 0 {
+		return nil, fmt.Errorf("at least one scenario must have a positive weight")
+	}
+	sort.Strings(m.names)
+	for _, name := range m.names {
+		m.weights = append(m.weights, weights[name])
+		m.total += weights[name]
+	}
+	return m, nil
+}
+
+func (m *mix) pick() string {
+	n := rand.IntN(m.total)
+	for index, weight := range m.weights {
+		if n < weight {
+			return m.names[index]
+		}
+		n -= weight
+	}
+	return m.names[len(m.names)-1]
+}
+
+// weightsByName returns the weight of every scenario of the mix.
+func (m *mix) weightsByName() map[string]int {
+	weights := make(map[string]int, len(m.names))
+	for index, name := range m.names {
+		weights[name] = m.weights[index]
+	}
+	return weights
+}
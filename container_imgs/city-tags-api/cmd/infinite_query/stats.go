@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// samples are the results of the requests of a scenario.
+type samples struct {
+	latencies   []time.Duration
+	statusCodes map[int]int
+	errors      int
+}
+
+// recorder collects the results of every worker.
+type recorder struct {
+	mu        sync.Mutex
+	scenarios map[string]*samples
+}
+
+func newRecorder() *recorder {
+	return &recorder{scenarios: map[string]*samples{}}
+}
+
+// record stores the result of a request, err is set when no response was
+// received and then statusCode is ignored.
+func (rec *recorder) record(scenario string, latency time.Duration, statusCode int, err error) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	s, ok := rec.scenarios[scenario]
+	if !ok {
+		s = &samples{statusCodes: map[int]int{}}
+		rec.scenarios[scenario] = s
+	}
+	if err != nil {
+		s.errors++
+		return
+	}
+	s.latencies = append(s.latencies, latency)
+	s.statusCodes[statusCode]++
+}
+
+type LatencySummary struct {
+	Min  float64 `json:"min"`
+	Mean float64 `json:"mean"`
+	P50  float64 `json:"p50"`
+	P90  float64 `json:"p90"`
+	P95  float64 `json:"p95"`
+	P99  float64 `json:"p99"`
+	Max  float64 `json:"max"`
+}
+
+type ScenarioSummary struct {
+	Requests int `json:"requests"`
+	// Errors are the requests without response, like timeouts.
+	Errors      int            `json:"errors"`
+	Throughput  float64        `json:"throughput_rps"`
+	StatusCodes map[string]int `json:"status_codes"`
+	LatencyMs   LatencySummary `json:"latency_ms"`
+}
+
+type Summary struct {
+	Target      string                     `json:"target"`
+	StartedAt   time.Time                  `json:"started_at"`
+	DurationSec float64                    `json:"duration_sec"`
+	Concurrency int                        `json:"concurrency"`
+	Rate        float64                    `json:"rate"`
+	Mix         map[string]int             `json:"mix"`
+	Total       ScenarioSummary            `json:"total"`
+	Scenarios   map[string]ScenarioSummary `json:"scenarios"`
+}
+
+// percentile returns the nearest rank percentile of sorted latencies.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(p/100*float64(len(sorted))+0.999999999) - 1
+	return sorted[min(max(rank, 0), len(sorted)-1)]
+}
+
+func milliseconds(duration time.Duration) float64 {
+	return float64(duration.Microseconds()) / 1000
+}
+
+func summarize(s *samples, elapsed time.Duration) ScenarioSummary {
+	summary := ScenarioSummary{
+		Requests:    len(s.latencies) + s.errors,
+		Errors:      s.errors,
+		StatusCodes: make(map[string]int, len(s.statusCodes)),
+	}
+	if elapsed > 0 {
+		summary.Throughput = float64(summary.Requests) / elapsed.Seconds()
+	}
+	for code, count := range s.statusCodes {
+		summary.StatusCodes[strconv.Itoa(code)] = count
+	}
+	if len(s.latencies) == 0 {
+		return summary
+	}
+
+	sorted := make([]time.Duration, len(s.latencies))
+	copy(sorted, s.latencies)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	var total time.Duration
+	for _, latency := range sorted {
+		total += latency
+	}
+	summary.LatencyMs = LatencySummary{
+		Min:  milliseconds(sorted[0]),
+		Mean: milliseconds(total / time.Duration(len(sorted))),
+		P50:  milliseconds(percentile(sorted, 50)),
+		P90:  milliseconds(percentile(sorted, 90)),
+		P95:  milliseconds(percentile(sorted, 95)),
+		P99:  milliseconds(percentile(sorted, 99)),
+		Max:  milliseconds(sorted[len(sorted)-1]),
+	}
+	return summary
+}
+
+// summary returns the statistics of every scenario and of all of them
+// together over the elapsed time.
+func (rec *recorder) summary(elapsed time.Duration) (ScenarioSummary, map[string]ScenarioSummary) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	all := &samples{statusCodes: map[int]int{}}
+	scenarioSummaries := make(map[string]ScenarioSummary, len(rec.scenarios))
+	for name, s := range rec.scenarios {
+		scenarioSummaries[name] = summarize(s, elapsed)
+		all.latencies = append(all.latencies, s.latencies...)
+		all.errors += s.errors
+		for code, count := range s.statusCodes {
+			all.statusCodes[code] += count
+		}
+	}
+	return summarize(all, elapsed), scenarioSummaries
+}
+
+// print writes the summary as a table, one row per scenario and the total.
+func (summary *Summary) print(out io.Writer) error {
+	fmt.Fprintf(out, "Target %s, %d workers, %.1fs\n\n", summary.Target, summary.Concurrency, summary.DurationSec)
+
+	table := tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(table, "SCENARIO\tREQUESTS\tERRORS\tRPS\tMEAN MS\tP50 MS\tP90 MS\tP95 MS\tP99 MS\tMAX MS\tSTATUS CODES\t")
+	row := func(name string, s ScenarioSummary) {
+		codes := make([]string, 0, len(s.StatusCodes))
+		for code, count := range s.StatusCodes {
+			codes = append(codes, fmt.Sprintf("%s:%d", code, count))
+		}
+		sort.Strings(codes)
+		latency := s.LatencyMs
+		fmt.Fprintf(table, "%s\t%d\t%d\t%.1f\t%.1f\t%.1f\t%.1f\t%.1f\t%.1f\t%.1f\t%s\t\n",
+			name, s.Requests, s.Errors, s.Throughput,
+			latency.Mean, latency.P50, latency.P90, latency.P95, latency.P99, latency.Max,
+			strings.Join(codes, " "),
+		)
+	}
+
+	names := make([]string, 0, len(summary.Scenarios))
+	for name := range summary.Scenarios {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		row(name, summary.Scenarios[name])
+	}
+	row("total", summary.Total)
+	return table.Flush()
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPercentile(t *testing.T) {
+	sorted := make([]time.Duration, 100)
+	for index := range sorted {
+		sorted[index] = time.Duration(index+1) * time.Millisecond
+	}
+
+	for p, expected := range map[float64]time.Duration{
+		50:  50 * time.Millisecond,
+		90:  90 * time.Millisecond,
+		99:  99 * time.Millisecond,
+		100: 100 * time.Millisecond,
+	} {
+		if actual := percentile(sorted, p); actual != expected {
+			t.Errorf("p%v = %s, expected %s", p, actual, expected)
+		}
+	}
+	if actual := percentile(sorted[:1], 99); actual != time.Millisecond {
+		t.Errorf("p99 of a single latency = %s, expected 1ms", actual)
+	}
+}
+
+func TestRecorderSummary(t *testing.T) {
+	rec := newRecorder()
+	rec.record("city", 10*time.Millisecond, 200, nil)
+	rec.record("city", 30*time.Millisecond, 404, nil)
+	rec.record("tags", 20*time.Millisecond, 200, nil)
+	rec.record("tags", 0, 0, context.DeadlineExceeded)
+
+	total, scenarioSummaries := rec.summary(2 * time.Second)
+	if total.Requests != 4 || total.Errors != 1 || total.Throughput != 2 {
+		t.Errorf("unexpected total %+v", total)
+	}
+	if total.StatusCodes["200"] != 2 || total.StatusCodes["404"] != 1 {
+		t.Errorf("unexpected status codes %v", total.StatusCodes)
+	}
+	if total.LatencyMs.Min != 10 || total.LatencyMs.Max != 30 || total.LatencyMs.Mean != 20 {
+		t.Errorf("unexpected latencies %+v", total.LatencyMs)
+	}
+	if city := scenarioSummaries["city"]; city.Requests != 2 || city.LatencyMs.P50 != 10 {
+		t.Errorf("unexpected city summary %+v", city)
+	}
+}
+
+func TestParseMix(t *testing.T) {
+	m, err := parseMix("city=3, tags=1,search=0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	weights := m.weightsByName()
+	if len(weights) != 2 || weights["city"] != 3 || weights["tags"] != 1 {
+		t.Errorf("unexpected weights %v", weights)
+	}
+
+	for _, value := range []string{"city", "city=x", "city=-1", "unknown=1", "city=0"} {
+		if _, err := parseMix(value); err == nil {
+			t.Errorf("expected an error for %s", value)
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"slices"
+	"time"
+
+	"city-tags-api/internal/api_errors"
+	"city-tags-api/pkg/client"
+)
+
+// maxFailures is the number of failures reported by a check, the rest are
+// only counted.
+const maxFailures = 20
+
+// errSkipped is returned by the checks without anything to check.
+var errSkipped = errors.New("nothing to check")
+
+type check struct {
+	name string
+	// run returns the failures found, err is set when the check could not run.
+	run func(ctx context.Context, m *monitor) (failures []string, err error)
+}
+
+var checks = []check{
+	{"known_cities", checkKnownCities},
+	{"pagination", checkPagination},
+	{"error_schemas", checkErrorSchemas},
+}
+
+// Result is a log entry, severity and message are read by Cloud Logging.
+type Result struct {
+	Severity   string   `json:"severity"`
+	Message    string   `json:"message"`
+	Check      string   `json:"check"`
+	Passed     bool     `json:"passed"`
+	Skipped    bool     `json:"skipped,omitempty"`
+	DurationMs int64    `json:"duration_ms"`
+	Failures   []string `json:"failures,omitempty"`
+	// FailureCount includes the failures not listed over maxFailures.
+	FailureCount int `json:"failure_count"`
+}
+
+func severity(passed bool) string {
+	if passed {
+		return "INFO"
+	}
+	return "ERROR"
+}
+
+// run runs every check, one after the other.
+func (m *monitor) run(ctx context.Context) []Result {
+	results := make([]Result, 0, len(checks))
+	for _, c := range checks {
+		start := time.Now()
+		failures, err := c.run(ctx, m)
+		result := Result{
+			Check:        c.name,
+			DurationMs:   time.Since(start).Milliseconds(),
+			Skipped:      errors.Is(err, errSkipped),
+			FailureCount: len(failures),
+		}
+		if err != nil && !result.Skipped {
+			failures = append(failures, fmt.Sprintf("check could not run: %v", err))
+			result.FailureCount++
+		}
+		result.Passed = len(failures) == 0
+		result.Failures = failures[:min(len(failures), maxFailures)]
+		result.Severity = severity(result.Passed)
+		switch {
+		case result.Skipped:
+			result.Message = fmt.Sprintf("%s skipped", c.name)
+		case result.Passed:
+			result.Message = fmt.Sprintf("%s passed", c.name)
+		default:
+			result.Message = fmt.Sprintf("%s failed with %d failures", c.name, result.FailureCount)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// checkKnownCities checks that the cities of the expectations have the
+// expected attributes and annual tags.
+func checkKnownCities(ctx context.Context, m *monitor) ([]string, error) {
+	if len(m.expectations) == 0 {
+		return nil, errSkipped
+	}
+
+	var failures []string
+	for _, expected := range m.expectations {
+		cityData, err := m.apiClient.GetCity(ctx, expected.CityId)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("city %d: %v", expected.CityId, err))
+			continue
+		}
+		if expected.CityName != "" && cityData.CityName != expected.CityName {
+			failures = append(failures, fmt.Sprintf("city %d: name is %s, expected %s", expected.CityId, cityData.CityName, expected.CityName))
+		}
+		if expected.Country3Code != "" && cityData.Country3Code != expected.Country3Code {
+			failures = append(failures, fmt.Sprintf("city %d: country is %s, expected %s", expected.CityId, cityData.Country3Code, expected.Country3Code))
+		}
+		if len(expected.Tags) == 0 {
+			continue
+		}
+
+		tagsData, err := m.apiClient.GetTags(ctx, expected.CityId, client.TagsQuery{})
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("city %d tags: %v", expected.CityId, err))
+			continue
+		}
+		tags, err := tagsByCategory(tagsData)
+		if err != nil {
+			return failures, err
+		}
+		for category, value := range expected.Tags {
+			if tags[category] != value {
+				failures = append(failures, fmt.Sprintf("city %d: %s is %q, expected %q", expected.CityId, category, tags[category], value))
+			}
+		}
+	}
+	return failures, nil
+}
+
+// tagsByCategory returns the tags keyed by their category name, as they are
+// serialized.
+func tagsByCategory(tagsData client.TagsData) (map[string]string, error) {
+	encoded, err := json.Marshal(tagsData)
+	if err != nil {
+		return nil, err
+	}
+	tags := map[string]any{}
+	if err := json.Unmarshal(encoded, &tags); err != nil {
+		return nil, err
+	}
+	values := make(map[string]string, len(tags))
+	for category, value := range tags {
+		if text, ok := value.(string); ok {
+			values[category] = text
+		}
+	}
+	return values, nil
+}
+
+// checkPagination walks through every page of the cities and checks that each
+// city is listed once, in order of city id as the listings are sorted, and
+// that they are as many as the cities of every country.
+func checkPagination(ctx context.Context, m *monitor) ([]string, error) {
+	var failures []string
+	seen := map[int]bool{}
+	previous := 0
+	cities := m.apiClient.Cities(ctx, client.CitiesQuery{PageSize: m.pageSize})
+	for cities.Next() {
+		cityId := cities.Value().CityId
+		if seen[cityId] {
+			failures = append(failures, fmt.Sprintf("city %d listed more than once", cityId))
+		} else if cityId < previous {
+			failures = append(failures, fmt.Sprintf("city %d listed after city %d", cityId, previous))
+		}
+		seen[cityId] = true
+		previous = max(previous, cityId)
+	}
+	if err := cities.Err(); err != nil {
+		return failures, err
+	}
+
+	expected := 0
+	countries := m.apiClient.Countries(ctx, "")
+	for countries.Next() {
+		expected += countries.Value().Cities
+	}
+	if err := countries.Err(); err != nil {
+		return failures, err
+	}
+	if len(seen) != expected {
+		failures = append(failures, fmt.Sprintf("%d cities listed, the countries have %d", len(seen), expected))
+	}
+	return failures, nil
+}
+
+// errorCase is a request that must fail with status. Param is the invalid
+// parameter the error must report, if any.
+type errorCase struct {
+	version string
+	path    string
+	noToken bool
+	status  int
+	param   string
+}
+
+var errorCases = []errorCase{
+	{version: "v0", path: "/cities/invalid", status: http.StatusBadRequest, param: "cityId"},
+	{version: "v0", path: "/cities/2147483647", status: http.StatusNotFound},
+	{version: "v0", path: "/cities", noToken: true, status: http.StatusUnauthorized},
+	{version: "v1", path: "/cities/invalid", status: http.StatusBadRequest, param: "cityId"},
+	{version: "v1", path: "/cities/2147483647", status: http.StatusNotFound},
+	{version: "v1", path: "/cities?temperature=invalid", status: http.StatusBadRequest, param: "temperature"},
+	{version: "v1", path: "/cities", noToken: true, status: http.StatusUnauthorized},
+}
+
+// checkErrorSchemas checks that the errors of v0 are ClientErr and the ones of
+// v1 problem details, with no unknown field.
+func checkErrorSchemas(ctx context.Context, m *monitor) ([]string, error) {
+	var failures []string
+	for _, errCase := range errorCases {
+		if err := m.checkError(ctx, errCase); err != nil {
+			failures = append(failures, fmt.Sprintf("GET /%s%s: %v", errCase.version, errCase.path, err))
+		}
+	}
+	return failures, nil
+}
+
+func (m *monitor) checkError(ctx context.Context, errCase errorCase) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.endpoint+"/"+errCase.version+errCase.path, nil)
+	if err != nil {
+		return err
+	}
+	if !errCase.noToken {
+		req.Header.Set("Authorization", "Bearer "+m.token)
+	}
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != errCase.status {
+		return fmt.Errorf("status code %d, expected %d", resp.StatusCode, errCase.status)
+	}
+	contentType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	decoder := json.NewDecoder(resp.Body)
+	decoder.DisallowUnknownFields()
+
+	if errCase.version == "v0" {
+		if contentType != "application/json" {
+			return fmt.Errorf("content type %s, expected application/json", contentType)
+		}
+		clientErr := api_errors.ClientErr{}
+		if err := decoder.Decode(&clientErr); err != nil {
+			return fmt.Errorf("invalid error body: %v", err)
+		}
+		return validateClientErr(clientErr, errCase)
+	}
+
+	if contentType != "application/problem+json" {
+		return fmt.Errorf("content type %s, expected application/problem+json", contentType)
+	}
+	problem := api_errors.Problem{}
+	if err := decoder.Decode(&problem); err != nil {
+		return fmt.Errorf("invalid problem body: %v", err)
+	}
+	return validateProblem(problem, errCase)
+}
+
+func validateClientErr(clientErr api_errors.ClientErr, errCase errorCase) error {
+	if clientErr.HttpCode != errCase.status {
+		return fmt.Errorf("code %d, expected %d", clientErr.HttpCode, errCase.status)
+	}
+	if clientErr.Message == "" {
+		return errors.New("empty message")
+	}
+	if _, ok := clientErr.Errors[errCase.param]; errCase.param != "" && !ok {
+		return fmt.Errorf("errors do not report %s", errCase.param)
+	}
+	return nil
+}
+
+func validateProblem(problem api_errors.Problem, errCase errorCase) error {
+	if problem.Type == "" {
+		return errors.New("empty type")
+	}
+	if problem.Status != errCase.status {
+		return fmt.Errorf("status %d, expected %d", problem.Status, errCase.status)
+	}
+	if problem.Title != http.StatusText(errCase.status) {
+		return fmt.Errorf("title %q, expected %q", problem.Title, http.StatusText(errCase.status))
+	}
+	reported := slices.ContainsFunc(problem.InvalidParams, func(param api_errors.InvalidParam) bool {
+		return param.Name == errCase.param && param.Reason != ""
+	})
+	if errCase.param != "" && !reported {
+		return fmt.Errorf("invalid_params do not report %s", errCase.param)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"city-tags-api/internal/api_errors"
+)
+
+// fakeApi serves the cities of cityIds page by page, like v1, and one country
+// with countryCities cities.
+func fakeApi(t *testing.T, cityIds []int, countryCities int) *monitor {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/cities", func(w http.ResponseWriter, r *http.Request) {
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		cities := []map[string]any{}
+		for _, cityId := range cityIds[min(offset, len(cityIds)):min(offset+limit, len(cityIds))] {
+			cities = append(cities, map[string]any{"city_id": cityId})
+		}
+		page := map[string]any{"offset": offset, "limit": limit, "next_offset": nil}
+		if len(cities) == limit {
+			page["next_offset"] = offset + limit
+		}
+		json.NewEncoder(w).Encode(map[string]any{"cities": cities, "page": page})
+	})
+	mux.HandleFunc("GET /v1/countries", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"countries": []map[string]any{{"alpha_3_code": "ESP", "cities": countryCities}},
+			"page":      map[string]any{"offset": 0, "limit": 100, "next_offset": nil},
+		})
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	m, err := newMonitor(func(key string) string {
+		return map[string]string{"ENDPOINT": server.URL, "PAGE_SIZE": "2"}[key]
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestCheckPagination(t *testing.T) {
+	failures, err := checkPagination(context.Background(), fakeApi(t, []int{1, 2, 5, 8, 9}, 5))
+	if err != nil || len(failures) != 0 {
+		t.Errorf("expected no failure, got %v and %v", failures, err)
+	}
+
+	failures, err = checkPagination(context.Background(), fakeApi(t, []int{1, 2, 2, 8, 3}, 5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"city 2 listed more than once",
+		"city 3 listed after city 8",
+		"4 cities listed, the countries have 5",
+	}
+	if strings.Join(failures, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected failures %v, got %v", expected, failures)
+	}
+}
+
+func TestRunSkipsKnownCitiesWithoutExpectations(t *testing.T) {
+	results := fakeApi(t, []int{1}, 1).run(context.Background())
+	if !results[0].Skipped || !results[0].Passed {
+		t.Errorf("expected known_cities to be skipped, got %+v", results[0])
+	}
+}
+
+func TestValidateErrors(t *testing.T) {
+	errCase := errorCase{version: "v1", status: http.StatusBadRequest, param: "cityId"}
+	clientErr := &api_errors.ClientErr{
+		HttpCode: http.StatusBadRequest,
+		Message:  "Parameters not present or invalid",
+		Errors:   map[string]string{"cityId": "Not present or invalid"},
+	}
+	if err := validateProblem(clientErr.Problem(), errCase); err != nil {
+		t.Errorf("unexpected problem error: %v", err)
+	}
+	if err := validateClientErr(*clientErr, errCase); err != nil {
+		t.Errorf("unexpected client error: %v", err)
+	}
+
+	clientErr.Errors = nil
+	if err := validateProblem(clientErr.Problem(), errCase); err == nil {
+		t.Errorf("expected the missing invalid param to be reported")
+	}
+	errCase.status = http.StatusNotFound
+	if err := validateClientErr(*clientErr, errCase); err == nil {
+		t.Errorf("expected the wrong code to be reported")
+	}
+}
@@ -0,0 +1,106 @@
+// Command monitor runs contract checks against the live API, see checks, and
+// logs their results as JSON lines, optionally pushing them as metrics to a
+// Prometheus Pushgateway. It exits with 1 when a check fails, to be run as a
+// scheduled job.
+//
+// It is configured with the environment variables:
+//   - ENDPOINT: URL of the API, https://city-tags-api.com by default.
+//   - JWT: token of the requests.
+//   - EXPECTATIONS: JSON list of known cities and their expected tags, see
+//     Expectation.
+//   - PAGE_SIZE: page size of the pagination check, 500 by default.
+//   - PUSHGATEWAY_URL: Pushgateway the metrics are pushed to, if set.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"city-tags-api/pkg/client"
+)
+
+// Expectation is a known city with some of its attributes and annual tags.
+type Expectation struct {
+	CityId       int               `json:"city_id"`
+	CityName     string            `json:"city_name"`
+	Country3Code string            `json:"country_3_code"`
+	Tags         map[string]string `json:"tags"`
+}
+
+// monitor holds what the checks need to query the API.
+type monitor struct {
+	endpoint     string
+	token        string
+	httpClient   *http.Client
+	apiClient    *client.Client
+	expectations []Expectation
+	pageSize     int
+}
+
+func newMonitor(getenv func(string) string) (*monitor, error) {
+	m := &monitor{
+		endpoint:   strings.TrimSuffix(getenv("ENDPOINT"), "/"),
+		token:      getenv("JWT"),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		pageSize:   500,
+	}
+	if m.endpoint == "" {
+		m.endpoint = "https://city-tags-api.com"
+	}
+	m.apiClient = client.New(m.endpoint, client.WithToken(m.token), client.WithHTTPClient(m.httpClient))
+
+	if value := getenv("EXPECTATIONS"); value != "" {
+		if err := json.Unmarshal([]byte(value), &m.expectations); err != nil {
+			return nil, fmt.Errorf("invalid EXPECTATIONS: %w", err)
+		}
+	}
+	if value := getenv("PAGE_SIZE"); value != "" {
+		pageSize, err := strconv.Atoi(value)
+		if err != nil || pageSize <= 0 {
+			return nil, fmt.Errorf("invalid PAGE_SIZE %s", value)
+		}
+		m.pageSize = pageSize
+	}
+	return m, nil
+}
+
+func main() {
+	m, err := newMonitor(os.Getenv)
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	ctx := context.Background()
+	results := m.run(ctx)
+
+	encoder := json.NewEncoder(os.Stdout)
+	failed := 0
+	for _, result := range results {
+		if !result.Passed {
+			failed++
+		}
+		encoder.Encode(result)
+	}
+	encoder.Encode(map[string]any{
+		"severity": severity(failed == 0),
+		"message":  "monitor finished",
+		"checks":   len(results),
+		"failed":   failed,
+	})
+
+	if pushgatewayUrl := os.Getenv("PUSHGATEWAY_URL"); pushgatewayUrl != "" {
+		if err := pushMetrics(ctx, m.httpClient, pushgatewayUrl, results); err != nil {
+			log.Printf("Error pushing metrics: %v", err)
+		}
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const metricsJob = "city_tags_monitor"
+
+// formatMetrics writes the results in the Prometheus text exposition format.
+func formatMetrics(results []Result, now time.Time) []byte {
+	var buf bytes.Buffer
+	metric := func(name, help, kind string, value func(Result) float64) {
+		fmt.Fprintf(&buf, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+		for _, result := range results {
+			fmt.Fprintf(&buf, "%s{check=%q} %g\n", name, result.Check, value(result))
+		}
+	}
+
+	metric("city_tags_monitor_check_passed", "Whether the check passed, skipped checks pass.", "gauge", func(result Result) float64 {
+		if result.Passed {
+			return 1
+		}
+		return 0
+	})
+	metric("city_tags_monitor_check_failures", "Number of failures found by the check.", "gauge", func(result Result) float64 {
+		return float64(result.FailureCount)
+	})
+	metric("city_tags_monitor_check_duration_seconds", "Duration of the check.", "gauge", func(result Result) float64 {
+		return float64(result.DurationMs) / 1000
+	})
+	fmt.Fprintf(&buf, "# HELP city_tags_monitor_last_run_timestamp_seconds Time of the last run.\n# TYPE city_tags_monitor_last_run_timestamp_seconds gauge\ncity_tags_monitor_last_run_timestamp_seconds %d\n", now.Unix())
+	return buf.Bytes()
+}
+
+// pushMetrics replaces the metrics of the job in the Pushgateway.
+func pushMetrics(ctx context.Context, httpClient *http.Client, pushgatewayUrl string, results []Result) error {
+	url := fmt.Sprintf("%s/metrics/job/%s", strings.TrimSuffix(pushgatewayUrl, "/"), metricsJob)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, bytes.NewReader(formatMetrics(results, time.Now())))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"slices"
+
+	"city-tags-api/internal/database"
+	"city-tags-api/internal/importer"
+	"city-tags-api/internal/tagging"
+
+	_ "github.com/joho/godotenv/autoload"
+)
+
+// auditActor is the actor recorded in the audit log for retagged rows.
+const auditActor = "retag"
+
+func main() {
+	rulesPath := flag.String("rules", "", "YAML file with the threshold rules, defaults to the ones of city_tags.tag_values")
+	batchSize := flag.Int("batch-size", 1000, "Number of rows upserted per transaction")
+	dryRun := flag.Bool("dry-run", false, "Derive the tags without writing to the database")
+	flag.Parse()
+
+	if *batchSize <= 0 {
+		log.Fatal("-batch-size must be a positive integer")
+	}
+
+	db := database.New()
+	defer db.Close()
+
+	rules, err := loadRules(db, *rulesPath)
+	if err != nil {
+		log.Fatalf("Invalid rules: %v", err)
+	}
+
+	metrics, err := tagging.LoadMonthlyMetrics(db)
+	if err != nil {
+		log.Fatalf("Unable to load metrics: %v", err)
+	}
+
+	cityIds := make([]int, 0, len(metrics))
+	for cityId := range metrics {
+		cityIds = append(cityIds, cityId)
+	}
+	slices.Sort(cityIds)
+
+	tags := make([]importer.TagsRow, 0, len(cityIds))
+	periodTags := []importer.PeriodTagsRow{}
+	incomplete := 0
+	for _, cityId := range cityIds {
+		months := metrics[cityId]
+		if len(months) != 12 {
+			incomplete++
+			log.Printf("Skipping city %d, it has metrics for %d months out of 12", cityId, len(months))
+			continue
+		}
+
+		tags = append(tags, importer.TagsRow{
+			CityId: cityId,
+			Values: tagValues(rules.Derive(tagging.Aggregate(months))),
+		})
+
+		for period, derived := range rules.DerivePeriods(months) {
+			periodTags = append(periodTags, importer.PeriodTagsRow{
+				CityId: cityId,
+				Period: period,
+				Values: tagValues(derived),
+			})
+		}
+	}
+
+	imp := importer.New(db, *batchSize, *dryRun).WithActor(auditActor)
+	upserted, err := imp.UpsertTags(tags)
+	if err != nil {
+		log.Fatalf("Retag failed: %v", err)
+	}
+	periodsUpserted, err := imp.UpsertPeriodTags(periodTags)
+	if err != nil {
+		log.Fatalf("Retag of months and seasons failed: %v", err)
+	}
+	log.Printf(
+		"Cities: %d retagged, %d skipped, %d updated, %d month and season variants updated. Dry run: %t",
+		len(tags), incomplete, upserted, periodsUpserted, *dryRun,
+	)
+}
+
+// tagValues orders the derived tags like the columns of importer.TagsHeader.
+func tagValues(derived map[string]string) []string {
+	values := make([]string, 0, len(importer.TagsHeader)-1)
+	for _, column := range importer.TagsHeader[1:] {
+		values = append(values, derived[column])
+	}
+	return values
+}
+
+// loadRules reads the rules of the YAML file at path, to try other thresholds,
+// and the ones of the database otherwise.
+func loadRules(db database.Service, path string) (*tagging.Rules, error) {
+	if path != "" {
+		return tagging.LoadRules(path)
+	}
+	return tagging.LoadDBRules(db)
+}
@@ -22,28 +22,206 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/v0/cities": {
+        "/v0/admin/cache/stats": {
             "get": {
-                "description": "Get cities with pagination",
+                "description": "Get hit, miss and eviction counters of the in-memory cache, empty without cache, requires a token with the admin role",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Get cities",
+                "summary": "Get cache statistics",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "$ref": "#/definitions/cache.Stats"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api_errors.ClientErr"
+                        }
+                    }
+                }
+            }
+        },
+        "/v0/admin/cities": {
+            "post": {
+                "description": "Create a new city, requires a token with the admin role",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Create city",
+                "parameters": [
+                    {
+                        "description": "City to create",
+                        "name": "city",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.CityWriteReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/server.CityData"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api_errors.ClientErr"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/api_errors.ClientErr"
+                        }
+                    }
+                }
+            }
+        },
+        "/v0/admin/cities/{cityId}": {
+            "put": {
+                "description": "Replace all the fields of a city, the optional ones omitted are cleared, requires a token with the admin role",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Replace city",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Offset for pagination",
-                        "name": "offset",
-                        "in": "query"
+                        "description": "City id",
+                        "name": "cityId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "City fields",
+                        "name": "city",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.CityWriteReq"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the current city",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.CityData"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api_errors.ClientErr"
+                        }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api_errors.ClientErr"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/api_errors.ClientErr"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete a city and its tags, requires a token with the admin role",
+                "summary": "Delete city",
+                "parameters": [
                     {
                         "type": "integer",
-                        "description": "Limit for pagination",
-                        "name": "limit",
-                        "in": "query"
+                        "description": "City id",
+                        "name": "cityId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the current city",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api_errors.ClientErr"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/api_errors.ClientErr"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Update some of the fields of a city, requires a token with the admin role",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Update city",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "City id",
+                        "name": "cityId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "City fields to update",
+                        "name": "city",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.CityWriteReq"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the current city",
+                        "name": "If-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -53,8 +231,20 @@ const docTemplate = `{
                             "$ref": "#/definitions/server.CityData"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api_errors.ClientErr"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api_errors.ClientErr"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
                         "schema": {
                             "$ref": "#/definitions/api_errors.ClientErr"
                         }
@@ -62,21 +252,48 @@ const docTemplate = `{
                 }
             }
         },
-        "/v0/cities/{cityId}": {
+        "/v0/admin/cities/{cityId}/history": {
             "get": {
-                "description": "Get city information by providing a specific city id",
+                "description": "Get every change made to a city and its tags, most recent first. Requires a token with the admin role",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Get city by city id",
+                "summary": "Get city history",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "City id",
+                        "name": "cityId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset for pagination",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit for pagination, 100 by default and 1000 at most",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/server.CityData"
+                            "$ref": "#/definitions/server.GetCityHistoryResp"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api_errors.ClientErr"
                         }
                     },
                     "500": {
@@ -88,16 +305,40 @@ const docTemplate = `{
                 }
             }
         },
-        "/v0/cities/{cityId}/tags": {
-            "get": {
-                "description": "Get tags information by providing a specific city id",
+        "/v0/admin/cities/{cityId}/tags": {
+            "put": {
+                "description": "Create or replace all the tags of a city, requires a token with the admin role",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Get city tags by city id",
+                "summary": "Replace city tags",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "City id",
+                        "name": "cityId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Tags",
+                        "name": "tags",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.TagsWriteReq"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the current tags",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -105,48 +346,1861 @@ const docTemplate = `{
                             "$ref": "#/definitions/server.TagsData"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api_errors.ClientErr"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api_errors.ClientErr"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
                         "schema": {
                             "$ref": "#/definitions/api_errors.ClientErr"
                         }
                     }
                 }
-            }
-        }
-    },
-    "definitions": {
-        "api_errors.ClientErr": {
-            "type": "object",
-            "properties": {
-                "code": {
-                    "type": "integer"
-                },
-                "errors": {
-                    "type": "object",
-                    "additionalProperties": {
-                        "type": "string"
+            },
+            "delete": {
+                "description": "Delete the tags of a city, requires a token with the admin role",
+                "summary": "Delete city tags",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "City id",
+                        "name": "cityId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the current tags",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api_errors.ClientErr"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/api_errors.ClientErr"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Update some of the tags of a city, requires a token with the admin role",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Update city tags",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "City id",
+                        "name": "cityId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Tags to update",
+                        "name": "tags",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.TagsWriteReq"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the current tags",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.TagsData"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api_errors.ClientErr"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api_errors.ClientErr"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/api_errors.ClientErr"
+                        }
                     }
-                },
-                "message": {
-                    "type": "string"
                 }
             }
         },
-        "server.CityData": {
-            "type": "object",
-            "properties": {
-                "city_id": {
-                    "type": "integer"
-                },
-                "city_name": {
-                    "type": "string"
-                },
-                "continent": {
-                    "type": "string"
-                },
-                "country_3_code": {
-                    "type": "string"
+        "/v0/admin/webhooks": {
+            "get": {
+                "description": "List the webhook subscriptions, without their secrets. Requires a token with the admin role",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "List webhook subscriptions",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Offset for pagination",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit for pagination, 100 by default and 1000 at most",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.ListSubscriptionsResp"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api_errors.ClientErr"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Subscribe a URL to the changes of the cities and their tags, requires a token with the admin role. The deliveries are signed with the secret, only returned here, in the X-Webhook-Signature header",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Create webhook subscription",
+                "parameters": [
+                    {
+                        "description": "Subscription",
+                        "name": "subscription",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.SubscriptionWriteReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/webhooks.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api_errors.ClientErr"
+                        }
+                    }
+                }
+            }
+        },
+        "/v0/admin/webhooks/deliveries/{deliveryId}/replay": {
+            "post": {
+                "description": "Send a delivery again, with every attempt available, whether it was delivered or is dead. Requires a token with the admin role",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Replay webhook delivery",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Delivery id",
+                        "name": "deliveryId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/webhooks.Delivery"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api_errors.ClientErr"
+                        }
+                    }
+                }
+            }
+        },
+        "/v0/admin/webhooks/{subscriptionId}": {
+            "get": {
+                "description": "Get a webhook subscription, without its secret. Requires a token with the admin role",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get webhook subscription",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Subscription id",
+                        "name": "subscriptionId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/webhooks.Subscription"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api_errors.ClientErr"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete a webhook subscription and its deliveries, requires a token with the admin role",
+                "summary": "Delete webhook subscription",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Subscription id",
+                        "name": "subscriptionId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api_errors.ClientErr"
+                        }
+                    }
+                }
+            }
+        },
+        "/v0/admin/webhooks/{subscriptionId}/deliveries": {
+            "get": {
+                "description": "List the deliveries of a webhook subscription, most recent first. Requires a token with the admin role",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "List webhook deliveries",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Subscription id",
+                        "name": "subscriptionId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only deliveries with this status (pending, delivered, dead)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset for pagination",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit for pagination, 100 by default and 1000 at most",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.ListDeliveriesResp"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api_errors.ClientErr"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api_errors.ClientErr"
+                        }
+                    }
+                }
+            }
+        },
+        "/v0/changes": {
+            "get": {
+                "description": "List the inserts, updates and deletes of the cities and their tags in the order they were made, to keep a copy of the dataset in sync. Start without since to read the whole log, or with now to only read the new changes, then pass the next token of each response. A change can be received again after a restart from an older token. With wait, the request waits up to that many seconds for a change when there is none",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "List changes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Token of the last change read, or now",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of changes, up to 1000",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Seconds to wait for a change when there is none, up to 25",
+                        "name": "wait",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.ListChangesResp"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api_errors.ClientErr"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api_errors.ClientErr"
+                        }
+                    }
+                }
+            }
+        },
+        "/v0/cities": {
+            "get": {
+                "description": "Get cities with pagination, optionally filtered by their annual tags or the tags of a month or season, by a bounding box and by continent or country, ordered by city id",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get cities",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Offset for pagination",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit for pagination, 100 by default and 1000 at most",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cloud coverage tag",
+                        "name": "cloud_coverage",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Humidity tag",
+                        "name": "humidity",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Temperature tag",
+                        "name": "temperature",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Precipitation tag",
+                        "name": "precipitation",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Air quality tag",
+                        "name": "air_quality",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Daylight hours tag",
+                        "name": "daylight_hours",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "City size tag",
+                        "name": "city_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Month (1 to 12) the tag filters apply to",
+                        "name": "month",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Season (winter, spring, summer, autumn) the tag filters apply to",
+                        "name": "season",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only cities inside the box minLon,minLat,maxLon,maxLat",
+                        "name": "bbox",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only cities of this continent",
+                        "name": "continent",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only cities of this country",
+                        "name": "country_3_code",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Language of names and labels (en, es, de), Accept-Language is used if missing",
+                        "name": "lang",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.CityData"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api_errors.ClientErr"
+                        }
+                    }
+                }
+            }
+        },
+        "/v0/cities/nearby": {
+            "get": {
+                "description": "Get the cities within a radius of a point, closest first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get nearby cities",
+                "parameters": [
+                    {
+                        "type": "number",
+                        "description": "Latitude of the center",
+                        "name": "lat",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "Longitude of the center",
+                        "name": "lon",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "Radius in km, 50 by default",
+                        "name": "radius_km",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of cities, 100 by default and 100 at most",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Language of names and labels (en, es, de), Accept-Language is used if missing",
+                        "name": "lang",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.GetNearbyResp"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api_errors.ClientErr"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api_errors.ClientErr"
+                        }
+                    }
+                }
+            }
+        },
+        "/v0/cities/{cityId}": {
+            "get": {
+                "description": "Get city information by providing a specific city id",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get city by city id",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Language of names and labels (en, es, de), Accept-Language is used if missing",
+                        "name": "lang",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.CityData"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api_errors.ClientErr"
+                        }
+                    }
+                }
+            }
+        },
+        "/v0/cities/{cityId}/similar": {
+            "get": {
+                "description": "Get the cities with the most similar tags to the given one, scored by the weighted distance between the ranks of every tag",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get similar cities",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Number of cities to return, 10 by default and 100 at most",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only return cities of this continent",
+                        "name": "continent",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only return cities of this country",
+                        "name": "country_3_code",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Weight of each tag category, e.g. temperature:2,humidity:0.5, 1 by default",
+                        "name": "weights",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Language of names and labels (en, es, de), Accept-Language is used if missing",
+                        "name": "lang",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.GetSimilarResp"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api_errors.ClientErr"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api_errors.ClientErr"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api_errors.ClientErr"
+                        }
+                    }
+                }
+            }
+        },
+        "/v0/cities/{cityId}/tags": {
+            "get": {
+                "description": "Get tags information by providing a specific city id, the annual tags unless a month or season is requested",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get city tags by city id",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Date (2006-01-02) or RFC 3339 timestamp to get the tags valid at that moment",
+                        "name": "as_of",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Month (1 to 12) to get the tags of",
+                        "name": "month",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Season (winter, spring, summer, autumn) to get the tags of, in the hemisphere of the city",
+                        "name": "season",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Language of names and labels (en, es, de), Accept-Language is used if missing",
+                        "name": "lang",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.LocalizedTags"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api_errors.ClientErr"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api_errors.ClientErr"
+                        }
+                    }
+                }
+            }
+        },
+        "/v0/cities/{cityId}/tags/history": {
+            "get": {
+                "description": "Get every version of the tags of a city with its validity period, oldest first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get city tags history by city id",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Offset for pagination",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit for pagination, 100 by default and 1000 at most",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.GetTagsHistoryResp"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api_errors.ClientErr"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api_errors.ClientErr"
+                        }
+                    }
+                }
+            }
+        },
+        "/v0/continents": {
+            "get": {
+                "description": "Get every continent with its number of countries and cities",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get continents",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.GetContinentsResp"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api_errors.ClientErr"
+                        }
+                    }
+                }
+            }
+        },
+        "/v0/countries": {
+            "get": {
+                "description": "Get countries ordered by alpha-3 code with their number of cities",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get countries",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Offset for pagination",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit for pagination, 100 by default and 1000 at most",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only countries of this continent",
+                        "name": "continent",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.GetCountriesResp"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api_errors.ClientErr"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api_errors.ClientErr"
+                        }
+                    }
+                }
+            }
+        },
+        "/v0/countries/{code}": {
+            "get": {
+                "description": "Get a country by its ISO 3166-1 alpha-2 or alpha-3 code with its number of cities and the distribution of their tags",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get country by code",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.CountryDetail"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api_errors.ClientErr"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api_errors.ClientErr"
+                        }
+                    }
+                }
+            }
+        },
+        "/v0/countries/{code}/cities": {
+            "get": {
+                "description": "Get the cities of a country by its ISO 3166-1 alpha-2 or alpha-3 code with pagination, ordered by city id",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get cities of a country",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Offset for pagination",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit for pagination, 100 by default and 1000 at most",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Language of names and labels (en, es, de), Accept-Language is used if missing",
+                        "name": "lang",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.GetCitiesResp"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api_errors.ClientErr"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api_errors.ClientErr"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api_errors.ClientErr"
+                        }
+                    }
+                }
+            }
+        },
+        "/v0/graphql": {
+            "post": {
+                "description": "Run a GraphQL query over cities, their tags and countries. The cities arguments mirror the filters of the cities listing, queries deeper or more complex than the limits are rejected",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "GraphQL query",
+                "parameters": [
+                    {
+                        "description": "GraphQL query",
+                        "name": "query",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.GraphQLReq"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Language of names and labels (en, es, de), Accept-Language is used if missing",
+                        "name": "lang",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api_errors.ClientErr"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api_errors.ClientErr"
+                        }
+                    }
+                }
+            }
+        },
+        "/v0/stats/tags": {
+            "get": {
+                "description": "Get the number and percentage of cities with each tag value, optionally grouped by continent or country and filtered like the cities listing",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get tag statistics",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group by continent or country",
+                        "name": "group_by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cloud coverage tag",
+                        "name": "cloud_coverage",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Humidity tag",
+                        "name": "humidity",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Temperature tag",
+                        "name": "temperature",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Precipitation tag",
+                        "name": "precipitation",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Air quality tag",
+                        "name": "air_quality",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Daylight hours tag",
+                        "name": "daylight_hours",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "City size tag",
+                        "name": "city_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Month (1 to 12) the tag filters apply to",
+                        "name": "month",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Season (winter, spring, summer, autumn) the tag filters apply to",
+                        "name": "season",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only cities inside the box minLon,minLat,maxLon,maxLat",
+                        "name": "bbox",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only cities of this continent",
+                        "name": "continent",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only cities of this country",
+                        "name": "country_3_code",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.GetTagStatsResp"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api_errors.ClientErr"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api_errors.ClientErr"
+                        }
+                    }
+                }
+            }
+        },
+        "/v0/stream/changes": {
+            "get": {
+                "description": "Stream the changes of the cities and their tags as server-sent events as they are committed, each a change event whose data is a Change and whose id resumes the stream in the Last-Event-ID header. Without it the stream starts with the new changes. Idle streams receive a comment every 15 seconds, and a client, identified by the id of its token or else by its address, can have 3 streams open at once",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "summary": "Stream changes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Id of the last event received",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only the changes of these countries, ISO 3166-1 alpha-3 codes separated by commas",
+                        "name": "country",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only the changes of these tag categories, separated by commas",
+                        "name": "category",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.Change"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api_errors.ClientErr"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/api_errors.ClientErr"
+                        }
+                    }
+                }
+            }
+        },
+        "/v0/tags": {
+            "get": {
+                "description": "Get every tag category with its allowed values, ordered by rank, their labels and the thresholds used to derive them",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get tag vocabulary",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Language of names and labels (en, es, de), Accept-Language is used if missing",
+                        "name": "lang",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.GetVocabularyResp"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api_errors.ClientErr"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "api_errors.ClientErr": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer"
+                },
+                "errors": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "cache.Stats": {
+            "type": "object",
+            "properties": {
+                "entries": {
+                    "type": "integer"
+                },
+                "evictions": {
+                    "type": "integer"
+                },
+                "hits": {
+                    "type": "integer"
+                },
+                "misses": {
+                    "type": "integer"
+                }
+            }
+        },
+        "server.AuditEntry": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "actor": {
+                    "type": "string"
+                },
+                "after": {
+                    "type": "object"
+                },
+                "audit_id": {
+                    "type": "integer"
+                },
+                "before": {
+                    "type": "object"
+                },
+                "changed_at": {
+                    "type": "string"
+                },
+                "city_id": {
+                    "type": "integer"
+                },
+                "request_id": {
+                    "type": "string"
+                },
+                "table": {
+                    "type": "string"
+                }
+            }
+        },
+        "server.Change": {
+            "type": "object",
+            "properties": {
+                "categories": {
+                    "description": "Categories are the tag categories changed, empty for the cities.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "changed_at": {
+                    "type": "string"
+                },
+                "city_id": {
+                    "type": "integer"
+                },
+                "country_3_code": {
+                    "description": "Country3Code is null for the tags deleted along with their city.",
+                    "type": "string"
+                },
+                "data": {
+                    "description": "Data is the city or its tags after the change, before it when deleted.",
+                    "type": "object"
+                },
+                "operation": {
+                    "type": "string",
+                    "enum": [
+                        "insert",
+                        "update",
+                        "delete"
+                    ]
+                },
+                "resource": {
+                    "type": "string",
+                    "enum": [
+                        "city",
+                        "tags"
+                    ]
+                },
+                "token": {
+                    "description": "Token resumes the feed right after this change.",
+                    "type": "string"
+                }
+            }
+        },
+        "server.CityData": {
+            "type": "object",
+            "properties": {
+                "alternate_names": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "city_id": {
+                    "type": "integer"
+                },
+                "city_name": {
+                    "type": "string"
+                },
+                "continent": {
+                    "type": "string"
+                },
+                "country_3_code": {
+                    "type": "string"
+                },
+                "latitude": {
+                    "type": "number"
+                },
+                "longitude": {
+                    "type": "number"
+                },
+                "population": {
+                    "type": "integer"
+                },
+                "timezone": {
+                    "type": "string"
+                }
+            }
+        },
+        "server.CityWriteReq": {
+            "type": "object",
+            "properties": {
+                "alternate_names": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "city_id": {
+                    "type": "integer"
+                },
+                "city_name": {
+                    "type": "string"
+                },
+                "continent": {
+                    "type": "string"
+                },
+                "country_3_code": {
+                    "type": "string"
+                },
+                "latitude": {
+                    "type": "number"
+                },
+                "longitude": {
+                    "type": "number"
+                },
+                "population": {
+                    "type": "integer"
+                },
+                "timezone": {
+                    "type": "string"
+                }
+            }
+        },
+        "server.ContinentData": {
+            "type": "object",
+            "properties": {
+                "cities": {
+                    "type": "integer"
+                },
+                "countries": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "server.CountryData": {
+            "type": "object",
+            "properties": {
+                "alpha_2_code": {
+                    "type": "string"
+                },
+                "alpha_3_code": {
+                    "type": "string"
+                },
+                "cities": {
+                    "type": "integer"
+                },
+                "continent": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "server.CountryDetail": {
+            "type": "object",
+            "properties": {
+                "alpha_2_code": {
+                    "type": "string"
+                },
+                "alpha_3_code": {
+                    "type": "string"
+                },
+                "cities": {
+                    "type": "integer"
+                },
+                "continent": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "tags": {
+                    "$ref": "#/definitions/server.TagDistribution"
+                }
+            }
+        },
+        "server.GetCitiesResp": {
+            "type": "object",
+            "properties": {
+                "cities": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.CityData"
+                    }
+                },
+                "offset": {
+                    "type": "integer"
+                }
+            }
+        },
+        "server.GetCityHistoryResp": {
+            "type": "object",
+            "properties": {
+                "history": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.AuditEntry"
+                    }
+                },
+                "offset": {
+                    "type": "integer"
+                }
+            }
+        },
+        "server.GetContinentsResp": {
+            "type": "object",
+            "properties": {
+                "continents": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.ContinentData"
+                    }
+                }
+            }
+        },
+        "server.GetCountriesResp": {
+            "type": "object",
+            "properties": {
+                "countries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.CountryData"
+                    }
+                },
+                "offset": {
+                    "type": "integer"
+                }
+            }
+        },
+        "server.GetNearbyResp": {
+            "type": "object",
+            "properties": {
+                "cities": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.NearbyCity"
+                    }
+                }
+            }
+        },
+        "server.GetSimilarResp": {
+            "type": "object",
+            "properties": {
+                "city_id": {
+                    "type": "integer"
+                },
+                "similar": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.SimilarCity"
+                    }
+                }
+            }
+        },
+        "server.GetTagStatsResp": {
+            "type": "object",
+            "properties": {
+                "group_by": {
+                    "type": "string"
+                },
+                "groups": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.TagStatsGroup"
+                    }
+                }
+            }
+        },
+        "server.GetTagsHistoryResp": {
+            "type": "object",
+            "properties": {
+                "history": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.TagsVersion"
+                    }
+                },
+                "offset": {
+                    "type": "integer"
+                }
+            }
+        },
+        "server.GetVocabularyResp": {
+            "type": "object",
+            "properties": {
+                "categories": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.TagCategory"
+                    }
+                }
+            }
+        },
+        "server.GraphQLReq": {
+            "type": "object",
+            "properties": {
+                "extensions": {
+                    "type": "object"
+                },
+                "operationName": {
+                    "type": "string"
+                },
+                "query": {
+                    "type": "string"
+                },
+                "variables": {
+                    "type": "object"
+                }
+            }
+        },
+        "server.ListChangesResp": {
+            "type": "object",
+            "properties": {
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.Change"
+                    }
+                },
+                "has_more": {
+                    "type": "boolean"
+                },
+                "next": {
+                    "description": "Next is the since of the following request.",
+                    "type": "string"
+                }
+            }
+        },
+        "server.ListDeliveriesResp": {
+            "type": "object",
+            "properties": {
+                "deliveries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/webhooks.Delivery"
+                    }
+                },
+                "page": {
+                    "$ref": "#/definitions/server.Page"
+                }
+            }
+        },
+        "server.ListSubscriptionsResp": {
+            "type": "object",
+            "properties": {
+                "page": {
+                    "$ref": "#/definitions/server.Page"
+                },
+                "subscriptions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/webhooks.Subscription"
+                    }
+                }
+            }
+        },
+        "server.LocalizedTags": {
+            "type": "object",
+            "properties": {
+                "air_quality": {
+                    "type": "string"
+                },
+                "city_id": {
+                    "type": "integer"
+                },
+                "city_size": {
+                    "type": "string"
+                },
+                "cloud_coverage": {
+                    "type": "string"
+                },
+                "daylight_hours": {
+                    "type": "string"
+                },
+                "humidity": {
+                    "type": "string"
+                },
+                "labels": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "precipitation": {
+                    "type": "string"
+                },
+                "temperature": {
+                    "type": "string"
+                }
+            }
+        },
+        "server.NearbyCity": {
+            "type": "object",
+            "properties": {
+                "alternate_names": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "city_id": {
+                    "type": "integer"
+                },
+                "city_name": {
+                    "type": "string"
+                },
+                "continent": {
+                    "type": "string"
+                },
+                "country_3_code": {
+                    "type": "string"
+                },
+                "distance_km": {
+                    "type": "number"
+                },
+                "latitude": {
+                    "type": "number"
+                },
+                "longitude": {
+                    "type": "number"
+                },
+                "population": {
+                    "type": "integer"
+                },
+                "timezone": {
+                    "type": "string"
+                }
+            }
+        },
+        "server.Page": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "type": "integer"
+                },
+                "next_offset": {
+                    "type": "integer"
+                },
+                "offset": {
+                    "type": "integer"
+                }
+            }
+        },
+        "server.SimilarCity": {
+            "type": "object",
+            "properties": {
+                "alternate_names": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "city_id": {
+                    "type": "integer"
+                },
+                "city_name": {
+                    "type": "string"
+                },
+                "continent": {
+                    "type": "string"
+                },
+                "country_3_code": {
+                    "type": "string"
+                },
+                "latitude": {
+                    "type": "number"
+                },
+                "longitude": {
+                    "type": "number"
+                },
+                "population": {
+                    "type": "integer"
+                },
+                "score": {
+                    "type": "number"
+                },
+                "tags": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/server.TagMatch"
+                    }
+                },
+                "timezone": {
+                    "type": "string"
+                }
+            }
+        },
+        "server.SubscriptionWriteReq": {
+            "type": "object",
+            "properties": {
+                "countries": {
+                    "description": "Countries are ISO 3166-1 alpha-3 codes, every country if missing.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "event_types": {
+                    "description": "EventTypes are every event type if missing.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "secret": {
+                    "description": "Secret signs the deliveries, one is generated if missing.",
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "server.TagCategory": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "type": "string"
+                },
+                "label": {
+                    "type": "string"
+                },
+                "metric": {
+                    "type": "string"
+                },
+                "unit": {
+                    "type": "string"
+                },
+                "values": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.TagValue"
+                    }
+                }
+            }
+        },
+        "server.TagDistribution": {
+            "type": "object",
+            "additionalProperties": {
+                "type": "object",
+                "additionalProperties": {
+                    "type": "integer"
+                }
+            }
+        },
+        "server.TagMatch": {
+            "type": "object",
+            "properties": {
+                "similarity": {
+                    "type": "number"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "server.TagStatsGroup": {
+            "type": "object",
+            "properties": {
+                "cities": {
+                    "type": "integer"
+                },
+                "group": {
+                    "description": "Group is the continent or country code, empty when not grouping.",
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "object",
+                        "additionalProperties": {
+                            "$ref": "#/definitions/server.TagValueStats"
+                        }
+                    }
+                }
+            }
+        },
+        "server.TagValue": {
+            "type": "object",
+            "properties": {
+                "label": {
+                    "type": "string"
+                },
+                "max_threshold": {
+                    "type": "number"
+                },
+                "min_threshold": {
+                    "type": "number"
+                },
+                "rank": {
+                    "type": "integer"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "server.TagValueStats": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "percentage": {
+                    "type": "number"
                 }
             }
         },
@@ -178,6 +2232,135 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "server.TagsVersion": {
+            "type": "object",
+            "properties": {
+                "air_quality": {
+                    "type": "string"
+                },
+                "city_id": {
+                    "type": "integer"
+                },
+                "city_size": {
+                    "type": "string"
+                },
+                "cloud_coverage": {
+                    "type": "string"
+                },
+                "daylight_hours": {
+                    "type": "string"
+                },
+                "humidity": {
+                    "type": "string"
+                },
+                "precipitation": {
+                    "type": "string"
+                },
+                "temperature": {
+                    "type": "string"
+                },
+                "valid_from": {
+                    "type": "string"
+                },
+                "valid_to": {
+                    "type": "string"
+                }
+            }
+        },
+        "server.TagsWriteReq": {
+            "type": "object",
+            "properties": {
+                "air_quality": {
+                    "type": "string"
+                },
+                "city_size": {
+                    "type": "string"
+                },
+                "cloud_coverage": {
+                    "type": "string"
+                },
+                "daylight_hours": {
+                    "type": "string"
+                },
+                "humidity": {
+                    "type": "string"
+                },
+                "precipitation": {
+                    "type": "string"
+                },
+                "temperature": {
+                    "type": "string"
+                }
+            }
+        },
+        "webhooks.Delivery": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "delivered_at": {
+                    "type": "string"
+                },
+                "delivery_id": {
+                    "type": "integer"
+                },
+                "event_id": {
+                    "type": "integer"
+                },
+                "event_type": {
+                    "type": "string"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "last_status_code": {
+                    "type": "integer"
+                },
+                "next_attempt_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "subscription_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "webhooks.Subscription": {
+            "type": "object",
+            "properties": {
+                "countries": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "event_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "secret": {
+                    "description": "Secret is only returned when the subscription is created.",
+                    "type": "string"
+                },
+                "subscription_id": {
+                    "type": "integer"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
         }
     },
     "securityDefinitions": {
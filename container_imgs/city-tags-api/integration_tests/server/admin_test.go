@@ -0,0 +1,203 @@
+package server
+
+import (
+	"bytes"
+	"city-tags-api/internal/server"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func adminRequest(t *testing.T, method string, url string, body any, headers map[string]string) (*http.Response, []byte) {
+	var reqBody io.Reader
+	if body != nil {
+		jsonBody, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reqBody = bytes.NewReader(jsonBody)
+	}
+
+	req, err := http.NewRequest(method, url, reqBody)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", testAdminJWT))
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, respBody
+}
+
+func TestAdminCityLifecycle(t *testing.T) {
+	cityURL := fmt.Sprintf("%s/v0/admin/cities/%d", endpoint, 9999999)
+
+	resp, body := adminRequest(t, "POST", fmt.Sprintf("%s/v0/admin/cities", endpoint), map[string]any{
+		"city_id":        9999999,
+		"city_name":      "Ushuaia",
+		"continent":      "South America",
+		"country_3_code": "ARG",
+	}, nil)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create city returned %d: %s", resp.StatusCode, body)
+	}
+	etag := resp.Header.Get("ETag")
+
+	resp, body = adminRequest(t, "PUT", cityURL+"/tags", map[string]any{
+		"cloud_coverage": "cloudy",
+		"humidity":       "humid",
+		"temperature":    "cold",
+		"precipitation":  "moderate",
+		"air_quality":    "good",
+		"daylight_hours": "low",
+		"city_size":      "small",
+	}, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("replace tags returned %d: %s", resp.StatusCode, body)
+	}
+
+	resp, body = adminRequest(t, "PATCH", cityURL, map[string]any{"city_name": "Ushuaia City"}, map[string]string{
+		"If-Match": `"stale"`,
+	})
+	if resp.StatusCode != http.StatusPreconditionFailed {
+		t.Errorf("patch with stale etag returned %d: %s", resp.StatusCode, body)
+	}
+
+	resp, body = adminRequest(t, "PATCH", cityURL, map[string]any{"city_name": "Ushuaia City"}, map[string]string{
+		"If-Match": etag,
+	})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("patch city returned %d: %s", resp.StatusCode, body)
+	}
+	cityData := server.CityData{}
+	if err := json.Unmarshal(body, &cityData); err != nil {
+		t.Fatal(err)
+	}
+	if cityData.CityName != "Ushuaia City" || cityData.Country3Code != "ARG" {
+		t.Errorf("patch city returned %v", cityData)
+	}
+
+	resp, body = adminRequest(t, "PATCH", cityURL+"/tags", map[string]any{"temperature": "freezing"}, nil)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("patch tags with invalid value returned %d: %s", resp.StatusCode, body)
+	}
+
+	resp, body = adminRequest(t, "DELETE", cityURL, nil, nil)
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("delete city returned %d: %s", resp.StatusCode, body)
+	}
+
+	resp, _ = adminRequest(t, "GET", fmt.Sprintf("%s/v0/cities/%d/tags", endpoint, 9999999), nil, nil)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("tags of deleted city returned %d", resp.StatusCode)
+	}
+
+	resp, body = adminRequest(t, "GET", cityURL+"/history", nil, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("city history returned %d: %s", resp.StatusCode, body)
+	}
+	history := server.GetCityHistoryResp{}
+	if err := json.Unmarshal(body, &history); err != nil {
+		t.Fatal(err)
+	}
+
+	expectedActions := []string{"delete", "delete", "update", "insert", "insert"}
+	if len(history.History) < len(expectedActions) {
+		t.Fatalf("city history returned %d entries want at least %d", len(history.History), len(expectedActions))
+	}
+	for index, action := range expectedActions {
+		entry := history.History[index]
+		if entry.Action != action || entry.Actor != "admin_user" || entry.RequestId == nil {
+			t.Errorf("history entry %d is %s by %s, want %s by admin_user with request id", index, entry.Action, entry.Actor, action)
+		}
+	}
+}
+
+func TestAdminForbidden(t *testing.T) {
+	req, err := http.NewRequest("DELETE", fmt.Sprintf("%s/v0/admin/cities/%d", endpoint, 3838859), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", testJWT))
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("admin endpoint without admin role returned %d want %d", resp.StatusCode, http.StatusForbidden)
+	}
+}
+
+func TestNearbyCities(t *testing.T) {
+	cities := []map[string]any{
+		{
+			"city_id": 9999990, "city_name": "Madrid", "continent": "Europe", "country_3_code": "ESP",
+			"latitude": 40.4168, "longitude": -3.7038, "timezone": "Europe/Madrid", "population": 3300000,
+			"alternate_names": []string{"Madriz"},
+		},
+		{
+			"city_id": 9999991, "city_name": "Toledo", "continent": "Europe", "country_3_code": "ESP",
+			"latitude": 39.8628, "longitude": -4.0273, "timezone": "Europe/Madrid",
+		},
+		{
+			"city_id": 9999992, "city_name": "Barcelona", "continent": "Europe", "country_3_code": "ESP",
+			"latitude": 41.3874, "longitude": 2.1686, "timezone": "Europe/Madrid",
+		},
+	}
+	for _, city := range cities {
+		resp, body := adminRequest(t, "POST", fmt.Sprintf("%s/v0/admin/cities", endpoint), city, nil)
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("create city returned %d: %s", resp.StatusCode, body)
+		}
+		cityId := city["city_id"]
+		defer adminRequest(t, "DELETE", fmt.Sprintf("%s/v0/admin/cities/%d", endpoint, cityId), nil, nil)
+	}
+
+	resp, body := adminRequest(t, "GET", fmt.Sprintf("%s/v0/cities/nearby?lat=40.4168&lon=-3.7038&radius_km=100", endpoint), nil, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("nearby cities returned %d: %s", resp.StatusCode, body)
+	}
+	nearby := server.GetNearbyResp{}
+	if err := json.Unmarshal(body, &nearby); err != nil {
+		t.Fatal(err)
+	}
+	if len(nearby.Cities) != 2 || nearby.Cities[0].CityId != 9999990 || nearby.Cities[1].CityId != 9999991 {
+		t.Fatalf("nearby cities returned %v want Madrid and Toledo", nearby.Cities)
+	}
+	if nearby.Cities[0].DistanceKm != 0 || nearby.Cities[1].DistanceKm < 60 || nearby.Cities[1].DistanceKm > 80 {
+		t.Errorf("nearby cities returned distances %f and %f", nearby.Cities[0].DistanceKm, nearby.Cities[1].DistanceKm)
+	}
+	if madrid := nearby.Cities[0]; *madrid.Timezone != "Europe/Madrid" || *madrid.Population != 3300000 || madrid.AlternateNames[0] != "Madriz" {
+		t.Errorf("nearby cities returned %v without its geographic data", madrid)
+	}
+
+	resp, body = adminRequest(t, "GET", fmt.Sprintf("%s/v0/cities?bbox=0,40,3,42", endpoint), nil, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("cities in bounding box returned %d: %s", resp.StatusCode, body)
+	}
+	inBox := server.GetCitiesResp{}
+	if err := json.Unmarshal(body, &inBox); err != nil {
+		t.Fatal(err)
+	}
+	if len(inBox.Cities) != 1 || inBox.Cities[0].CityId != 9999992 {
+		t.Errorf("cities in bounding box returned %v want Barcelona", inBox.Cities)
+	}
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"city-tags-api/internal/server"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestGetCountry(t *testing.T) {
+	for _, code := range []string{"ARG", "ar"} {
+		url := fmt.Sprintf("%s/v0/countries/%s", endpoint, code)
+		country := server.CountryDetail{}
+		if code := getJSON(t, url, &country); code != http.StatusOK {
+			t.Fatalf("%s returned %d want %d", url, code, http.StatusOK)
+		}
+
+		if country.Alpha3Code != "ARG" || country.Alpha2Code != "AR" || country.Name != "Argentina" || country.Cities != 3 {
+			t.Errorf("%s returned %v want Argentina with 3 cities", url, country.CountryData)
+		}
+		expected := map[string]int{"very cold": 0, "cold": 1, "mild": 2, "warm": 0, "hot": 0}
+		if !reflect.DeepEqual(country.Tags["temperature"], expected) {
+			t.Errorf("%s returned temperature distribution %v want %v", url, country.Tags["temperature"], expected)
+		}
+	}
+
+	url := fmt.Sprintf("%s/v0/countries/XX", endpoint)
+	if code := getJSON(t, url, nil); code != http.StatusNotFound {
+		t.Errorf("%s returned %d want %d", url, code, http.StatusNotFound)
+	}
+}
+
+func TestGetCountries(t *testing.T) {
+	url := fmt.Sprintf("%s/v0/countries?continent=South%%20America", endpoint)
+	countriesResp := server.GetCountriesResp{}
+	if code := getJSON(t, url, &countriesResp); code != http.StatusOK {
+		t.Fatalf("%s returned %d want %d", url, code, http.StatusOK)
+	}
+
+	found := false
+	for _, country := range countriesResp.Countries {
+		if country.Continent != "South America" {
+			t.Errorf("%s returned %s of %s", url, country.Alpha3Code, country.Continent)
+		}
+		if country.Alpha3Code == "ARG" {
+			found = true
+			if country.Cities != 3 {
+				t.Errorf("%s returned %d cities for ARG want 3", url, country.Cities)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("%s didn't return ARG", url)
+	}
+}
+
+func TestGetCountryCities(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected []int
+	}{
+		{"All cities", "", []int{3430443, 3430988, 3838859}},
+		{"Paginated", "?offset=1&limit=1", []int{3430988}},
+		{"Tag filter", "?temperature=cold", []int{3838859}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url := fmt.Sprintf("%s/v0/countries/ARG/cities%s", endpoint, tt.query)
+			citiesResp := server.GetCitiesResp{}
+			if code := getJSON(t, url, &citiesResp); code != http.StatusOK {
+				t.Fatalf("%s returned %d want %d", url, code, http.StatusOK)
+			}
+
+			cityIds := []int{}
+			for _, city := range citiesResp.Cities {
+				cityIds = append(cityIds, city.CityId)
+			}
+			if !reflect.DeepEqual(cityIds, tt.expected) {
+				t.Errorf("%s returned cities %v want %v", url, cityIds, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGetContinents(t *testing.T) {
+	url := fmt.Sprintf("%s/v0/continents", endpoint)
+	continentsResp := server.GetContinentsResp{}
+	if code := getJSON(t, url, &continentsResp); code != http.StatusOK {
+		t.Fatalf("%s returned %d want %d", url, code, http.StatusOK)
+	}
+
+	if len(continentsResp.Continents) != 7 {
+		t.Fatalf("%s returned %d continents want 7", url, len(continentsResp.Continents))
+	}
+	for _, continent := range continentsResp.Continents {
+		if continent.Name == "South America" && (continent.Cities != 3 || continent.Countries == 0) {
+			t.Errorf("%s returned %v want 3 cities in South America", url, continent)
+		}
+	}
+}
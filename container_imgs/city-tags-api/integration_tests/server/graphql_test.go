@@ -0,0 +1,100 @@
+package server
+
+import (
+	"bytes"
+	"city-tags-api/internal/server"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func graphqlRequest(t *testing.T, token string, query string, target any) int {
+	body, err := json.Marshal(server.GraphQLReq{Query: query})
+	if err != nil {
+		t.Fatal(err)
+	}
+	url := fmt.Sprintf("%s/v0/graphql", endpoint)
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "" {
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
+	}
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if target != nil && resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestGraphQLCountryCities(t *testing.T) {
+	query := `{ country(code: "AR") { name cities(temperature: "mild") { cities { id tags { temperature { value } } } } } }`
+	var result struct {
+		Data struct {
+			Country struct {
+				Name   string
+				Cities struct {
+					Cities []struct {
+						Id   int
+						Tags struct {
+							Temperature struct {
+								Value string
+							}
+						}
+					}
+				}
+			}
+		}
+		Errors []any
+	}
+	if code := graphqlRequest(t, testJWT, query, &result); code != http.StatusOK {
+		t.Fatalf("%s returned %d want %d", query, code, http.StatusOK)
+	}
+	if len(result.Errors) > 0 {
+		t.Fatalf("%s returned errors %v", query, result.Errors)
+	}
+
+	cityIds := []int{}
+	for _, city := range result.Data.Country.Cities.Cities {
+		cityIds = append(cityIds, city.Id)
+		if city.Tags.Temperature.Value != "mild" {
+			t.Errorf("%s returned temperature %s for %d want mild", query, city.Tags.Temperature.Value, city.Id)
+		}
+	}
+	if result.Data.Country.Name != "Argentina" || !reflect.DeepEqual(cityIds, []int{3430443, 3430988}) {
+		t.Errorf("%s returned %s with cities %v want Argentina with [3430443 3430988]", query, result.Data.Country.Name, cityIds)
+	}
+}
+
+func TestGraphQLLimits(t *testing.T) {
+	tests := []struct {
+		name     string
+		token    string
+		query    string
+		expected int
+	}{
+		{"Without token", "", `{ city(id: 3838859) { name } }`, http.StatusUnauthorized},
+		{"Too complex", testJWT, `{ countries { cities { cities { id } } } }`, http.StatusBadRequest},
+		{"Empty query", testJWT, "", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := graphqlRequest(t, tt.token, tt.query, nil); code != tt.expected {
+				t.Errorf("%s returned %d want %d", tt.query, code, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"city-tags-api/internal/server"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func localizedRequest(t *testing.T, url string, acceptLanguage string, target any) *http.Response {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", testJWT))
+	req.Header.Set("Accept-Language", acceptLanguage)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode == http.StatusOK {
+		if err := json.Unmarshal(body, target); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return resp
+}
+
+func TestLocalizedCity(t *testing.T) {
+	tests := []struct {
+		name             string
+		query            string
+		acceptLanguage   string
+		expectedLanguage string
+		expectedName     string
+	}{
+		{"Default language", "", "", "en", "Río Gallegos"},
+		{"Accept-Language", "", "de-DE,de;q=0.9", "de", "Rio Gallegos"},
+		{"Unsupported languages fall back", "", "fr, es;q=0.5", "es", "Río Gallegos"},
+		{"Lang parameter", "?lang=de", "es", "de", "Rio Gallegos"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url := fmt.Sprintf("%s/v0/cities/3838859%s", endpoint, tt.query)
+			cityData := server.CityData{}
+			resp := localizedRequest(t, url, tt.acceptLanguage, &cityData)
+
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("%s returned %d want %d", url, resp.StatusCode, http.StatusOK)
+			}
+			if language := resp.Header.Get("Content-Language"); language != tt.expectedLanguage {
+				t.Errorf("%s returned Content-Language %s want %s", url, language, tt.expectedLanguage)
+			}
+			if cityData.CityName != tt.expectedName {
+				t.Errorf("%s returned name %s want %s", url, cityData.CityName, tt.expectedName)
+			}
+		})
+	}
+
+	url := fmt.Sprintf("%s/v0/cities/3838859?lang=fr", endpoint)
+	if resp := localizedRequest(t, url, "", nil); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("%s returned %d want %d", url, resp.StatusCode, http.StatusBadRequest)
+	}
+}
+
+func TestLocalizedTags(t *testing.T) {
+	url := fmt.Sprintf("%s/v0/cities/3838859/tags", endpoint)
+	tags := server.LocalizedTags{}
+	localizedRequest(t, url, "de", &tags)
+
+	if tags.Temp != "cold" || tags.Labels["temperature"] != "Kalt" {
+		t.Errorf("%s returned temperature %s labeled %s want cold labeled Kalt", url, tags.Temp, tags.Labels["temperature"])
+	}
+
+	url = fmt.Sprintf("%s/v0/tags?lang=es", endpoint)
+	vocabulary := server.GetVocabularyResp{}
+	localizedRequest(t, url, "", &vocabulary)
+	for _, category := range vocabulary.Categories {
+		if category.Category == "air_quality" && (category.Label != "Calidad del aire" || category.Values[0].Label != "Buena") {
+			t.Errorf("%s returned air quality %s with %s want Calidad del aire with Buena", url, category.Label, category.Values[0].Label)
+		}
+	}
+}
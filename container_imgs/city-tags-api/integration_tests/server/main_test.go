@@ -8,6 +8,7 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"reflect"
 	"testing"
 
 	_ "github.com/lib/pq"
@@ -15,6 +16,7 @@ import (
 
 var endpoint string
 var testJWT string
+var testAdminJWT string
 
 func TestMain(m *testing.M) {
 	serverHost := os.Getenv("SERVER_HOST")
@@ -22,6 +24,7 @@ func TestMain(m *testing.M) {
 	endpoint = fmt.Sprintf("http://%s:%s", serverHost, serverPort)
 
 	testJWT = os.Getenv("TEST_JWT")
+	testAdminJWT = os.Getenv("TEST_ADMIN_JWT")
 
 	code := m.Run()
 	os.Exit(code)
@@ -39,6 +42,7 @@ func TestGetCity(t *testing.T) {
 			"3838859",
 			server.CityData{
 				CityId: 3838859, CityName: "Río Gallegos", Continent: "South America", Country3Code: "ARG",
+				AlternateNames: []string{},
 			},
 			false,
 		},
@@ -83,7 +87,7 @@ func TestGetCity(t *testing.T) {
 					t.Fatal(err)
 				}
 
-				if *fmtResp != tt.expected.(server.CityData) {
+				if !reflect.DeepEqual(*fmtResp, tt.expected.(server.CityData)) {
 					t.Errorf("%s returned %v want %v", endpoint, *fmtResp, tt.expected)
 				}
 			} else {
@@ -114,22 +118,25 @@ func TestGetCities(t *testing.T) {
 			server.GetCitiesResp{
 				Cities: []server.CityData{
 					{
-						CityId:       3838859,
-						CityName:     "Río Gallegos",
-						Continent:    "South America",
-						Country3Code: "ARG",
+						CityId:         3838859,
+						CityName:       "Río Gallegos",
+						Continent:      "South America",
+						Country3Code:   "ARG",
+						AlternateNames: []string{},
 					},
 					{
-						CityId:       3430443,
-						CityName:     "Necochea",
-						Continent:    "South America",
-						Country3Code: "ARG",
+						CityId:         3430443,
+						CityName:       "Necochea",
+						Continent:      "South America",
+						Country3Code:   "ARG",
+						AlternateNames: []string{},
 					},
 					{
-						CityId:       3430988,
-						CityName:     "Luján",
-						Continent:    "South America",
-						Country3Code: "ARG",
+						CityId:         3430988,
+						CityName:       "Luján",
+						Continent:      "South America",
+						Country3Code:   "ARG",
+						AlternateNames: []string{},
 					},
 				},
 				Offset: 3,
@@ -141,16 +148,18 @@ func TestGetCities(t *testing.T) {
 			server.GetCitiesResp{
 				Cities: []server.CityData{
 					{
-						CityId:       3430443,
-						CityName:     "Necochea",
-						Continent:    "South America",
-						Country3Code: "ARG",
+						CityId:         3430443,
+						CityName:       "Necochea",
+						Continent:      "South America",
+						Country3Code:   "ARG",
+						AlternateNames: []string{},
 					},
 					{
-						CityId:       3430988,
-						CityName:     "Luján",
-						Continent:    "South America",
-						Country3Code: "ARG",
+						CityId:         3430988,
+						CityName:       "Luján",
+						Continent:      "South America",
+						Country3Code:   "ARG",
+						AlternateNames: []string{},
 					},
 				},
 				Offset: 3,
@@ -162,10 +171,11 @@ func TestGetCities(t *testing.T) {
 			server.GetCitiesResp{
 				Cities: []server.CityData{
 					{
-						CityId:       3838859,
-						CityName:     "Río Gallegos",
-						Continent:    "South America",
-						Country3Code: "ARG",
+						CityId:         3838859,
+						CityName:       "Río Gallegos",
+						Continent:      "South America",
+						Country3Code:   "ARG",
+						AlternateNames: []string{},
 					},
 				},
 				Offset: 1,
@@ -177,10 +187,11 @@ func TestGetCities(t *testing.T) {
 			server.GetCitiesResp{
 				Cities: []server.CityData{
 					{
-						CityId:       3430443,
-						CityName:     "Necochea",
-						Continent:    "South America",
-						Country3Code: "ARG",
+						CityId:         3430443,
+						CityName:       "Necochea",
+						Continent:      "South America",
+						Country3Code:   "ARG",
+						AlternateNames: []string{},
 					},
 				},
 				Offset: 2,
@@ -216,7 +227,7 @@ func TestGetCities(t *testing.T) {
 			}
 
 			for index, city := range fmtResp.Cities {
-				if tt.expected.Cities[index] != city {
+				if !reflect.DeepEqual(tt.expected.Cities[index], city) {
 					t.Errorf("%s returned city %v want %v", tt.URL, city, tt.expected.Cities[index])
 				}
 			}
@@ -309,3 +320,295 @@ func TestGetTags(t *testing.T) {
 		})
 	}
 }
+
+func TestGetTagsHistory(t *testing.T) {
+	url := fmt.Sprintf("%s/v0/cities/%s/tags/history", endpoint, "3838859")
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", testJWT))
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fmtResp := &server.GetTagsHistoryResp{}
+	err = json.Unmarshal(body, fmtResp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(fmtResp.History) != 1 {
+		t.Fatalf("%s returned %d versions want 1", url, len(fmtResp.History))
+	}
+	if fmtResp.History[0].Temp != "cold" || fmtResp.History[0].ValidTo != nil {
+		t.Errorf("%s returned %v want current version with cold temperature", url, fmtResp.History[0])
+	}
+}
+
+func TestGetTagsAsOf(t *testing.T) {
+	tests := []struct {
+		name     string
+		asOf     string
+		expected int
+	}{
+		{"Before tags existed", "2000-01-01", http.StatusNotFound},
+		{"Current tags", "2999-01-01", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url := fmt.Sprintf("%s/v0/cities/%s/tags?as_of=%s", endpoint, "3838859", tt.asOf)
+
+			req, err := http.NewRequest("GET", url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", testJWT))
+
+			client := &http.Client{}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.expected {
+				t.Errorf("%s returned %d want %d", url, resp.StatusCode, tt.expected)
+			}
+		})
+	}
+}
+
+// getJSON sends an authenticated GET request and decodes the response body
+// into target when it is not nil, returning the status code.
+func getJSON(t *testing.T, url string, target any) int {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", testJWT))
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target != nil && resp.StatusCode == http.StatusOK {
+		if err := json.Unmarshal(body, target); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestGetVocabulary(t *testing.T) {
+	url := fmt.Sprintf("%s/v0/tags", endpoint)
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", testJWT))
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fmtResp := &server.GetVocabularyResp{}
+	err = json.Unmarshal(body, fmtResp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(fmtResp.Categories) != 7 {
+		t.Fatalf("%s returned %d categories want 7", url, len(fmtResp.Categories))
+	}
+	for _, category := range fmtResp.Categories {
+		if category.Category != "temperature" {
+			continue
+		}
+		expected := []string{"very cold", "cold", "mild", "warm", "hot"}
+		if len(category.Values) != len(expected) {
+			t.Fatalf("%s returned temperature values %v want %v", url, category.Values, expected)
+		}
+		for index, value := range category.Values {
+			if value.Value != expected[index] || value.Rank != index {
+				t.Errorf("%s returned temperature value %v want %s with rank %d", url, value, expected[index], index)
+			}
+		}
+	}
+}
+
+func TestGetPeriodTags(t *testing.T) {
+	tests := []struct {
+		name         string
+		query        string
+		expectedCode int
+		expectedTemp string
+	}{
+		{"Annual", "", http.StatusOK, "cold"},
+		{"Month", "?month=7", http.StatusOK, "very cold"},
+		{"Season", "?season=summer", http.StatusOK, "mild"},
+		{"Missing period", "?month=3", http.StatusNotFound, ""},
+		{"Invalid month", "?month=13", http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url := fmt.Sprintf("%s/v0/cities/%s/tags%s", endpoint, "3838859", tt.query)
+
+			req, err := http.NewRequest("GET", url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", testJWT))
+
+			client := &http.Client{}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedCode {
+				t.Fatalf("%s returned %d want %d", url, resp.StatusCode, tt.expectedCode)
+			}
+			if tt.expectedTemp == "" {
+				return
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tagsData := server.TagsData{}
+			if err := json.Unmarshal(body, &tagsData); err != nil {
+				t.Fatal(err)
+			}
+			if tagsData.Temp != tt.expectedTemp {
+				t.Errorf("%s returned temperature %s want %s", url, tagsData.Temp, tt.expectedTemp)
+			}
+		})
+	}
+}
+
+func TestGetCitiesFiltered(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected []int
+	}{
+		{"Annual tags", "?temperature=mild", []int{3430443, 3430988}},
+		{"Several tags", "?temperature=mild&precipitation=moderately%20wet", []int{3430443}},
+		{"Month", "?temperature=warm&month=1", []int{3430443}},
+		{"Season", "?temperature=very%20cold&season=winter", []int{3838859}},
+		{"No matches", "?temperature=hot", []int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url := fmt.Sprintf("%s/v0/cities%s", endpoint, tt.query)
+
+			req, err := http.NewRequest("GET", url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", testJWT))
+
+			client := &http.Client{}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			citiesResp := server.GetCitiesResp{}
+			if err := json.Unmarshal(body, &citiesResp); err != nil {
+				t.Fatal(err)
+			}
+
+			cityIds := []int{}
+			for _, city := range citiesResp.Cities {
+				cityIds = append(cityIds, city.CityId)
+			}
+			if !reflect.DeepEqual(cityIds, tt.expected) {
+				t.Errorf("%s returned cities %v want %v", url, cityIds, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGetSimilar(t *testing.T) {
+	url := fmt.Sprintf("%s/v0/cities/%s/similar?weights=temperature:2", endpoint, "3430988")
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", testJWT))
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	similarResp := server.GetSimilarResp{}
+	if err := json.Unmarshal(body, &similarResp); err != nil {
+		t.Fatal(err)
+	}
+
+	cityIds := []int{}
+	for _, city := range similarResp.Similar {
+		cityIds = append(cityIds, city.CityId)
+	}
+	expected := []int{3430443, 3838859}
+	if !reflect.DeepEqual(cityIds, expected) {
+		t.Fatalf("%s returned cities %v want %v", url, cityIds, expected)
+	}
+
+	temperature := similarResp.Similar[1].Tags["temperature"]
+	if temperature.Value != "cold" || temperature.Similarity != 0.75 {
+		t.Errorf("%s returned temperature match %v want cold with similarity 0.75", url, temperature)
+	}
+}
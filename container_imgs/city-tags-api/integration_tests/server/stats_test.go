@@ -0,0 +1,50 @@
+package server
+
+import (
+	"city-tags-api/internal/server"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestGetTagStats(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		expectedGroups []string
+		expectedCities int
+		expectedMild   server.TagValueStats
+	}{
+		{"All cities", "", []string{""}, 3, server.TagValueStats{Count: 2, Percentage: 66.67}},
+		{"By country", "?group_by=country", []string{"ARG"}, 3, server.TagValueStats{Count: 2, Percentage: 66.67}},
+		{"Filtered", "?group_by=continent&precipitation=moderate", []string{"South America"}, 2, server.TagValueStats{Count: 1, Percentage: 50}},
+		{"No matches", "?group_by=continent&continent=Europe", []string{}, 0, server.TagValueStats{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url := fmt.Sprintf("%s/v0/stats/tags%s", endpoint, tt.query)
+			statsResp := server.GetTagStatsResp{}
+			if code := getJSON(t, url, &statsResp); code != http.StatusOK {
+				t.Fatalf("%s returned %d want %d", url, code, http.StatusOK)
+			}
+
+			if len(statsResp.Groups) != len(tt.expectedGroups) {
+				t.Fatalf("%s returned %d groups want %v", url, len(statsResp.Groups), tt.expectedGroups)
+			}
+			for index, group := range statsResp.Groups {
+				if group.Group != tt.expectedGroups[index] || group.Cities != tt.expectedCities {
+					t.Errorf("%s returned group %s with %d cities want %s with %d", url, group.Group, group.Cities, tt.expectedGroups[index], tt.expectedCities)
+				}
+				if mild := group.Tags["temperature"]["mild"]; mild != tt.expectedMild {
+					t.Errorf("%s returned mild temperature %v want %v", url, mild, tt.expectedMild)
+				}
+			}
+		})
+	}
+
+	url := fmt.Sprintf("%s/v0/stats/tags?group_by=city", endpoint)
+	if code := getJSON(t, url, nil); code != http.StatusBadRequest {
+		t.Errorf("%s returned %d want %d", url, code, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,41 @@
+package server
+
+import (
+	"city-tags-api/internal/server"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestListCitiesV1(t *testing.T) {
+	url := fmt.Sprintf("%s/v1/countries/ARG/cities?limit=2", endpoint)
+	citiesResp := server.ListCitiesResp{}
+	if code := getJSON(t, url, &citiesResp); code != http.StatusOK {
+		t.Fatalf("%s returned %d want %d", url, code, http.StatusOK)
+	}
+	if len(citiesResp.Cities) != 2 || citiesResp.Page.NextOffset == nil || *citiesResp.Page.NextOffset != 2 {
+		t.Errorf("%s returned %d cities and page %+v want 2 cities and next offset 2", url, len(citiesResp.Cities), citiesResp.Page)
+	}
+
+	url = fmt.Sprintf("%s/v1/countries/ARG/cities?offset=2&limit=2", endpoint)
+	citiesResp = server.ListCitiesResp{}
+	if code := getJSON(t, url, &citiesResp); code != http.StatusOK {
+		t.Fatalf("%s returned %d want %d", url, code, http.StatusOK)
+	}
+	if len(citiesResp.Cities) != 1 || citiesResp.Page.NextOffset != nil {
+		t.Errorf("%s returned %d cities and page %+v want the last city", url, len(citiesResp.Cities), citiesResp.Page)
+	}
+}
+
+func TestGetTagsV1(t *testing.T) {
+	url := fmt.Sprintf("%s/v1/cities/3838859/tags", endpoint)
+	cityTags := server.CityTags{}
+	if code := getJSON(t, url, &cityTags); code != http.StatusOK {
+		t.Fatalf("%s returned %d want %d", url, code, http.StatusOK)
+	}
+
+	temperature := cityTags.Tags["temperature"]
+	if cityTags.CityId != 3838859 || temperature.Value != "cold" || temperature.Rank == 0 || temperature.Label == "" {
+		t.Errorf("%s returned %+v want a ranked and labeled cold temperature", url, cityTags)
+	}
+}
@@ -1,6 +1,9 @@
 package api_errors
 
-import "net/http"
+import (
+	"net/http"
+	"sort"
+)
 
 type ClientErr struct {
 	HttpCode int               `json:"code"`
@@ -25,3 +28,85 @@ var UnauthErr = ClientErr{
 	HttpCode: http.StatusUnauthorized,
 	Message:  "Unauthorized",
 }
+
+var CityNotFoundErr = ClientErr{
+	HttpCode: http.StatusNotFound,
+	Message:  "City not found",
+}
+
+var PeriodTagsNotFoundErr = ClientErr{
+	HttpCode: http.StatusNotFound,
+	Message:  "Tags not found for the requested period",
+}
+
+var CountryNotFoundErr = ClientErr{
+	HttpCode: http.StatusNotFound,
+	Message:  "Country not found",
+}
+
+var SubscriptionNotFoundErr = ClientErr{
+	HttpCode: http.StatusNotFound,
+	Message:  "Webhook subscription not found",
+}
+
+var DeliveryNotFoundErr = ClientErr{
+	HttpCode: http.StatusNotFound,
+	Message:  "Webhook delivery not found",
+}
+
+var TooManyStreamsErr = ClientErr{
+	HttpCode: http.StatusTooManyRequests,
+	Message:  "Too many open streams",
+}
+
+var ForbiddenErr = ClientErr{
+	HttpCode: http.StatusForbidden,
+	Message:  "Forbidden",
+}
+
+var PreconditionFailedErr = ClientErr{
+	HttpCode: http.StatusPreconditionFailed,
+	Message:  "Resource has been modified",
+}
+
+// Problem is the RFC 9457 problem details representation of errors returned
+// from v1 on.
+type Problem struct {
+	Type          string         `json:"type"`
+	Title         string         `json:"title"`
+	Status        int            `json:"status"`
+	Detail        string         `json:"detail,omitempty"`
+	InvalidParams []InvalidParam `json:"invalid_params,omitempty"`
+}
+
+type InvalidParam struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+func (err *ClientErr) Problem() Problem {
+	invalidParams := make([]InvalidParam, 0, len(err.Errors))
+	for name, reason := range err.Errors {
+		invalidParams = append(invalidParams, InvalidParam{Name: name, Reason: reason})
+	}
+	sort.Slice(invalidParams, func(i, j int) bool {
+		return invalidParams[i].Name < invalidParams[j].Name
+	})
+
+	return Problem{
+		Type:          "about:blank",
+		Title:         http.StatusText(err.HttpCode),
+		Status:        err.HttpCode,
+		Detail:        err.Message,
+		InvalidParams: invalidParams,
+	}
+}
+
+func (err *InternalErr) Problem() Problem {
+	return Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(err.HttpCode),
+		Status: err.HttpCode,
+		Detail: err.Message,
+	}
+}
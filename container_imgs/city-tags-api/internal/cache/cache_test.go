@@ -0,0 +1,120 @@
+package cache
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCache_GetSet(t *testing.T) {
+	c := New[int, string](2, time.Minute)
+
+	c.Set(1, "one")
+	c.Set(2, "two")
+	c.Set(3, "three")
+
+	if _, ok := c.Get(1); ok {
+		t.Errorf("Expected key 1 to be evicted")
+	}
+	if value, ok := c.Get(3); !ok || value != "three" {
+		t.Errorf("Get(3) = %v, %v; want three, true", value, ok)
+	}
+
+	stats := c.Stats()
+	expected := Stats{Hits: 1, Misses: 1, Evictions: 1, Entries: 2}
+	if stats != expected {
+		t.Errorf("Stats() = %v; want %v", stats, expected)
+	}
+}
+
+func TestCache_LRUOrder(t *testing.T) {
+	c := New[int, string](2, time.Minute)
+
+	c.Set(1, "one")
+	c.Set(2, "two")
+	c.Get(1)
+	c.Set(3, "three")
+
+	if _, ok := c.Get(1); !ok {
+		t.Errorf("Expected recently used key 1 to be kept")
+	}
+	if _, ok := c.Get(2); ok {
+		t.Errorf("Expected least recently used key 2 to be evicted")
+	}
+}
+
+func TestCache_TTL(t *testing.T) {
+	c := New[int, string](10, time.Millisecond)
+
+	c.Set(1, "one")
+	time.Sleep(5 * time.Millisecond)
+
+	if _, ok := c.Get(1); ok {
+		t.Errorf("Expected key 1 to be expired")
+	}
+	if c.Stats().Entries != 0 {
+		t.Errorf("Expected expired entry to be removed")
+	}
+}
+
+func TestCache_Invalidate(t *testing.T) {
+	c := New[int, string](10, time.Minute)
+
+	c.Set(1, "one")
+	c.Set(2, "two")
+	c.Invalidate(1)
+
+	if _, ok := c.Get(1); ok {
+		t.Errorf("Expected key 1 to be invalidated")
+	}
+	if _, ok := c.Get(2); !ok {
+		t.Errorf("Expected key 2 to be kept")
+	}
+
+	c.Purge()
+	if c.Stats().Entries != 0 {
+		t.Errorf("Expected cache to be empty after Purge")
+	}
+}
+
+func TestCache_GetOrLoad(t *testing.T) {
+	c := New[int, string](10, time.Minute)
+
+	loadErr := errors.New("load error")
+	_, err := c.GetOrLoad(1, func() (string, error) {
+		return "", loadErr
+	})
+	if err != loadErr {
+		t.Errorf("GetOrLoad returned %v; want %v", err, loadErr)
+	}
+	if c.Stats().Entries != 0 {
+		t.Errorf("Expected errors not to be cached")
+	}
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := c.GetOrLoad(1, func() (string, error) {
+				calls.Add(1)
+				<-release
+				return "one", nil
+			})
+			if err != nil || value != "one" {
+				t.Errorf("GetOrLoad = %v, %v; want one, nil", value, err)
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls.Load() != 1 {
+		t.Errorf("Loader called %d times; want 1", calls.Load())
+	}
+}
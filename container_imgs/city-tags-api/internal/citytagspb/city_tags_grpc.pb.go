@@ -0,0 +1,249 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: citytags/v1/city_tags.proto
+
+package citytagspb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	CityTags_GetCity_FullMethodName    = "/citytags.v1.CityTags/GetCity"
+	CityTags_ListCities_FullMethodName = "/citytags.v1.CityTags/ListCities"
+	CityTags_GetTags_FullMethodName    = "/citytags.v1.CityTags/GetTags"
+	CityTags_BatchGet_FullMethodName   = "/citytags.v1.CityTags/BatchGet"
+)
+
+// CityTagsClient is the client API for CityTags service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// CityTags serves the cities and tags of the REST API to gRPC clients, with
+// the same JWT sent in the authorization metadata.
+type CityTagsClient interface {
+	GetCity(ctx context.Context, in *GetCityRequest, opts ...grpc.CallOption) (*City, error)
+	// ListCities streams every city matching the filters, ordered by city id.
+	ListCities(ctx context.Context, in *ListCitiesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[City], error)
+	GetTags(ctx context.Context, in *GetTagsRequest, opts ...grpc.CallOption) (*Tags, error)
+	// BatchGet returns up to 100 cities with their annual tags.
+	BatchGet(ctx context.Context, in *BatchGetRequest, opts ...grpc.CallOption) (*BatchGetResponse, error)
+}
+
+type cityTagsClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCityTagsClient(cc grpc.ClientConnInterface) CityTagsClient {
+	return &cityTagsClient{cc}
+}
+
+func (c *cityTagsClient) GetCity(ctx context.Context, in *GetCityRequest, opts ...grpc.CallOption) (*City, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(City)
+	err := c.cc.Invoke(ctx, CityTags_GetCity_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cityTagsClient) ListCities(ctx context.Context, in *ListCitiesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[City], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CityTags_ServiceDesc.Streams[0], CityTags_ListCities_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ListCitiesRequest, City]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CityTags_ListCitiesClient = grpc.ServerStreamingClient[City]
+
+func (c *cityTagsClient) GetTags(ctx context.Context, in *GetTagsRequest, opts ...grpc.CallOption) (*Tags, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Tags)
+	err := c.cc.Invoke(ctx, CityTags_GetTags_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cityTagsClient) BatchGet(ctx context.Context, in *BatchGetRequest, opts ...grpc.CallOption) (*BatchGetResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchGetResponse)
+	err := c.cc.Invoke(ctx, CityTags_BatchGet_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CityTagsServer is the server API for CityTags service.
+// All implementations must embed UnimplementedCityTagsServer
+// for forward compatibility.
+//
+// CityTags serves the cities and tags of the REST API to gRPC clients, with
+// the same JWT sent in the authorization metadata.
+type CityTagsServer interface {
+	GetCity(context.Context, *GetCityRequest) (*City, error)
+	// ListCities streams every city matching the filters, ordered by city id.
+	ListCities(*ListCitiesRequest, grpc.ServerStreamingServer[City]) error
+	GetTags(context.Context, *GetTagsRequest) (*Tags, error)
+	// BatchGet returns up to 100 cities with their annual tags.
+	BatchGet(context.Context, *BatchGetRequest) (*BatchGetResponse, error)
+	mustEmbedUnimplementedCityTagsServer()
+}
+
+// UnimplementedCityTagsServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedCityTagsServer struct{}
+
+func (UnimplementedCityTagsServer) GetCity(context.Context, *GetCityRequest) (*City, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCity not implemented")
+}
+func (UnimplementedCityTagsServer) ListCities(*ListCitiesRequest, grpc.ServerStreamingServer[City]) error {
+	return status.Errorf(codes.Unimplemented, "method ListCities not implemented")
+}
+func (UnimplementedCityTagsServer) GetTags(context.Context, *GetTagsRequest) (*Tags, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTags not implemented")
+}
+func (UnimplementedCityTagsServer) BatchGet(context.Context, *BatchGetRequest) (*BatchGetResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchGet not implemented")
+}
+func (UnimplementedCityTagsServer) mustEmbedUnimplementedCityTagsServer() {}
+func (UnimplementedCityTagsServer) testEmbeddedByValue()                  {}
+
+// UnsafeCityTagsServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CityTagsServer will
+// result in compilation errors.
+type UnsafeCityTagsServer interface {
+	mustEmbedUnimplementedCityTagsServer()
+}
+
+func RegisterCityTagsServer(s grpc.ServiceRegistrar, srv CityTagsServer) {
+	// If the following call pancis, it indicates UnimplementedCityTagsServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&CityTags_ServiceDesc, srv)
+}
+
+func _CityTags_GetCity_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCityRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CityTagsServer).GetCity(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CityTags_GetCity_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CityTagsServer).GetCity(ctx, req.(*GetCityRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CityTags_ListCities_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListCitiesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CityTagsServer).ListCities(m, &grpc.GenericServerStream[ListCitiesRequest, City]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CityTags_ListCitiesServer = grpc.ServerStreamingServer[City]
+
+func _CityTags_GetTags_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTagsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CityTagsServer).GetTags(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CityTags_GetTags_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CityTagsServer).GetTags(ctx, req.(*GetTagsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CityTags_BatchGet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchGetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CityTagsServer).BatchGet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CityTags_BatchGet_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CityTagsServer).BatchGet(ctx, req.(*BatchGetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// CityTags_ServiceDesc is the grpc.ServiceDesc for CityTags service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var CityTags_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "citytags.v1.CityTags",
+	HandlerType: (*CityTagsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetCity",
+			Handler:    _CityTags_GetCity_Handler,
+		},
+		{
+			MethodName: "GetTags",
+			Handler:    _CityTags_GetTags_Handler,
+		},
+		{
+			MethodName: "BatchGet",
+			Handler:    _CityTags_BatchGet_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ListCities",
+			Handler:       _CityTags_ListCities_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "citytags/v1/city_tags.proto",
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -17,6 +18,9 @@ type Service interface {
 	Health() map[string]string
 	Close() error
 	Query(query string, args ...interface{}) (pgx.Rows, error)
+	Exec(query string, args ...interface{}) (pgconn.CommandTag, error)
+	WithTx(fn func(tx pgx.Tx) error) error
+	Listen(ctx context.Context, channel string, notify func(payload string)) error
 }
 
 type database struct {
@@ -131,3 +135,59 @@ func (db *database) Close() error {
 func (db *database) Query(query string, args ...interface{}) (pgx.Rows, error) {
 	return db.pool.Query(context.Background(), query, args...)
 }
+
+func (db *database) Exec(query string, args ...interface{}) (pgconn.CommandTag, error) {
+	return db.pool.Exec(context.Background(), query, args...)
+}
+
+// WithTx runs fn inside a transaction, committing it if fn returns nil and
+// rolling it back otherwise.
+func (db *database) WithTx(fn func(tx pgx.Tx) error) error {
+	return pgx.BeginFunc(context.Background(), db.pool, fn)
+}
+
+// Listen calls notify with the payload of every notification sent to channel,
+// on a connection of its own, until ctx is cancelled or the connection fails.
+func (db *database) Listen(ctx context.Context, channel string, notify func(payload string)) error {
+	pooled, err := db.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	// The connection is closed rather than returned to the pool, where it
+	// would keep listening.
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "listen "+pgx.Identifier{channel}.Sanitize()); err != nil {
+		return err
+	}
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		notify(notification.Payload)
+	}
+}
+
+// ChangesChannel is notified with the id of every city written, once the
+// write is committed.
+const ChangesChannel = "city_tags_changes"
+
+// Notify sends payload to the listeners of channel once tx is committed.
+func Notify(tx pgx.Tx, channel string, payload string) error {
+	_, err := tx.Exec(context.Background(), "select pg_notify($1, $2)", channel, payload)
+	return err
+}
+
+// SetAuditContext attributes the changes made in tx to actor and requestId in
+// the audit log.
+func SetAuditContext(tx pgx.Tx, actor string, requestId string) error {
+	_, err := tx.Exec(
+		context.Background(),
+		"select set_config('city_tags.actor', $1, true), set_config('city_tags.request_id', $2, true)",
+		actor,
+		requestId,
+	)
+	return err
+}
@@ -0,0 +1,125 @@
+package geo
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// EarthRadiusKm is the mean radius of the Earth.
+const EarthRadiusKm = 6371.0088
+
+type Point struct {
+	Lat float64
+	Lon float64
+}
+
+func (point Point) Valid() bool {
+	return point.Lat >= -90 && point.Lat <= 90 && point.Lon >= -180 && point.Lon <= 180
+}
+
+// Distance returns the great circle distance between two points in km using
+// the haversine formula.
+func Distance(from Point, to Point) float64 {
+	dLat := radians(to.Lat - from.Lat)
+	dLon := radians(to.Lon - from.Lon)
+	h := math.Pow(math.Sin(dLat/2), 2) +
+		math.Cos(radians(from.Lat))*math.Cos(radians(to.Lat))*math.Pow(math.Sin(dLon/2), 2)
+	return 2 * EarthRadiusKm * math.Asin(math.Sqrt(math.Min(1, h)))
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+func degrees(radians float64) float64 {
+	return radians * 180 / math.Pi
+}
+
+// BBox is a bounding box in degrees. When MinLon is greater than MaxLon the box
+// crosses the antimeridian.
+type BBox struct {
+	MinLon float64
+	MinLat float64
+	MaxLon float64
+	MaxLat float64
+}
+
+// ParseBBox reads a box in the "minLon,minLat,maxLon,maxLat" order of GeoJSON.
+func ParseBBox(value string) (BBox, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 4 {
+		return BBox{}, fmt.Errorf("expected 4 coordinates, got %d", len(parts))
+	}
+
+	coordinates := make([]float64, len(parts))
+	for index, part := range parts {
+		coordinate, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return BBox{}, err
+		}
+		coordinates[index] = coordinate
+	}
+
+	box := BBox{MinLon: coordinates[0], MinLat: coordinates[1], MaxLon: coordinates[2], MaxLat: coordinates[3]}
+	if !(Point{Lat: box.MinLat, Lon: box.MinLon}).Valid() || !(Point{Lat: box.MaxLat, Lon: box.MaxLon}).Valid() {
+		return BBox{}, fmt.Errorf("coordinates out of range")
+	}
+	if box.MinLat > box.MaxLat {
+		return BBox{}, fmt.Errorf("minimum latitude is greater than the maximum one")
+	}
+	return box, nil
+}
+
+func (box BBox) CrossesAntimeridian() bool {
+	return box.MinLon > box.MaxLon
+}
+
+func (box BBox) Contains(point Point) bool {
+	if point.Lat < box.MinLat || point.Lat > box.MaxLat {
+		return false
+	}
+	if box.CrossesAntimeridian() {
+		return point.Lon >= box.MinLon || point.Lon <= box.MaxLon
+	}
+	return point.Lon >= box.MinLon && point.Lon <= box.MaxLon
+}
+
+// Around returns the smallest box containing every point within radiusKm of
+// center, used to discard far away points before computing distances.
+func Around(center Point, radiusKm float64) BBox {
+	angular := radiusKm / EarthRadiusKm
+	box := BBox{
+		MinLat: center.Lat - degrees(angular),
+		MaxLat: center.Lat + degrees(angular),
+		MinLon: -180,
+		MaxLon: 180,
+	}
+
+	// Boxes reaching a pole contain every longitude.
+	if box.MinLat <= -90 || box.MaxLat >= 90 {
+		box.MinLat = math.Max(box.MinLat, -90)
+		box.MaxLat = math.Min(box.MaxLat, 90)
+		return box
+	}
+
+	ratio := math.Sin(angular) / math.Cos(radians(center.Lat))
+	if ratio >= 1 {
+		return box
+	}
+	dLon := degrees(math.Asin(ratio))
+	box.MinLon = wrapLon(center.Lon - dLon)
+	box.MaxLon = wrapLon(center.Lon + dLon)
+	return box
+}
+
+func wrapLon(lon float64) float64 {
+	if lon < -180 {
+		return lon + 360
+	}
+	if lon > 180 {
+		return lon - 360
+	}
+	return lon
+}
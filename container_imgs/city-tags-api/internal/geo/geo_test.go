@@ -0,0 +1,108 @@
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		name     string
+		from     Point
+		to       Point
+		expected float64
+	}{
+		{"same point", Point{Lat: 40.4168, Lon: -3.7038}, Point{Lat: 40.4168, Lon: -3.7038}, 0},
+		{"Madrid to Barcelona", Point{Lat: 40.4168, Lon: -3.7038}, Point{Lat: 41.3874, Lon: 2.1686}, 505},
+		{"across the antimeridian", Point{Lat: 0, Lon: 179.5}, Point{Lat: 0, Lon: -179.5}, 111.2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			distance := Distance(tt.from, tt.to)
+			if math.Abs(distance-tt.expected) > 1 {
+				t.Errorf("Distance(%v, %v) = %f; want %f", tt.from, tt.to, distance, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseBBox(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected BBox
+		isError  bool
+	}{
+		{"valid", "-10,35,5,44", BBox{MinLon: -10, MinLat: 35, MaxLon: 5, MaxLat: 44}, false},
+		{"crossing the antimeridian", "170,-50,-170,-30", BBox{MinLon: 170, MinLat: -50, MaxLon: -170, MaxLat: -30}, false},
+		{"missing coordinates", "-10,35,5", BBox{}, true},
+		{"not a number", "-10,35,5,a", BBox{}, true},
+		{"out of range", "-10,35,5,95", BBox{}, true},
+		{"inverted latitudes", "-10,44,5,35", BBox{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			box, err := ParseBBox(tt.input)
+			if tt.isError != (err != nil) {
+				t.Fatalf("ParseBBox(%s) error = %v; want error %t", tt.input, err, tt.isError)
+			}
+			if box != tt.expected {
+				t.Errorf("ParseBBox(%s) = %v; want %v", tt.input, box, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBBox_Contains(t *testing.T) {
+	box := BBox{MinLon: 170, MinLat: -50, MaxLon: -170, MaxLat: -30}
+
+	if !box.Contains(Point{Lat: -40, Lon: 175}) || !box.Contains(Point{Lat: -40, Lon: -175}) {
+		t.Errorf("box crossing the antimeridian doesn't contain points on both sides")
+	}
+	if box.Contains(Point{Lat: -40, Lon: 0}) || box.Contains(Point{Lat: -20, Lon: 175}) {
+		t.Errorf("box crossing the antimeridian contains points outside of it")
+	}
+}
+
+func TestAround(t *testing.T) {
+	center := Point{Lat: -34.6037, Lon: -58.3816}
+	box := Around(center, 100)
+
+	for _, bearing := range []float64{0, 45, 90, 135, 180, 225, 270, 315} {
+		point := destination(center, 99.9, bearing)
+		if !box.Contains(point) {
+			t.Errorf("Around(%v, 100) = %v doesn't contain %v", center, box, point)
+		}
+	}
+	if box.Contains(Point{Lat: -34.6037, Lon: -56}) {
+		t.Errorf("Around(%v, 100) = %v contains a point more than 200 km away", center, box)
+	}
+
+	polar := Around(Point{Lat: 89.5, Lon: 10}, 100)
+	if polar.MaxLat != 90 || polar.MinLon != -180 || polar.MaxLon != 180 {
+		t.Errorf("Around() near the pole = %v; want every longitude up to the pole", polar)
+	}
+
+	wrapped := Around(Point{Lat: 0, Lon: 179.9}, 100)
+	if !wrapped.CrossesAntimeridian() || !wrapped.Contains(Point{Lat: 0, Lon: -179.9}) {
+		t.Errorf("Around() next to the antimeridian = %v; want it to wrap", wrapped)
+	}
+}
+
+// destination returns the point at distanceKm from origin with the given
+// bearing in degrees.
+func destination(origin Point, distanceKm float64, bearing float64) Point {
+	angular := distanceKm / EarthRadiusKm
+	lat := radians(origin.Lat)
+	lon := radians(origin.Lon)
+	theta := radians(bearing)
+
+	destLat := math.Asin(math.Sin(lat)*math.Cos(angular) + math.Cos(lat)*math.Sin(angular)*math.Cos(theta))
+	destLon := lon + math.Atan2(
+		math.Sin(theta)*math.Sin(angular)*math.Cos(lat),
+		math.Cos(angular)-math.Sin(lat)*math.Sin(destLat),
+	)
+	return Point{Lat: degrees(destLat), Lon: degrees(destLon)}
+}
@@ -0,0 +1,190 @@
+package importer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"city-tags-api/internal/iso3166"
+	"city-tags-api/internal/vocabulary"
+)
+
+var (
+	CitiesHeader = []string{"city_id", "city_name", "continent", "country_3_code"}
+	TagsHeader   = []string{
+		"city_id",
+		"cloud_coverage_tag",
+		"humidity_tag",
+		"temp_tag",
+		"precipitation_tag",
+		"air_quality_tag",
+		"daylight_hours_tag",
+		"city_size_tag",
+	}
+)
+
+// PeriodTagsHeader are the columns of city_tags.city_period_tags, the tags of
+// a city for a month or season. They are derived by the retag command and not
+// imported from files.
+var PeriodTagsHeader = append([]string{"city_id", "period"}, TagsHeader[1:]...)
+
+type CityRow struct {
+	CityId       int
+	CityName     string
+	Continent    string
+	Country3Code string
+}
+
+type TagsRow struct {
+	CityId int
+	// Values holds the tag values in the same order as the columns of
+	// TagsHeader after city_id.
+	Values []string
+}
+
+type PeriodTagsRow struct {
+	CityId int
+	Period string
+	// Values holds the tag values in the same order as the columns of
+	// TagsHeader after city_id.
+	Values []string
+}
+
+type RejectedRow struct {
+	File    string            `json:"file"`
+	Line    int               `json:"line"`
+	CityId  string            `json:"city_id"`
+	Reasons map[string]string `json:"reasons"`
+}
+
+// readRecords reads a CSV file checking that its header is the expected one.
+// The callback receives every record with its line number in the file.
+func readRecords(reader io.Reader, header []string, fn func(line int, record []string)) error {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+
+	fileHeader, err := csvReader.Read()
+	if err != nil {
+		return fmt.Errorf("unable to read header: %v", err)
+	}
+	if !slices.Equal(fileHeader, header) {
+		return fmt.Errorf("unexpected header %v, want %v", fileHeader, header)
+	}
+
+	line := 1
+	for {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		line++
+		if err != nil {
+			return fmt.Errorf("unable to read line %d: %v", line, err)
+		}
+		fn(line, record)
+	}
+}
+
+func parseCityId(value string, seen map[int]bool, reasons map[string]string) int {
+	cityId, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || cityId <= 0 {
+		reasons["city_id"] = "Must be a positive integer"
+		return 0
+	}
+	if seen[cityId] {
+		reasons["city_id"] = "Duplicated in file"
+	}
+	return cityId
+}
+
+// ReadCities parses and validates a cities CSV file, returning the valid rows
+// and the rejected ones with the reasons.
+func ReadCities(file string, reader io.Reader) ([]CityRow, []RejectedRow, error) {
+	var rows []CityRow
+	var rejected []RejectedRow
+	seen := map[int]bool{}
+
+	err := readRecords(reader, CitiesHeader, func(line int, record []string) {
+		reasons := map[string]string{}
+		if len(record) != len(CitiesHeader) {
+			reasons["row"] = fmt.Sprintf("Expected %d columns, got %d", len(CitiesHeader), len(record))
+			rejected = append(rejected, RejectedRow{File: file, Line: line, CityId: record[0], Reasons: reasons})
+			return
+		}
+
+		row := CityRow{
+			CityId:       parseCityId(record[0], seen, reasons),
+			CityName:     record[1],
+			Continent:    record[2],
+			Country3Code: record[3],
+		}
+		if strings.TrimSpace(row.CityName) == "" || len([]rune(row.CityName)) > 50 {
+			reasons["city_name"] = "Must have between 1 and 50 characters"
+		}
+		if !iso3166.IsContinent(row.Continent) {
+			reasons["continent"] = fmt.Sprintf("Must be one of: %s", strings.Join(iso3166.Continents, ", "))
+		}
+		if !iso3166.IsAlpha3(row.Country3Code) {
+			reasons["country_3_code"] = "Must be an ISO 3166-1 alpha-3 code"
+		}
+
+		if len(reasons) > 0 {
+			rejected = append(rejected, RejectedRow{File: file, Line: line, CityId: record[0], Reasons: reasons})
+			return
+		}
+		seen[row.CityId] = true
+		rows = append(rows, row)
+	})
+	return rows, rejected, err
+}
+
+// ReadTags parses and validates a tags CSV file. cityExists reports whether a
+// city id is present either in the database or in the imported cities.
+func ReadTags(file string, reader io.Reader, cityExists func(cityId int) bool) ([]TagsRow, []RejectedRow, error) {
+	var rows []TagsRow
+	var rejected []RejectedRow
+	seen := map[int]bool{}
+
+	err := readRecords(reader, TagsHeader, func(line int, record []string) {
+		reasons := map[string]string{}
+		if len(record) != len(TagsHeader) {
+			reasons["row"] = fmt.Sprintf("Expected %d columns, got %d", len(TagsHeader), len(record))
+			rejected = append(rejected, RejectedRow{File: file, Line: line, CityId: record[0], Reasons: reasons})
+			return
+		}
+
+		row := TagsRow{
+			CityId: parseCityId(record[0], seen, reasons),
+			Values: record[1:],
+		}
+		if _, invalid := reasons["city_id"]; !invalid && !cityExists(row.CityId) {
+			reasons["city_id"] = "City does not exist"
+		}
+		for index, value := range row.Values {
+			category := categoryByColumn(TagsHeader[index+1])
+			if category.Rank(value) < 0 {
+				reasons[TagsHeader[index+1]] = fmt.Sprintf("Must be one of: %s", strings.Join(category.Values, ", "))
+			}
+		}
+
+		if len(reasons) > 0 {
+			rejected = append(rejected, RejectedRow{File: file, Line: line, CityId: record[0], Reasons: reasons})
+			return
+		}
+		seen[row.CityId] = true
+		rows = append(rows, row)
+	})
+	return rows, rejected, err
+}
+
+func categoryByColumn(column string) vocabulary.Category {
+	for _, category := range vocabulary.Categories {
+		if category.Column == column {
+			return category
+		}
+	}
+	return vocabulary.Category{}
+}
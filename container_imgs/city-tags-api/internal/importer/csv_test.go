@@ -0,0 +1,159 @@
+package importer
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+const (
+	citiesFixture = "../../integration_tests/init_db/test_data/cities_table.csv"
+	tagsFixture   = "../../integration_tests/init_db/test_data/city_tags.csv"
+)
+
+func TestReadFixtures(t *testing.T) {
+	citiesFile, err := os.Open(citiesFixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer citiesFile.Close()
+
+	cities, rejected, err := ReadCities(citiesFixture, citiesFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cities) != 3 || len(rejected) != 0 {
+		t.Errorf("ReadCities returned %d cities and %d rejected; want 3 and 0", len(cities), len(rejected))
+	}
+
+	cityIds := map[int]bool{}
+	for _, city := range cities {
+		cityIds[city.CityId] = true
+	}
+
+	tagsFile, err := os.Open(tagsFixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tagsFile.Close()
+
+	tags, rejected, err := ReadTags(tagsFixture, tagsFile, func(cityId int) bool {
+		return cityIds[cityId]
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tags) != 3 || len(rejected) != 0 {
+		t.Errorf("ReadTags returned %d tags and %d rejected; want 3 and 0: %v", len(tags), len(rejected), rejected)
+	}
+}
+
+func TestReadCities_rejected(t *testing.T) {
+	input := strings.Join([]string{
+		"city_id,city_name,continent,country_3_code",
+		"1,Valid,Europe,ESP",
+		"a,Invalid id,Europe,ESP",
+		"2,Invalid country,Europe,XXX",
+		"3,Invalid continent,Atlantis,ESP",
+		"1,Duplicated,Europe,ESP",
+		"4,Missing column,Europe",
+	}, "\n")
+
+	cities, rejected, err := ReadCities("cities.csv", strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cities) != 1 {
+		t.Errorf("ReadCities returned %d cities; want 1", len(cities))
+	}
+
+	expected := []struct {
+		line   int
+		reason string
+	}{
+		{3, "city_id"},
+		{4, "country_3_code"},
+		{5, "continent"},
+		{6, "city_id"},
+		{7, "row"},
+	}
+	if len(rejected) != len(expected) {
+		t.Fatalf("ReadCities returned %d rejected rows; want %d", len(rejected), len(expected))
+	}
+	for index, exp := range expected {
+		if rejected[index].Line != exp.line {
+			t.Errorf("Rejected row %d has line %d; want %d", index, rejected[index].Line, exp.line)
+		}
+		if _, ok := rejected[index].Reasons[exp.reason]; !ok {
+			t.Errorf("Rejected row at line %d has reasons %v; want %s", exp.line, rejected[index].Reasons, exp.reason)
+		}
+	}
+}
+
+func TestReadTags_rejected(t *testing.T) {
+	input := strings.Join([]string{
+		"city_id,cloud_coverage_tag,humidity_tag,temp_tag,precipitation_tag,air_quality_tag,daylight_hours_tag,city_size_tag",
+		"1,partly cloudy,moderate,cold,moderate,good,high,small",
+		"2,partly cloudy,moderate,cold,moderate,good,high,small",
+		"1,partly cloudy,moderate,freezing,moderate,good,high,small",
+	}, "\n")
+
+	tags, rejected, err := ReadTags("tags.csv", strings.NewReader(input), func(cityId int) bool {
+		return cityId == 1
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tags) != 1 {
+		t.Errorf("ReadTags returned %d tags; want 1", len(tags))
+	}
+	if len(rejected) != 2 {
+		t.Fatalf("ReadTags returned %d rejected rows; want 2", len(rejected))
+	}
+	if _, ok := rejected[0].Reasons["city_id"]; !ok {
+		t.Errorf("Expected unknown city to be rejected, got %v", rejected[0].Reasons)
+	}
+	if _, ok := rejected[1].Reasons["temp_tag"]; !ok {
+		t.Errorf("Expected invalid temperature to be rejected, got %v", rejected[1].Reasons)
+	}
+}
+
+func TestReadCities_header(t *testing.T) {
+	_, _, err := ReadCities("cities.csv", strings.NewReader("id,name\n1,Valid"))
+	if err == nil {
+		t.Errorf("Expected error for unexpected header but none was received")
+	}
+}
+
+func TestUpsertQuery(t *testing.T) {
+	query := upsertQuery("cities", "import_cities", CitiesHeader, 1)
+
+	for _, expected := range []string{
+		"insert into city_tags.cities as target (city_id, city_name, continent, country_3_code)",
+		"select city_id, city_name, continent, country_3_code from import_cities",
+		"continent = excluded.continent",
+		"is distinct from (excluded.city_name, excluded.continent, excluded.country_3_code)",
+		"on conflict (city_id) do update",
+		"select pg_notify($1, city_id::text) from upserted",
+	} {
+		if !strings.Contains(query, expected) {
+			t.Errorf("upsertQuery() = %s; want it to contain %s", query, expected)
+		}
+	}
+}
+
+func TestUpsertQuery_compositeKey(t *testing.T) {
+	query := upsertQuery("city_period_tags", "import_city_period_tags", PeriodTagsHeader, 2)
+
+	for _, expected := range []string{
+		"on conflict (city_id, period) do update set cloud_coverage_tag = excluded.cloud_coverage_tag",
+		"where (target.cloud_coverage_tag, target.humidity_tag",
+	} {
+		if !strings.Contains(query, expected) {
+			t.Errorf("upsertQuery() = %s; want it to contain %s", query, expected)
+		}
+	}
+	if strings.Contains(query, "period = excluded.period") {
+		t.Errorf("upsertQuery() = %s; want the key columns not to be updated", query)
+	}
+}
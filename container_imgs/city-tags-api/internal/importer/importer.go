@@ -0,0 +1,267 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"city-tags-api/internal/database"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// auditActor is the actor recorded in the audit log for imported rows.
+const auditActor = "importer"
+
+type FileReport struct {
+	Total    int   `json:"total"`
+	Accepted int   `json:"accepted"`
+	Rejected int   `json:"rejected"`
+	Upserted int64 `json:"upserted"`
+}
+
+type Report struct {
+	DryRun       bool          `json:"dry_run"`
+	Cities       FileReport    `json:"cities"`
+	Tags         FileReport    `json:"tags"`
+	RejectedRows []RejectedRow `json:"rejected_rows"`
+}
+
+type Importer struct {
+	db        database.Service
+	batchSize int
+	dryRun    bool
+	actor     string
+	runId     string
+}
+
+func New(db database.Service, batchSize int, dryRun bool) *Importer {
+	return &Importer{
+		db:        db,
+		batchSize: batchSize,
+		dryRun:    dryRun,
+		actor:     auditActor,
+		runId:     fmt.Sprintf("import-%d", time.Now().Unix()),
+	}
+}
+
+// WithActor sets the actor and prefix of the run id recorded in the audit log,
+// for writes that come from other tools than the CSV importer.
+func (imp *Importer) WithActor(actor string) *Importer {
+	imp.actor = actor
+	imp.runId = fmt.Sprintf("%s-%d", actor, time.Now().Unix())
+	return imp
+}
+
+// Run validates the given files and upserts the valid rows. Either path can be
+// empty to skip that file. Rows that are already up to date are not rewritten
+// so running the same import twice is a no-op.
+func (imp *Importer) Run(citiesPath string, tagsPath string) (*Report, error) {
+	report := &Report{
+		DryRun:       imp.dryRun,
+		RejectedRows: []RejectedRow{},
+	}
+
+	var cities []CityRow
+	if citiesPath != "" {
+		file, err := os.Open(citiesPath)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		var rejected []RejectedRow
+		cities, rejected, err = ReadCities(citiesPath, file)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", citiesPath, err)
+		}
+		report.Cities = FileReport{
+			Total:    len(cities) + len(rejected),
+			Accepted: len(cities),
+			Rejected: len(rejected),
+		}
+		report.RejectedRows = append(report.RejectedRows, rejected...)
+	}
+
+	var tags []TagsRow
+	if tagsPath != "" {
+		existing, err := imp.existingCityIds()
+		if err != nil {
+			return nil, err
+		}
+		for _, city := range cities {
+			existing[city.CityId] = true
+		}
+
+		file, err := os.Open(tagsPath)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		var rejected []RejectedRow
+		tags, rejected, err = ReadTags(tagsPath, file, func(cityId int) bool {
+			return existing[cityId]
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", tagsPath, err)
+		}
+		report.Tags = FileReport{
+			Total:    len(tags) + len(rejected),
+			Accepted: len(tags),
+			Rejected: len(rejected),
+		}
+		report.RejectedRows = append(report.RejectedRows, rejected...)
+	}
+
+	if imp.dryRun {
+		return report, nil
+	}
+
+	var err error
+	report.Cities.Upserted, err = imp.upsertCities(cities)
+	if err != nil {
+		return report, err
+	}
+	report.Tags.Upserted, err = imp.UpsertTags(tags)
+	return report, err
+}
+
+func (imp *Importer) existingCityIds() (map[int]bool, error) {
+	rows, err := imp.db.Query("select city_id from city_tags.cities")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cityIds := map[int]bool{}
+	for rows.Next() {
+		var cityId int
+		if err := rows.Scan(&cityId); err != nil {
+			return nil, err
+		}
+		cityIds[cityId] = true
+	}
+	return cityIds, rows.Err()
+}
+
+func (imp *Importer) upsertCities(cities []CityRow) (int64, error) {
+	rows := make([][]any, len(cities))
+	for index, city := range cities {
+		rows[index] = []any{city.CityId, city.CityName, city.Continent, city.Country3Code}
+	}
+	return imp.upsert("cities", CitiesHeader, 1, rows)
+}
+
+// UpsertTags writes already validated tags, returning the number of rows that
+// changed. It doesn't write anything in dry run mode.
+func (imp *Importer) UpsertTags(tags []TagsRow) (int64, error) {
+	if imp.dryRun {
+		return 0, nil
+	}
+	rows := make([][]any, len(tags))
+	for index, tag := range tags {
+		row := []any{tag.CityId}
+		for _, value := range tag.Values {
+			row = append(row, value)
+		}
+		rows[index] = row
+	}
+	return imp.upsert("city_tags", TagsHeader, 1, rows)
+}
+
+// UpsertPeriodTags writes the tags of months and seasons, returning the number
+// of rows that changed. It doesn't write anything in dry run mode.
+func (imp *Importer) UpsertPeriodTags(tags []PeriodTagsRow) (int64, error) {
+	if imp.dryRun {
+		return 0, nil
+	}
+
+	rows := make([][]any, len(tags))
+	for index, tag := range tags {
+		row := []any{tag.CityId, tag.Period}
+		for _, value := range tag.Values {
+			row = append(row, value)
+		}
+		rows[index] = row
+	}
+	return imp.upsert("city_period_tags", PeriodTagsHeader, 2, rows)
+}
+
+// upsert copies rows in batches into a temporary table and merges them into
+// city_tags.<table>, only touching rows whose values changed, and notifies
+// database.ChangesChannel of the cities touched. The first keyCount columns
+// are the primary key of the table, starting with city_id.
+func (imp *Importer) upsert(table string, columns []string, keyCount int, rows [][]any) (int64, error) {
+	var upserted int64
+	for start := 0; start < len(rows); start += imp.batchSize {
+		batch := rows[start:min(start+imp.batchSize, len(rows))]
+
+		err := imp.db.WithTx(func(tx pgx.Tx) error {
+			ctx := context.Background()
+			stagingTable := fmt.Sprintf("import_%s", table)
+
+			if err := database.SetAuditContext(tx, imp.actor, imp.runId); err != nil {
+				return err
+			}
+
+			_, err := tx.Exec(ctx, fmt.Sprintf(
+				"create temp table %s (like city_tags.%s including defaults) on commit drop",
+				stagingTable, table,
+			))
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.CopyFrom(ctx, pgx.Identifier{stagingTable}, columns, pgx.CopyFromRows(batch))
+			if err != nil {
+				return err
+			}
+
+			tag, err := tx.Exec(ctx, upsertQuery(table, stagingTable, columns, keyCount), database.ChangesChannel)
+			if err != nil {
+				return err
+			}
+			upserted += tag.RowsAffected()
+			return nil
+		})
+		if err != nil {
+			return upserted, err
+		}
+	}
+	return upserted, nil
+}
+
+func upsertQuery(table string, stagingTable string, columns []string, keyCount int) string {
+	keys, values := columns[:keyCount], columns[keyCount:]
+	updates := make([]string, 0, len(values))
+	for _, column := range values {
+		updates = append(updates, fmt.Sprintf("%s = excluded.%s", column, column))
+	}
+	columnList := strings.Join(columns, ", ")
+
+	return fmt.Sprintf(
+		`with upserted as (
+			insert into city_tags.%s as target (%s)
+			select %s from %s
+			on conflict (%s) do update set %s
+			where (%s) is distinct from (%s)
+			returning target.city_id
+		)
+		select pg_notify($1, city_id::text) from upserted`,
+		table, columnList,
+		columnList, stagingTable,
+		strings.Join(keys, ", "), strings.Join(updates, ", "),
+		prefixColumns("target", values), prefixColumns("excluded", values),
+	)
+}
+
+func prefixColumns(prefix string, columns []string) string {
+	prefixed := make([]string, len(columns))
+	for index, column := range columns {
+		prefixed[index] = fmt.Sprintf("%s.%s", prefix, column)
+	}
+	return strings.Join(prefixed, ", ")
+}
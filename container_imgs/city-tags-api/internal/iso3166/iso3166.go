@@ -0,0 +1,312 @@
+package iso3166
+
+type Country struct {
+	Alpha2    string
+	Alpha3    string
+	Name      string
+	Continent string
+}
+
+// Countries lists every ISO 3166-1 country with the continent used by the
+// cities dataset.
+var Countries = []Country{
+	{"AF", "AFG", "Afghanistan", "Asia"},
+	{"AX", "ALA", "Åland Islands", "Europe"},
+	{"AL", "ALB", "Albania", "Europe"},
+	{"DZ", "DZA", "Algeria", "Africa"},
+	{"AS", "ASM", "American Samoa", "Oceania"},
+	{"AD", "AND", "Andorra", "Europe"},
+	{"AO", "AGO", "Angola", "Africa"},
+	{"AI", "AIA", "Anguilla", "North America"},
+	{"AQ", "ATA", "Antarctica", "Antarctica"},
+	{"AG", "ATG", "Antigua and Barbuda", "North America"},
+	{"AR", "ARG", "Argentina", "South America"},
+	{"AM", "ARM", "Armenia", "Asia"},
+	{"AW", "ABW", "Aruba", "North America"},
+	{"AU", "AUS", "Australia", "Oceania"},
+	{"AT", "AUT", "Austria", "Europe"},
+	{"AZ", "AZE", "Azerbaijan", "Asia"},
+	{"BS", "BHS", "Bahamas", "North America"},
+	{"BH", "BHR", "Bahrain", "Asia"},
+	{"BD", "BGD", "Bangladesh", "Asia"},
+	{"BB", "BRB", "Barbados", "North America"},
+	{"BY", "BLR", "Belarus", "Europe"},
+	{"BE", "BEL", "Belgium", "Europe"},
+	{"BZ", "BLZ", "Belize", "North America"},
+	{"BJ", "BEN", "Benin", "Africa"},
+	{"BM", "BMU", "Bermuda", "North America"},
+	{"BT", "BTN", "Bhutan", "Asia"},
+	{"BO", "BOL", "Bolivia", "South America"},
+	{"BQ", "BES", "Bonaire, Sint Eustatius and Saba", "North America"},
+	{"BA", "BIH", "Bosnia and Herzegovina", "Europe"},
+	{"BW", "BWA", "Botswana", "Africa"},
+	{"BV", "BVT", "Bouvet Island", "Antarctica"},
+	{"BR", "BRA", "Brazil", "South America"},
+	{"IO", "IOT", "British Indian Ocean Territory", "Asia"},
+	{"BN", "BRN", "Brunei Darussalam", "Asia"},
+	{"BG", "BGR", "Bulgaria", "Europe"},
+	{"BF", "BFA", "Burkina Faso", "Africa"},
+	{"BI", "BDI", "Burundi", "Africa"},
+	{"CV", "CPV", "Cabo Verde", "Africa"},
+	{"KH", "KHM", "Cambodia", "Asia"},
+	{"CM", "CMR", "Cameroon", "Africa"},
+	{"CA", "CAN", "Canada", "North America"},
+	{"KY", "CYM", "Cayman Islands", "North America"},
+	{"CF", "CAF", "Central African Republic", "Africa"},
+	{"TD", "TCD", "Chad", "Africa"},
+	{"CL", "CHL", "Chile", "South America"},
+	{"CN", "CHN", "China", "Asia"},
+	{"CX", "CXR", "Christmas Island", "Asia"},
+	{"CC", "CCK", "Cocos (Keeling) Islands", "Asia"},
+	{"CO", "COL", "Colombia", "South America"},
+	{"KM", "COM", "Comoros", "Africa"},
+	{"CG", "COG", "Congo", "Africa"},
+	{"CD", "COD", "Congo, Democratic Republic of the", "Africa"},
+	{"CK", "COK", "Cook Islands", "Oceania"},
+	{"CR", "CRI", "Costa Rica", "North America"},
+	{"CI", "CIV", "Côte d'Ivoire", "Africa"},
+	{"HR", "HRV", "Croatia", "Europe"},
+	{"CU", "CUB", "Cuba", "North America"},
+	{"CW", "CUW", "Curaçao", "North America"},
+	{"CY", "CYP", "Cyprus", "Asia"},
+	{"CZ", "CZE", "Czechia", "Europe"},
+	{"DK", "DNK", "Denmark", "Europe"},
+	{"DJ", "DJI", "Djibouti", "Africa"},
+	{"DM", "DMA", "Dominica", "North America"},
+	{"DO", "DOM", "Dominican Republic", "North America"},
+	{"EC", "ECU", "Ecuador", "South America"},
+	{"EG", "EGY", "Egypt", "Africa"},
+	{"SV", "SLV", "El Salvador", "North America"},
+	{"GQ", "GNQ", "Equatorial Guinea", "Africa"},
+	{"ER", "ERI", "Eritrea", "Africa"},
+	{"EE", "EST", "Estonia", "Europe"},
+	{"SZ", "SWZ", "Eswatini", "Africa"},
+	{"ET", "ETH", "Ethiopia", "Africa"},
+	{"FK", "FLK", "Falkland Islands (Malvinas)", "South America"},
+	{"FO", "FRO", "Faroe Islands", "Europe"},
+	{"FJ", "FJI", "Fiji", "Oceania"},
+	{"FI", "FIN", "Finland", "Europe"},
+	{"FR", "FRA", "France", "Europe"},
+	{"GF", "GUF", "French Guiana", "South America"},
+	{"PF", "PYF", "French Polynesia", "Oceania"},
+	{"TF", "ATF", "French Southern Territories", "Antarctica"},
+	{"GA", "GAB", "Gabon", "Africa"},
+	{"GM", "GMB", "Gambia", "Africa"},
+	{"GE", "GEO", "Georgia", "Asia"},
+	{"DE", "DEU", "Germany", "Europe"},
+	{"GH", "GHA", "Ghana", "Africa"},
+	{"GI", "GIB", "Gibraltar", "Europe"},
+	{"GR", "GRC", "Greece", "Europe"},
+	{"GL", "GRL", "Greenland", "North America"},
+	{"GD", "GRD", "Grenada", "North America"},
+	{"GP", "GLP", "Guadeloupe", "North America"},
+	{"GU", "GUM", "Guam", "Oceania"},
+	{"GT", "GTM", "Guatemala", "North America"},
+	{"GG", "GGY", "Guernsey", "Europe"},
+	{"GN", "GIN", "Guinea", "Africa"},
+	{"GW", "GNB", "Guinea-Bissau", "Africa"},
+	{"GY", "GUY", "Guyana", "South America"},
+	{"HT", "HTI", "Haiti", "North America"},
+	{"HM", "HMD", "Heard Island and McDonald Islands", "Antarctica"},
+	{"VA", "VAT", "Holy See", "Europe"},
+	{"HN", "HND", "Honduras", "North America"},
+	{"HK", "HKG", "Hong Kong", "Asia"},
+	{"HU", "HUN", "Hungary", "Europe"},
+	{"IS", "ISL", "Iceland", "Europe"},
+	{"IN", "IND", "India", "Asia"},
+	{"ID", "IDN", "Indonesia", "Asia"},
+	{"IR", "IRN", "Iran", "Asia"},
+	{"IQ", "IRQ", "Iraq", "Asia"},
+	{"IE", "IRL", "Ireland", "Europe"},
+	{"IM", "IMN", "Isle of Man", "Europe"},
+	{"IL", "ISR", "Israel", "Asia"},
+	{"IT", "ITA", "Italy", "Europe"},
+	{"JM", "JAM", "Jamaica", "North America"},
+	{"JP", "JPN", "Japan", "Asia"},
+	{"JE", "JEY", "Jersey", "Europe"},
+	{"JO", "JOR", "Jordan", "Asia"},
+	{"KZ", "KAZ", "Kazakhstan", "Asia"},
+	{"KE", "KEN", "Kenya", "Africa"},
+	{"KI", "KIR", "Kiribati", "Oceania"},
+	{"KP", "PRK", "Korea, Democratic People's Republic of", "Asia"},
+	{"KR", "KOR", "Korea, Republic of", "Asia"},
+	{"KW", "KWT", "Kuwait", "Asia"},
+	{"KG", "KGZ", "Kyrgyzstan", "Asia"},
+	{"LA", "LAO", "Lao People's Democratic Republic", "Asia"},
+	{"LV", "LVA", "Latvia", "Europe"},
+	{"LB", "LBN", "Lebanon", "Asia"},
+	{"LS", "LSO", "Lesotho", "Africa"},
+	{"LR", "LBR", "Liberia", "Africa"},
+	{"LY", "LBY", "Libya", "Africa"},
+	{"LI", "LIE", "Liechtenstein", "Europe"},
+	{"LT", "LTU", "Lithuania", "Europe"},
+	{"LU", "LUX", "Luxembourg", "Europe"},
+	{"MO", "MAC", "Macao", "Asia"},
+	{"MG", "MDG", "Madagascar", "Africa"},
+	{"MW", "MWI", "Malawi", "Africa"},
+	{"MY", "MYS", "Malaysia", "Asia"},
+	{"MV", "MDV", "Maldives", "Asia"},
+	{"ML", "MLI", "Mali", "Africa"},
+	{"MT", "MLT", "Malta", "Europe"},
+	{"MH", "MHL", "Marshall Islands", "Oceania"},
+	{"MQ", "MTQ", "Martinique", "North America"},
+	{"MR", "MRT", "Mauritania", "Africa"},
+	{"MU", "MUS", "Mauritius", "Africa"},
+	{"YT", "MYT", "Mayotte", "Africa"},
+	{"MX", "MEX", "Mexico", "North America"},
+	{"FM", "FSM", "Micronesia", "Oceania"},
+	{"MD", "MDA", "Moldova", "Europe"},
+	{"MC", "MCO", "Monaco", "Europe"},
+	{"MN", "MNG", "Mongolia", "Asia"},
+	{"ME", "MNE", "Montenegro", "Europe"},
+	{"MS", "MSR", "Montserrat", "North America"},
+	{"MA", "MAR", "Morocco", "Africa"},
+	{"MZ", "MOZ", "Mozambique", "Africa"},
+	{"MM", "MMR", "Myanmar", "Asia"},
+	{"NA", "NAM", "Namibia", "Africa"},
+	{"NR", "NRU", "Nauru", "Oceania"},
+	{"NP", "NPL", "Nepal", "Asia"},
+	{"NL", "NLD", "Netherlands", "Europe"},
+	{"NC", "NCL", "New Caledonia", "Oceania"},
+	{"NZ", "NZL", "New Zealand", "Oceania"},
+	{"NI", "NIC", "Nicaragua", "North America"},
+	{"NE", "NER", "Niger", "Africa"},
+	{"NG", "NGA", "Nigeria", "Africa"},
+	{"NU", "NIU", "Niue", "Oceania"},
+	{"NF", "NFK", "Norfolk Island", "Oceania"},
+	{"MK", "MKD", "North Macedonia", "Europe"},
+	{"MP", "MNP", "Northern Mariana Islands", "Oceania"},
+	{"NO", "NOR", "Norway", "Europe"},
+	{"OM", "OMN", "Oman", "Asia"},
+	{"PK", "PAK", "Pakistan", "Asia"},
+	{"PW", "PLW", "Palau", "Oceania"},
+	{"PS", "PSE", "Palestine, State of", "Asia"},
+	{"PA", "PAN", "Panama", "North America"},
+	{"PG", "PNG", "Papua New Guinea", "Oceania"},
+	{"PY", "PRY", "Paraguay", "South America"},
+	{"PE", "PER", "Peru", "South America"},
+	{"PH", "PHL", "Philippines", "Asia"},
+	{"PN", "PCN", "Pitcairn", "Oceania"},
+	{"PL", "POL", "Poland", "Europe"},
+	{"PT", "PRT", "Portugal", "Europe"},
+	{"PR", "PRI", "Puerto Rico", "North America"},
+	{"QA", "QAT", "Qatar", "Asia"},
+	{"RE", "REU", "Réunion", "Africa"},
+	{"RO", "ROU", "Romania", "Europe"},
+	{"RU", "RUS", "Russian Federation", "Europe"},
+	{"RW", "RWA", "Rwanda", "Africa"},
+	{"BL", "BLM", "Saint Barthélemy", "North America"},
+	{"SH", "SHN", "Saint Helena, Ascension and Tristan da Cunha", "Africa"},
+	{"KN", "KNA", "Saint Kitts and Nevis", "North America"},
+	{"LC", "LCA", "Saint Lucia", "North America"},
+	{"MF", "MAF", "Saint Martin (French part)", "North America"},
+	{"PM", "SPM", "Saint Pierre and Miquelon", "North America"},
+	{"VC", "VCT", "Saint Vincent and the Grenadines", "North America"},
+	{"WS", "WSM", "Samoa", "Oceania"},
+	{"SM", "SMR", "San Marino", "Europe"},
+	{"ST", "STP", "Sao Tome and Principe", "Africa"},
+	{"SA", "SAU", "Saudi Arabia", "Asia"},
+	{"SN", "SEN", "Senegal", "Africa"},
+	{"RS", "SRB", "Serbia", "Europe"},
+	{"SC", "SYC", "Seychelles", "Africa"},
+	{"SL", "SLE", "Sierra Leone", "Africa"},
+	{"SG", "SGP", "Singapore", "Asia"},
+	{"SX", "SXM", "Sint Maarten (Dutch part)", "North America"},
+	{"SK", "SVK", "Slovakia", "Europe"},
+	{"SI", "SVN", "Slovenia", "Europe"},
+	{"SB", "SLB", "Solomon Islands", "Oceania"},
+	{"SO", "SOM", "Somalia", "Africa"},
+	{"ZA", "ZAF", "South Africa", "Africa"},
+	{"GS", "SGS", "South Georgia and the South Sandwich Islands", "Antarctica"},
+	{"SS", "SSD", "South Sudan", "Africa"},
+	{"ES", "ESP", "Spain", "Europe"},
+	{"LK", "LKA", "Sri Lanka", "Asia"},
+	{"SD", "SDN", "Sudan", "Africa"},
+	{"SR", "SUR", "Suriname", "South America"},
+	{"SJ", "SJM", "Svalbard and Jan Mayen", "Europe"},
+	{"SE", "SWE", "Sweden", "Europe"},
+	{"CH", "CHE", "Switzerland", "Europe"},
+	{"SY", "SYR", "Syrian Arab Republic", "Asia"},
+	{"TW", "TWN", "Taiwan", "Asia"},
+	{"TJ", "TJK", "Tajikistan", "Asia"},
+	{"TZ", "TZA", "Tanzania", "Africa"},
+	{"TH", "THA", "Thailand", "Asia"},
+	{"TL", "TLS", "Timor-Leste", "Asia"},
+	{"TG", "TGO", "Togo", "Africa"},
+	{"TK", "TKL", "Tokelau", "Oceania"},
+	{"TO", "TON", "Tonga", "Oceania"},
+	{"TT", "TTO", "Trinidad and Tobago", "North America"},
+	{"TN", "TUN", "Tunisia", "Africa"},
+	{"TR", "TUR", "Türkiye", "Asia"},
+	{"TM", "TKM", "Turkmenistan", "Asia"},
+	{"TC", "TCA", "Turks and Caicos Islands", "North America"},
+	{"TV", "TUV", "Tuvalu", "Oceania"},
+	{"UG", "UGA", "Uganda", "Africa"},
+	{"UA", "UKR", "Ukraine", "Europe"},
+	{"AE", "ARE", "United Arab Emirates", "Asia"},
+	{"GB", "GBR", "United Kingdom", "Europe"},
+	{"US", "USA", "United States of America", "North America"},
+	{"UM", "UMI", "United States Minor Outlying Islands", "Oceania"},
+	{"UY", "URY", "Uruguay", "South America"},
+	{"UZ", "UZB", "Uzbekistan", "Asia"},
+	{"VU", "VUT", "Vanuatu", "Oceania"},
+	{"VE", "VEN", "Venezuela", "South America"},
+	{"VN", "VNM", "Viet Nam", "Asia"},
+	{"VG", "VGB", "Virgin Islands (British)", "North America"},
+	{"VI", "VIR", "Virgin Islands (U.S.)", "North America"},
+	{"WF", "WLF", "Wallis and Futuna", "Oceania"},
+	{"EH", "ESH", "Western Sahara", "Africa"},
+	{"YE", "YEM", "Yemen", "Asia"},
+	{"ZM", "ZMB", "Zambia", "Africa"},
+	{"ZW", "ZWE", "Zimbabwe", "Africa"},
+}
+
+var Continents = []string{
+	"Africa",
+	"Antarctica",
+	"Asia",
+	"Europe",
+	"North America",
+	"Oceania",
+	"South America",
+}
+
+var byAlpha3 = func() map[string]Country {
+	countries := make(map[string]Country, len(Countries))
+	for _, country := range Countries {
+		countries[country.Alpha3] = country
+	}
+	return countries
+}()
+
+var byAlpha2 = func() map[string]Country {
+	countries := make(map[string]Country, len(Countries))
+	for _, country := range Countries {
+		countries[country.Alpha2] = country
+	}
+	return countries
+}()
+
+func ByAlpha2(code string) (Country, bool) {
+	country, ok := byAlpha2[code]
+	return country, ok
+}
+
+func ByAlpha3(code string) (Country, bool) {
+	country, ok := byAlpha3[code]
+	return country, ok
+}
+
+func IsAlpha3(code string) bool {
+	_, ok := byAlpha3[code]
+	return ok
+}
+
+func IsContinent(continent string) bool {
+	for _, name := range Continents {
+		if name == continent {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package locale
+
+import (
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Default is the language of the names and labels stored with the cities and
+// the vocabulary, it ends every fallback chain.
+const Default = "en"
+
+// Supported lists the languages with translated names and labels.
+var Supported = []string{"en", "es", "de"}
+
+// Base returns the lowercase language of a tag without its region or script,
+// "es-AR" is "es".
+func Base(tag string) string {
+	base, _, _ := strings.Cut(strings.TrimSpace(tag), "-")
+	return strings.ToLower(base)
+}
+
+func IsSupported(tag string) bool {
+	return slices.Contains(Supported, Base(tag))
+}
+
+// Negotiate returns the fallback chain of supported languages for a request:
+// lang first, then the ones in the Accept-Language header by descending
+// quality and Default last. Unsupported languages are skipped and regional
+// variants fall back to their base language.
+func Negotiate(lang string, acceptLanguage string) []string {
+	chain := []string{}
+	add := func(tag string) {
+		base := Base(tag)
+		if slices.Contains(Supported, base) && !slices.Contains(chain, base) {
+			chain = append(chain, base)
+		}
+	}
+
+	if lang != "" {
+		add(lang)
+	}
+	for _, tag := range parseAcceptLanguage(acceptLanguage) {
+		add(tag)
+	}
+	add(Default)
+	return chain
+}
+
+type weightedTag struct {
+	tag     string
+	quality float64
+}
+
+// parseAcceptLanguage returns the tags of an Accept-Language header ordered by
+// descending quality, ignoring the wildcard and the ones with quality 0.
+func parseAcceptLanguage(header string) []string {
+	weighted := []weightedTag{}
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range strings.Split(params, ";") {
+			name, value, found := strings.Cut(strings.TrimSpace(param), "=")
+			if !found || strings.TrimSpace(name) != "q" {
+				continue
+			}
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				parsed = 0
+			}
+			quality = parsed
+		}
+		if quality <= 0 {
+			continue
+		}
+		weighted = append(weighted, weightedTag{tag: tag, quality: quality})
+	}
+
+	sort.SliceStable(weighted, func(i, j int) bool {
+		return weighted[i].quality > weighted[j].quality
+	})
+	tags := make([]string, len(weighted))
+	for index, tag := range weighted {
+		tags[index] = tag.tag
+	}
+	return tags
+}
@@ -0,0 +1,41 @@
+package locale
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name           string
+		lang           string
+		acceptLanguage string
+		expected       []string
+	}{
+		{"nothing requested", "", "", []string{"en"}},
+		{"lang parameter", "es", "", []string{"es", "en"}},
+		{"regional variant", "", "de-CH", []string{"de", "en"}},
+		{"quality order", "", "en;q=0.5, es;q=0.8, de", []string{"de", "es", "en"}},
+		{"lang before header", "de", "es-AR,es;q=0.9", []string{"de", "es", "en"}},
+		{"unsupported languages", "", "fr-FR, it;q=0.9, es;q=0.1", []string{"es", "en"}},
+		{"wildcard and zero quality", "", "*, es;q=0", []string{"en"}},
+		{"invalid quality", "", "de;q=abc, es", []string{"es", "en"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain := Negotiate(tt.lang, tt.acceptLanguage)
+			if !reflect.DeepEqual(chain, tt.expected) {
+				t.Errorf("Negotiate(%q, %q) = %v; want %v", tt.lang, tt.acceptLanguage, chain, tt.expected)
+			}
+		})
+	}
+}
+
+func TestIsSupported(t *testing.T) {
+	for tag, expected := range map[string]bool{"es": true, "ES-mx": true, "de-AT": true, "fr": false, "": false} {
+		if IsSupported(tag) != expected {
+			t.Errorf("IsSupported(%q) = %t; want %t", tag, !expected, expected)
+		}
+	}
+}
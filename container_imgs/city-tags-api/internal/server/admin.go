@@ -0,0 +1,557 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"city-tags-api/internal/api_errors"
+	"city-tags-api/internal/database"
+	"city-tags-api/internal/geo"
+	"city-tags-api/internal/iso3166"
+	"city-tags-api/internal/vocabulary"
+	"city-tags-api/internal/webhooks"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/jackc/pgx/v5"
+)
+
+type CityWriteReq struct {
+	CityId         *int      `json:"city_id"`
+	CityName       *string   `json:"city_name"`
+	Continent      *string   `json:"continent"`
+	Country3Code   *string   `json:"country_3_code"`
+	Latitude       *float64  `json:"latitude"`
+	Longitude      *float64  `json:"longitude"`
+	Timezone       *string   `json:"timezone"`
+	Population     *int64    `json:"population"`
+	AlternateNames *[]string `json:"alternate_names"`
+}
+
+func decodeBody(r *http.Request, dst any) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(dst); err != nil {
+		return &api_errors.ClientErr{
+			HttpCode: http.StatusBadRequest,
+			Message:  "Invalid request body",
+			LogMess:  err.Error(),
+		}
+	}
+	return nil
+}
+
+// validate checks the request body. When partial is true, as in PATCH
+// requests, missing fields are allowed.
+func (cityW *CityWriteReq) validate(partial bool) error {
+	errs := map[string]string{}
+
+	if cityW.CityId == nil && !partial {
+		errs["city_id"] = "Not present or invalid"
+	}
+	if cityW.CityId != nil && *cityW.CityId <= 0 {
+		errs["city_id"] = "Must be a positive integer"
+	}
+	if cityW.CityName == nil && !partial {
+		errs["city_name"] = "Not present or invalid"
+	}
+	if cityW.CityName != nil && (strings.TrimSpace(*cityW.CityName) == "" || len([]rune(*cityW.CityName)) > 50) {
+		errs["city_name"] = "Must have between 1 and 50 characters"
+	}
+	if cityW.Continent == nil && !partial {
+		errs["continent"] = "Not present or invalid"
+	}
+	if cityW.Continent != nil && !iso3166.IsContinent(*cityW.Continent) {
+		errs["continent"] = fmt.Sprintf("Must be one of: %s", strings.Join(iso3166.Continents, ", "))
+	}
+	if cityW.Country3Code == nil && !partial {
+		errs["country_3_code"] = "Not present or invalid"
+	}
+	if cityW.Country3Code != nil && !iso3166.IsAlpha3(*cityW.Country3Code) {
+		errs["country_3_code"] = "Must be an ISO 3166-1 alpha-3 code"
+	}
+	if (cityW.Latitude == nil) != (cityW.Longitude == nil) {
+		errs["latitude"] = "Latitude and longitude must be set together"
+	} else if cityW.Latitude != nil && !(geo.Point{Lat: *cityW.Latitude, Lon: *cityW.Longitude}).Valid() {
+		errs["latitude"] = "Latitude must be between -90 and 90 and longitude between -180 and 180"
+	}
+	if cityW.Timezone != nil {
+		if _, err := time.LoadLocation(*cityW.Timezone); err != nil || *cityW.Timezone == "" || *cityW.Timezone == "Local" || len(*cityW.Timezone) > 64 {
+			errs["timezone"] = "Must be an IANA time zone name"
+		}
+	}
+	if cityW.Population != nil && *cityW.Population < 0 {
+		errs["population"] = "Must be a non negative integer"
+	}
+	if cityW.AlternateNames != nil {
+		for _, name := range *cityW.AlternateNames {
+			if strings.TrimSpace(name) == "" || len([]rune(name)) > 100 {
+				errs["alternate_names"] = "Every name must have between 1 and 100 characters"
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return &api_errors.ClientErr{
+			HttpCode: http.StatusBadRequest,
+			Message:  "Parameters not present or invalid",
+			Errors:   errs,
+		}
+	}
+	return nil
+}
+
+func (cityW *CityWriteReq) apply(cityData CityData) CityData {
+	if cityW.CityName != nil {
+		cityData.CityName = *cityW.CityName
+	}
+	if cityW.Continent != nil {
+		cityData.Continent = *cityW.Continent
+	}
+	if cityW.Country3Code != nil {
+		cityData.Country3Code = *cityW.Country3Code
+	}
+	if cityW.Latitude != nil {
+		cityData.Latitude = cityW.Latitude
+		cityData.Longitude = cityW.Longitude
+	}
+	if cityW.Timezone != nil {
+		cityData.Timezone = cityW.Timezone
+	}
+	if cityW.Population != nil {
+		cityData.Population = cityW.Population
+	}
+	if cityW.AlternateNames != nil {
+		cityData.AlternateNames = *cityW.AlternateNames
+	}
+	if cityData.AlternateNames == nil {
+		cityData.AlternateNames = []string{}
+	}
+	return cityData
+}
+
+type TagsWriteReq struct {
+	CloudCoverage *string `json:"cloud_coverage"`
+	Humidity      *string `json:"humidity"`
+	Temp          *string `json:"temperature"`
+	Precipitation *string `json:"precipitation"`
+	AirQuality    *string `json:"air_quality"`
+	DaylightHours *string `json:"daylight_hours"`
+	CitySize      *string `json:"city_size"`
+}
+
+func (tagsW *TagsWriteReq) fields() map[string]*string {
+	return map[string]*string{
+		"cloud_coverage": tagsW.CloudCoverage,
+		"humidity":       tagsW.Humidity,
+		"temperature":    tagsW.Temp,
+		"precipitation":  tagsW.Precipitation,
+		"air_quality":    tagsW.AirQuality,
+		"daylight_hours": tagsW.DaylightHours,
+		"city_size":      tagsW.CitySize,
+	}
+}
+
+func (tagsW *TagsWriteReq) validate(partial bool) error {
+	errs := map[string]string{}
+	fields := tagsW.fields()
+
+	for _, category := range vocabulary.Categories {
+		value := fields[category.Name]
+		if value == nil {
+			if !partial {
+				errs[category.Name] = "Not present or invalid"
+			}
+			continue
+		}
+		if category.Rank(*value) < 0 {
+			errs[category.Name] = fmt.Sprintf("Must be one of: %s", strings.Join(category.Values, ", "))
+		}
+	}
+
+	if len(errs) > 0 {
+		return &api_errors.ClientErr{
+			HttpCode: http.StatusBadRequest,
+			Message:  "Parameters not present or invalid",
+			Errors:   errs,
+		}
+	}
+	return nil
+}
+
+func (tagsW *TagsWriteReq) apply(tagsData TagsData) TagsData {
+	set := func(dst *string, value *string) {
+		if value != nil {
+			*dst = *value
+		}
+	}
+	set(&tagsData.CloudCoverage, tagsW.CloudCoverage)
+	set(&tagsData.Humidity, tagsW.Humidity)
+	set(&tagsData.Temp, tagsW.Temp)
+	set(&tagsData.Precipitation, tagsW.Precipitation)
+	set(&tagsData.AirQuality, tagsW.AirQuality)
+	set(&tagsData.DaylightHours, tagsW.DaylightHours)
+	set(&tagsData.CitySize, tagsW.CitySize)
+	return tagsData
+}
+
+// checkIfMatch implements optimistic concurrency. Requests without If-Match
+// are always allowed, otherwise the header must match the current entity tag.
+func checkIfMatch(r *http.Request, exists bool, currentETag string) error {
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" {
+		return nil
+	}
+
+	for _, candidate := range strings.Split(ifMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if exists && (candidate == "*" || candidate == currentETag) {
+			return nil
+		}
+	}
+	return &api_errors.PreconditionFailedErr
+}
+
+func parseCityId(r *http.Request) (int, error) {
+	getCityReq := GetCityReq{}
+	if err := getCityReq.validate(r); err != nil {
+		return 0, err
+	}
+	return getCityReq.cityId, nil
+}
+
+// mutate runs fn in a transaction attributed to the caller in the audit log,
+// notifies the change streams of every instance on commit, then wakes up the
+// change feed and drops the cached values of cityId. Only the errors of the
+// transaction are returned.
+func (api *Api) mutate(r *http.Request, cityId int, fn func(tx pgx.Tx) error) error {
+	err := api.db.WithTx(func(tx pgx.Tx) error {
+		err := database.SetAuditContext(tx, subject(r), middleware.GetReqID(r.Context()))
+		if err != nil {
+			return err
+		}
+		if err := fn(tx); err != nil {
+			return err
+		}
+		return database.Notify(tx, database.ChangesChannel, strconv.Itoa(cityId))
+	})
+	if err != nil {
+		return err
+	}
+	api.changesNotifier.notify()
+	// The write is committed whatever happens to the cache, which catches up
+	// on the notification of the write or when its entries expire.
+	if api.cache != nil {
+		if err := api.cache.Invalidate(cityId); err != nil {
+			log.Printf("Error invalidating the cache of city %d: %s", cityId, err.Error())
+		}
+	}
+	return nil
+}
+
+// event returns the webhook event of a change of the city or its tags, data
+// is what the partners receive.
+func event(eventType string, cityData CityData, data any) webhooks.Event {
+	return webhooks.Event{
+		Type:         eventType,
+		CityId:       cityData.CityId,
+		Country3Code: cityData.Country3Code,
+		Data:         data,
+	}
+}
+
+// @Summary		Create city
+// @Description	Create a new city, requires a token with the admin role
+// @Accept		json
+// @Produce		json
+// @Param       city	body		CityWriteReq	true	"City to create"
+// @Success		201 	{object} 	CityData
+// @Failure     400 	{object} 	api_errors.ClientErr
+// @Failure     409 	{object} 	api_errors.ClientErr
+// @Router		/v0/admin/cities [post]
+func (api *Api) createCity(w http.ResponseWriter, r *http.Request) error {
+	cityW := &CityWriteReq{}
+	if err := decodeBody(r, cityW); err != nil {
+		return err
+	}
+	if err := cityW.validate(false); err != nil {
+		return err
+	}
+
+	cityData := cityW.apply(CityData{CityId: *cityW.CityId})
+	err := api.mutate(r, cityData.CityId, func(tx pgx.Tx) error {
+		if err := insertCityRow(tx, cityData); err != nil {
+			return err
+		}
+		return webhooks.Enqueue(tx, event(webhooks.EventCityCreated, cityData, cityData))
+	})
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/%s/cities/%d", requestVersion(r), cityData.CityId))
+	w.Header().Set("ETag", etag(cityData))
+	respond(w, r, http.StatusCreated, cityData)
+	return nil
+}
+
+func (api *Api) writeCity(w http.ResponseWriter, r *http.Request, partial bool) error {
+	cityId, err := parseCityId(r)
+	if err != nil {
+		return err
+	}
+
+	cityW := &CityWriteReq{}
+	if err := decodeBody(r, cityW); err != nil {
+		return err
+	}
+	if cityW.CityId == nil {
+		cityW.CityId = &cityId
+	}
+	if err := cityW.validate(partial); err != nil {
+		return err
+	}
+	if *cityW.CityId != cityId {
+		return &api_errors.ClientErr{
+			HttpCode: http.StatusBadRequest,
+			Message:  "Parameters not present or invalid",
+			Errors: map[string]string{
+				"city_id": "Must match the city id of the path",
+			},
+		}
+	}
+
+	var cityData CityData
+	err = api.mutate(r, cityId, func(tx pgx.Tx) error {
+		current, err := selectCityForUpdate(tx, cityId)
+		if err != nil {
+			return err
+		}
+		if err := checkIfMatch(r, true, etag(current)); err != nil {
+			return err
+		}
+		// A replacement clears the optional fields it omits.
+		if !partial {
+			current = CityData{CityId: cityId}
+		}
+		cityData = cityW.apply(current)
+		if err := updateCityRow(tx, cityData); err != nil {
+			return err
+		}
+		return webhooks.Enqueue(tx, event(webhooks.EventCityUpdated, cityData, cityData))
+	})
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("ETag", etag(cityData))
+	respond(w, r, http.StatusOK, cityData)
+	return nil
+}
+
+// @Summary		Replace city
+// @Description	Replace all the fields of a city, the optional ones omitted are cleared, requires a token with the admin role
+// @Accept		json
+// @Produce		json
+// @Param       cityId	path		int				true	"City id"
+// @Param       city	body		CityWriteReq	true	"City fields"
+// @Param       If-Match	header	string			false	"ETag of the current city"
+// @Success		200 	{object} 	CityData
+// @Failure     400 	{object} 	api_errors.ClientErr
+// @Failure     404 	{object} 	api_errors.ClientErr
+// @Failure     412 	{object} 	api_errors.ClientErr
+// @Router		/v0/admin/cities/{cityId} [put]
+func (api *Api) replaceCity(w http.ResponseWriter, r *http.Request) error {
+	return api.writeCity(w, r, false)
+}
+
+// @Summary		Update city
+// @Description	Update some of the fields of a city, requires a token with the admin role
+// @Accept		json
+// @Produce		json
+// @Param       cityId	path		int				true	"City id"
+// @Param       city	body		CityWriteReq	true	"City fields to update"
+// @Param       If-Match	header	string			false	"ETag of the current city"
+// @Success		200 	{object} 	CityData
+// @Failure     400 	{object} 	api_errors.ClientErr
+// @Failure     404 	{object} 	api_errors.ClientErr
+// @Failure     412 	{object} 	api_errors.ClientErr
+// @Router		/v0/admin/cities/{cityId} [patch]
+func (api *Api) updateCity(w http.ResponseWriter, r *http.Request) error {
+	return api.writeCity(w, r, true)
+}
+
+// @Summary		Delete city
+// @Description	Delete a city and its tags, requires a token with the admin role
+// @Param       cityId	path		int		true	"City id"
+// @Param       If-Match	header	string	false	"ETag of the current city"
+// @Success		204
+// @Failure     404 	{object} 	api_errors.ClientErr
+// @Failure     412 	{object} 	api_errors.ClientErr
+// @Router		/v0/admin/cities/{cityId} [delete]
+func (api *Api) deleteCity(w http.ResponseWriter, r *http.Request) error {
+	cityId, err := parseCityId(r)
+	if err != nil {
+		return err
+	}
+
+	err = api.mutate(r, cityId, func(tx pgx.Tx) error {
+		current, err := selectCityForUpdate(tx, cityId)
+		if err != nil {
+			return err
+		}
+		if err := checkIfMatch(r, true, etag(current)); err != nil {
+			return err
+		}
+		if err := deleteCityRow(tx, cityId); err != nil {
+			return err
+		}
+		return webhooks.Enqueue(tx, event(webhooks.EventCityDeleted, current, current))
+	})
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// writtenTags are the tags returned by the admin endpoints, v0 returned them
+// without labels.
+type writtenTags struct {
+	CityTags
+}
+
+func (tags writtenTags) v0() any {
+	return tags.TagsData()
+}
+
+func (api *Api) writeTags(w http.ResponseWriter, r *http.Request, partial bool) error {
+	cityId, err := parseCityId(r)
+	if err != nil {
+		return err
+	}
+
+	tagsW := &TagsWriteReq{}
+	if err := decodeBody(r, tagsW); err != nil {
+		return err
+	}
+
+	var tagsData TagsData
+	err = api.mutate(r, cityId, func(tx pgx.Tx) error {
+		cityData, err := selectCityForUpdate(tx, cityId)
+		if err != nil {
+			return err
+		}
+		current, exists, err := selectTagsForUpdate(tx, cityId)
+		if err != nil {
+			return err
+		}
+		if err := checkIfMatch(r, exists, etag(current)); err != nil {
+			return err
+		}
+		if err := tagsW.validate(partial && exists); err != nil {
+			return err
+		}
+		current.CityId = cityId
+		tagsData = tagsW.apply(current)
+		if err := upsertTagsRow(tx, tagsData); err != nil {
+			return err
+		}
+		return webhooks.Enqueue(tx, event(webhooks.EventTagsUpdated, cityData, tagsData))
+	})
+	if err != nil {
+		return err
+	}
+
+	categories, err := api.repo.GetVocabulary()
+	if err != nil {
+		return err
+	}
+	localizer, err := api.localizer(r)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("ETag", etag(tagsData))
+	respond(w, r, http.StatusOK, writtenTags{localizer.cityTags(tagsData, categories)})
+	return nil
+}
+
+// @Summary		Replace city tags
+// @Description	Create or replace all the tags of a city, requires a token with the admin role
+// @Accept		json
+// @Produce		json
+// @Param       cityId	path		int				true	"City id"
+// @Param       tags	body		TagsWriteReq	true	"Tags"
+// @Param       If-Match	header	string			false	"ETag of the current tags"
+// @Success		200 	{object} 	TagsData
+// @Failure     400 	{object} 	api_errors.ClientErr
+// @Failure     404 	{object} 	api_errors.ClientErr
+// @Failure     412 	{object} 	api_errors.ClientErr
+// @Router		/v0/admin/cities/{cityId}/tags [put]
+func (api *Api) replaceTags(w http.ResponseWriter, r *http.Request) error {
+	return api.writeTags(w, r, false)
+}
+
+// @Summary		Update city tags
+// @Description	Update some of the tags of a city, requires a token with the admin role
+// @Accept		json
+// @Produce		json
+// @Param       cityId	path		int				true	"City id"
+// @Param       tags	body		TagsWriteReq	true	"Tags to update"
+// @Param       If-Match	header	string			false	"ETag of the current tags"
+// @Success		200 	{object} 	TagsData
+// @Failure     400 	{object} 	api_errors.ClientErr
+// @Failure     404 	{object} 	api_errors.ClientErr
+// @Failure     412 	{object} 	api_errors.ClientErr
+// @Router		/v0/admin/cities/{cityId}/tags [patch]
+func (api *Api) updateTags(w http.ResponseWriter, r *http.Request) error {
+	return api.writeTags(w, r, true)
+}
+
+// @Summary		Delete city tags
+// @Description	Delete the tags of a city, requires a token with the admin role
+// @Param       cityId	path		int		true	"City id"
+// @Param       If-Match	header	string	false	"ETag of the current tags"
+// @Success		204
+// @Failure     404 	{object} 	api_errors.ClientErr
+// @Failure     412 	{object} 	api_errors.ClientErr
+// @Router		/v0/admin/cities/{cityId}/tags [delete]
+func (api *Api) deleteTags(w http.ResponseWriter, r *http.Request) error {
+	cityId, err := parseCityId(r)
+	if err != nil {
+		return err
+	}
+
+	err = api.mutate(r, cityId, func(tx pgx.Tx) error {
+		cityData, err := selectCityForUpdate(tx, cityId)
+		if err != nil {
+			return err
+		}
+		current, exists, err := selectTagsForUpdate(tx, cityId)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return &api_errors.CityNotFoundErr
+		}
+		if err := checkIfMatch(r, true, etag(current)); err != nil {
+			return err
+		}
+		if err := deleteTagsRow(tx, cityId); err != nil {
+			return err
+		}
+		return webhooks.Enqueue(tx, event(webhooks.EventTagsDeleted, cityData, current))
+	})
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
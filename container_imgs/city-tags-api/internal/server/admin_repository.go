@@ -0,0 +1,113 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"city-tags-api/internal/api_errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func selectCityForUpdate(tx pgx.Tx, cityId int) (CityData, error) {
+	cityData, err := scanCity(tx.QueryRow(
+		context.Background(),
+		"select "+cityColumns+" from city_tags.cities c where c.city_id = $1 for update",
+		cityId,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return CityData{}, &api_errors.CityNotFoundErr
+	}
+	return cityData, err
+}
+
+func insertCityRow(tx pgx.Tx, cityData CityData) error {
+	tag, err := tx.Exec(
+		context.Background(),
+		`insert into city_tags.cities
+		(city_id, city_name, continent, country_3_code, latitude, longitude, timezone, population, alternate_names)
+		values ($1, $2, $3, $4, $5, $6, $7, $8, $9) on conflict (city_id) do nothing`,
+		cityData.CityId, cityData.CityName, cityData.Continent, cityData.Country3Code,
+		cityData.Latitude, cityData.Longitude, cityData.Timezone, cityData.Population, alternateNames(cityData),
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return &api_errors.ClientErr{
+			HttpCode: http.StatusConflict,
+			Message:  "City already exists",
+		}
+	}
+	return nil
+}
+
+func updateCityRow(tx pgx.Tx, cityData CityData) error {
+	_, err := tx.Exec(
+		context.Background(),
+		`update city_tags.cities set city_name = $2, continent = $3, country_3_code = $4,
+		latitude = $5, longitude = $6, timezone = $7, population = $8, alternate_names = $9
+		where city_id = $1`,
+		cityData.CityId, cityData.CityName, cityData.Continent, cityData.Country3Code,
+		cityData.Latitude, cityData.Longitude, cityData.Timezone, cityData.Population, alternateNames(cityData),
+	)
+	return err
+}
+
+// alternateNames avoids writing NULL for a city without alternate names, pgx
+// encodes nil slices as NULL.
+func alternateNames(cityData CityData) []string {
+	if cityData.AlternateNames == nil {
+		return []string{}
+	}
+	return cityData.AlternateNames
+}
+
+func deleteCityRow(tx pgx.Tx, cityId int) error {
+	_, err := tx.Exec(context.Background(), "delete from city_tags.cities where city_id = $1", cityId)
+	return err
+}
+
+func selectTagsForUpdate(tx pgx.Tx, cityId int) (TagsData, bool, error) {
+	tagsData, err := scanTags(tx.QueryRow(
+		context.Background(),
+		`select `+tagsColumns+` from city_tags.city_tags where city_id = $1 for update`,
+		cityId,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return TagsData{}, false, nil
+	}
+	return tagsData, err == nil, err
+}
+
+func upsertTagsRow(tx pgx.Tx, tagsData TagsData) error {
+	_, err := tx.Exec(
+		context.Background(),
+		`insert into city_tags.city_tags (city_id, cloud_coverage_tag, humidity_tag, temp_tag,
+		precipitation_tag, air_quality_tag, daylight_hours_tag, city_size_tag)
+		values ($1, $2, $3, $4, $5, $6, $7, $8)
+		on conflict (city_id) do update set
+			cloud_coverage_tag = excluded.cloud_coverage_tag,
+			humidity_tag = excluded.humidity_tag,
+			temp_tag = excluded.temp_tag,
+			precipitation_tag = excluded.precipitation_tag,
+			air_quality_tag = excluded.air_quality_tag,
+			daylight_hours_tag = excluded.daylight_hours_tag,
+			city_size_tag = excluded.city_size_tag`,
+		tagsData.CityId,
+		tagsData.CloudCoverage,
+		tagsData.Humidity,
+		tagsData.Temp,
+		tagsData.Precipitation,
+		tagsData.AirQuality,
+		tagsData.DaylightHours,
+		tagsData.CitySize,
+	)
+	return err
+}
+
+func deleteTagsRow(tx pgx.Tx, cityId int) error {
+	_, err := tx.Exec(context.Background(), "delete from city_tags.city_tags where city_id = $1", cityId)
+	return err
+}
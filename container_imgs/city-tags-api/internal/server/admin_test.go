@@ -0,0 +1,202 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"city-tags-api/internal/database"
+
+	"github.com/go-chi/jwtauth/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func ptr[T any](value T) *T {
+	return &value
+}
+
+func TestCityWriteReq_validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   CityWriteReq
+		partial bool
+		isError bool
+	}{
+		{
+			"valid city",
+			CityWriteReq{CityId: ptr(1), CityName: ptr("Luján"), Continent: ptr("South America"), Country3Code: ptr("ARG")},
+			false,
+			false,
+		},
+		{"missing fields", CityWriteReq{CityId: ptr(1)}, false, true},
+		{"partial update", CityWriteReq{CityName: ptr("Luján")}, true, false},
+		{"invalid country code", CityWriteReq{Country3Code: ptr("XXX")}, true, true},
+		{"invalid continent", CityWriteReq{Continent: ptr("Atlantis")}, true, true},
+		{"empty city name", CityWriteReq{CityName: ptr(" ")}, true, true},
+		{"invalid city id", CityWriteReq{CityId: ptr(-1)}, true, true},
+		{
+			"geographic data",
+			CityWriteReq{
+				Latitude:       ptr(-34.57),
+				Longitude:      ptr(-59.1),
+				Timezone:       ptr("America/Argentina/Buenos_Aires"),
+				Population:     ptr(int64(106273)),
+				AlternateNames: ptr([]string{"Lujan"}),
+			},
+			true,
+			false,
+		},
+		{"latitude without longitude", CityWriteReq{Latitude: ptr(-34.57)}, true, true},
+		{"latitude out of range", CityWriteReq{Latitude: ptr(-95.0), Longitude: ptr(-59.1)}, true, true},
+		{"unknown timezone", CityWriteReq{Timezone: ptr("America/Lujan")}, true, true},
+		{"negative population", CityWriteReq{Population: ptr(int64(-1))}, true, true},
+		{"empty alternate name", CityWriteReq{AlternateNames: ptr([]string{""})}, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.input.validate(tt.partial)
+			if !tt.isError && err != nil {
+				t.Errorf("Didn't expect an error but one was received: %v", err)
+			}
+			if tt.isError && err == nil {
+				t.Errorf("Expected error but none was received")
+			}
+		})
+	}
+}
+
+func TestTagsWriteReq_validate(t *testing.T) {
+	allTags := TagsWriteReq{
+		CloudCoverage: ptr("partly cloudy"),
+		Humidity:      ptr("moderate"),
+		Temp:          ptr("mild"),
+		Precipitation: ptr("moderately wet"),
+		AirQuality:    ptr("good"),
+		DaylightHours: ptr("high"),
+		CitySize:      ptr("small"),
+	}
+
+	tests := []struct {
+		name    string
+		input   TagsWriteReq
+		partial bool
+		isError bool
+	}{
+		{"all tags", allTags, false, false},
+		{"missing tags", TagsWriteReq{Temp: ptr("mild")}, false, true},
+		{"partial update", TagsWriteReq{Temp: ptr("mild")}, true, false},
+		{"value not in vocabulary", TagsWriteReq{Temp: ptr("freezing")}, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.input.validate(tt.partial)
+			if !tt.isError && err != nil {
+				t.Errorf("Didn't expect an error but one was received: %v", err)
+			}
+			if tt.isError && err == nil {
+				t.Errorf("Expected error but none was received")
+			}
+		})
+	}
+}
+
+func TestCheckIfMatch(t *testing.T) {
+	currentETag := etag(CityData{CityId: 1})
+
+	tests := []struct {
+		name    string
+		ifMatch string
+		exists  bool
+		isError bool
+	}{
+		{"no header", "", true, false},
+		{"matching etag", currentETag, true, false},
+		{"one of several etags", `"other", ` + currentETag, true, false},
+		{"stale etag", `"stale"`, true, true},
+		{"wildcard", "*", true, false},
+		{"wildcard without resource", "*", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("PUT", "/v0/admin/cities/1", nil)
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+
+			err := checkIfMatch(req, tt.exists, currentETag)
+			if !tt.isError && err != nil {
+				t.Errorf("Didn't expect an error but one was received: %v", err)
+			}
+			if tt.isError && err == nil {
+				t.Errorf("Expected error but none was received")
+			}
+		})
+	}
+}
+
+func TestAdminOnly(t *testing.T) {
+	tokenAuth := jwtauth.New("HS256", []byte("test_enc_key"), nil)
+
+	tests := []struct {
+		name     string
+		claims   map[string]interface{}
+		expected int
+	}{
+		{"admin role", map[string]interface{}{"sub": "admin_user", "role": "admin"}, http.StatusOK},
+		{"no role", map[string]interface{}{"sub": "anonymous_user"}, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, _, err := tokenAuth.Encode(tt.claims)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req := httptest.NewRequest("POST", "/v0/admin/cities", nil)
+			req = req.WithContext(jwtauth.NewContext(context.Background(), token, nil))
+
+			writer := httptest.NewRecorder()
+			AdminOnly(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})).ServeHTTP(writer, req)
+
+			if writer.Code != tt.expected {
+				t.Errorf("AdminOnly returned code %d; want %d", writer.Code, tt.expected)
+			}
+		})
+	}
+}
+
+// committingDb runs every transaction and commits it, the methods it doesn't
+// override panic.
+type committingDb struct {
+	database.Service
+}
+
+func (committingDb) WithTx(fn func(tx pgx.Tx) error) error {
+	return fn(committingTx{})
+}
+
+type committingTx struct {
+	pgx.Tx
+}
+
+func (committingTx) Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, nil
+}
+
+func TestMutate_withoutCache(t *testing.T) {
+	api := NewApi(committingDb{}, stubRepository{}, jwtauth.New("HS256", []byte("test_enc_key"), nil), DefaultStreamConfig())
+	err := api.mutate(httptest.NewRequest("PUT", "/v1/admin/cities/3838859", nil), 3838859, func(tx pgx.Tx) error {
+		return nil
+	})
+	if err != nil {
+		t.Errorf("Didn't expect an error but one was received: %v", err)
+	}
+}
@@ -1,34 +1,105 @@
 package server
 
 import (
+	"context"
 	"fmt"
+	"log"
+	"net"
 	"net/http"
+	"strconv"
 	"time"
 
 	"city-tags-api/internal/database"
+	"city-tags-api/internal/webhooks"
 
 	"github.com/go-chi/jwtauth/v5"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 type Api struct {
 	db        database.Service
+	repo      Repository
+	cache     *CachedRepository
+	webhooks  webhooks.Store
+	changes   ChangeLog
 	tokenAuth *jwtauth.JWTAuth
+	stream    *ChangeHub
+	// changesNotifier wakes up the change feed requests waiting for a change.
+	changesNotifier *changeNotifier
 }
 
-func NewServer(port int) *http.Server {
-
+// NewApi returns the API reading from repo and writing to db, whose tokens
+// are verified with tokenAuth and whose change streams follow streamCfg. The
+// cache endpoints and the invalidation of the admin endpoints require repo to
+// be a CachedRepository.
+func NewApi(db database.Service, repo Repository, tokenAuth *jwtauth.JWTAuth, streamCfg StreamConfig) *Api {
 	api := &Api{
-		db:        database.New(),
-		tokenAuth: getTokenAuth(),
+		db:              db,
+		repo:            repo,
+		webhooks:        webhooks.NewStore(db),
+		changes:         NewChangeLog(db),
+		tokenAuth:       tokenAuth,
+		changesNotifier: &changeNotifier{},
+	}
+	api.stream = NewChangeHub(api.changes, streamCfg)
+	if cachedRepo, ok := repo.(*CachedRepository); ok {
+		api.cache = cachedRepo
 	}
+	return api
+}
+
+// NewServer returns the server of the API on port. The background work, and
+// the requests still open, like the change streams, stop when ctx is
+// cancelled.
+func NewServer(ctx context.Context, port int) *http.Server {
 
+	db := database.New()
+	cachedRepo := NewCachedRepository(&dbRepository{db: db}, getCacheCfg())
+	api := NewApi(db, cachedRepo, getTokenAuth(), getStreamCfg())
+	go webhooks.NewDispatcher(api.webhooks, getWebhooksCfg()).Run(ctx)
+	notified := make(chan struct{}, 1)
+	go api.listenChanges(ctx, notified)
+	go api.stream.Run(ctx, notified)
+	go cachedRepo.Run(ctx)
+
+	// gRPC is served on the same port over HTTP/2 without TLS.
 	server := &http.Server{
 		Addr:         fmt.Sprintf(":%d", port),
-		Handler:      api.RegisterRoutes(),
+		Handler:      h2c.NewHandler(multiplexGRPC(api.NewGRPCServer(), api.RegisterRoutes()), &http2.Server{}),
 		IdleTimeout:  time.Minute,
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 30 * time.Second,
+		BaseContext:  func(net.Listener) context.Context { return ctx },
 	}
 
 	return server
 }
+
+// listenChanges follows the commits of every instance notified on
+// database.ChangesChannel: it drops the cached values of the city, wakes up the change
+// feed and sends to notified for the change streams.
+func (api *Api) listenChanges(ctx context.Context, notified chan<- struct{}) {
+	for ctx.Err() == nil {
+		err := api.db.Listen(ctx, database.ChangesChannel, func(payload string) {
+			if cityId, err := strconv.Atoi(payload); err == nil && api.cache != nil {
+				if err := api.cache.Invalidate(cityId); err != nil {
+					log.Printf("Error invalidating the cache of city %d: %s", cityId, err.Error())
+				}
+			}
+			api.changesNotifier.notify()
+			select {
+			case notified <- struct{}{}:
+			default:
+			}
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("Error listening to %s, retrying: %s", database.ChangesChannel, err.Error())
+		select {
+		case <-ctx.Done():
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+type AuditEntry struct {
+	AuditId   int64           `json:"audit_id"`
+	Table     string          `json:"table"`
+	Action    string          `json:"action"`
+	CityId    int             `json:"city_id"`
+	Actor     string          `json:"actor"`
+	RequestId *string         `json:"request_id"`
+	ChangedAt time.Time       `json:"changed_at"`
+	Before    json.RawMessage `json:"before" swaggertype:"object"`
+	After     json.RawMessage `json:"after" swaggertype:"object"`
+}
+
+type GetCityHistoryResp struct {
+	History []AuditEntry `json:"history"`
+	Offset  int          `json:"offset"`
+}
+
+type ListCityHistoryResp struct {
+	History []AuditEntry `json:"history"`
+	Page    Page         `json:"page"`
+}
+
+func (resp ListCityHistoryResp) v0() any {
+	return GetCityHistoryResp{History: resp.History, Offset: resp.Page.Offset + len(resp.History)}
+}
+
+type GetCityHistoryReq struct {
+	cityId int
+	offset int
+	limit  int
+}
+
+func (getHistR *GetCityHistoryReq) validate(r *http.Request) error {
+	cityId, err := parseCityId(r)
+	if err != nil {
+		return err
+	}
+
+	offset, limit, err := parsePagination(r.URL.Query())
+	if err != nil {
+		return err
+	}
+
+	getHistR.cityId = cityId
+	getHistR.offset = offset
+	getHistR.limit = limit
+	return nil
+}
+
+// @Summary		Get city history
+// @Description	Get every change made to a city and its tags, most recent first. Requires a token with the admin role
+// @Accept		json
+// @Produce		json
+// @Param       cityId	path	int		true	"City id"
+// @Param       offset  query	int		false	"Offset for pagination"
+// @Param       limit   query	int		false	"Limit for pagination, 100 by default and 1000 at most"
+// @Success		200 	{object} 	GetCityHistoryResp
+// @Failure     400 	{object} 	api_errors.ClientErr
+// @Failure     500 	{object} 	api_errors.ClientErr
+// @Router		/v0/admin/cities/{cityId}/history [get]
+func (api *Api) getCityHistory(w http.ResponseWriter, r *http.Request) error {
+	histReq := &GetCityHistoryReq{}
+	if err := histReq.validate(r); err != nil {
+		return err
+	}
+
+	rows, err := api.db.Query(
+		`select audit_id, table_name, action, city_id, actor, request_id, changed_at, before, after
+		from city_tags.audit_log where city_id = $1
+		order by audit_id desc limit $2 offset $3`,
+		histReq.cityId, histReq.limit, histReq.offset,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	history := ListCityHistoryResp{
+		History: []AuditEntry{},
+	}
+	for rows.Next() {
+		entry := AuditEntry{}
+		err = rows.Scan(
+			&entry.AuditId,
+			&entry.Table,
+			&entry.Action,
+			&entry.CityId,
+			&entry.Actor,
+			&entry.RequestId,
+			&entry.ChangedAt,
+			&entry.Before,
+			&entry.After,
+		)
+		if err != nil {
+			return err
+		}
+		history.History = append(history.History, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	history.Page = newPage(histReq.offset, histReq.limit, len(history.History))
+
+	respond(w, r, http.StatusOK, history)
+	return nil
+}
@@ -0,0 +1,44 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestGetCityHistoryReq_validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		cityId   string
+		query    string
+		expected GetCityHistoryReq
+		isError  bool
+	}{
+		{"default values", "3838859", "", GetCityHistoryReq{cityId: 3838859, limit: 100, offset: 0}, false},
+		{"pagination", "3838859", "?limit=10&offset=20", GetCityHistoryReq{cityId: 3838859, limit: 10, offset: 20}, false},
+		{"incorrect city id", "incorrectCityId", "", GetCityHistoryReq{}, true},
+		{"incorrect limit", "3838859", "?limit=a", GetCityHistoryReq{}, true},
+		{"listing filters ignored", "3838859", "?temperature=scorching&bbox=a", GetCityHistoryReq{cityId: 3838859, limit: 100, offset: 0}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/v0/admin/cities/{cityId}/history"+tt.query, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.SetPathValue("cityId", tt.cityId)
+
+			histReq := &GetCityHistoryReq{}
+			err = histReq.validate(req)
+			if !tt.isError && err != nil {
+				t.Errorf("Didn't expect an error but one was received")
+			}
+			if tt.isError && err == nil {
+				t.Errorf("Expected error but none was received")
+			}
+			if *histReq != tt.expected {
+				t.Errorf("GetCityHistoryReq.validate() = %v; want %v", *histReq, tt.expected)
+			}
+		})
+	}
+}
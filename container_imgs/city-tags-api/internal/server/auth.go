@@ -16,6 +16,8 @@ var (
 	env = os.Getenv("ENV")
 )
 
+const adminRole = "admin"
+
 func getTokenAuth() *jwtauth.JWTAuth {
 	var encKey string
 	if env == "LOCAL" {
@@ -37,19 +39,8 @@ func Authenticator(ja *jwtauth.JWTAuth) func(http.Handler) http.Handler {
 		return NewHandler(
 			func(w http.ResponseWriter, r *http.Request) error {
 				token, _, err := jwtauth.FromContext(r.Context())
-
-				if err != nil {
-					return &api_errors.ClientErr{
-						HttpCode: http.StatusUnauthorized,
-						Message:  err.Error(),
-					}
-				}
-
-				if token == nil || jwt.Validate(token, []jwt.ValidateOption{}...) != nil {
-					return &api_errors.ClientErr{
-						HttpCode: http.StatusUnauthorized,
-						Message:  http.StatusText(http.StatusUnauthorized),
-					}
+				if err := authenticate(token, err); err != nil {
+					return err
 				}
 
 				next.ServeHTTP(w, r)
@@ -58,3 +49,55 @@ func Authenticator(ja *jwtauth.JWTAuth) func(http.Handler) http.Handler {
 		)
 	}
 }
+
+// authenticate checks the token verified by jwtauth, err is the error of the
+// verification. It is shared by the REST and gRPC APIs.
+func authenticate(token jwt.Token, err error) error {
+	if err != nil {
+		return &api_errors.ClientErr{
+			HttpCode: http.StatusUnauthorized,
+			Message:  err.Error(),
+		}
+	}
+
+	if token == nil || jwt.Validate(token, []jwt.ValidateOption{}...) != nil {
+		return &api_errors.ClientErr{
+			HttpCode: http.StatusUnauthorized,
+			Message:  http.StatusText(http.StatusUnauthorized),
+		}
+	}
+	return nil
+}
+
+// AdminOnly only lets through requests whose token has the admin role claim.
+func AdminOnly(next http.Handler) http.Handler {
+	return NewHandler(
+		func(w http.ResponseWriter, r *http.Request) error {
+			_, claims, err := jwtauth.FromContext(r.Context())
+			if err != nil {
+				return &api_errors.ClientErr{
+					HttpCode: http.StatusUnauthorized,
+					Message:  err.Error(),
+				}
+			}
+
+			if role, _ := claims["role"].(string); role != adminRole {
+				return &api_errors.ForbiddenErr
+			}
+
+			next.ServeHTTP(w, r)
+			return nil
+		},
+	)
+}
+
+// subject returns the subject of the token of the request, or an empty string
+// if there is none.
+func subject(r *http.Request) string {
+	_, claims, err := jwtauth.FromContext(r.Context())
+	if err != nil {
+		return ""
+	}
+	sub, _ := claims["sub"].(string)
+	return sub
+}
@@ -0,0 +1,570 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"city-tags-api/internal/api_errors"
+	"city-tags-api/internal/cache"
+	"city-tags-api/internal/geo"
+	"city-tags-api/internal/locale"
+	"city-tags-api/internal/similarity"
+)
+
+type CacheCfg struct {
+	MaxEntries int
+	TTL        time.Duration
+	WarmUp     bool
+}
+
+func getCacheCfg() CacheCfg {
+	cfg := CacheCfg{
+		MaxEntries: 10000,
+		TTL:        10 * time.Minute,
+	}
+
+	if maxEntries := os.Getenv("CACHE_MAX_ENTRIES"); maxEntries != "" {
+		value, err := strconv.Atoi(maxEntries)
+		if err != nil {
+			log.Fatalf("CACHE_MAX_ENTRIES env variable error when parsing to integer %s", err.Error())
+		}
+		cfg.MaxEntries = value
+	}
+
+	if ttl := os.Getenv("CACHE_TTL"); ttl != "" {
+		value, err := time.ParseDuration(ttl)
+		if err != nil {
+			log.Fatalf("CACHE_TTL env variable error when parsing to duration %s", err.Error())
+		}
+		cfg.TTL = value
+	}
+
+	cfg.WarmUp = os.Getenv("CACHE_WARMUP") == "true"
+	return cfg
+}
+
+type pageKey struct {
+	offset int
+	limit  int
+	filter string
+}
+
+type periodKey struct {
+	cityId int
+	period string
+}
+
+// catalogue holds the whole dataset in memory when the cache runs in warm-up
+// mode, so reads never reach the database.
+type catalogue struct {
+	mu sync.RWMutex
+	// order holds the city ids in ascending order, the order of the listings
+	// in the database.
+	order      []int
+	cities     map[int]CityData
+	tags       map[int]TagsData
+	vocabulary []TagCategory
+	// reloads counts the cities reloaded, a refresh loaded before one of
+	// them is dropped.
+	reloads int
+}
+
+type CachedRepository struct {
+	repo *dbRepository
+	// refreshInterval is the time between two reloads of the catalogue, which
+	// picks up the writes missed while not listening to their notifications.
+	refreshInterval time.Duration
+
+	cities       *cache.Cache[int, CityData]
+	tags         *cache.Cache[int, TagsData]
+	periodTags   *cache.Cache[periodKey, TagsData]
+	pages        *cache.Cache[pageKey, []CityData]
+	vocabulary   *cache.Cache[string, []TagCategory]
+	similarity   *cache.Cache[string, *similarity.Index]
+	countries    *cache.Cache[pageKey, []CountryData]
+	country      *cache.Cache[string, CountryDetail]
+	continents   *cache.Cache[string, []ContinentData]
+	tagStats     *cache.Cache[string, []TagStatsGroup]
+	translations *cache.Cache[string, *Translations]
+	catalogue    *catalogue
+}
+
+func NewCachedRepository(repo *dbRepository, cfg CacheCfg) *CachedRepository {
+	cachedRepo := &CachedRepository{
+		repo:            repo,
+		refreshInterval: cfg.TTL,
+		cities:          cache.New[int, CityData](cfg.MaxEntries, cfg.TTL),
+		tags:            cache.New[int, TagsData](cfg.MaxEntries, cfg.TTL),
+		periodTags:      cache.New[periodKey, TagsData](cfg.MaxEntries, cfg.TTL),
+		pages:           cache.New[pageKey, []CityData](cfg.MaxEntries, cfg.TTL),
+		vocabulary:      cache.New[string, []TagCategory](1, cfg.TTL),
+		similarity:      cache.New[string, *similarity.Index](1, cfg.TTL),
+		countries:       cache.New[pageKey, []CountryData](cfg.MaxEntries, cfg.TTL),
+		country:         cache.New[string, CountryDetail](cfg.MaxEntries, cfg.TTL),
+		continents:      cache.New[string, []ContinentData](1, cfg.TTL),
+		tagStats:        cache.New[string, []TagStatsGroup](cfg.MaxEntries, cfg.TTL),
+		translations:    cache.New[string, *Translations](len(locale.Supported), cfg.TTL),
+	}
+
+	if cfg.WarmUp {
+		if err := cachedRepo.warmUp(); err != nil {
+			log.Fatalf("Unable to warm up cache: %v", err)
+		}
+	}
+	return cachedRepo
+}
+
+func (cachedRepo *CachedRepository) warmUp() error {
+	catalogue, err := cachedRepo.loadCatalogue()
+	if err != nil {
+		return err
+	}
+	cachedRepo.catalogue = catalogue
+	log.Printf("Cache warmed up with %d cities and %d tags", len(catalogue.cities), len(catalogue.tags))
+	return nil
+}
+
+func (cachedRepo *CachedRepository) loadCatalogue() (*catalogue, error) {
+	cities, err := cachedRepo.repo.getAllCities()
+	if err != nil {
+		return nil, err
+	}
+	tags, err := cachedRepo.repo.getAllTags()
+	if err != nil {
+		return nil, err
+	}
+	vocabulary, err := cachedRepo.repo.GetVocabulary()
+	if err != nil {
+		return nil, err
+	}
+
+	catalogue := &catalogue{
+		order:      make([]int, 0, len(cities)),
+		cities:     make(map[int]CityData, len(cities)),
+		tags:       make(map[int]TagsData, len(tags)),
+		vocabulary: vocabulary,
+	}
+	for _, city := range cities {
+		catalogue.order = append(catalogue.order, city.CityId)
+		catalogue.cities[city.CityId] = city
+	}
+	for _, tag := range tags {
+		catalogue.tags[tag.CityId] = tag
+	}
+	return catalogue, nil
+}
+
+// Run reloads the catalogue every refreshInterval until ctx is cancelled, the
+// per-key caches expire with their TTL instead.
+func (cachedRepo *CachedRepository) Run(ctx context.Context) {
+	cat := cachedRepo.catalogue
+	if cat == nil || cachedRepo.refreshInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(cachedRepo.refreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		cat.mu.RLock()
+		reloads := cat.reloads
+		cat.mu.RUnlock()
+		loaded, err := cachedRepo.loadCatalogue()
+		if err != nil {
+			log.Printf("Error refreshing the cache: %s", err.Error())
+			continue
+		}
+		cat.mu.Lock()
+		if cat.reloads == reloads {
+			cat.order, cat.cities, cat.tags, cat.vocabulary = loaded.order, loaded.cities, loaded.tags, loaded.vocabulary
+		}
+		cat.mu.Unlock()
+		cachedRepo.similarity.Purge()
+	}
+}
+
+func (cachedRepo *CachedRepository) GetCity(cityId int) (CityData, error) {
+	if cat := cachedRepo.catalogue; cat != nil {
+		cat.mu.RLock()
+		defer cat.mu.RUnlock()
+		city, ok := cat.cities[cityId]
+		if !ok {
+			return CityData{}, &api_errors.CityNotFoundErr
+		}
+		return city, nil
+	}
+
+	return cachedRepo.cities.GetOrLoad(cityId, func() (CityData, error) {
+		return cachedRepo.repo.GetCity(cityId)
+	})
+}
+
+// GetCitiesBatch serves the cached cities and loads the rest with a single
+// query.
+func (cachedRepo *CachedRepository) GetCitiesBatch(cityIds []int) (map[int]CityData, error) {
+	cities := make(map[int]CityData, len(cityIds))
+	if cat := cachedRepo.catalogue; cat != nil {
+		cat.mu.RLock()
+		defer cat.mu.RUnlock()
+		for _, cityId := range cityIds {
+			if city, ok := cat.cities[cityId]; ok {
+				cities[cityId] = city
+			}
+		}
+		return cities, nil
+	}
+
+	missing := []int{}
+	for _, cityId := range cityIds {
+		if city, ok := cachedRepo.cities.Get(cityId); ok {
+			cities[cityId] = city
+		} else {
+			missing = append(missing, cityId)
+		}
+	}
+	if len(missing) == 0 {
+		return cities, nil
+	}
+
+	loaded, err := cachedRepo.repo.GetCitiesBatch(missing)
+	if err != nil {
+		return nil, err
+	}
+	for cityId, city := range loaded {
+		cachedRepo.cities.Set(cityId, city)
+		cities[cityId] = city
+	}
+	return cities, nil
+}
+
+func (cachedRepo *CachedRepository) GetCities(offset int, limit int) ([]CityData, error) {
+	if cat := cachedRepo.catalogue; cat != nil {
+		cat.mu.RLock()
+		defer cat.mu.RUnlock()
+		cities := []CityData{}
+		for index := max(offset, 0); index < len(cat.order) && len(cities) < limit; index++ {
+			cities = append(cities, cat.cities[cat.order[index]])
+		}
+		return cities, nil
+	}
+
+	return cachedRepo.pages.GetOrLoad(pageKey{offset: offset, limit: limit}, func() ([]CityData, error) {
+		return cachedRepo.repo.GetCities(offset, limit)
+	})
+}
+
+// FilterCities is served from memory in warm-up mode only for the annual tags,
+// the tags of months and seasons are not part of the catalogue.
+func (cachedRepo *CachedRepository) FilterCities(filter CityFilter, offset int, limit int) ([]CityData, error) {
+	if cat := cachedRepo.catalogue; cat != nil && filter.Period == "" {
+		cat.mu.RLock()
+		defer cat.mu.RUnlock()
+		cities := []CityData{}
+		skipped := 0
+		for _, cityId := range cat.order {
+			var tagsData *TagsData
+			if tags, ok := cat.tags[cityId]; ok {
+				tagsData = &tags
+			}
+			if !filter.matches(cat.cities[cityId], tagsData) {
+				continue
+			}
+			if skipped < offset {
+				skipped++
+				continue
+			}
+			if len(cities) == limit {
+				break
+			}
+			cities = append(cities, cat.cities[cityId])
+		}
+		return cities, nil
+	}
+
+	key := pageKey{offset: offset, limit: limit, filter: filter.key()}
+	return cachedRepo.pages.GetOrLoad(key, func() ([]CityData, error) {
+		return cachedRepo.repo.FilterCities(filter, offset, limit)
+	})
+}
+
+func (cachedRepo *CachedRepository) GetTags(cityId int) (TagsData, error) {
+	if cat := cachedRepo.catalogue; cat != nil {
+		cat.mu.RLock()
+		defer cat.mu.RUnlock()
+		tags, ok := cat.tags[cityId]
+		if !ok {
+			return TagsData{}, &api_errors.CityNotFoundErr
+		}
+		return tags, nil
+	}
+
+	return cachedRepo.tags.GetOrLoad(cityId, func() (TagsData, error) {
+		return cachedRepo.repo.GetTags(cityId)
+	})
+}
+
+// GetPeriodTags is cached only until the TTL expires, the tags of months and
+// seasons are written by the retag command and not through the API.
+func (cachedRepo *CachedRepository) GetPeriodTags(cityId int, period string) (TagsData, error) {
+	key := periodKey{cityId: cityId, period: period}
+	return cachedRepo.periodTags.GetOrLoad(key, func() (TagsData, error) {
+		return cachedRepo.repo.GetPeriodTags(cityId, period)
+	})
+}
+
+// GetTagsBatch serves the cached tags and loads the rest with a single query.
+func (cachedRepo *CachedRepository) GetTagsBatch(cityIds []int, period string) (map[int]TagsData, error) {
+	tags := make(map[int]TagsData, len(cityIds))
+	if cat := cachedRepo.catalogue; cat != nil && period == "" {
+		cat.mu.RLock()
+		defer cat.mu.RUnlock()
+		for _, cityId := range cityIds {
+			if tagsData, ok := cat.tags[cityId]; ok {
+				tags[cityId] = tagsData
+			}
+		}
+		return tags, nil
+	}
+
+	missing := []int{}
+	for _, cityId := range cityIds {
+		var tagsData TagsData
+		var ok bool
+		if period == "" {
+			tagsData, ok = cachedRepo.tags.Get(cityId)
+		} else {
+			tagsData, ok = cachedRepo.periodTags.Get(periodKey{cityId: cityId, period: period})
+		}
+		if ok {
+			tags[cityId] = tagsData
+		} else {
+			missing = append(missing, cityId)
+		}
+	}
+	if len(missing) == 0 {
+		return tags, nil
+	}
+
+	loaded, err := cachedRepo.repo.GetTagsBatch(missing, period)
+	if err != nil {
+		return nil, err
+	}
+	for cityId, tagsData := range loaded {
+		if period == "" {
+			cachedRepo.tags.Set(cityId, tagsData)
+		} else {
+			cachedRepo.periodTags.Set(periodKey{cityId: cityId, period: period}, tagsData)
+		}
+		tags[cityId] = tagsData
+	}
+	return tags, nil
+}
+
+// GetTagsAsOf is not cached, point in time queries are rare and would
+// otherwise need to be invalidated on every write.
+func (cachedRepo *CachedRepository) GetTagsAsOf(cityId int, asOf time.Time) (TagsData, error) {
+	return cachedRepo.repo.GetTagsAsOf(cityId, asOf)
+}
+
+func (cachedRepo *CachedRepository) GetTagsHistory(cityId int, offset int, limit int) ([]TagsVersion, error) {
+	return cachedRepo.repo.GetTagsHistory(cityId, offset, limit)
+}
+
+func (cachedRepo *CachedRepository) GetVocabulary() ([]TagCategory, error) {
+	if cat := cachedRepo.catalogue; cat != nil {
+		cat.mu.RLock()
+		defer cat.mu.RUnlock()
+		return cat.vocabulary, nil
+	}
+	return cachedRepo.vocabulary.GetOrLoad("vocabulary", cachedRepo.repo.GetVocabulary)
+}
+
+// GetSimilarityIndex builds the index once and reuses it until the TTL expires
+// or a city changes. In warm-up mode it is built from the catalogue.
+func (cachedRepo *CachedRepository) GetSimilarityIndex() (*similarity.Index, error) {
+	return cachedRepo.similarity.GetOrLoad("similarity", func() (*similarity.Index, error) {
+		cat := cachedRepo.catalogue
+		if cat == nil {
+			return cachedRepo.repo.GetSimilarityIndex()
+		}
+
+		cat.mu.RLock()
+		defer cat.mu.RUnlock()
+		cities := make([]CityData, 0, len(cat.order))
+		for _, cityId := range cat.order {
+			cities = append(cities, cat.cities[cityId])
+		}
+		return newSimilarityIndex(cities, cat.tags), nil
+	})
+}
+
+// GetNearbyCities is not cached, coordinates are rarely repeated. In warm-up
+// mode distances are computed over the catalogue.
+func (cachedRepo *CachedRepository) GetNearbyCities(center geo.Point, radiusKm float64, limit int) ([]NearbyCity, error) {
+	cat := cachedRepo.catalogue
+	if cat == nil {
+		return cachedRepo.repo.GetNearbyCities(center, radiusKm, limit)
+	}
+
+	cat.mu.RLock()
+	defer cat.mu.RUnlock()
+	cities := make([]CityData, 0, len(cat.order))
+	for _, cityId := range cat.order {
+		cities = append(cities, cat.cities[cityId])
+	}
+	return nearbyCities(cities, center, radiusKm, limit), nil
+}
+
+// GetCountries, GetCountry and GetContinents are aggregates computed by the
+// database also in warm-up mode, their results are cached until a city changes.
+func (cachedRepo *CachedRepository) GetCountries(continent string, offset int, limit int) ([]CountryData, error) {
+	key := pageKey{offset: offset, limit: limit, filter: continent}
+	return cachedRepo.countries.GetOrLoad(key, func() ([]CountryData, error) {
+		return cachedRepo.repo.GetCountries(continent, offset, limit)
+	})
+}
+
+func (cachedRepo *CachedRepository) GetCountry(alpha3Code string) (CountryDetail, error) {
+	return cachedRepo.country.GetOrLoad(alpha3Code, func() (CountryDetail, error) {
+		return cachedRepo.repo.GetCountry(alpha3Code)
+	})
+}
+
+func (cachedRepo *CachedRepository) GetContinents() ([]ContinentData, error) {
+	return cachedRepo.continents.GetOrLoad("continents", cachedRepo.repo.GetContinents)
+}
+
+// GetTagStats is computed by the database also in warm-up mode and cached per
+// filter and grouping until a city changes.
+func (cachedRepo *CachedRepository) GetTagStats(filter CityFilter, groupBy string) ([]TagStatsGroup, error) {
+	return cachedRepo.tagStats.GetOrLoad(groupBy+"|"+filter.key(), func() ([]TagStatsGroup, error) {
+		return cachedRepo.repo.GetTagStats(filter, groupBy)
+	})
+}
+
+// GetTranslations is cached only until the TTL expires, translations are
+// loaded into the database and not written through the API.
+func (cachedRepo *CachedRepository) GetTranslations(language string) (*Translations, error) {
+	return cachedRepo.translations.GetOrLoad(language, func() (*Translations, error) {
+		return cachedRepo.repo.GetTranslations(language)
+	})
+}
+
+// Invalidate drops every cached value that depends on cityId. In warm-up mode
+// the city is reloaded from the database instead.
+func (cachedRepo *CachedRepository) Invalidate(cityId int) error {
+	cachedRepo.cities.Invalidate(cityId)
+	cachedRepo.tags.Invalidate(cityId)
+	// Deleting a city also deletes the tags of its months and seasons.
+	cachedRepo.periodTags.Purge()
+	cachedRepo.pages.Purge()
+	cachedRepo.countries.Purge()
+	cachedRepo.country.Purge()
+	cachedRepo.continents.Purge()
+	cachedRepo.tagStats.Purge()
+
+	if cachedRepo.catalogue != nil {
+		if err := cachedRepo.reloadCity(cityId); err != nil {
+			return err
+		}
+	}
+	// Purged last so the index is not rebuilt from a stale catalogue.
+	cachedRepo.similarity.Purge()
+	return nil
+}
+
+func (cachedRepo *CachedRepository) reloadCity(cityId int) error {
+	city, cityErr := cachedRepo.repo.GetCity(cityId)
+	if cityErr != nil && !isNotFound(cityErr) {
+		return cityErr
+	}
+	tags, tagsErr := cachedRepo.repo.GetTags(cityId)
+	if tagsErr != nil && !isNotFound(tagsErr) {
+		return tagsErr
+	}
+
+	cat := cachedRepo.catalogue
+	cat.mu.Lock()
+	defer cat.mu.Unlock()
+
+	cat.reloads++
+	_, existed := cat.cities[cityId]
+	if cityErr != nil {
+		delete(cat.cities, cityId)
+		if existed {
+			cat.order = removeCityId(cat.order, cityId)
+		}
+	} else {
+		cat.cities[cityId] = city
+		if !existed {
+			// The pages are ordered by city id, like in the database.
+			index, _ := slices.BinarySearch(cat.order, cityId)
+			cat.order = slices.Insert(cat.order, index, cityId)
+		}
+	}
+
+	if tagsErr != nil {
+		delete(cat.tags, cityId)
+	} else {
+		cat.tags[cityId] = tags
+	}
+	return nil
+}
+
+func (cachedRepo *CachedRepository) Stats() map[string]cache.Stats {
+	return map[string]cache.Stats{
+		"cities":       cachedRepo.cities.Stats(),
+		"tags":         cachedRepo.tags.Stats(),
+		"period_tags":  cachedRepo.periodTags.Stats(),
+		"pages":        cachedRepo.pages.Stats(),
+		"vocabulary":   cachedRepo.vocabulary.Stats(),
+		"countries":    cachedRepo.countries.Stats(),
+		"country":      cachedRepo.country.Stats(),
+		"continents":   cachedRepo.continents.Stats(),
+		"tag_stats":    cachedRepo.tagStats.Stats(),
+		"translations": cachedRepo.translations.Stats(),
+	}
+}
+
+func isNotFound(err error) bool {
+	var clientErr *api_errors.ClientErr
+	return errors.As(err, &clientErr) && clientErr.HttpCode == http.StatusNotFound
+}
+
+func removeCityId(order []int, cityId int) []int {
+	for index, id := range order {
+		if id == cityId {
+			return append(order[:index], order[index+1:]...)
+		}
+	}
+	return order
+}
+
+// @Summary		Get cache statistics
+// @Description	Get hit, miss and eviction counters of the in-memory cache, empty without cache, requires a token with the admin role
+// @Accept		json
+// @Produce		json
+// @Success		200 {object} map[string]cache.Stats
+// @Failure     500 {object} api_errors.ClientErr
+// @Router		/v0/admin/cache/stats [get]
+func (api *Api) getCacheStats(w http.ResponseWriter, r *http.Request) error {
+	// An API without cache has no statistics.
+	stats := map[string]cache.Stats{}
+	if api.cache != nil {
+		stats = api.cache.Stats()
+	}
+	respond(w, r, http.StatusOK, stats)
+	return nil
+}
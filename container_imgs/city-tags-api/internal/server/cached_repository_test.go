@@ -0,0 +1,82 @@
+package server
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newTestCatalogue() *CachedRepository {
+	cities := []CityData{
+		{CityId: 3838859, CityName: "Río Gallegos", Continent: "South America", Country3Code: "ARG"},
+		{CityId: 3430443, CityName: "Necochea", Continent: "South America", Country3Code: "ARG"},
+		{CityId: 3430988, CityName: "Luján", Continent: "South America", Country3Code: "ARG"},
+	}
+	cat := &catalogue{
+		cities: map[int]CityData{},
+		tags:   map[int]TagsData{},
+	}
+	for _, city := range cities {
+		cat.order = append(cat.order, city.CityId)
+		cat.cities[city.CityId] = city
+		cat.tags[city.CityId] = TagsData{CityId: city.CityId, CitySize: "small"}
+	}
+	return &CachedRepository{catalogue: cat}
+}
+
+func TestCachedRepository_GetCities(t *testing.T) {
+	repo := newTestCatalogue()
+
+	tests := []struct {
+		name     string
+		offset   int
+		limit    int
+		expected []int
+	}{
+		{"all cities", 0, 100, []int{3838859, 3430443, 3430988}},
+		{"offset", 1, 100, []int{3430443, 3430988}},
+		{"limit", 0, 1, []int{3838859}},
+		{"limit and offset", 1, 1, []int{3430443}},
+		{"offset out of range", 10, 100, []int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cities, err := repo.GetCities(tt.offset, tt.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cityIds := []int{}
+			for _, city := range cities {
+				cityIds = append(cityIds, city.CityId)
+			}
+			if !reflect.DeepEqual(cityIds, tt.expected) {
+				t.Errorf("GetCities(%d, %d) = %v; want %v", tt.offset, tt.limit, cityIds, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCachedRepository_NotFound(t *testing.T) {
+	repo := newTestCatalogue()
+
+	if _, err := repo.GetCity(1); !isNotFound(err) {
+		t.Errorf("GetCity returned %v; want not found error", err)
+	}
+	if _, err := repo.GetTags(1); !isNotFound(err) {
+		t.Errorf("GetTags returned %v; want not found error", err)
+	}
+
+	tags, err := repo.GetTags(3430443)
+	if err != nil || tags.CitySize != "small" {
+		t.Errorf("GetTags(3430443) = %v, %v; want small city", tags, err)
+	}
+}
+
+func TestGetCacheStats_withoutCache(t *testing.T) {
+	writer := serveAdmin(t, nil, "GET", "/v1/admin/cache/stats", "")
+	if writer.Code != http.StatusOK || strings.TrimSpace(writer.Body.String()) != "{}" {
+		t.Errorf("expected empty statistics, got %d %s", writer.Code, writer.Body.String())
+	}
+}
@@ -0,0 +1,304 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"city-tags-api/internal/api_errors"
+	"city-tags-api/internal/database"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	maxChangesLimit = 1000
+	maxChangesWait  = 25 * time.Second
+	// changesPollInterval is the time between two reads of the change log
+	// while waiting, the changes held back by an older transaction are only
+	// seen then.
+	changesPollInterval = time.Second
+)
+
+// ChangeToken is the position of a change in the change log, ordered by the
+// transaction of the change and then by the change itself.
+type ChangeToken struct {
+	TxId     uint64
+	ChangeId int64
+}
+
+// String returns the token as sent to the clients, which don't need to know
+// what it is made of.
+func (token ChangeToken) String() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d.%d", token.TxId, token.ChangeId)))
+}
+
+// Less reports whether token is before other in the change log.
+func (token ChangeToken) Less(other ChangeToken) bool {
+	return token.TxId < other.TxId || (token.TxId == other.TxId && token.ChangeId < other.ChangeId)
+}
+
+func parseChangeToken(value string) (ChangeToken, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return ChangeToken{}, err
+	}
+	txId, changeId, found := strings.Cut(string(decoded), ".")
+	if !found {
+		return ChangeToken{}, fmt.Errorf("malformed change token %q", decoded)
+	}
+	token := ChangeToken{}
+	if token.TxId, err = strconv.ParseUint(txId, 10, 64); err != nil {
+		return ChangeToken{}, err
+	}
+	if token.ChangeId, err = strconv.ParseInt(changeId, 10, 64); err != nil {
+		return ChangeToken{}, err
+	}
+	return token, nil
+}
+
+type Change struct {
+	// Token resumes the feed right after this change.
+	Token     string `json:"token"`
+	Resource  string `json:"resource" enums:"city,tags"`
+	Operation string `json:"operation" enums:"insert,update,delete"`
+	CityId    int    `json:"city_id"`
+	// Country3Code is null for the tags deleted along with their city.
+	Country3Code *string `json:"country_3_code"`
+	// Categories are the tag categories changed, empty for the cities.
+	Categories []string  `json:"categories"`
+	ChangedAt  time.Time `json:"changed_at"`
+	// Data is the city or its tags after the change, before it when deleted.
+	Data json.RawMessage `json:"data" swaggertype:"object"`
+}
+
+type ListChangesResp struct {
+	Changes []Change `json:"changes"`
+	// Next is the since of the following request.
+	Next    string `json:"next"`
+	HasMore bool   `json:"has_more"`
+}
+
+// ChangeLog reads the changes recorded by the triggers of the cities and the
+// tags.
+type ChangeLog interface {
+	// Changes returns the changes after since, only once every transaction
+	// that could still add changes before them is finished.
+	Changes(since ChangeToken, limit int) ([]Change, error)
+	// Latest returns the token before the changes not visible yet.
+	Latest() (ChangeToken, error)
+}
+
+type dbChangeLog struct {
+	db database.Service
+}
+
+func NewChangeLog(db database.Service) ChangeLog {
+	return &dbChangeLog{db: db}
+}
+
+func (changeLog *dbChangeLog) Changes(since ChangeToken, limit int) ([]Change, error) {
+	rows, err := changeLog.db.Query(
+		`select tx_id::text, change_id, resource, operation, city_id, country_3_code, categories, changed_at, data
+		from city_tags.change_log
+		where (tx_id, change_id) > ($1::text::xid8, $2)
+		and tx_id < pg_snapshot_xmin(pg_current_snapshot())
+		order by tx_id, change_id limit $3`,
+		strconv.FormatUint(since.TxId, 10), since.ChangeId, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (Change, error) {
+		var txId string
+		token := ChangeToken{}
+		change := Change{}
+		err := row.Scan(
+			&txId,
+			&token.ChangeId,
+			&change.Resource,
+			&change.Operation,
+			&change.CityId,
+			&change.Country3Code,
+			&change.Categories,
+			&change.ChangedAt,
+			&change.Data,
+		)
+		if err != nil {
+			return change, err
+		}
+		if token.TxId, err = strconv.ParseUint(txId, 10, 64); err != nil {
+			return change, err
+		}
+		change.Token = token.String()
+		return change, nil
+	})
+}
+
+func (changeLog *dbChangeLog) Latest() (ChangeToken, error) {
+	rows, err := changeLog.db.Query("select pg_snapshot_xmin(pg_current_snapshot())::text")
+	if err != nil {
+		return ChangeToken{}, err
+	}
+	xmin, err := pgx.CollectExactlyOneRow(rows, pgx.RowTo[string])
+	if err != nil {
+		return ChangeToken{}, err
+	}
+	txId, err := strconv.ParseUint(xmin, 10, 64)
+	if err != nil {
+		return ChangeToken{}, err
+	}
+	return ChangeToken{TxId: txId}, nil
+}
+
+// changeNotifier wakes up the long-polling requests when a change is
+// committed. The zero value is ready to use, a nil one never notifies.
+type changeNotifier struct {
+	mu      sync.Mutex
+	changed chan struct{}
+}
+
+// wait returns a channel closed on the next notification.
+func (notifier *changeNotifier) wait() <-chan struct{} {
+	if notifier == nil {
+		return nil
+	}
+	notifier.mu.Lock()
+	defer notifier.mu.Unlock()
+	if notifier.changed == nil {
+		notifier.changed = make(chan struct{})
+	}
+	return notifier.changed
+}
+
+func (notifier *changeNotifier) notify() {
+	if notifier == nil {
+		return
+	}
+	notifier.mu.Lock()
+	defer notifier.mu.Unlock()
+	if notifier.changed != nil {
+		close(notifier.changed)
+		notifier.changed = nil
+	}
+}
+
+type GetChangesReq struct {
+	since ChangeToken
+	// now starts the feed at the changes not visible yet.
+	now   bool
+	limit int
+	wait  time.Duration
+}
+
+func (getChR *GetChangesReq) validate(r *http.Request) error {
+	errs := map[string]string{}
+	query := r.URL.Query()
+
+	switch since := query.Get("since"); since {
+	case "":
+	case "now":
+		getChR.now = true
+	default:
+		token, err := parseChangeToken(since)
+		if err != nil {
+			errs["since"] = "Must be a token returned by this endpoint or now"
+		}
+		getChR.since = token
+	}
+
+	getChR.limit = defaultLimit
+	if limitParam := query.Get("limit"); limitParam != "" {
+		limit, err := strconv.Atoi(limitParam)
+		if err != nil || limit < 1 || limit > maxChangesLimit {
+			errs["limit"] = fmt.Sprintf("Must be an integer between 1 and %d", maxChangesLimit)
+		}
+		getChR.limit = limit
+	}
+
+	if waitParam := query.Get("wait"); waitParam != "" {
+		wait, err := strconv.Atoi(waitParam)
+		if err != nil || wait < 0 || time.Duration(wait)*time.Second > maxChangesWait {
+			errs["wait"] = fmt.Sprintf("Must be a number of seconds between 0 and %d", int(maxChangesWait.Seconds()))
+		}
+		getChR.wait = time.Duration(wait) * time.Second
+	}
+
+	if len(errs) > 0 {
+		return &api_errors.ClientErr{
+			HttpCode: http.StatusBadRequest,
+			Message:  "Parameters not present or invalid",
+			Errors:   errs,
+		}
+	}
+	return nil
+}
+
+// @Summary		List changes
+// @Description	List the inserts, updates and deletes of the cities and their tags in the order they were made, to keep a copy of the dataset in sync. Start without since to read the whole log, or with now to only read the new changes, then pass the next token of each response. A change can be received again after a restart from an older token. With wait, the request waits up to that many seconds for a change when there is none
+// @Produce		json
+// @Param       since	query	string	false	"Token of the last change read, or now"
+// @Param       limit	query	int		false	"Maximum number of changes, up to 1000"
+// @Param       wait	query	int		false	"Seconds to wait for a change when there is none, up to 25"
+// @Success		200 	{object} 	ListChangesResp
+// @Failure     400 	{object} 	api_errors.ClientErr
+// @Failure     500 	{object} 	api_errors.ClientErr
+// @Router		/v0/changes [get]
+func (api *Api) getChanges(w http.ResponseWriter, r *http.Request) error {
+	changesReq := &GetChangesReq{}
+	if err := changesReq.validate(r); err != nil {
+		return err
+	}
+
+	since := changesReq.since
+	if changesReq.now {
+		latest, err := api.changes.Latest()
+		if err != nil {
+			return err
+		}
+		since = latest
+	}
+
+	deadline := time.NewTimer(changesReq.wait)
+	defer deadline.Stop()
+	for {
+		// The notification is awaited before reading so that a change
+		// committed in between isn't missed.
+		changed := api.changesNotifier.wait()
+		changes, err := api.changes.Changes(since, changesReq.limit)
+		if err != nil {
+			return err
+		}
+		if len(changes) > 0 || changesReq.wait == 0 {
+			respond(w, r, http.StatusOK, newListChangesResp(since, changes, changesReq.limit))
+			return nil
+		}
+
+		select {
+		case <-r.Context().Done():
+			return nil
+		case <-deadline.C:
+			respond(w, r, http.StatusOK, newListChangesResp(since, changes, changesReq.limit))
+			return nil
+		case <-changed:
+		case <-time.After(changesPollInterval):
+		}
+	}
+}
+
+func newListChangesResp(since ChangeToken, changes []Change, limit int) ListChangesResp {
+	resp := ListChangesResp{
+		Changes: append([]Change{}, changes...),
+		Next:    since.String(),
+		HasMore: len(changes) == limit,
+	}
+	if len(changes) > 0 {
+		resp.Next = changes[len(changes)-1].Token
+	}
+	return resp
+}
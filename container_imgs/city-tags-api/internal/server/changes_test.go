@@ -0,0 +1,148 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/jwtauth/v5"
+)
+
+// memoryChangeLog returns every change after since, all of them in the same
+// transaction.
+type memoryChangeLog struct {
+	mu      sync.Mutex
+	changes []Change
+	latest  ChangeToken
+}
+
+func (changeLog *memoryChangeLog) add(operation string) {
+	changeLog.append(Change{Resource: "tags", Operation: operation, CityId: 3117735})
+}
+
+func (changeLog *memoryChangeLog) append(change Change) {
+	changeLog.mu.Lock()
+	defer changeLog.mu.Unlock()
+	change.Token = ChangeToken{TxId: 1, ChangeId: int64(len(changeLog.changes) + 1)}.String()
+	changeLog.changes = append(changeLog.changes, change)
+}
+
+func (changeLog *memoryChangeLog) Changes(since ChangeToken, limit int) ([]Change, error) {
+	changeLog.mu.Lock()
+	defer changeLog.mu.Unlock()
+	start := min(int(since.ChangeId), len(changeLog.changes))
+	return changeLog.changes[start:min(start+limit, len(changeLog.changes))], nil
+}
+
+func (changeLog *memoryChangeLog) Latest() (ChangeToken, error) {
+	return changeLog.latest, nil
+}
+
+func serveChanges(t *testing.T, api *Api, target string) ListChangesResp {
+	api.repo = stubRepository{}
+	api.tokenAuth = jwtauth.New("HS256", []byte("test_enc_key"), nil)
+	_, token, err := api.tokenAuth.Encode(map[string]interface{}{"sub": "test_user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest("GET", target, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	writer := httptest.NewRecorder()
+	api.RegisterRoutes().ServeHTTP(writer, req)
+	if writer.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", writer.Code, writer.Body.String())
+	}
+
+	resp := ListChangesResp{}
+	if err := json.Unmarshal(writer.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestParseChangeToken(t *testing.T) {
+	token := ChangeToken{TxId: 18446744073709551615, ChangeId: 42}
+	parsed, err := parseChangeToken(token.String())
+	if err != nil || parsed != token {
+		t.Errorf("expected %+v, got %+v %v", token, parsed, err)
+	}
+
+	for _, value := range []string{"now", "MTIz", "YS4x", "MS4y.x"} {
+		if _, err := parseChangeToken(value); err == nil {
+			t.Errorf("expected %q to be invalid", value)
+		}
+	}
+}
+
+func TestGetChangesReq_validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		isError bool
+	}{
+		{"no parameters", "", false},
+		{"every parameter", "?since=MS4y&limit=1000&wait=25", false},
+		{"since now", "?since=now", false},
+		{"invalid since", "?since=yesterday", true},
+		{"limit too high", "?limit=1001", true},
+		{"negative wait", "?wait=-1", true},
+		{"wait too long", "?wait=26", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&GetChangesReq{}).validate(httptest.NewRequest("GET", "/v0/changes"+tt.query, nil))
+			if !tt.isError && err != nil {
+				t.Errorf("Didn't expect an error but one was received: %v", err)
+			}
+			if tt.isError && err == nil {
+				t.Errorf("Expected error but none was received")
+			}
+		})
+	}
+}
+
+func TestGetChanges_resumes(t *testing.T) {
+	changeLog := &memoryChangeLog{}
+	for _, operation := range []string{"insert", "update", "delete"} {
+		changeLog.add(operation)
+	}
+	api := &Api{changes: changeLog}
+
+	first := serveChanges(t, api, "/v0/changes?limit=2")
+	if len(first.Changes) != 2 || !first.HasMore || first.Next != first.Changes[1].Token {
+		t.Fatalf("unexpected first page %+v", first)
+	}
+	second := serveChanges(t, api, "/v0/changes?limit=2&since="+first.Next)
+	if len(second.Changes) != 1 || second.HasMore || second.Changes[0].Operation != "delete" {
+		t.Fatalf("unexpected second page %+v", second)
+	}
+	last := serveChanges(t, api, "/v1/changes?since="+second.Next)
+	if len(last.Changes) != 0 || last.Next != second.Next {
+		t.Errorf("expected no change and the same token, got %+v", last)
+	}
+}
+
+func TestGetChanges_longPolling(t *testing.T) {
+	changeLog := &memoryChangeLog{latest: ChangeToken{TxId: 1}}
+	api := &Api{changes: changeLog, changesNotifier: &changeNotifier{}}
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		changeLog.add("update")
+		api.changesNotifier.notify()
+	}()
+
+	start := time.Now()
+	resp := serveChanges(t, api, "/v0/changes?since=now&wait=10")
+	if len(resp.Changes) != 1 || resp.Changes[0].Operation != "update" {
+		t.Errorf("expected the change made while waiting, got %+v", resp)
+	}
+	if elapsed := time.Since(start); elapsed > changesPollInterval {
+		t.Errorf("expected the notification to wake up the request, it took %s", elapsed)
+	}
+}
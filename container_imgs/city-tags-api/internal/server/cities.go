@@ -2,16 +2,37 @@ package server
 
 import (
 	"city-tags-api/internal/api_errors"
+	"city-tags-api/internal/geo"
+	"city-tags-api/internal/iso3166"
+	"city-tags-api/internal/vocabulary"
 	"fmt"
 	"net/http"
+	"net/url"
+	"slices"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type CityData struct {
-	CityId       int    `json:"city_id"`
-	CityName     string `json:"city_name"`
-	Continent    string `json:"continent"`
-	Country3Code string `json:"country_3_code"`
+	CityId         int      `json:"city_id"`
+	CityName       string   `json:"city_name"`
+	Continent      string   `json:"continent"`
+	Country3Code   string   `json:"country_3_code"`
+	Latitude       *float64 `json:"latitude"`
+	Longitude      *float64 `json:"longitude"`
+	Timezone       *string  `json:"timezone"`
+	Population     *int64   `json:"population"`
+	AlternateNames []string `json:"alternate_names"`
+}
+
+// location returns the coordinates of the city, if they are known.
+func (cityData CityData) location() (geo.Point, bool) {
+	if cityData.Latitude == nil || cityData.Longitude == nil {
+		return geo.Point{}, false
+	}
+	return geo.Point{Lat: *cityData.Latitude, Lon: *cityData.Longitude}, true
 }
 
 type GetCitiesResp struct {
@@ -19,6 +40,15 @@ type GetCitiesResp struct {
 	Offset int        `json:"offset"`
 }
 
+type ListCitiesResp struct {
+	Cities []CityData `json:"cities"`
+	Page   Page       `json:"page"`
+}
+
+func (resp ListCitiesResp) v0() any {
+	return GetCitiesResp{Cities: resp.Cities, Offset: resp.Page.Offset + len(resp.Cities)}
+}
+
 type TagsData struct {
 	CityId        int    `json:"city_id"`
 	CloudCoverage string `json:"cloud_coverage"`
@@ -30,69 +60,342 @@ type TagsData struct {
 	CitySize      string `json:"city_size"`
 }
 
+// byColumn returns the value of the given tag column.
+func (tagsData TagsData) byColumn(column string) string {
+	switch column {
+	case "cloud_coverage_tag":
+		return tagsData.CloudCoverage
+	case "humidity_tag":
+		return tagsData.Humidity
+	case "temp_tag":
+		return tagsData.Temp
+	case "precipitation_tag":
+		return tagsData.Precipitation
+	case "air_quality_tag":
+		return tagsData.AirQuality
+	case "daylight_hours_tag":
+		return tagsData.DaylightHours
+	case "city_size_tag":
+		return tagsData.CitySize
+	}
+	return ""
+}
+
+// setByColumn sets the value of the given tag column.
+func (tagsData *TagsData) setByColumn(column string, value string) {
+	switch column {
+	case "cloud_coverage_tag":
+		tagsData.CloudCoverage = value
+	case "humidity_tag":
+		tagsData.Humidity = value
+	case "temp_tag":
+		tagsData.Temp = value
+	case "precipitation_tag":
+		tagsData.Precipitation = value
+	case "air_quality_tag":
+		tagsData.AirQuality = value
+	case "daylight_hours_tag":
+		tagsData.DaylightHours = value
+	case "city_size_tag":
+		tagsData.CitySize = value
+	}
+}
+
+type Tag struct {
+	Value string `json:"value"`
+	Rank  int    `json:"rank"`
+	Label string `json:"label"`
+}
+
+// CityTags are the tags of a city keyed by category, the v1 contract of
+// TagsData.
+type CityTags struct {
+	CityId int            `json:"city_id"`
+	Tags   map[string]Tag `json:"tags"`
+}
+
+// TagsData returns the tags in the v0 contract, without ranks nor labels.
+func (cityTags CityTags) TagsData() TagsData {
+	tagsData := TagsData{CityId: cityTags.CityId}
+	for _, category := range vocabulary.Categories {
+		tagsData.setByColumn(category.Column, cityTags.Tags[category.Name].Value)
+	}
+	return tagsData
+}
+
+func (cityTags CityTags) v0() any {
+	labels := make(map[string]string, len(cityTags.Tags))
+	for category, tag := range cityTags.Tags {
+		labels[category] = tag.Label
+	}
+	return LocalizedTags{TagsData: cityTags.TagsData(), Labels: labels}
+}
+
+type TagsVersion struct {
+	TagsData
+	ValidFrom time.Time  `json:"valid_from"`
+	ValidTo   *time.Time `json:"valid_to"`
+}
+
+type GetTagsHistoryResp struct {
+	History []TagsVersion `json:"history"`
+	Offset  int           `json:"offset"`
+}
+
+type TagsRevision struct {
+	CityTags
+	ValidFrom time.Time  `json:"valid_from"`
+	ValidTo   *time.Time `json:"valid_to"`
+}
+
+type ListTagsHistoryResp struct {
+	History []TagsRevision `json:"history"`
+	Page    Page           `json:"page"`
+}
+
+func (resp ListTagsHistoryResp) v0() any {
+	history := make([]TagsVersion, len(resp.History))
+	for index, revision := range resp.History {
+		history[index] = TagsVersion{
+			TagsData:  revision.TagsData(),
+			ValidFrom: revision.ValidFrom,
+			ValidTo:   revision.ValidTo,
+		}
+	}
+	return GetTagsHistoryResp{History: history, Offset: resp.Page.Offset + len(history)}
+}
+
 type GetTagsResp struct {
 	Tags map[string]string `json:"tags"`
 }
 
+// CityFilter selects the cities whose tags match every value in Tags, keyed
+// by tag column. When Period is set the tags of that month or season are
+// matched instead of the annual ones. When BBox is set only the cities with
+// coordinates inside it are selected. Continent and Country, an ISO 3166-1
+// alpha-3 code, restrict the cities to a region.
+type CityFilter struct {
+	Period    string
+	Tags      map[string]string
+	BBox      *geo.BBox
+	Continent string
+	Country   string
+}
+
+func (filter CityFilter) isEmpty() bool {
+	return len(filter.Tags) == 0 && filter.BBox == nil && filter.Continent == "" && filter.Country == ""
+}
+
+// key returns a canonical representation of the filter to cache its results.
+func (filter CityFilter) key() string {
+	conditions := make([]string, 0, len(filter.Tags))
+	for column, value := range filter.Tags {
+		conditions = append(conditions, fmt.Sprintf("%s=%s", column, value))
+	}
+	sort.Strings(conditions)
+
+	key := filter.Period + "|" + strings.Join(conditions, ",")
+	if filter.BBox != nil {
+		key += fmt.Sprintf("|%v", *filter.BBox)
+	}
+	if filter.Continent != "" || filter.Country != "" {
+		key += fmt.Sprintf("|%s|%s", filter.Continent, filter.Country)
+	}
+	return key
+}
+
+// matches reports whether a city with the given annual tags, nil if it has
+// none, is selected by the filter.
+func (filter CityFilter) matches(cityData CityData, tagsData *TagsData) bool {
+	if (filter.Continent != "" && cityData.Continent != filter.Continent) ||
+		(filter.Country != "" && cityData.Country3Code != filter.Country) {
+		return false
+	}
+	if filter.BBox != nil {
+		location, ok := cityData.location()
+		if !ok || !filter.BBox.Contains(location) {
+			return false
+		}
+	}
+	if len(filter.Tags) == 0 {
+		return true
+	}
+	if tagsData == nil {
+		return false
+	}
+	for column, value := range filter.Tags {
+		if tagsData.byColumn(column) != value {
+			return false
+		}
+	}
+	return true
+}
+
+const (
+	// defaultLimit is the page size of the listings when no limit is requested.
+	defaultLimit = 100
+	// maxLimit is the largest page size of the listings.
+	maxLimit = 1000
+)
+
 type GetCitiesReq struct {
 	offset int
 	limit  int
+	filter CityFilter
 }
 
 func (getCitR *GetCitiesReq) validate(r *http.Request) error {
-	var err error
-	var offset int
-	clientErr := &api_errors.ClientErr{
-		HttpCode: http.StatusBadRequest,
-		Message:  "Parameters not present or invalid",
+	return getCitR.validateQuery(r.URL.Query())
+}
+
+func (getCitR *GetCitiesReq) validateQuery(query url.Values) error {
+	offset, limit, err := parsePagination(query)
+	if err != nil {
+		return err
 	}
 
-	offsetParam := r.URL.Query().Get("offset")
-	if offsetParam == "" {
-		offset = 0
-	} else {
-		offset, err = strconv.Atoi(offsetParam)
+	filter, errors := parseCityFilter(query)
+	if len(errors) > 0 {
+		return &api_errors.ClientErr{
+			HttpCode: http.StatusBadRequest,
+			Message:  "Parameters not present or invalid",
+			Errors:   errors,
+		}
 	}
-	if err != nil {
-		clientErr.Errors = map[string]string{
-			"offset": "Not present or invalid",
+
+	getCitR.offset = offset
+	getCitR.limit = limit
+	getCitR.filter = filter
+	return nil
+}
+
+// parsePagination reads the offset and limit of a listing, for the listings
+// that don't take the filters of the cities.
+func parsePagination(query url.Values) (int, int, error) {
+	errors := map[string]string{}
+
+	offset := 0
+	if offsetParam := query.Get("offset"); offsetParam != "" {
+		var err error
+		offset, err = strconv.Atoi(offsetParam)
+		if err != nil || offset < 0 {
+			errors["offset"] = "Must be a non-negative integer"
 		}
-		clientErr.LogMess = err.Error()
 	}
 
-	var limit int
-	limitParam := r.URL.Query().Get("limit")
-	if limitParam == "" {
-		limit = 100
-	} else {
+	limit := defaultLimit
+	if limitParam := query.Get("limit"); limitParam != "" {
+		var err error
 		limit, err = strconv.Atoi(limitParam)
+		if err != nil || limit < 1 || limit > maxLimit {
+			errors["limit"] = fmt.Sprintf("Must be an integer between 1 and %d", maxLimit)
+		}
 	}
-	if err != nil {
-		clientErr.Errors = map[string]string{
-			"offset": "Not present or invalid",
+
+	if len(errors) > 0 {
+		return 0, 0, &api_errors.ClientErr{
+			HttpCode: http.StatusBadRequest,
+			Message:  "Parameters not present or invalid",
+			Errors:   errors,
 		}
-		if clientErr.LogMess != "" {
-			clientErr.LogMess += fmt.Sprintf(", %s", err.Error())
+	}
+	return offset, limit, nil
+}
+
+// parseCityFilter reads the tag filters, named after the tag categories, the
+// month or season they apply to and the region filters.
+func parseCityFilter(query url.Values) (CityFilter, map[string]string) {
+	errors := map[string]string{}
+	filter := CityFilter{Tags: map[string]string{}}
+
+	for _, category := range vocabulary.Categories {
+		value := query.Get(category.Name)
+		if value == "" {
+			continue
+		}
+		if category.Rank(value) < 0 {
+			errors[category.Name] = fmt.Sprintf("Must be one of: %s", strings.Join(category.Values, ", "))
+			continue
+		}
+		filter.Tags[category.Column] = value
+	}
+
+	if bboxParam := query.Get("bbox"); bboxParam != "" {
+		box, err := geo.ParseBBox(bboxParam)
+		if err != nil {
+			errors["bbox"] = "Must be minLon,minLat,maxLon,maxLat with valid coordinates"
 		} else {
-			clientErr.LogMess = err.Error()
+			filter.BBox = &box
 		}
 	}
 
-	if clientErr.LogMess != "" {
-		return clientErr
+	filter.Continent = query.Get("continent")
+	if filter.Continent != "" && !iso3166.IsContinent(filter.Continent) {
+		errors["continent"] = fmt.Sprintf("Must be one of: %s", strings.Join(iso3166.Continents, ", "))
+	}
+	filter.Country = query.Get("country_3_code")
+	if filter.Country != "" && !iso3166.IsAlpha3(filter.Country) {
+		errors["country_3_code"] = "Must be an ISO 3166-1 alpha-3 code"
 	}
 
-	getCitR.offset = offset
-	getCitR.limit = limit
-	return nil
+	period, periodErrors := parsePeriod(query)
+	for param, message := range periodErrors {
+		errors[param] = message
+	}
+	if period != "" && len(filter.Tags) == 0 {
+		param := "month"
+		if query.Get("season") != "" {
+			param = "season"
+		}
+		errors[param] = "Only allowed together with tag filters"
+	}
+	filter.Period = period
+	return filter, errors
+}
+
+// parsePeriod reads the optional month (1 to 12) or season parameters, which
+// are mutually exclusive.
+func parsePeriod(query url.Values) (string, map[string]string) {
+	monthParam := query.Get("month")
+	seasonParam := query.Get("season")
+
+	switch {
+	case monthParam != "" && seasonParam != "":
+		return "", map[string]string{"season": "Can't be used together with month"}
+	case monthParam != "":
+		month, err := strconv.Atoi(monthParam)
+		if err != nil || month < 1 || month > 12 {
+			return "", map[string]string{"month": "Must be an integer between 1 and 12"}
+		}
+		return vocabulary.MonthPeriod(month), nil
+	case seasonParam != "":
+		if !slices.Contains(vocabulary.Seasons, seasonParam) {
+			return "", map[string]string{"season": fmt.Sprintf("Must be one of: %s", strings.Join(vocabulary.Seasons, ", "))}
+		}
+		return seasonParam, nil
+	}
+	return "", nil
 }
 
 // @Summary		Get cities
-// @Description	Get cities with pagination
+// @Description	Get cities with pagination, optionally filtered by their annual tags or the tags of a month or season, by a bounding box and by continent or country, ordered by city id
 // @Accept		json
 // @Produce		json
 // @Param       offset  query int	false	"Offset for pagination"
-// @Param       limit   query int	false	"Limit for pagination"
+// @Param       limit   query int	false	"Limit for pagination, 100 by default and 1000 at most"
+// @Param       cloud_coverage	query string	false	"Cloud coverage tag"
+// @Param       humidity		query string	false	"Humidity tag"
+// @Param       temperature		query string	false	"Temperature tag"
+// @Param       precipitation	query string	false	"Precipitation tag"
+// @Param       air_quality		query string	false	"Air quality tag"
+// @Param       daylight_hours	query string	false	"Daylight hours tag"
+// @Param       city_size		query string	false	"City size tag"
+// @Param       month	query int		false	"Month (1 to 12) the tag filters apply to"
+// @Param       season	query string	false	"Season (winter, spring, summer, autumn) the tag filters apply to"
+// @Param       bbox	query string	false	"Only cities inside the box minLon,minLat,maxLon,maxLat"
+// @Param       continent		query string	false	"Only cities of this continent"
+// @Param       country_3_code	query string	false	"Only cities of this country"
+// @Param       lang	query string	false	"Language of names and labels (en, es, de), Accept-Language is used if missing"
 // @Success		200 	{object} 	CityData
 // @Failure     500 	{object} 	api_errors.ClientErr
 // @Router		/v0/cities [get]
@@ -103,27 +406,24 @@ func (api *Api) getCities(w http.ResponseWriter, r *http.Request) error {
 		return err
 	}
 
-	rows, err := api.db.Query("select * from city_tags.cities LIMIT $1 OFFSET $2", citiesReq.limit, citiesReq.offset)
+	var cities []CityData
+	if citiesReq.filter.isEmpty() {
+		cities, err = api.repo.GetCities(citiesReq.offset, citiesReq.limit)
+	} else {
+		cities, err = api.repo.FilterCities(citiesReq.filter, citiesReq.offset, citiesReq.limit)
+	}
 	if err != nil {
 		return err
 	}
-
-	var citiesData = GetCitiesResp{
-		Cities: []CityData{},
-	}
-	count := 0
-	for rows.Next() {
-		cityData := CityData{}
-		err = rows.Scan(&cityData.CityId, &cityData.CityName, &cityData.Continent, &cityData.Country3Code)
-		if err != nil {
-			return err
-		}
-		citiesData.Cities = append(citiesData.Cities, cityData)
-		count++
+	localizer, err := api.localizer(r)
+	if err != nil {
+		return err
 	}
-	citiesData.Offset = citiesReq.offset + count
 
-	respondWithJSON(w, http.StatusOK, citiesData)
+	respond(w, r, http.StatusOK, ListCitiesResp{
+		Cities: localizer.cities(cities),
+		Page:   newPage(citiesReq.offset, citiesReq.limit, len(cities)),
+	})
 	return nil
 }
 
@@ -151,6 +451,7 @@ func (getCitR *GetCityReq) validate(r *http.Request) error {
 // @Description	Get city information by providing a specific city id
 // @Accept			json
 // @Produce		json
+// @Param       lang	query string	false	"Language of names and labels (en, es, de), Accept-Language is used if missing"
 // @Success		200 {object} CityData
 // @Failure      500  {object} api_errors.ClientErr
 // @Router			/v0/cities/{cityId} [get]
@@ -161,30 +462,26 @@ func (api *Api) getCity(w http.ResponseWriter, r *http.Request) error {
 		return err
 	}
 
-	rows, err := api.db.Query("select * from city_tags.cities where city_id = $1", GetCityReq.cityId)
+	cityData, err := api.repo.GetCity(GetCityReq.cityId)
 	if err != nil {
 		return err
 	}
-
-	var cityData CityData
-	if rows.Next() {
-		err = rows.Scan(&cityData.CityId, &cityData.CityName, &cityData.Continent, &cityData.Country3Code)
-		if err != nil {
-			return err
-		}
-	} else {
-		return &api_errors.ClientErr{
-			HttpCode: http.StatusNotFound,
-			Message:  "City not found",
-		}
+	localizer, err := api.localizer(r)
+	if err != nil {
+		return err
 	}
 
-	respondWithJSON(w, http.StatusOK, cityData)
+	// The ETag identifies the stored city, whatever the language, so it can
+	// be sent back in If-Match to the admin endpoints.
+	w.Header().Set("ETag", etag(cityData))
+	respond(w, r, http.StatusOK, localizer.city(cityData))
 	return nil
 }
 
 type GetTagsReq struct {
 	cityId int
+	asOf   *time.Time
+	period string
 }
 
 func (getTagsR *GetTagsReq) validate(r *http.Request) error {
@@ -199,15 +496,59 @@ func (getTagsR *GetTagsReq) validate(r *http.Request) error {
 			},
 		}
 	}
+
+	asOfParam := r.URL.Query().Get("as_of")
+	if asOfParam != "" {
+		asOf, err := parseAsOf(asOfParam)
+		if err != nil {
+			return &api_errors.ClientErr{
+				HttpCode: http.StatusBadRequest,
+				Message:  "Parameters not present or invalid",
+				LogMess:  err.Error(),
+				Errors: map[string]string{
+					"as_of": "Must be a date (2006-01-02) or a RFC 3339 timestamp",
+				},
+			}
+		}
+		getTagsR.asOf = &asOf
+	}
+
+	period, errors := parsePeriod(r.URL.Query())
+	if len(errors) == 0 && period != "" && getTagsR.asOf != nil {
+		errors = map[string]string{"as_of": "Can't be used together with month or season"}
+	}
+	if len(errors) > 0 {
+		return &api_errors.ClientErr{
+			HttpCode: http.StatusBadRequest,
+			Message:  "Parameters not present or invalid",
+			Errors:   errors,
+		}
+	}
+
 	getTagsR.cityId = cityId
+	getTagsR.period = period
 	return nil
 }
 
+// parseAsOf accepts either a date, interpreted as the start of the day in UTC,
+// or a full RFC 3339 timestamp.
+func parseAsOf(value string) (time.Time, error) {
+	if asOf, err := time.Parse(time.DateOnly, value); err == nil {
+		return asOf, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
 // @Summary		Get city tags by city id
-// @Description	Get tags information by providing a specific city id
+// @Description	Get tags information by providing a specific city id, the annual tags unless a month or season is requested
 // @Accept			json
 // @Produce		json
-// @Success		200 {object} TagsData
+// @Param       as_of	query	string	false	"Date (2006-01-02) or RFC 3339 timestamp to get the tags valid at that moment"
+// @Param       month	query	int		false	"Month (1 to 12) to get the tags of"
+// @Param       season	query	string	false	"Season (winter, spring, summer, autumn) to get the tags of, in the hemisphere of the city"
+// @Param       lang	query string	false	"Language of names and labels (en, es, de), Accept-Language is used if missing"
+// @Success		200 {object} LocalizedTags
+// @Failure      404  {object} api_errors.ClientErr
 // @Failure      500  {object} api_errors.ClientErr
 // @Router			/v0/cities/{cityId}/tags [get]
 func (api *Api) getTags(w http.ResponseWriter, r *http.Request) error {
@@ -217,33 +558,99 @@ func (api *Api) getTags(w http.ResponseWriter, r *http.Request) error {
 		return err
 	}
 
-	rows, err := api.db.Query("select * from city_tags.city_tags where city_id = $1", getTagsReq.cityId)
+	var tagsData TagsData
+	if getTagsReq.asOf != nil {
+		tagsData, err = api.repo.GetTagsAsOf(getTagsReq.cityId, *getTagsReq.asOf)
+	} else if getTagsReq.period != "" {
+		tagsData, err = api.repo.GetPeriodTags(getTagsReq.cityId, getTagsReq.period)
+	} else {
+		tagsData, err = api.repo.GetTags(getTagsReq.cityId)
+	}
 	if err != nil {
 		return err
 	}
 
-	var tagsData TagsData
-	if rows.Next() {
-		err = rows.Scan(
-			&tagsData.CityId,
-			&tagsData.CloudCoverage,
-			&tagsData.Humidity,
-			&tagsData.Temp,
-			&tagsData.Precipitation,
-			&tagsData.AirQuality,
-			&tagsData.DaylightHours,
-			&tagsData.CitySize,
-		)
-		if err != nil {
-			return err
-		}
-	} else {
-		return &api_errors.ClientErr{
-			HttpCode: http.StatusNotFound,
-			Message:  "City not found",
+	categories, err := api.repo.GetVocabulary()
+	if err != nil {
+		return err
+	}
+	localizer, err := api.localizer(r)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("ETag", etag(tagsData))
+	respond(w, r, http.StatusOK, localizer.cityTags(tagsData, categories))
+	return nil
+}
+
+type GetTagsHistoryReq struct {
+	cityId int
+	offset int
+	limit  int
+}
+
+func (getTagsHistR *GetTagsHistoryReq) validate(r *http.Request) error {
+	cityId, err := parseCityId(r)
+	if err != nil {
+		return err
+	}
+
+	offset, limit, err := parsePagination(r.URL.Query())
+	if err != nil {
+		return err
+	}
+
+	getTagsHistR.cityId = cityId
+	getTagsHistR.offset = offset
+	getTagsHistR.limit = limit
+	return nil
+}
+
+// @Summary		Get city tags history by city id
+// @Description	Get every version of the tags of a city with its validity period, oldest first
+// @Accept			json
+// @Produce		json
+// @Param       offset  query int	false	"Offset for pagination"
+// @Param       limit   query int	false	"Limit for pagination, 100 by default and 1000 at most"
+// @Success		200 {object} GetTagsHistoryResp
+// @Failure      404  {object} api_errors.ClientErr
+// @Failure      500  {object} api_errors.ClientErr
+// @Router			/v0/cities/{cityId}/tags/history [get]
+func (api *Api) getTagsHistory(w http.ResponseWriter, r *http.Request) error {
+	histReq := &GetTagsHistoryReq{}
+	err := histReq.validate(r)
+	if err != nil {
+		return err
+	}
+
+	versions, err := api.repo.GetTagsHistory(histReq.cityId, histReq.offset, histReq.limit)
+	if err != nil {
+		return err
+	}
+	if len(versions) == 0 && histReq.offset == 0 {
+		return &api_errors.CityNotFoundErr
+	}
+
+	categories, err := api.repo.GetVocabulary()
+	if err != nil {
+		return err
+	}
+	localizer, err := api.localizer(r)
+	if err != nil {
+		return err
+	}
+	history := make([]TagsRevision, len(versions))
+	for index, version := range versions {
+		history[index] = TagsRevision{
+			CityTags:  localizer.cityTags(version.TagsData, categories),
+			ValidFrom: version.ValidFrom,
+			ValidTo:   version.ValidTo,
 		}
 	}
 
-	respondWithJSON(w, http.StatusOK, tagsData)
+	respond(w, r, http.StatusOK, ListTagsHistoryResp{
+		History: history,
+		Page:    newPage(histReq.offset, histReq.limit, len(versions)),
+	})
 	return nil
 }
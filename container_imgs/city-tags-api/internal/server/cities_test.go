@@ -1,8 +1,16 @@
 package server
 
 import (
+	"city-tags-api/internal/api_errors"
+	"city-tags-api/internal/geo"
+	"errors"
 	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestGetCitiesReq_validate(t *testing.T) {
@@ -19,6 +27,11 @@ func TestGetCitiesReq_validate(t *testing.T) {
 		{"only limit", "/v0/cities?limit=200", GetCitiesReq{limit: 200, offset: 0}, false},
 		{"incorrect limit", "/v0/cities?limit=200a", GetCitiesReq{}, true},
 		{"incorrect offset", "/v0/cities?offset=a200", GetCitiesReq{}, true},
+		{"negative offset", "/v0/cities?offset=-1", GetCitiesReq{}, true},
+		{"zero limit", "/v0/cities?limit=0", GetCitiesReq{}, true},
+		{"negative limit", "/v0/cities?limit=-10", GetCitiesReq{}, true},
+		{"maximum limit", "/v0/cities?limit=1000", GetCitiesReq{limit: 1000, offset: 0}, false},
+		{"limit over the maximum", "/v0/cities?limit=1001", GetCitiesReq{}, true},
 	}
 
 	for _, tt := range tests {
@@ -33,13 +46,158 @@ func TestGetCitiesReq_validate(t *testing.T) {
 			if tt.isError && err == nil {
 				t.Errorf("Expected error but none was received")
 			}
-			if *GetCitiesReq != tt.expected {
+			if GetCitiesReq.offset != tt.expected.offset || GetCitiesReq.limit != tt.expected.limit || !GetCitiesReq.filter.isEmpty() {
 				t.Errorf("GetCitiesReq.validate(%s) = %v; want %v", tt.input, *GetCitiesReq, tt.expected)
 			}
 		})
 	}
 }
 
+func TestParsePagination_errorFields(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{"invalid limit", "limit=a", []string{"limit"}},
+		{"invalid offset", "offset=-5", []string{"offset"}},
+		{"both invalid", "offset=a&limit=0", []string{"limit", "offset"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, _, err = parsePagination(query)
+			var clientErr *api_errors.ClientErr
+			if !errors.As(err, &clientErr) {
+				t.Fatalf("parsePagination(%s) = %v; want a client error", tt.query, err)
+			}
+			var fields []string
+			for field := range clientErr.Errors {
+				fields = append(fields, field)
+			}
+			slices.Sort(fields)
+			if !slices.Equal(fields, tt.expected) {
+				t.Errorf("parsePagination(%s) reports %v; want %v", tt.query, fields, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGetCitiesReq_validateFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected CityFilter
+		errors   []string
+	}{
+		{
+			"annual tags",
+			"/v0/cities?temperature=warm&precipitation=dry",
+			CityFilter{Tags: map[string]string{"temp_tag": "warm", "precipitation_tag": "dry"}},
+			nil,
+		},
+		{
+			"month",
+			"/v0/cities?temperature=warm&precipitation=dry&month=12",
+			CityFilter{Period: "12", Tags: map[string]string{"temp_tag": "warm", "precipitation_tag": "dry"}},
+			nil,
+		},
+		{
+			"season",
+			"/v0/cities?humidity=humid&season=summer",
+			CityFilter{Period: "summer", Tags: map[string]string{"humidity_tag": "humid"}},
+			nil,
+		},
+		{"unknown tag value", "/v0/cities?temperature=freezing", CityFilter{}, []string{"temperature"}},
+		{"month out of range", "/v0/cities?temperature=warm&month=13", CityFilter{}, []string{"month"}},
+		{"unknown season", "/v0/cities?temperature=warm&season=monsoon", CityFilter{}, []string{"season"}},
+		{"month and season", "/v0/cities?temperature=warm&month=1&season=winter", CityFilter{}, []string{"season"}},
+		{"period without tags", "/v0/cities?season=winter", CityFilter{}, []string{"season"}},
+		{
+			"bounding box",
+			"/v0/cities?bbox=-10,35,5,44",
+			CityFilter{Tags: map[string]string{}, BBox: &geo.BBox{MinLon: -10, MinLat: 35, MaxLon: 5, MaxLat: 44}},
+			nil,
+		},
+		{"invalid bounding box", "/v0/cities?bbox=-10,35,5", CityFilter{}, []string{"bbox"}},
+		{
+			"region",
+			"/v0/cities?continent=Europe&country_3_code=ESP",
+			CityFilter{Tags: map[string]string{}, Continent: "Europe", Country: "ESP"},
+			nil,
+		},
+		{"unknown region", "/v0/cities?continent=Atlantis&country_3_code=XXX", CityFilter{}, []string{"continent", "country_3_code"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", tt.input, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			citiesReq := &GetCitiesReq{}
+			err = citiesReq.validate(req)
+			if tt.errors == nil {
+				if err != nil {
+					t.Fatalf("Didn't expect an error but received %v", err)
+				}
+				if !reflect.DeepEqual(citiesReq.filter, tt.expected) {
+					t.Errorf("GetCitiesReq.validate(%s) filter = %v; want %v", tt.input, citiesReq.filter, tt.expected)
+				}
+				return
+			}
+
+			clientErr, ok := err.(*api_errors.ClientErr)
+			if !ok {
+				t.Fatalf("Expected a client error but received %v", err)
+			}
+			for _, param := range tt.errors {
+				if _, ok := clientErr.Errors[param]; !ok {
+					t.Errorf("GetCitiesReq.validate(%s) errors = %v; want an error for %s", tt.input, clientErr.Errors, param)
+				}
+			}
+		})
+	}
+}
+
+func TestCityFilter_key(t *testing.T) {
+	first := CityFilter{Period: "12", Tags: map[string]string{"temp_tag": "warm", "precipitation_tag": "dry"}}
+	second := CityFilter{Period: "12", Tags: map[string]string{"precipitation_tag": "dry", "temp_tag": "warm"}}
+	annual := CityFilter{Tags: map[string]string{"temp_tag": "warm", "precipitation_tag": "dry"}}
+
+	if first.key() != second.key() {
+		t.Errorf("CityFilter.key() = %s and %s; want them to be equal", first.key(), second.key())
+	}
+	if first.key() == annual.key() {
+		t.Errorf("CityFilter.key() = %s for both the annual and the monthly filter", annual.key())
+	}
+}
+
+func TestFilterCitiesQuery(t *testing.T) {
+	query, args := filterCitiesQuery(CityFilter{
+		Period: "7",
+		Tags:   map[string]string{"temp_tag": "warm", "precipitation_tag": "dry"},
+	})
+
+	expectedArgs := []any{"7", "dry", "warm"}
+	if !reflect.DeepEqual(args, expectedArgs) {
+		t.Errorf("filterCitiesQuery() args = %v; want %v", args, expectedArgs)
+	}
+	for _, expected := range []string{
+		"join city_tags.city_period_tags t on t.city_id = c.city_id and t.period = $1",
+		"where t.precipitation_tag = $2 and t.temp_tag = $3",
+	} {
+		if !strings.Contains(query, expected) {
+			t.Errorf("filterCitiesQuery() = %s; want it to contain %s", query, expected)
+		}
+	}
+}
+
 func TestGetCityReq_validate(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -107,3 +265,120 @@ func TestGetTagsReq_validate(t *testing.T) {
 		})
 	}
 }
+
+func TestGetTagsReq_validateAsOf(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected time.Time
+		isError  bool
+	}{
+		{"date", "?as_of=2025-01-01", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), false},
+		{"timestamp", "?as_of=2025-01-01T10:30:00Z", time.Date(2025, 1, 1, 10, 30, 0, 0, time.UTC), false},
+		{"incorrect date", "?as_of=01/01/2025", time.Time{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/v0/cities/{cityId}/tags"+tt.query, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.SetPathValue("cityId", "3838859")
+
+			GetTagsReq := &GetTagsReq{}
+			err = GetTagsReq.validate(req)
+			if tt.isError {
+				if err == nil {
+					t.Errorf("Expected error but none was received")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Didn't expect an error but one was received")
+			}
+			if GetTagsReq.asOf == nil || !GetTagsReq.asOf.Equal(tt.expected) {
+				t.Errorf("GetTagsReq.validate(%s) as_of = %v; want %v", tt.query, GetTagsReq.asOf, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGetTagsReq_validatePeriod(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+		isError  bool
+	}{
+		{"annual", "", "", false},
+		{"month", "?month=7", "7", false},
+		{"season", "?season=winter", "winter", false},
+		{"month out of range", "?month=0", "", true},
+		{"unknown season", "?season=Winter", "", true},
+		{"month and season", "?month=7&season=summer", "", true},
+		{"period and as_of", "?month=7&as_of=2025-01-01", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/v0/cities/{cityId}/tags"+tt.query, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.SetPathValue("cityId", "3838859")
+
+			GetTagsReq := &GetTagsReq{}
+			err = GetTagsReq.validate(req)
+			if tt.isError {
+				if err == nil {
+					t.Errorf("Expected error but none was received")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Didn't expect an error but one was received")
+			}
+			if GetTagsReq.period != tt.expected {
+				t.Errorf("GetTagsReq.validate(%s) period = %q; want %q", tt.query, GetTagsReq.period, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGetTagsHistoryReq_validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		cityId   string
+		query    string
+		expected GetTagsHistoryReq
+		isError  bool
+	}{
+		{"default values", "3838859", "", GetTagsHistoryReq{cityId: 3838859, limit: 100}, false},
+		{"pagination", "3838859", "?limit=10&offset=20", GetTagsHistoryReq{cityId: 3838859, limit: 10, offset: 20}, false},
+		{"incorrect offset", "3838859", "?offset=a", GetTagsHistoryReq{}, true},
+		{"listing filters ignored", "3838859", "?month=13&continent=Atlantis", GetTagsHistoryReq{cityId: 3838859, limit: 100}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/v0/cities/{cityId}/tags/history"+tt.query, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.SetPathValue("cityId", tt.cityId)
+
+			histReq := &GetTagsHistoryReq{}
+			err = histReq.validate(req)
+			if !tt.isError && err != nil {
+				t.Errorf("Didn't expect an error but one was received")
+			}
+			if tt.isError && err == nil {
+				t.Errorf("Expected error but none was received")
+			}
+			if *histReq != tt.expected {
+				t.Errorf("GetTagsHistoryReq.validate() = %v; want %v", *histReq, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package server
+
+import (
+	"bytes"
+	"city-tags-api/internal/api_errors"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		expected       string
+	}{
+		{"no header", "", ""},
+		{"only gzip", "gzip", "gzip"},
+		{"server preference", "gzip, deflate, br, zstd", "br"},
+		{"quality values", "br;q=0.5, gzip;q=0.8", "gzip"},
+		{"rejected encoding", "br;q=0, zstd", "zstd"},
+		{"wildcard", "*", "br"},
+		{"unsupported", "deflate", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoding := negotiateEncoding(tt.acceptEncoding)
+			if encoding != tt.expected {
+				t.Errorf("negotiateEncoding(%s) = %s; want %s", tt.acceptEncoding, encoding, tt.expected)
+			}
+		})
+	}
+}
+
+func decode(t *testing.T, encoding string, body []byte) string {
+	var reader io.Reader
+	var err error
+	switch encoding {
+	case "gzip":
+		reader, err = gzip.NewReader(bytes.NewReader(body))
+	case "br":
+		reader = brotli.NewReader(bytes.NewReader(body))
+	case "zstd":
+		reader, err = zstd.NewReader(bytes.NewReader(body))
+	default:
+		return string(body)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(decoded)
+}
+
+func TestCompress(t *testing.T) {
+	largeBody := `{"cities":"` + strings.Repeat("a", 2048) + `"}`
+	smallBody := `{"cities":[]}`
+
+	tests := []struct {
+		name             string
+		acceptEncoding   string
+		contentType      string
+		body             string
+		expectedEncoding string
+	}{
+		{"gzip", "gzip", "application/json", largeBody, "gzip"},
+		{"brotli", "br", "application/json", largeBody, "br"},
+		{"zstd", "zstd", "application/json", largeBody, "zstd"},
+		{"below threshold", "gzip", "application/json", smallBody, ""},
+		{"no accept encoding", "", "application/json", largeBody, ""},
+		{"not compressible", "gzip", "image/png", largeBody, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := Compress(1024)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(tt.body))
+			}))
+
+			req := httptest.NewRequest("GET", "/v0/cities", nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			writer := httptest.NewRecorder()
+			handler.ServeHTTP(writer, req)
+
+			if writer.Code != http.StatusOK {
+				t.Errorf("Wrong code response, got %d wanted %d", writer.Code, http.StatusOK)
+			}
+
+			encoding := writer.Header().Get("Content-Encoding")
+			if encoding != tt.expectedEncoding {
+				t.Errorf("Wrong Content-Encoding header, got %s wanted %s", encoding, tt.expectedEncoding)
+			}
+
+			if vary := writer.Header().Get("Vary"); vary != "Accept-Encoding" {
+				t.Errorf("Wrong Vary header, got %s wanted Accept-Encoding", vary)
+			}
+
+			body := decode(t, encoding, writer.Body.Bytes())
+			if body != tt.body {
+				t.Errorf("Wrong body response, got %d bytes wanted %d", len(body), len(tt.body))
+			}
+		})
+	}
+}
+
+func TestCompress_ErrorStatus(t *testing.T) {
+	handler := Compress(1024)(NewHandler(func(w http.ResponseWriter, r *http.Request) error {
+		return &api_errors.CityNotFoundErr
+	}))
+
+	req := httptest.NewRequest("GET", "/v0/cities/1", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	writer := httptest.NewRecorder()
+	handler.ServeHTTP(writer, req)
+
+	if writer.Code != http.StatusNotFound {
+		t.Errorf("Wrong code response, got %d wanted %d", writer.Code, http.StatusNotFound)
+	}
+	if writer.Header().Get("Content-Encoding") != "" {
+		t.Errorf("Expected small error response not to be compressed")
+	}
+}
@@ -0,0 +1,291 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"city-tags-api/internal/api_errors"
+	"city-tags-api/internal/iso3166"
+)
+
+type CountryData struct {
+	Alpha3Code string `json:"alpha_3_code"`
+	Alpha2Code string `json:"alpha_2_code"`
+	Name       string `json:"name"`
+	Continent  string `json:"continent"`
+	Cities     int    `json:"cities"`
+}
+
+type CountryDetail struct {
+	CountryData
+	Tags TagDistribution `json:"tags"`
+}
+
+type GetCountriesResp struct {
+	Countries []CountryData `json:"countries"`
+	Offset    int           `json:"offset"`
+}
+
+type ListCountriesResp struct {
+	Countries []CountryData `json:"countries"`
+	Page      Page          `json:"page"`
+}
+
+func (resp ListCountriesResp) v0() any {
+	return GetCountriesResp{Countries: resp.Countries, Offset: resp.Page.Offset + len(resp.Countries)}
+}
+
+type ContinentData struct {
+	Name      string `json:"name"`
+	Countries int    `json:"countries"`
+	Cities    int    `json:"cities"`
+}
+
+type GetContinentsResp struct {
+	Continents []ContinentData `json:"continents"`
+}
+
+const countryColumns = `co.alpha_3_code, co.alpha_2_code, co.name, co.continent, count(c.city_id)`
+
+func (repo *dbRepository) GetCountries(continent string, offset int, limit int) ([]CountryData, error) {
+	query := `select ` + countryColumns + ` from city_tags.countries co
+	left join city_tags.cities c on c.country_3_code = co.alpha_3_code`
+	args := []any{}
+	if continent != "" {
+		args = append(args, continent)
+		query += " where co.continent = $1"
+	}
+	args = append(args, limit, offset)
+	rows, err := repo.db.Query(
+		fmt.Sprintf(
+			"%s group by co.alpha_3_code order by co.alpha_3_code limit $%d offset $%d",
+			query, len(args)-1, len(args),
+		),
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	countries := []CountryData{}
+	for rows.Next() {
+		var country CountryData
+		err := rows.Scan(&country.Alpha3Code, &country.Alpha2Code, &country.Name, &country.Continent, &country.Cities)
+		if err != nil {
+			return nil, err
+		}
+		countries = append(countries, country)
+	}
+	return countries, rows.Err()
+}
+
+func (repo *dbRepository) GetCountry(alpha3Code string) (CountryDetail, error) {
+	rows, err := repo.db.Query(
+		`select `+countryColumns+` from city_tags.countries co
+		left join city_tags.cities c on c.country_3_code = co.alpha_3_code
+		where co.alpha_3_code = $1 group by co.alpha_3_code`,
+		alpha3Code,
+	)
+	if err != nil {
+		return CountryDetail{}, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return CountryDetail{}, &api_errors.CountryNotFoundErr
+	}
+	var country CountryDetail
+	err = rows.Scan(&country.Alpha3Code, &country.Alpha2Code, &country.Name, &country.Continent, &country.Cities)
+	if err != nil {
+		return CountryDetail{}, err
+	}
+	rows.Close()
+
+	country.Tags, err = repo.getTagDistribution(CityFilter{Country: alpha3Code})
+	return country, err
+}
+
+func (repo *dbRepository) GetContinents() ([]ContinentData, error) {
+	// Cities are counted by their own continent, which differs from the one of
+	// their country for transcontinental countries.
+	rows, err := repo.db.Query(
+		`select ct.name,
+		(select count(*) from city_tags.countries co where co.continent = ct.name),
+		(select count(*) from city_tags.cities c where c.continent = ct.name)
+		from city_tags.continents ct order by ct.name`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	continents := []ContinentData{}
+	for rows.Next() {
+		var continent ContinentData
+		if err := rows.Scan(&continent.Name, &continent.Countries, &continent.Cities); err != nil {
+			return nil, err
+		}
+		continents = append(continents, continent)
+	}
+	return continents, rows.Err()
+}
+
+// parseCountryCode resolves the country path parameter, either an ISO 3166-1
+// alpha-2 or alpha-3 code in any case.
+func parseCountryCode(r *http.Request) (iso3166.Country, error) {
+	return countryByCode(r.PathValue("code"))
+}
+
+func countryByCode(code string) (iso3166.Country, error) {
+	code = strings.ToUpper(code)
+	country, ok := iso3166.ByAlpha3(code)
+	if !ok {
+		country, ok = iso3166.ByAlpha2(code)
+	}
+	if !ok {
+		return iso3166.Country{}, &api_errors.CountryNotFoundErr
+	}
+	return country, nil
+}
+
+type GetCountriesReq struct {
+	continent string
+	offset    int
+	limit     int
+}
+
+func (getCounR *GetCountriesReq) validate(r *http.Request) error {
+	offset, limit, err := parsePagination(r.URL.Query())
+	if err != nil {
+		return err
+	}
+
+	continent := r.URL.Query().Get("continent")
+	if continent != "" && !iso3166.IsContinent(continent) {
+		return &api_errors.ClientErr{
+			HttpCode: http.StatusBadRequest,
+			Message:  "Parameters not present or invalid",
+			Errors: map[string]string{
+				"continent": fmt.Sprintf("Must be one of: %s", strings.Join(iso3166.Continents, ", ")),
+			},
+		}
+	}
+
+	getCounR.continent = continent
+	getCounR.offset = offset
+	getCounR.limit = limit
+	return nil
+}
+
+// @Summary		Get countries
+// @Description	Get countries ordered by alpha-3 code with their number of cities
+// @Accept		json
+// @Produce		json
+// @Param       offset		query int		false	"Offset for pagination"
+// @Param       limit		query int		false	"Limit for pagination, 100 by default and 1000 at most"
+// @Param       continent	query string	false	"Only countries of this continent"
+// @Success		200 	{object} 	GetCountriesResp
+// @Failure     400 	{object} 	api_errors.ClientErr
+// @Failure     500 	{object} 	api_errors.ClientErr
+// @Router		/v0/countries [get]
+func (api *Api) getCountries(w http.ResponseWriter, r *http.Request) error {
+	countriesReq := &GetCountriesReq{}
+	err := countriesReq.validate(r)
+	if err != nil {
+		return err
+	}
+
+	countries, err := api.repo.GetCountries(countriesReq.continent, countriesReq.offset, countriesReq.limit)
+	if err != nil {
+		return err
+	}
+
+	respond(w, r, http.StatusOK, ListCountriesResp{
+		Countries: countries,
+		Page:      newPage(countriesReq.offset, countriesReq.limit, len(countries)),
+	})
+	return nil
+}
+
+// @Summary		Get country by code
+// @Description	Get a country by its ISO 3166-1 alpha-2 or alpha-3 code with its number of cities and the distribution of their tags
+// @Accept		json
+// @Produce		json
+// @Success		200 	{object} 	CountryDetail
+// @Failure     404 	{object} 	api_errors.ClientErr
+// @Failure     500 	{object} 	api_errors.ClientErr
+// @Router		/v0/countries/{code} [get]
+func (api *Api) getCountry(w http.ResponseWriter, r *http.Request) error {
+	country, err := parseCountryCode(r)
+	if err != nil {
+		return err
+	}
+
+	countryDetail, err := api.repo.GetCountry(country.Alpha3)
+	if err != nil {
+		return err
+	}
+
+	respond(w, r, http.StatusOK, countryDetail)
+	return nil
+}
+
+// @Summary		Get cities of a country
+// @Description	Get the cities of a country by its ISO 3166-1 alpha-2 or alpha-3 code with pagination, ordered by city id
+// @Accept		json
+// @Produce		json
+// @Param       offset  query int	false	"Offset for pagination"
+// @Param       limit   query int	false	"Limit for pagination, 100 by default and 1000 at most"
+// @Param       lang	query string	false	"Language of names and labels (en, es, de), Accept-Language is used if missing"
+// @Success		200 	{object} 	GetCitiesResp
+// @Failure     400 	{object} 	api_errors.ClientErr
+// @Failure     404 	{object} 	api_errors.ClientErr
+// @Failure     500 	{object} 	api_errors.ClientErr
+// @Router		/v0/countries/{code}/cities [get]
+func (api *Api) getCountryCities(w http.ResponseWriter, r *http.Request) error {
+	country, err := parseCountryCode(r)
+	if err != nil {
+		return err
+	}
+
+	citiesReq := &GetCitiesReq{}
+	err = citiesReq.validate(r)
+	if err != nil {
+		return err
+	}
+	citiesReq.filter.Country = country.Alpha3
+
+	cities, err := api.repo.FilterCities(citiesReq.filter, citiesReq.offset, citiesReq.limit)
+	if err != nil {
+		return err
+	}
+	localizer, err := api.localizer(r)
+	if err != nil {
+		return err
+	}
+
+	respond(w, r, http.StatusOK, ListCitiesResp{
+		Cities: localizer.cities(cities),
+		Page:   newPage(citiesReq.offset, citiesReq.limit, len(cities)),
+	})
+	return nil
+}
+
+// @Summary		Get continents
+// @Description	Get every continent with its number of countries and cities
+// @Accept		json
+// @Produce		json
+// @Success		200 	{object} 	GetContinentsResp
+// @Failure     500 	{object} 	api_errors.ClientErr
+// @Router		/v0/continents [get]
+func (api *Api) getContinents(w http.ResponseWriter, r *http.Request) error {
+	continents, err := api.repo.GetContinents()
+	if err != nil {
+		return err
+	}
+
+	respond(w, r, http.StatusOK, GetContinentsResp{Continents: continents})
+	return nil
+}
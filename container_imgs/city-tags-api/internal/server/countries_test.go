@@ -0,0 +1,85 @@
+package server
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseCountryCode(t *testing.T) {
+	tests := []struct {
+		code     string
+		expected string
+		isError  bool
+	}{
+		{"ESP", "ESP", false},
+		{"es", "ESP", false},
+		{"arg", "ARG", false},
+		{"XXX", "", true},
+		{"", "", true},
+	}
+
+	for _, tt := range tests {
+		req, err := http.NewRequest("GET", "/v0/countries/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.SetPathValue("code", tt.code)
+
+		country, err := parseCountryCode(req)
+		if tt.isError != (err != nil) {
+			t.Errorf("parseCountryCode(%s) error = %v; want error %t", tt.code, err, tt.isError)
+		}
+		if country.Alpha3 != tt.expected {
+			t.Errorf("parseCountryCode(%s) = %s; want %s", tt.code, country.Alpha3, tt.expected)
+		}
+	}
+}
+
+func TestGetCountriesReq_validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected GetCountriesReq
+		isError  bool
+	}{
+		{"default values", "/v0/countries", GetCountriesReq{limit: 100}, false},
+		{"continent", "/v0/countries?continent=Oceania&offset=10", GetCountriesReq{continent: "Oceania", offset: 10, limit: 100}, false},
+		{"unknown continent", "/v0/countries?continent=Atlantis", GetCountriesReq{}, true},
+		{"incorrect offset", "/v0/countries?offset=a", GetCountriesReq{}, true},
+		{"city filters ignored", "/v0/countries?temperature=scorching", GetCountriesReq{limit: 100}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", tt.input, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			countriesReq := &GetCountriesReq{}
+			err = countriesReq.validate(req)
+			if tt.isError != (err != nil) {
+				t.Errorf("GetCountriesReq.validate(%s) error = %v; want error %t", tt.input, err, tt.isError)
+			}
+			if *countriesReq != tt.expected {
+				t.Errorf("GetCountriesReq.validate(%s) = %v; want %v", tt.input, *countriesReq, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFilterCitiesQuery_region(t *testing.T) {
+	query, args := filterCitiesQuery(CityFilter{
+		Tags:    map[string]string{"temp_tag": "warm"},
+		Country: "ARG",
+	})
+
+	if !reflect.DeepEqual(args, []any{"warm", "ARG"}) {
+		t.Errorf("filterCitiesQuery() args = %v; want [warm ARG]", args)
+	}
+	if !strings.Contains(query, "where t.temp_tag = $1 and c.country_3_code = $2") {
+		t.Errorf("filterCitiesQuery() = %s; want it to filter by country", query)
+	}
+}
@@ -0,0 +1,67 @@
+package server
+
+import "sync"
+
+// loader batches the keys requested while a GraphQL query is resolved. load
+// queues a key and returns a thunk, the first thunk called fetches every
+// queued key with a single call of batch, so resolving a field of every item
+// of a list doesn't query the database once per item.
+type loader[K comparable, V any] struct {
+	mu        sync.Mutex
+	batch     func(keys []K) (map[K]V, error)
+	requested map[K]bool
+	queued    []K
+	results   map[K]V
+	errors    map[K]error
+}
+
+func newLoader[K comparable, V any](batch func(keys []K) (map[K]V, error)) *loader[K, V] {
+	return &loader[K, V]{
+		batch:     batch,
+		requested: map[K]bool{},
+		results:   map[K]V{},
+		errors:    map[K]error{},
+	}
+}
+
+// load returns a thunk with the value of key and whether it was found.
+func (l *loader[K, V]) load(key K) func() (V, bool, error) {
+	l.mu.Lock()
+	if !l.requested[key] {
+		l.requested[key] = true
+		l.queued = append(l.queued, key)
+	}
+	l.mu.Unlock()
+
+	return func() (V, bool, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.dispatch()
+		if err, ok := l.errors[key]; ok {
+			var empty V
+			return empty, false, err
+		}
+		value, ok := l.results[key]
+		return value, ok, nil
+	}
+}
+
+// dispatch fetches the queued keys, l.mu must be held.
+func (l *loader[K, V]) dispatch() {
+	if len(l.queued) == 0 {
+		return
+	}
+	keys := l.queued
+	l.queued = nil
+
+	values, err := l.batch(keys)
+	for _, key := range keys {
+		if err != nil {
+			l.errors[key] = err
+			continue
+		}
+		if value, ok := values[key]; ok {
+			l.results[key] = value
+		}
+	}
+}
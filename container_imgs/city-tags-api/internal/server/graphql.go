@@ -0,0 +1,433 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"city-tags-api/internal/api_errors"
+	"city-tags-api/internal/iso3166"
+	"city-tags-api/internal/vocabulary"
+
+	"github.com/graphql-go/graphql"
+)
+
+type GraphQLReq struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables" swaggertype:"object"`
+	Extensions    map[string]any `json:"extensions" swaggertype:"object"`
+}
+
+func (gqlR *GraphQLReq) validate() error {
+	if strings.TrimSpace(gqlR.Query) == "" {
+		return &api_errors.ClientErr{
+			HttpCode: http.StatusBadRequest,
+			Message:  "Parameters not present or invalid",
+			Errors: map[string]string{
+				"query": "Not present or invalid",
+			},
+		}
+	}
+	return checkQueryLimits(gqlR.Query, gqlR.OperationName, gqlR.Variables)
+}
+
+type graphqlKey struct{}
+
+// graphqlRequest holds the state shared by the resolvers of a query, the tags
+// loaders are keyed by period.
+type graphqlRequest struct {
+	repo       Repository
+	localizer  *localizer
+	categories []TagCategory
+	mu         sync.Mutex
+	tags       map[string]*loader[int, TagsData]
+}
+
+func requestGraphQL(ctx context.Context) *graphqlRequest {
+	return ctx.Value(graphqlKey{}).(*graphqlRequest)
+}
+
+func (gqlR *graphqlRequest) tagsLoader(period string) *loader[int, TagsData] {
+	gqlR.mu.Lock()
+	defer gqlR.mu.Unlock()
+	tagsLoader, ok := gqlR.tags[period]
+	if !ok {
+		tagsLoader = newLoader(func(cityIds []int) (map[int]TagsData, error) {
+			return gqlR.repo.GetTagsBatch(cityIds, period)
+		})
+		gqlR.tags[period] = tagsLoader
+	}
+	return tagsLoader
+}
+
+// graphqlClientErr adds the status and the invalid parameters of a client
+// error to the extensions of the GraphQL error.
+type graphqlClientErr struct {
+	*api_errors.ClientErr
+}
+
+func (err graphqlClientErr) Error() string {
+	return err.Message
+}
+
+func (err graphqlClientErr) Extensions() map[string]any {
+	extensions := map[string]any{"code": err.HttpCode}
+	if len(err.Errors) > 0 {
+		extensions["invalid_params"] = err.Errors
+	}
+	return extensions
+}
+
+// graphqlError hides the details of internal errors from the response as
+// respondWithError does.
+func graphqlError(err error) error {
+	if clientErr, ok := err.(*api_errors.ClientErr); ok {
+		return graphqlClientErr{clientErr}
+	}
+	log.Printf("Error: %s", err.Error())
+	return errors.New(internalErr.Message)
+}
+
+// camelCase converts the snake case names of the REST API to GraphQL names.
+func camelCase(name string) string {
+	words := strings.Split(name, "_")
+	for index := 1; index < len(words); index++ {
+		words[index] = strings.ToUpper(words[index][:1]) + words[index][1:]
+	}
+	return strings.Join(words, "")
+}
+
+// cityListArgs returns the arguments of the city listings, the ones of the
+// REST filters in camel case. region adds the continent and country filters.
+func cityListArgs(region bool) graphql.FieldConfigArgument {
+	args := graphql.FieldConfigArgument{
+		"offset": {Type: graphql.Int},
+		"limit":  {Type: graphql.Int},
+		"month":  {Type: graphql.Int},
+		"season": {Type: graphql.String},
+		"bbox":   {Type: graphql.String},
+	}
+	for _, category := range vocabulary.Categories {
+		args[camelCase(category.Name)] = &graphql.ArgumentConfig{Type: graphql.String}
+	}
+	if region {
+		args["continent"] = &graphql.ArgumentConfig{Type: graphql.String}
+		args["countryCode"] = &graphql.ArgumentConfig{Type: graphql.String}
+	}
+	return args
+}
+
+// restQuery translates the arguments of a field to the query parameters of
+// the REST endpoint with the same filters, so both are validated alike.
+func restQuery(args map[string]any) url.Values {
+	params := map[string]string{"countryCode": "country_3_code"}
+	for _, category := range vocabulary.Categories {
+		params[camelCase(category.Name)] = category.Name
+	}
+
+	query := url.Values{}
+	for name, value := range args {
+		if param, ok := params[name]; ok {
+			name = param
+		}
+		query.Set(name, fmt.Sprint(value))
+	}
+	return query
+}
+
+// resolveCities lists the cities like getCities, country restricts them to a
+// country when not empty.
+func resolveCities(p graphql.ResolveParams, country string) (any, error) {
+	citiesReq := &GetCitiesReq{}
+	if err := citiesReq.validateQuery(restQuery(p.Args)); err != nil {
+		return nil, graphqlError(err)
+	}
+	if country != "" {
+		citiesReq.filter.Country = country
+	}
+
+	gqlR := requestGraphQL(p.Context)
+	var cities []CityData
+	var err error
+	if citiesReq.filter.isEmpty() {
+		cities, err = gqlR.repo.GetCities(citiesReq.offset, citiesReq.limit)
+	} else {
+		cities, err = gqlR.repo.FilterCities(citiesReq.filter, citiesReq.offset, citiesReq.limit)
+	}
+	if err != nil {
+		return nil, graphqlError(err)
+	}
+	return ListCitiesResp{
+		Cities: gqlR.localizer.cities(cities),
+		Page:   newPage(citiesReq.offset, citiesReq.limit, len(cities)),
+	}, nil
+}
+
+// resolveTags returns a thunk so the tags of every city of a listing are
+// loaded with a single query.
+func resolveTags(p graphql.ResolveParams) (any, error) {
+	query := url.Values{}
+	for name, value := range p.Args {
+		query.Set(name, fmt.Sprint(value))
+	}
+	period, periodErrors := parsePeriod(query)
+	if len(periodErrors) > 0 {
+		return nil, graphqlError(&api_errors.ClientErr{
+			HttpCode: http.StatusBadRequest,
+			Message:  "Parameters not present or invalid",
+			Errors:   periodErrors,
+		})
+	}
+
+	gqlR := requestGraphQL(p.Context)
+	load := gqlR.tagsLoader(period).load(p.Source.(CityData).CityId)
+	return func() (any, error) {
+		tagsData, ok, err := load()
+		if err != nil {
+			return nil, graphqlError(err)
+		}
+		if !ok {
+			return nil, nil
+		}
+		return gqlR.localizer.cityTags(tagsData, gqlR.categories), nil
+	}, nil
+}
+
+func newGraphQLSchema() (graphql.Schema, error) {
+	pageType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Page",
+		Fields: graphql.Fields{
+			"offset":     {Type: graphql.NewNonNull(graphql.Int)},
+			"limit":      {Type: graphql.NewNonNull(graphql.Int)},
+			"nextOffset": {Type: graphql.Int},
+		},
+	})
+
+	tagType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Tag",
+		Fields: graphql.Fields{
+			"value": {Type: graphql.NewNonNull(graphql.String)},
+			"rank":  {Type: graphql.NewNonNull(graphql.Int)},
+			"label": {Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+
+	tagsFields := graphql.Fields{
+		"cityId": {Type: graphql.NewNonNull(graphql.Int)},
+	}
+	for _, category := range vocabulary.Categories {
+		name := category.Name
+		tagsFields[camelCase(name)] = &graphql.Field{
+			Type: graphql.NewNonNull(tagType),
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				return p.Source.(CityTags).Tags[name], nil
+			},
+		}
+	}
+	tagsType := graphql.NewObject(graphql.ObjectConfig{Name: "Tags", Fields: tagsFields})
+
+	var cityPageType *graphql.Object
+	countryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Country",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"alpha3Code": {
+					Type: graphql.NewNonNull(graphql.String),
+					Resolve: func(p graphql.ResolveParams) (any, error) {
+						return p.Source.(iso3166.Country).Alpha3, nil
+					},
+				},
+				"alpha2Code": {
+					Type: graphql.NewNonNull(graphql.String),
+					Resolve: func(p graphql.ResolveParams) (any, error) {
+						return p.Source.(iso3166.Country).Alpha2, nil
+					},
+				},
+				"name":      {Type: graphql.NewNonNull(graphql.String)},
+				"continent": {Type: graphql.NewNonNull(graphql.String)},
+				"cities": {
+					Type: graphql.NewNonNull(cityPageType),
+					Args: cityListArgs(false),
+					Resolve: func(p graphql.ResolveParams) (any, error) {
+						return resolveCities(p, p.Source.(iso3166.Country).Alpha3)
+					},
+				},
+			}
+		}),
+	})
+
+	cityType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "City",
+		Fields: graphql.Fields{
+			"id": {
+				Type: graphql.NewNonNull(graphql.Int),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return p.Source.(CityData).CityId, nil
+				},
+			},
+			"name": {
+				Type: graphql.NewNonNull(graphql.String),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return p.Source.(CityData).CityName, nil
+				},
+			},
+			"continent": {Type: graphql.NewNonNull(graphql.String)},
+			"countryCode": {
+				Type: graphql.NewNonNull(graphql.String),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return p.Source.(CityData).Country3Code, nil
+				},
+			},
+			"country": {
+				Type: countryType,
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					if country, ok := iso3166.ByAlpha3(p.Source.(CityData).Country3Code); ok {
+						return country, nil
+					}
+					return nil, nil
+				},
+			},
+			"latitude":       {Type: graphql.Float},
+			"longitude":      {Type: graphql.Float},
+			"timezone":       {Type: graphql.String},
+			"population":     {Type: graphql.Int},
+			"alternateNames": {Type: graphql.NewList(graphql.NewNonNull(graphql.String))},
+			"tags": {
+				Type: tagsType,
+				Args: graphql.FieldConfigArgument{
+					"month":  {Type: graphql.Int},
+					"season": {Type: graphql.String},
+				},
+				Resolve: resolveTags,
+			},
+		},
+	})
+
+	cityPageType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "CityPage",
+		Fields: graphql.Fields{
+			"cities": {Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(cityType)))},
+			"page":   {Type: graphql.NewNonNull(pageType)},
+		},
+	})
+
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"city": {
+				Type: cityType,
+				Args: graphql.FieldConfigArgument{
+					"id": {Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					gqlR := requestGraphQL(p.Context)
+					cityData, err := gqlR.repo.GetCity(p.Args["id"].(int))
+					if err != nil {
+						return nil, graphqlError(err)
+					}
+					return gqlR.localizer.city(cityData), nil
+				},
+			},
+			"cities": {
+				Type: graphql.NewNonNull(cityPageType),
+				Args: cityListArgs(true),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return resolveCities(p, "")
+				},
+			},
+			"country": {
+				Type: countryType,
+				Args: graphql.FieldConfigArgument{
+					"code": {Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					country, err := countryByCode(p.Args["code"].(string))
+					if err != nil {
+						return nil, graphqlError(err)
+					}
+					return country, nil
+				},
+			},
+			"countries": {
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(countryType))),
+				Args: graphql.FieldConfigArgument{
+					"continent": {Type: graphql.String},
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					continent, _ := p.Args["continent"].(string)
+					if continent != "" && !iso3166.IsContinent(continent) {
+						return nil, graphqlError(&api_errors.ClientErr{
+							HttpCode: http.StatusBadRequest,
+							Message:  "Parameters not present or invalid",
+							Errors: map[string]string{
+								"continent": fmt.Sprintf("Must be one of: %s", strings.Join(iso3166.Continents, ", ")),
+							},
+						})
+					}
+					countries := []iso3166.Country{}
+					for _, country := range iso3166.Countries {
+						if continent == "" || country.Continent == continent {
+							countries = append(countries, country)
+						}
+					}
+					return countries, nil
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: queryType})
+}
+
+// @Summary		GraphQL query
+// @Description	Run a GraphQL query over cities, their tags and countries. The cities arguments mirror the filters of the cities listing, queries deeper or more complex than the limits are rejected
+// @Accept		json
+// @Produce		json
+// @Param       query	body		GraphQLReq	true	"GraphQL query"
+// @Param       lang	query string	false	"Language of names and labels (en, es, de), Accept-Language is used if missing"
+// @Success		200 	{object} 	object
+// @Failure     400 	{object} 	api_errors.ClientErr
+// @Failure     500 	{object} 	api_errors.ClientErr
+// @Router		/v0/graphql [post]
+func (api *Api) graphql(schema graphql.Schema) CustomHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		gqlReq := &GraphQLReq{}
+		if err := decodeBody(r, gqlReq); err != nil {
+			return err
+		}
+		if err := gqlReq.validate(); err != nil {
+			return err
+		}
+
+		categories, err := api.repo.GetVocabulary()
+		if err != nil {
+			return err
+		}
+		localizer, err := api.localizer(r)
+		if err != nil {
+			return err
+		}
+		ctx := context.WithValue(r.Context(), graphqlKey{}, &graphqlRequest{
+			repo:       api.repo,
+			localizer:  localizer,
+			categories: categories,
+			tags:       map[string]*loader[int, TagsData]{},
+		})
+
+		result := graphql.Do(graphql.Params{
+			Schema:         schema,
+			RequestString:  gqlReq.Query,
+			VariableValues: gqlReq.Variables,
+			OperationName:  gqlReq.OperationName,
+			Context:        ctx,
+		})
+		respond(w, r, http.StatusOK, result)
+		return nil
+	}
+}
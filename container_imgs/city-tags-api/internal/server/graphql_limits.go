@@ -0,0 +1,159 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"city-tags-api/internal/api_errors"
+	"city-tags-api/internal/iso3166"
+
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+const (
+	graphqlMaxDepth      = 8
+	graphqlMaxComplexity = 10000
+)
+
+// queryCost measures an operation before it is executed. Every field costs
+// one and the fields returning lists multiply the cost of their selections by
+// the number of items they may return. Introspection fields are free.
+type queryCost struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]any
+	visiting  map[string]bool
+}
+
+// selectionSet returns the depth and the complexity of the selections, the
+// complexity is capped above the maximum to avoid overflows. page is set for
+// the selections of a CityPage, whose cities are already counted by the
+// listing.
+func (cost *queryCost) selectionSet(set *ast.SelectionSet, page bool) (int, int) {
+	if set == nil {
+		return 0, 0
+	}
+
+	depth, complexity := 0, 0
+	for _, selection := range set.Selections {
+		var selectionDepth, selectionComplexity int
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(selection.Name.Value, "__") {
+				continue
+			}
+			listing := selection.Name.Value == "cities" && !page
+			childDepth, childComplexity := cost.selectionSet(selection.SelectionSet, listing)
+			multiplier := 1
+			if listing || selection.Name.Value == "countries" {
+				multiplier = cost.multiplier(selection)
+			}
+			selectionDepth = childDepth + 1
+			selectionComplexity = min(1+multiplier*childComplexity, graphqlMaxComplexity+1)
+		case *ast.InlineFragment:
+			selectionDepth, selectionComplexity = cost.selectionSet(selection.SelectionSet, page)
+		case *ast.FragmentSpread:
+			name := selection.Name.Value
+			fragment, ok := cost.fragments[name]
+			if !ok || cost.visiting[name] {
+				continue
+			}
+			cost.visiting[name] = true
+			selectionDepth, selectionComplexity = cost.selectionSet(fragment.SelectionSet, page)
+			delete(cost.visiting, name)
+		}
+		depth = max(depth, selectionDepth)
+		complexity = min(complexity+selectionComplexity, graphqlMaxComplexity+1)
+	}
+	return depth, complexity
+}
+
+// multiplier returns the number of items a field may return, the limit of
+// the city listings or every country.
+func (cost *queryCost) multiplier(field *ast.Field) int {
+	if field.Name.Value == "countries" {
+		return len(iso3166.Countries)
+	}
+
+	limit := defaultLimit
+	for _, argument := range field.Arguments {
+		if argument.Name.Value != "limit" {
+			continue
+		}
+		switch value := argument.Value.(type) {
+		case *ast.IntValue:
+			if parsed, err := strconv.Atoi(value.Value); err == nil {
+				limit = parsed
+			}
+		case *ast.Variable:
+			switch variable := cost.variables[value.Name.Value].(type) {
+			case float64:
+				limit = int(variable)
+			case int:
+				limit = variable
+			}
+		}
+	}
+	return min(max(limit, 0), graphqlMaxComplexity+1)
+}
+
+// checkQueryLimits rejects the operation if it is deeper or more complex than
+// the limits. Queries that can't be parsed are left to the executor, which
+// reports their errors.
+func checkQueryLimits(query string, operationName string, variables map[string]any) error {
+	document, err := parser.Parse(parser.ParseParams{Source: query})
+	if err != nil {
+		return nil
+	}
+
+	cost := &queryCost{
+		fragments: map[string]*ast.FragmentDefinition{},
+		variables: map[string]any{},
+		visiting:  map[string]bool{},
+	}
+	var operation *ast.OperationDefinition
+	for _, definition := range document.Definitions {
+		switch definition := definition.(type) {
+		case *ast.FragmentDefinition:
+			cost.fragments[definition.Name.Value] = definition
+		case *ast.OperationDefinition:
+			if operation == nil && (operationName == "" || (definition.Name != nil && definition.Name.Value == operationName)) {
+				operation = definition
+			}
+		}
+	}
+	if operation == nil {
+		return nil
+	}
+
+	for _, definition := range operation.VariableDefinitions {
+		if value, ok := definition.DefaultValue.(*ast.IntValue); ok {
+			if parsed, err := strconv.Atoi(value.Value); err == nil {
+				cost.variables[definition.Variable.Name.Value] = parsed
+			}
+		}
+	}
+	for name, value := range variables {
+		cost.variables[name] = value
+	}
+
+	depth, complexity := cost.selectionSet(operation.SelectionSet, false)
+	var reason string
+	if depth > graphqlMaxDepth {
+		reason = fmt.Sprintf("Depth %d exceeds the maximum of %d", depth, graphqlMaxDepth)
+	} else if complexity > graphqlMaxComplexity {
+		reason = fmt.Sprintf("Complexity exceeds the maximum of %d", graphqlMaxComplexity)
+	}
+	if reason == "" {
+		return nil
+	}
+	return &api_errors.ClientErr{
+		HttpCode: http.StatusBadRequest,
+		Message:  "Parameters not present or invalid",
+		Errors: map[string]string{
+			"query": reason,
+		},
+	}
+}
@@ -0,0 +1,185 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/go-chi/jwtauth/v5"
+)
+
+func TestCheckQueryLimits(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]any
+		expected  bool
+	}{
+		{"shallow query", `{ city(id: 1) { name tags { temperature { value } } } }`, nil, true},
+		{"nested listings", `{ cities(limit: 10) { cities { country { cities(limit: 10) { cities { id } } } } } }`, nil, true},
+		{"too deep", `{ cities { cities { country { cities { cities { country { cities { cities { id } } } } } } } } }`, nil, false},
+		{"too complex", `{ countries { cities { cities { id name } } } }`, nil, false},
+		{"limit variable", `query($limit: Int) { countries { cities(limit: $limit) { cities { id } } } }`, map[string]any{"limit": float64(1)}, true},
+		{"limit default", `query($limit: Int = 1) { countries { cities(limit: $limit) { cities { id } } } }`, nil, true},
+		{"fragment", `{ countries { ...cities } } fragment cities on Country { cities { cities { id } } }`, nil, false},
+		{"introspection", `{ __schema { types { fields { type { ofType { ofType { ofType { ofType { ofType { name } } } } } } } } } }`, nil, true},
+		{"invalid query", `{ cities(`, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkQueryLimits(tt.query, "", tt.variables)
+			if (err == nil) != tt.expected {
+				t.Errorf("checkQueryLimits(%s) = %v; want accepted %t", tt.query, err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLoader(t *testing.T) {
+	batches := [][]int{}
+	tagsLoader := newLoader(func(cityIds []int) (map[int]TagsData, error) {
+		batches = append(batches, cityIds)
+		tags := map[int]TagsData{}
+		for _, cityId := range cityIds {
+			if cityId != 3 {
+				tags[cityId] = TagsData{CityId: cityId}
+			}
+		}
+		return tags, nil
+	})
+
+	thunks := []func() (TagsData, bool, error){}
+	for _, cityId := range []int{1, 2, 3, 1} {
+		thunks = append(thunks, tagsLoader.load(cityId))
+	}
+	found := []bool{}
+	for _, thunk := range thunks {
+		_, ok, err := thunk()
+		if err != nil {
+			t.Fatal(err)
+		}
+		found = append(found, ok)
+	}
+	tagsLoader.load(3)()
+
+	if !reflect.DeepEqual(batches, [][]int{{1, 2, 3}}) {
+		t.Errorf("loader batches = %v; want [[1 2 3]]", batches)
+	}
+	if !reflect.DeepEqual(found, []bool{true, true, false, true}) {
+		t.Errorf("loader found = %v; want [true true false true]", found)
+	}
+}
+
+// batchingRepository serves three cities and records the batches of tags.
+type batchingRepository struct {
+	stubRepository
+	batches [][]int
+}
+
+func (*batchingRepository) GetCities(offset int, limit int) ([]CityData, error) {
+	return []CityData{{CityId: 1}, {CityId: 2}, {CityId: 3}}, nil
+}
+
+func (repo *batchingRepository) GetTagsBatch(cityIds []int, period string) (map[int]TagsData, error) {
+	repo.batches = append(repo.batches, cityIds)
+	tags := map[int]TagsData{}
+	for _, cityId := range cityIds {
+		tags[cityId] = TagsData{CityId: cityId, Temp: "cold"}
+	}
+	return tags, nil
+}
+
+func TestGraphQL(t *testing.T) {
+	tokenAuth := jwtauth.New("HS256", []byte("test_enc_key"), nil)
+	repo := &batchingRepository{}
+	api := &Api{repo: repo, tokenAuth: tokenAuth}
+
+	_, token, err := tokenAuth.Encode(map[string]interface{}{"sub": "test_user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := json.Marshal(GraphQLReq{
+		Query: `{ cities(limit: 3) { cities { id tags { temperature { value rank label } } } page { nextOffset } } }`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest("POST", "/v0/graphql", bytes.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	writer := httptest.NewRecorder()
+	api.RegisterRoutes().ServeHTTP(writer, req)
+	if writer.Code != http.StatusOK {
+		t.Fatalf("POST /v0/graphql returned code %d; want %d: %s", writer.Code, http.StatusOK, writer.Body)
+	}
+
+	var result struct {
+		Data struct {
+			Cities struct {
+				Cities []struct {
+					Id   int
+					Tags struct {
+						Temperature Tag
+					}
+				}
+				Page struct {
+					NextOffset *int
+				}
+			}
+		}
+		Errors []any
+	}
+	if err := json.Unmarshal(writer.Body.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Errors) > 0 {
+		t.Fatalf("POST /v0/graphql returned errors %v", result.Errors)
+	}
+
+	cities := result.Data.Cities.Cities
+	if len(cities) != 3 || cities[2].Tags.Temperature != (Tag{Value: "cold", Rank: 1, Label: "Cold"}) {
+		t.Errorf("POST /v0/graphql returned %+v; want 3 cities with cold temperature", cities)
+	}
+	if next := result.Data.Cities.Page.NextOffset; next == nil || *next != 3 {
+		t.Errorf("POST /v0/graphql returned next offset %v; want 3", next)
+	}
+	if !reflect.DeepEqual(repo.batches, [][]int{{1, 2, 3}}) {
+		t.Errorf("tags loaded in batches %v; want [[1 2 3]]", repo.batches)
+	}
+}
+
+func TestGraphQL_clientErr(t *testing.T) {
+	tokenAuth := jwtauth.New("HS256", []byte("test_enc_key"), nil)
+	api := &Api{repo: stubRepository{}, tokenAuth: tokenAuth}
+
+	_, token, err := tokenAuth.Encode(map[string]interface{}{"sub": "test_user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := json.Marshal(GraphQLReq{Query: `{ city(id: 1) { name } }`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest("POST", "/v0/graphql", bytes.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	writer := httptest.NewRecorder()
+	api.RegisterRoutes().ServeHTTP(writer, req)
+
+	var result struct {
+		Errors []struct {
+			Message    string
+			Extensions map[string]any
+		}
+	}
+	if err := json.Unmarshal(writer.Body.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Errors) != 1 || result.Errors[0].Extensions["code"] != float64(http.StatusNotFound) {
+		t.Errorf("POST /v0/graphql returned %s; want a not found error", writer.Body)
+	}
+}
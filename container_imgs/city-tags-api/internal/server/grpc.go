@@ -0,0 +1,358 @@
+package server
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"city-tags-api/internal/api_errors"
+	"city-tags-api/internal/citytagspb"
+	"city-tags-api/internal/locale"
+	"city-tags-api/internal/vocabulary"
+
+	"github.com/go-chi/jwtauth/v5"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+// grpcService implements the gRPC API with the repository of the REST API.
+type grpcService struct {
+	citytagspb.UnimplementedCityTagsServer
+	api *Api
+}
+
+// NewGRPCServer returns the gRPC server of the API, authenticated with the same
+// JWT as the REST API and with reflection enabled.
+func (api *Api) NewGRPCServer() *grpc.Server {
+	grpcServer := grpc.NewServer(
+		grpc.UnaryInterceptor(api.authenticateUnary),
+		grpc.StreamInterceptor(api.authenticateStream),
+	)
+	citytagspb.RegisterCityTagsServer(grpcServer, &grpcService{api: api})
+	reflection.Register(grpcServer)
+	return grpcServer
+}
+
+// multiplexGRPC serves the gRPC requests, HTTP/2 with a gRPC content type,
+// with grpcServer and the rest with handler, so both APIs share the port.
+func multiplexGRPC(grpcServer *grpc.Server, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			grpcServer.ServeHTTP(w, r)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// authenticateContext verifies the bearer token of the authorization metadata
+// like jwtauth.Verifier and Authenticator do with the header.
+func (api *Api) authenticateContext(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	var tokenString string
+	if values := md.Get("authorization"); len(values) > 0 && len(values[0]) > 7 && strings.EqualFold(values[0][:7], "bearer ") {
+		tokenString = values[0][7:]
+	}
+
+	if tokenString == "" {
+		return nil, grpcError(authenticate(nil, jwtauth.ErrNoTokenFound))
+	}
+	token, err := jwtauth.VerifyToken(api.tokenAuth, tokenString)
+	if err := authenticate(token, err); err != nil {
+		return nil, grpcError(err)
+	}
+	return jwtauth.NewContext(ctx, token, nil), nil
+}
+
+func (api *Api) authenticateUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := api.authenticateContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (stream authenticatedStream) Context() context.Context {
+	return stream.ctx
+}
+
+func (api *Api) authenticateStream(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := api.authenticateContext(stream.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, authenticatedStream{ServerStream: stream, ctx: ctx})
+}
+
+// grpcCodes maps the status codes of the client errors to gRPC codes.
+var grpcCodes = map[int]codes.Code{
+	http.StatusBadRequest:         codes.InvalidArgument,
+	http.StatusUnauthorized:       codes.Unauthenticated,
+	http.StatusForbidden:          codes.PermissionDenied,
+	http.StatusNotFound:           codes.NotFound,
+	http.StatusConflict:           codes.AlreadyExists,
+	http.StatusPreconditionFailed: codes.FailedPrecondition,
+}
+
+// grpcError converts err to a gRPC status, the invalid parameters of client
+// errors are sent as BadRequest details and internal errors are hidden.
+func grpcError(err error) error {
+	clientErr, ok := err.(*api_errors.ClientErr)
+	if !ok {
+		log.Printf("Error: %s", err.Error())
+		return status.Error(codes.Internal, internalErr.Message)
+	}
+
+	code, ok := grpcCodes[clientErr.HttpCode]
+	if !ok {
+		code = codes.Unknown
+	}
+	st := status.New(code, clientErr.Message)
+	if len(clientErr.Errors) == 0 {
+		return st.Err()
+	}
+
+	badRequest := &errdetails.BadRequest{}
+	for _, param := range clientErr.Problem().InvalidParams {
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       param.Name,
+			Description: param.Reason,
+		})
+	}
+	if detailed, err := st.WithDetails(badRequest); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// localizer negotiates the languages with the accept-language metadata.
+func (service *grpcService) localizer(ctx context.Context) (*localizer, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	return service.api.localizerFor(locale.Negotiate("", strings.Join(md.Get("accept-language"), ",")))
+}
+
+func cityMessage(cityData CityData) *citytagspb.City {
+	return &citytagspb.City{
+		CityId:         int32(cityData.CityId),
+		CityName:       cityData.CityName,
+		Continent:      cityData.Continent,
+		Country_3Code:  cityData.Country3Code,
+		Latitude:       cityData.Latitude,
+		Longitude:      cityData.Longitude,
+		Timezone:       cityData.Timezone,
+		Population:     cityData.Population,
+		AlternateNames: cityData.AlternateNames,
+	}
+}
+
+func tagsMessage(cityTags CityTags) *citytagspb.Tags {
+	tags := make(map[string]*citytagspb.Tag, len(cityTags.Tags))
+	for category, tag := range cityTags.Tags {
+		tags[category] = &citytagspb.Tag{Value: tag.Value, Rank: int32(tag.Rank), Label: tag.Label}
+	}
+	return &citytagspb.Tags{CityId: int32(cityTags.CityId), Tags: tags}
+}
+
+func (service *grpcService) GetCity(ctx context.Context, req *citytagspb.GetCityRequest) (*citytagspb.City, error) {
+	cityData, err := service.api.repo.GetCity(int(req.CityId))
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	localizer, err := service.localizer(ctx)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return cityMessage(localizer.city(cityData)), nil
+}
+
+// listCitiesQuery translates the request to the query parameters of the cities
+// listing, so both are validated alike.
+func listCitiesQuery(req *citytagspb.ListCitiesRequest) (url.Values, error) {
+	errors := map[string]string{}
+	if req.Limit < 0 {
+		errors["limit"] = "Not present or invalid"
+	}
+
+	query := url.Values{}
+	query.Set("offset", strconv.Itoa(int(req.Offset)))
+	for category, value := range req.Tags {
+		if _, ok := vocabulary.ByName(category); !ok {
+			errors["tags"] = "Unknown tag category " + category
+			continue
+		}
+		query.Set(category, value)
+	}
+	if req.Month != 0 {
+		query.Set("month", strconv.Itoa(int(req.Month)))
+	}
+	params := map[string]string{
+		"season":         req.Season,
+		"bbox":           req.Bbox,
+		"continent":      req.Continent,
+		"country_3_code": req.Country_3Code,
+	}
+	for param, value := range params {
+		if value != "" {
+			query.Set(param, value)
+		}
+	}
+
+	if len(errors) > 0 {
+		return nil, &api_errors.ClientErr{
+			HttpCode: http.StatusBadRequest,
+			Message:  "Parameters not present or invalid",
+			Errors:   errors,
+		}
+	}
+	return query, nil
+}
+
+// ListCities pages through the repository with the page size of the REST API
+// until the limit or the last city is reached.
+func (service *grpcService) ListCities(req *citytagspb.ListCitiesRequest, stream grpc.ServerStreamingServer[citytagspb.City]) error {
+	query, err := listCitiesQuery(req)
+	if err != nil {
+		return grpcError(err)
+	}
+	citiesReq := &GetCitiesReq{}
+	if err := citiesReq.validateQuery(query); err != nil {
+		return grpcError(err)
+	}
+	localizer, err := service.localizer(stream.Context())
+	if err != nil {
+		return grpcError(err)
+	}
+
+	offset := citiesReq.offset
+	remaining := int(req.Limit)
+	for {
+		pageSize := defaultLimit
+		if req.Limit > 0 {
+			pageSize = min(pageSize, remaining)
+		}
+
+		var cities []CityData
+		if citiesReq.filter.isEmpty() {
+			cities, err = service.api.repo.GetCities(offset, pageSize)
+		} else {
+			cities, err = service.api.repo.FilterCities(citiesReq.filter, offset, pageSize)
+		}
+		if err != nil {
+			return grpcError(err)
+		}
+		for _, cityData := range cities {
+			if err := stream.Send(cityMessage(localizer.city(cityData))); err != nil {
+				return err
+			}
+		}
+
+		offset += len(cities)
+		remaining -= len(cities)
+		if len(cities) < pageSize || (req.Limit > 0 && remaining == 0) {
+			return nil
+		}
+	}
+}
+
+func (service *grpcService) GetTags(ctx context.Context, req *citytagspb.GetTagsRequest) (*citytagspb.Tags, error) {
+	query := url.Values{}
+	if req.Month != 0 {
+		query.Set("month", strconv.Itoa(int(req.Month)))
+	}
+	if req.Season != "" {
+		query.Set("season", req.Season)
+	}
+	period, errors := parsePeriod(query)
+	if len(errors) > 0 {
+		return nil, grpcError(&api_errors.ClientErr{
+			HttpCode: http.StatusBadRequest,
+			Message:  "Parameters not present or invalid",
+			Errors:   errors,
+		})
+	}
+
+	var tagsData TagsData
+	var err error
+	if period != "" {
+		tagsData, err = service.api.repo.GetPeriodTags(int(req.CityId), period)
+	} else {
+		tagsData, err = service.api.repo.GetTags(int(req.CityId))
+	}
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	categories, err := service.api.repo.GetVocabulary()
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	localizer, err := service.localizer(ctx)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return tagsMessage(localizer.cityTags(tagsData, categories)), nil
+}
+
+// BatchGet returns the cities in the order of the request, the tags of every
+// city are loaded with a single query.
+func (service *grpcService) BatchGet(ctx context.Context, req *citytagspb.BatchGetRequest) (*citytagspb.BatchGetResponse, error) {
+	if len(req.CityIds) == 0 || len(req.CityIds) > defaultLimit {
+		return nil, grpcError(&api_errors.ClientErr{
+			HttpCode: http.StatusBadRequest,
+			Message:  "Parameters not present or invalid",
+			Errors: map[string]string{
+				"city_ids": "Must have between 1 and " + strconv.Itoa(defaultLimit) + " city ids",
+			},
+		})
+	}
+
+	cityIds := make([]int, len(req.CityIds))
+	for index, cityId := range req.CityIds {
+		cityIds[index] = int(cityId)
+	}
+	cities, err := service.api.repo.GetCitiesBatch(cityIds)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	localizer, err := service.localizer(ctx)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	var tags map[int]TagsData
+	var categories []TagCategory
+	if req.IncludeTags {
+		if tags, err = service.api.repo.GetTagsBatch(cityIds, ""); err != nil {
+			return nil, grpcError(err)
+		}
+		if categories, err = service.api.repo.GetVocabulary(); err != nil {
+			return nil, grpcError(err)
+		}
+	}
+
+	resp := &citytagspb.BatchGetResponse{}
+	for _, cityId := range cityIds {
+		cityData, ok := cities[cityId]
+		if !ok {
+			resp.NotFound = append(resp.NotFound, int32(cityId))
+			continue
+		}
+		cityWithTags := &citytagspb.CityWithTags{City: cityMessage(localizer.city(cityData))}
+		if tagsData, ok := tags[cityId]; ok {
+			cityWithTags.Tags = tagsMessage(localizer.cityTags(tagsData, categories))
+		}
+		resp.Cities = append(resp.Cities, cityWithTags)
+	}
+	return resp, nil
+}
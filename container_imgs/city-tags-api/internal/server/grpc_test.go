@@ -0,0 +1,215 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"city-tags-api/internal/citytagspb"
+
+	"github.com/go-chi/jwtauth/v5"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// listingRepository serves the cities 1 to 250, every one with tags.
+type listingRepository struct {
+	stubRepository
+}
+
+func (listingRepository) GetCity(cityId int) (CityData, error) {
+	cities, _ := listingRepository{}.GetCitiesBatch([]int{cityId})
+	if cityData, ok := cities[cityId]; ok {
+		return cityData, nil
+	}
+	return stubRepository{}.GetCity(cityId)
+}
+
+func (listingRepository) GetCities(offset int, limit int) ([]CityData, error) {
+	cities := []CityData{}
+	for cityId := offset + 1; cityId <= 250 && len(cities) < limit; cityId++ {
+		cities = append(cities, CityData{CityId: cityId})
+	}
+	return cities, nil
+}
+
+func (listingRepository) GetCitiesBatch(cityIds []int) (map[int]CityData, error) {
+	cities := map[int]CityData{}
+	for _, cityId := range cityIds {
+		if cityId >= 1 && cityId <= 250 {
+			cities[cityId] = CityData{CityId: cityId}
+		}
+	}
+	return cities, nil
+}
+
+func (listingRepository) GetTagsBatch(cityIds []int, period string) (map[int]TagsData, error) {
+	tags := map[int]TagsData{}
+	for _, cityId := range cityIds {
+		tags[cityId] = TagsData{CityId: cityId, Temp: "cold"}
+	}
+	return tags, nil
+}
+
+// newGRPCClient serves the API like NewServer does, both gRPC and REST on the
+// same port, and returns a client with a valid token and the server URL.
+func newGRPCClient(t *testing.T) (citytagspb.CityTagsClient, context.Context, string) {
+	tokenAuth := jwtauth.New("HS256", []byte("test_enc_key"), nil)
+	api := &Api{repo: listingRepository{}, tokenAuth: tokenAuth}
+
+	server := httptest.NewServer(h2c.NewHandler(multiplexGRPC(api.NewGRPCServer(), api.RegisterRoutes()), &http2.Server{}))
+	t.Cleanup(server.Close)
+
+	conn, err := grpc.NewClient(strings.TrimPrefix(server.URL, "http://"), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	_, token, err := tokenAuth.Encode(map[string]interface{}{"sub": "test_user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+	return citytagspb.NewCityTagsClient(conn), ctx, server.URL
+}
+
+func TestGRPC_authentication(t *testing.T) {
+	client, _, _ := newGRPCClient(t)
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+	}{
+		{"no token", context.Background()},
+		{"invalid token", metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer invalid")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.GetCity(tt.ctx, &citytagspb.GetCityRequest{CityId: 1})
+			if status.Code(err) != codes.Unauthenticated {
+				t.Errorf("GetCity returned %v; want Unauthenticated", err)
+			}
+		})
+	}
+}
+
+func TestGRPC_GetCity(t *testing.T) {
+	client, ctx, url := newGRPCClient(t)
+
+	city, err := client.GetCity(ctx, &citytagspb.GetCityRequest{CityId: 7})
+	if err != nil || city.CityId != 7 {
+		t.Errorf("GetCity(7) = %v, %v; want city 7", city, err)
+	}
+	if _, err := client.GetCity(ctx, &citytagspb.GetCityRequest{CityId: 999}); status.Code(err) != codes.NotFound {
+		t.Errorf("GetCity(999) returned %v; want NotFound", err)
+	}
+
+	resp, err := http.Get(url + "/ping")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("GET /ping on the gRPC port returned %d; want %d", resp.StatusCode, http.StatusOK)
+	}
+}
+
+func TestGRPC_ListCities(t *testing.T) {
+	client, ctx, _ := newGRPCClient(t)
+
+	tests := []struct {
+		name  string
+		req   *citytagspb.ListCitiesRequest
+		first int32
+		count int
+	}{
+		{"every city", &citytagspb.ListCitiesRequest{}, 1, 250},
+		{"offset and limit", &citytagspb.ListCitiesRequest{Offset: 10, Limit: 150}, 11, 150},
+		{"limit past the end", &citytagspb.ListCitiesRequest{Offset: 200, Limit: 100}, 201, 50},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream, err := client.ListCities(ctx, tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cityIds := []int32{}
+			for {
+				city, err := stream.Recv()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				cityIds = append(cityIds, city.CityId)
+			}
+			if len(cityIds) != tt.count || cityIds[0] != tt.first {
+				t.Errorf("ListCities(%v) streamed %d cities from %d; want %d from %d", tt.req, len(cityIds), cityIds[0], tt.count, tt.first)
+			}
+		})
+	}
+}
+
+func TestGRPC_ListCitiesInvalid(t *testing.T) {
+	client, ctx, _ := newGRPCClient(t)
+
+	stream, err := client.ListCities(ctx, &citytagspb.ListCitiesRequest{Tags: map[string]string{"temperature": "boiling"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = stream.Recv()
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("ListCities returned %v; want InvalidArgument", err)
+	}
+
+	fields := []string{}
+	for _, detail := range status.Convert(err).Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			for _, violation := range badRequest.FieldViolations {
+				fields = append(fields, violation.Field)
+			}
+		}
+	}
+	if !reflect.DeepEqual(fields, []string{"temperature"}) {
+		t.Errorf("ListCities returned violations of %v; want [temperature]", fields)
+	}
+}
+
+func TestGRPC_BatchGet(t *testing.T) {
+	client, ctx, _ := newGRPCClient(t)
+
+	resp, err := client.BatchGet(ctx, &citytagspb.BatchGetRequest{CityIds: []int32{2, 999, 1}, IncludeTags: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cityIds := []int32{}
+	for _, city := range resp.Cities {
+		cityIds = append(cityIds, city.City.CityId)
+		if city.Tags.GetTags()["temperature"].GetValue() != "cold" {
+			t.Errorf("BatchGet returned tags %v for %d; want cold temperature", city.Tags, city.City.CityId)
+		}
+	}
+	if !reflect.DeepEqual(cityIds, []int32{2, 1}) || !reflect.DeepEqual(resp.NotFound, []int32{999}) {
+		t.Errorf("BatchGet returned %v and not found %v; want [2 1] and [999]", cityIds, resp.NotFound)
+	}
+
+	if _, err := client.BatchGet(ctx, &citytagspb.BatchGetRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("BatchGet without city ids returned %v; want InvalidArgument", err)
+	}
+}
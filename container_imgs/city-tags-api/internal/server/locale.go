@@ -0,0 +1,222 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"city-tags-api/internal/api_errors"
+	"city-tags-api/internal/locale"
+	"city-tags-api/internal/vocabulary"
+)
+
+// Translations has the city names and vocabulary labels of one language.
+type Translations struct {
+	CityNames      map[int]string
+	CategoryLabels map[string]string
+	ValueLabels    map[string]map[string]string
+}
+
+// LocalizedTags adds the labels of the tag values, keyed by category, in the
+// language of the request.
+type LocalizedTags struct {
+	TagsData
+	Labels map[string]string `json:"labels"`
+}
+
+func (repo *dbRepository) GetTranslations(language string) (*Translations, error) {
+	translations := &Translations{
+		CityNames:      map[int]string{},
+		CategoryLabels: map[string]string{},
+		ValueLabels:    map[string]map[string]string{},
+	}
+
+	rows, err := repo.db.Query("select city_id, name from city_tags.city_names where language = $1", language)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var cityId int
+		var name string
+		if err := rows.Scan(&cityId, &name); err != nil {
+			return nil, err
+		}
+		translations.CityNames[cityId] = name
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = repo.db.Query("select category, label from city_tags.tag_category_labels where language = $1", language)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var category, label string
+		if err := rows.Scan(&category, &label); err != nil {
+			return nil, err
+		}
+		translations.CategoryLabels[category] = label
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = repo.db.Query("select category, value, label from city_tags.tag_value_labels where language = $1", language)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var category, value, label string
+		if err := rows.Scan(&category, &value, &label); err != nil {
+			return nil, err
+		}
+		if translations.ValueLabels[category] == nil {
+			translations.ValueLabels[category] = map[string]string{}
+		}
+		translations.ValueLabels[category][value] = label
+	}
+	return translations, rows.Err()
+}
+
+type languagesKey struct{}
+
+// Localize negotiates the languages of the response from the lang parameter
+// and the Accept-Language header. The first one is sent in Content-Language
+// and the rest are the fallbacks of the names and labels it doesn't have.
+func Localize(next http.Handler) http.Handler {
+	return NewHandler(
+		func(w http.ResponseWriter, r *http.Request) error {
+			lang := r.URL.Query().Get("lang")
+			if lang != "" && !locale.IsSupported(lang) {
+				return &api_errors.ClientErr{
+					HttpCode: http.StatusBadRequest,
+					Message:  "Parameters not present or invalid",
+					Errors: map[string]string{
+						"lang": fmt.Sprintf("Must be one of: %s", strings.Join(locale.Supported, ", ")),
+					},
+				}
+			}
+
+			languages := locale.Negotiate(lang, r.Header.Get("Accept-Language"))
+			w.Header().Set("Content-Language", languages[0])
+			w.Header().Add("Vary", "Accept-Language")
+
+			ctx := context.WithValue(r.Context(), languagesKey{}, languages)
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return nil
+		},
+	)
+}
+
+func requestLanguages(r *http.Request) []string {
+	if languages, ok := r.Context().Value(languagesKey{}).([]string); ok {
+		return languages
+	}
+	return []string{locale.Default}
+}
+
+// localizer translates names and labels with the first language of the
+// request that has them, keeping the stored ones when none does.
+type localizer struct {
+	chain []*Translations
+}
+
+func (api *Api) localizer(r *http.Request) (*localizer, error) {
+	return api.localizerFor(requestLanguages(r))
+}
+
+func (api *Api) localizerFor(languages []string) (*localizer, error) {
+	chain := make([]*Translations, 0, len(languages))
+	for _, language := range languages {
+		translations, err := api.repo.GetTranslations(language)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, translations)
+	}
+	return &localizer{chain: chain}, nil
+}
+
+func (l *localizer) city(cityData CityData) CityData {
+	for _, translations := range l.chain {
+		if name, ok := translations.CityNames[cityData.CityId]; ok {
+			cityData.CityName = name
+			break
+		}
+	}
+	return cityData
+}
+
+// cities returns a localized copy, the given slice may be shared with the
+// cache.
+func (l *localizer) cities(cities []CityData) []CityData {
+	localized := make([]CityData, len(cities))
+	for index, cityData := range cities {
+		localized[index] = l.city(cityData)
+	}
+	return localized
+}
+
+func (l *localizer) categoryLabel(category string, label string) string {
+	for _, translations := range l.chain {
+		if translated, ok := translations.CategoryLabels[category]; ok {
+			return translated
+		}
+	}
+	return label
+}
+
+func (l *localizer) valueLabel(category string, value string, label string) string {
+	for _, translations := range l.chain {
+		if translated, ok := translations.ValueLabels[category][value]; ok {
+			return translated
+		}
+	}
+	return label
+}
+
+// vocabulary returns a localized copy of the vocabulary.
+func (l *localizer) vocabulary(categories []TagCategory) []TagCategory {
+	localized := make([]TagCategory, len(categories))
+	for index, category := range categories {
+		values := make([]TagValue, len(category.Values))
+		for position, value := range category.Values {
+			value.Label = l.valueLabel(category.Category, value.Value, value.Label)
+			values[position] = value
+		}
+		category.Label = l.categoryLabel(category.Category, category.Label)
+		category.Values = values
+		localized[index] = category
+	}
+	return localized
+}
+
+// cityTags returns the tags of tagsData keyed by category with their rank and
+// label, categories is the vocabulary with the stored labels.
+func (l *localizer) cityTags(tagsData TagsData, categories []TagCategory) CityTags {
+	stored := make(map[string]map[string]TagValue, len(categories))
+	for _, category := range categories {
+		stored[category.Category] = make(map[string]TagValue, len(category.Values))
+		for _, value := range category.Values {
+			stored[category.Category][value.Value] = value
+		}
+	}
+
+	tags := make(map[string]Tag, len(vocabulary.Categories))
+	for _, category := range vocabulary.Categories {
+		value := tagsData.byColumn(category.Column)
+		tag := Tag{Value: value, Label: value}
+		if storedValue, ok := stored[category.Name][value]; ok {
+			tag.Rank = storedValue.Rank
+			tag.Label = storedValue.Label
+		}
+		tag.Label = l.valueLabel(category.Name, value, tag.Label)
+		tags[category.Name] = tag
+	}
+	return CityTags{CityId: tagsData.CityId, Tags: tags}
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestLocalize(t *testing.T) {
+	tests := []struct {
+		name           string
+		url            string
+		acceptLanguage string
+		expectedCode   int
+		expected       []string
+	}{
+		{"default language", "/v0/cities", "", http.StatusOK, []string{"en"}},
+		{"accept language", "/v0/cities", "de-DE,es;q=0.5", http.StatusOK, []string{"de", "es", "en"}},
+		{"lang parameter", "/v0/cities?lang=es", "de", http.StatusOK, []string{"es", "de", "en"}},
+		{"unsupported lang parameter", "/v0/cities?lang=fr", "", http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", tt.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Accept-Language", tt.acceptLanguage)
+
+			var languages []string
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				languages = requestLanguages(r)
+			})
+			recorder := httptest.NewRecorder()
+			Localize(next).ServeHTTP(recorder, req)
+
+			if recorder.Code != tt.expectedCode {
+				t.Fatalf("Localize() returned %d; want %d", recorder.Code, tt.expectedCode)
+			}
+			if !reflect.DeepEqual(languages, tt.expected) {
+				t.Errorf("Localize() languages = %v; want %v", languages, tt.expected)
+			}
+			if tt.expected != nil && recorder.Header().Get("Content-Language") != tt.expected[0] {
+				t.Errorf("Localize() Content-Language = %s; want %s", recorder.Header().Get("Content-Language"), tt.expected[0])
+			}
+		})
+	}
+}
+
+func TestLocalizer(t *testing.T) {
+	localizer := &localizer{chain: []*Translations{
+		{
+			CityNames:      map[int]string{1: "Múnich"},
+			CategoryLabels: map[string]string{"temperature": "Temperatura"},
+			ValueLabels:    map[string]map[string]string{"temperature": {"cold": "Frío"}},
+		},
+		{
+			CityNames:      map[int]string{1: "Munich", 2: "Cologne"},
+			CategoryLabels: map[string]string{},
+			ValueLabels:    map[string]map[string]string{},
+		},
+	}}
+
+	cities := []CityData{{CityId: 1, CityName: "München"}, {CityId: 2, CityName: "Köln"}, {CityId: 3, CityName: "Bonn"}}
+	names := []string{}
+	for _, city := range localizer.cities(cities) {
+		names = append(names, city.CityName)
+	}
+	if !reflect.DeepEqual(names, []string{"Múnich", "Cologne", "Bonn"}) {
+		t.Errorf("localizer.cities() names = %v; want [Múnich Cologne Bonn]", names)
+	}
+	if cities[0].CityName != "München" {
+		t.Errorf("localizer.cities() modified the given cities")
+	}
+
+	categories := []TagCategory{{
+		Category: "temperature",
+		Label:    "Temperature",
+		Values:   []TagValue{{Value: "cold", Rank: 1, Label: "Cold"}, {Value: "mild", Rank: 2, Label: "Mild"}},
+	}}
+	localized := localizer.vocabulary(categories)
+	if localized[0].Label != "Temperatura" || localized[0].Values[0].Label != "Frío" || localized[0].Values[1].Label != "Mild" {
+		t.Errorf("localizer.vocabulary() = %v; want Temperatura with Frío and Mild", localized[0])
+	}
+	if categories[0].Values[0].Label != "Cold" {
+		t.Errorf("localizer.vocabulary() modified the given vocabulary")
+	}
+
+	cityTags := localizer.cityTags(TagsData{CityId: 1, Temp: "cold", CitySize: "small"}, categories)
+	if cityTags.Tags["temperature"] != (Tag{Value: "cold", Rank: 1, Label: "Frío"}) ||
+		cityTags.Tags["city_size"] != (Tag{Value: "small", Label: "small"}) {
+		t.Errorf("localizer.cityTags() = %v; want cold ranked 1 as Frío and small unranked", cityTags.Tags)
+	}
+}
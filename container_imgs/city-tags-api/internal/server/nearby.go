@@ -0,0 +1,206 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"city-tags-api/internal/api_errors"
+	"city-tags-api/internal/geo"
+)
+
+const (
+	defaultRadiusKm = 50
+	maxRadiusKm     = 20000
+	maxNearbyLimit  = 100
+)
+
+type NearbyCity struct {
+	CityData
+	DistanceKm float64 `json:"distance_km"`
+}
+
+type GetNearbyResp struct {
+	Cities []NearbyCity `json:"cities"`
+}
+
+// bboxCondition returns the condition selecting the cities inside box,
+// appending its parameters to args.
+func bboxCondition(box geo.BBox, args []any) (string, []any) {
+	args = append(args, box.MinLat, box.MaxLat, box.MinLon, box.MaxLon)
+	minLat, maxLat, minLon, maxLon := len(args)-3, len(args)-2, len(args)-1, len(args)
+
+	lonOperator := "and"
+	if box.CrossesAntimeridian() {
+		lonOperator = "or"
+	}
+	return fmt.Sprintf(
+		"c.latitude between $%d and $%d and (c.longitude >= $%d %s c.longitude <= $%d)",
+		minLat, maxLat, minLon, lonOperator, maxLon,
+	), args
+}
+
+func (repo *dbRepository) GetNearbyCities(center geo.Point, radiusKm float64, limit int) ([]NearbyCity, error) {
+	// The bounding box uses the index on the coordinates to discard most of
+	// the cities before computing the haversine distance of the rest.
+	condition, args := bboxCondition(geo.Around(center, radiusKm), []any{center.Lat, center.Lon, geo.EarthRadiusKm})
+	args = append(args, radiusKm, limit)
+	rows, err := repo.db.Query(
+		fmt.Sprintf(
+			`select * from (
+				select `+cityColumns+`, 2 * $3 * asin(sqrt(least(1,
+					power(sin(radians(c.latitude - $1) / 2), 2) +
+					cos(radians($1)) * cos(radians(c.latitude)) * power(sin(radians(c.longitude - $2) / 2), 2)
+				))) as distance_km
+				from city_tags.cities c
+				where %s
+			) nearby
+			where distance_km <= $%d
+			order by distance_km, city_id
+			limit $%d`,
+			condition, len(args)-1, len(args),
+		),
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cities := []NearbyCity{}
+	for rows.Next() {
+		var city NearbyCity
+		err := rows.Scan(
+			&city.CityId,
+			&city.CityName,
+			&city.Continent,
+			&city.Country3Code,
+			&city.Latitude,
+			&city.Longitude,
+			&city.Timezone,
+			&city.Population,
+			&city.AlternateNames,
+			&city.DistanceKm,
+		)
+		if err != nil {
+			return nil, err
+		}
+		cities = append(cities, city)
+	}
+	return cities, rows.Err()
+}
+
+// nearbyCities is the in-memory version of GetNearbyCities used in warm-up
+// mode.
+func nearbyCities(cities []CityData, center geo.Point, radiusKm float64, limit int) []NearbyCity {
+	box := geo.Around(center, radiusKm)
+	nearby := []NearbyCity{}
+	for _, city := range cities {
+		location, ok := city.location()
+		if !ok || !box.Contains(location) {
+			continue
+		}
+		distance := geo.Distance(center, location)
+		if distance <= radiusKm {
+			nearby = append(nearby, NearbyCity{CityData: city, DistanceKm: distance})
+		}
+	}
+
+	sort.Slice(nearby, func(i, j int) bool {
+		if nearby[i].DistanceKm != nearby[j].DistanceKm {
+			return nearby[i].DistanceKm < nearby[j].DistanceKm
+		}
+		return nearby[i].CityId < nearby[j].CityId
+	})
+	if len(nearby) > limit {
+		nearby = nearby[:limit]
+	}
+	return nearby
+}
+
+type GetNearbyReq struct {
+	center   geo.Point
+	radiusKm float64
+	limit    int
+}
+
+func (getNearR *GetNearbyReq) validate(r *http.Request) error {
+	query := r.URL.Query()
+	errors := map[string]string{}
+
+	lat, err := strconv.ParseFloat(query.Get("lat"), 64)
+	if err != nil || math.IsNaN(lat) || lat < -90 || lat > 90 {
+		errors["lat"] = "Not present or invalid, must be between -90 and 90"
+	}
+	lon, err := strconv.ParseFloat(query.Get("lon"), 64)
+	if err != nil || math.IsNaN(lon) || lon < -180 || lon > 180 {
+		errors["lon"] = "Not present or invalid, must be between -180 and 180"
+	}
+
+	radiusKm := float64(defaultRadiusKm)
+	if radiusParam := query.Get("radius_km"); radiusParam != "" {
+		radiusKm, err = strconv.ParseFloat(radiusParam, 64)
+		if err != nil || math.IsNaN(radiusKm) || radiusKm <= 0 || radiusKm > maxRadiusKm {
+			errors["radius_km"] = fmt.Sprintf("Must be greater than 0 and at most %d", maxRadiusKm)
+		}
+	}
+
+	limit := maxNearbyLimit
+	if limitParam := query.Get("limit"); limitParam != "" {
+		limit, err = strconv.Atoi(limitParam)
+		if err != nil || limit < 1 || limit > maxNearbyLimit {
+			errors["limit"] = fmt.Sprintf("Must be an integer between 1 and %d", maxNearbyLimit)
+		}
+	}
+
+	if len(errors) > 0 {
+		return &api_errors.ClientErr{
+			HttpCode: http.StatusBadRequest,
+			Message:  "Parameters not present or invalid",
+			Errors:   errors,
+		}
+	}
+
+	getNearR.center = geo.Point{Lat: lat, Lon: lon}
+	getNearR.radiusKm = radiusKm
+	getNearR.limit = limit
+	return nil
+}
+
+// @Summary		Get nearby cities
+// @Description	Get the cities within a radius of a point, closest first
+// @Accept		json
+// @Produce		json
+// @Param       lat			query number	true	"Latitude of the center"
+// @Param       lon			query number	true	"Longitude of the center"
+// @Param       radius_km	query number	false	"Radius in km, 50 by default"
+// @Param       limit		query int		false	"Maximum number of cities, 100 by default and 100 at most"
+// @Param       lang	query string	false	"Language of names and labels (en, es, de), Accept-Language is used if missing"
+// @Success		200 	{object} 	GetNearbyResp
+// @Failure     400 	{object} 	api_errors.ClientErr
+// @Failure     500 	{object} 	api_errors.ClientErr
+// @Router		/v0/cities/nearby [get]
+func (api *Api) getNearbyCities(w http.ResponseWriter, r *http.Request) error {
+	nearbyReq := &GetNearbyReq{}
+	err := nearbyReq.validate(r)
+	if err != nil {
+		return err
+	}
+
+	cities, err := api.repo.GetNearbyCities(nearbyReq.center, nearbyReq.radiusKm, nearbyReq.limit)
+	if err != nil {
+		return err
+	}
+	localizer, err := api.localizer(r)
+	if err != nil {
+		return err
+	}
+	for index := range cities {
+		cities[index].CityData = localizer.city(cities[index].CityData)
+	}
+
+	respond(w, r, http.StatusOK, GetNearbyResp{Cities: cities})
+	return nil
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"city-tags-api/internal/geo"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBBoxCondition(t *testing.T) {
+	condition, args := bboxCondition(geo.BBox{MinLon: 170, MinLat: -50, MaxLon: -170, MaxLat: -30}, []any{"warm"})
+
+	expected := "c.latitude between $2 and $3 and (c.longitude >= $4 or c.longitude <= $5)"
+	if condition != expected {
+		t.Errorf("bboxCondition() = %s; want %s", condition, expected)
+	}
+	if !reflect.DeepEqual(args, []any{"warm", -50.0, -30.0, 170.0, -170.0}) {
+		t.Errorf("bboxCondition() args = %v", args)
+	}
+
+	condition, _ = bboxCondition(geo.BBox{MinLon: -10, MinLat: 35, MaxLon: 5, MaxLat: 44}, nil)
+	if !strings.Contains(condition, "c.longitude >= $3 and c.longitude <= $4") {
+		t.Errorf("bboxCondition() = %s; want both longitudes to be required", condition)
+	}
+}
+
+func TestNearbyCities(t *testing.T) {
+	city := func(cityId int, lat float64, lon float64) CityData {
+		return CityData{CityId: cityId, Latitude: &lat, Longitude: &lon}
+	}
+	cities := []CityData{
+		city(1, 41.3874, 2.1686),
+		city(2, 39.8628, -4.0273),
+		city(3, 40.4168, -3.7038),
+		{CityId: 4},
+	}
+	madrid := geo.Point{Lat: 40.4168, Lon: -3.7038}
+
+	nearby := nearbyCities(cities, madrid, 100, 10)
+	if len(nearby) != 2 || nearby[0].CityId != 3 || nearby[1].CityId != 2 {
+		t.Errorf("nearbyCities(100 km) = %v; want cities 3 and 2", nearby)
+	}
+	if nearby := nearbyCities(cities, madrid, 1000, 2); len(nearby) != 2 || nearby[1].CityId != 2 {
+		t.Errorf("nearbyCities(1000 km, limit 2) = %v; want cities 3 and 2", nearby)
+	}
+}
+
+func TestGetNearbyReq_validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		isError bool
+	}{
+		{"defaults", "?lat=40.4&lon=-3.7", false},
+		{"radius and limit", "?lat=40.4&lon=-3.7&radius_km=10&limit=5", false},
+		{"missing coordinates", "?radius_km=10", true},
+		{"latitude out of range", "?lat=91&lon=-3.7", true},
+		{"negative radius", "?lat=40.4&lon=-3.7&radius_km=-1", true},
+		{"invalid limit", "?lat=40.4&lon=-3.7&limit=0", true},
+		{"limit too high", "?lat=40.4&lon=-3.7&limit=101", true},
+		{"latitude not a number", "?lat=NaN&lon=-3.7", true},
+		{"longitude not a number", "?lat=40.4&lon=NaN", true},
+		{"radius not a number", "?lat=40.4&lon=-3.7&radius_km=NaN", true},
+		{"infinite radius", "?lat=40.4&lon=-3.7&radius_km=Inf", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/v0/cities/nearby"+tt.query, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			nearbyReq := &GetNearbyReq{}
+			err = nearbyReq.validate(req)
+			if tt.isError != (err != nil) {
+				t.Errorf("GetNearbyReq.validate(%s) error = %v; want error %t", tt.query, err, tt.isError)
+			}
+		})
+	}
+}
@@ -0,0 +1,332 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"city-tags-api/internal/api_errors"
+	"city-tags-api/internal/database"
+	"city-tags-api/internal/geo"
+	"city-tags-api/internal/similarity"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type Repository interface {
+	GetCity(cityId int) (CityData, error)
+	GetCities(offset int, limit int) ([]CityData, error)
+	GetCitiesBatch(cityIds []int) (map[int]CityData, error)
+	FilterCities(filter CityFilter, offset int, limit int) ([]CityData, error)
+	GetTags(cityId int) (TagsData, error)
+	GetPeriodTags(cityId int, period string) (TagsData, error)
+	GetTagsAsOf(cityId int, asOf time.Time) (TagsData, error)
+	GetTagsBatch(cityIds []int, period string) (map[int]TagsData, error)
+	GetTagsHistory(cityId int, offset int, limit int) ([]TagsVersion, error)
+	GetVocabulary() ([]TagCategory, error)
+	GetSimilarityIndex() (*similarity.Index, error)
+	GetNearbyCities(center geo.Point, radiusKm float64, limit int) ([]NearbyCity, error)
+	GetCountries(continent string, offset int, limit int) ([]CountryData, error)
+	GetCountry(alpha3Code string) (CountryDetail, error)
+	GetContinents() ([]ContinentData, error)
+	GetTagStats(filter CityFilter, groupBy string) ([]TagStatsGroup, error)
+	GetTranslations(language string) (*Translations, error)
+}
+
+type dbRepository struct {
+	db database.Service
+}
+
+func NewRepository(db database.Service) Repository {
+	return &dbRepository{db: db}
+}
+
+func (repo *dbRepository) GetCity(cityId int) (CityData, error) {
+	rows, err := repo.db.Query("select "+cityColumns+" from city_tags.cities c where c.city_id = $1", cityId)
+	if err != nil {
+		return CityData{}, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return CityData{}, &api_errors.CityNotFoundErr
+	}
+	return scanCity(rows)
+}
+
+// GetCitiesBatch returns the given cities keyed by city id, the ones that
+// don't exist are left out.
+func (repo *dbRepository) GetCitiesBatch(cityIds []int) (map[int]CityData, error) {
+	rows, err := repo.db.Query("select "+cityColumns+" from city_tags.cities c where c.city_id = any($1)", cityIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cities := make(map[int]CityData, len(cityIds))
+	for rows.Next() {
+		cityData, err := scanCity(rows)
+		if err != nil {
+			return nil, err
+		}
+		cities[cityData.CityId] = cityData
+	}
+	return cities, rows.Err()
+}
+
+func (repo *dbRepository) GetCities(offset int, limit int) ([]CityData, error) {
+	rows, err := repo.db.Query("select "+cityColumns+" from city_tags.cities c order by c.city_id LIMIT $1 OFFSET $2", limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return scanCities(rows)
+}
+
+func (repo *dbRepository) FilterCities(filter CityFilter, offset int, limit int) ([]CityData, error) {
+	query, args := filterCitiesQuery(filter)
+	args = append(args, limit, offset)
+	rows, err := repo.db.Query(
+		fmt.Sprintf("%s order by c.city_id limit $%d offset $%d", query, len(args)-1, len(args)),
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanCities(rows)
+}
+
+// filterCitiesQuery builds the query of the cities matching filter. Columns
+// come from the vocabulary, only the values are user input.
+func filterCitiesQuery(filter CityFilter) (string, []any) {
+	var query string
+	var args []any
+	if filter.Period != "" {
+		args = append(args, filter.Period)
+		query = `select ` + cityColumns + ` from city_tags.cities c
+		join city_tags.city_period_tags t on t.city_id = c.city_id and t.period = $1`
+	} else if len(filter.Tags) > 0 {
+		query = `select ` + cityColumns + ` from city_tags.cities c
+		join city_tags.city_tags t on t.city_id = c.city_id`
+	} else {
+		query = `select ` + cityColumns + ` from city_tags.cities c`
+	}
+
+	columns := make([]string, 0, len(filter.Tags))
+	for column := range filter.Tags {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	conditions := make([]string, 0, len(columns)+3)
+	for _, column := range columns {
+		args = append(args, filter.Tags[column])
+		conditions = append(conditions, fmt.Sprintf("t.%s = $%d", column, len(args)))
+	}
+	if filter.Continent != "" {
+		args = append(args, filter.Continent)
+		conditions = append(conditions, fmt.Sprintf("c.continent = $%d", len(args)))
+	}
+	if filter.Country != "" {
+		args = append(args, filter.Country)
+		conditions = append(conditions, fmt.Sprintf("c.country_3_code = $%d", len(args)))
+	}
+	if filter.BBox != nil {
+		var condition string
+		condition, args = bboxCondition(*filter.BBox, args)
+		conditions = append(conditions, condition)
+	}
+	if len(conditions) > 0 {
+		query += " where " + strings.Join(conditions, " and ")
+	}
+	return query, args
+}
+
+func (repo *dbRepository) GetTags(cityId int) (TagsData, error) {
+	rows, err := repo.db.Query("select "+tagsColumns+" from city_tags.city_tags where city_id = $1", cityId)
+	if err != nil {
+		return TagsData{}, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return TagsData{}, &api_errors.CityNotFoundErr
+	}
+	return scanTags(rows)
+}
+
+func (repo *dbRepository) GetPeriodTags(cityId int, period string) (TagsData, error) {
+	rows, err := repo.db.Query(
+		"select "+tagsColumns+" from city_tags.city_period_tags where city_id = $1 and period = $2",
+		cityId, period,
+	)
+	if err != nil {
+		return TagsData{}, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return TagsData{}, &api_errors.PeriodTagsNotFoundErr
+	}
+	return scanTags(rows)
+}
+
+// GetTagsBatch returns the tags of the given cities keyed by city id, the
+// annual ones unless period is set. Cities without tags are left out.
+func (repo *dbRepository) GetTagsBatch(cityIds []int, period string) (map[int]TagsData, error) {
+	query := "select " + tagsColumns + " from city_tags.city_tags where city_id = any($1)"
+	args := []any{cityIds}
+	if period != "" {
+		query = "select " + tagsColumns + " from city_tags.city_period_tags where city_id = any($1) and period = $2"
+		args = append(args, period)
+	}
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := make(map[int]TagsData, len(cityIds))
+	for rows.Next() {
+		tagsData, err := scanTags(rows)
+		if err != nil {
+			return nil, err
+		}
+		tags[tagsData.CityId] = tagsData
+	}
+	return tags, rows.Err()
+}
+
+func (repo *dbRepository) GetTagsAsOf(cityId int, asOf time.Time) (TagsData, error) {
+	rows, err := repo.db.Query(
+		`select `+tagsColumns+` from city_tags.city_tags_history
+		where city_id = $1 and valid_from <= $2 and (valid_to is null or valid_to > $2)`,
+		cityId, asOf,
+	)
+	if err != nil {
+		return TagsData{}, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return TagsData{}, &api_errors.CityNotFoundErr
+	}
+	return scanTags(rows)
+}
+
+func (repo *dbRepository) GetTagsHistory(cityId int, offset int, limit int) ([]TagsVersion, error) {
+	rows, err := repo.db.Query(
+		`select `+tagsColumns+`, valid_from, valid_to from city_tags.city_tags_history
+		where city_id = $1 order by valid_from limit $2 offset $3`,
+		cityId, limit, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := []TagsVersion{}
+	for rows.Next() {
+		version := TagsVersion{}
+		err := rows.Scan(
+			&version.CityId,
+			&version.CloudCoverage,
+			&version.Humidity,
+			&version.Temp,
+			&version.Precipitation,
+			&version.AirQuality,
+			&version.DaylightHours,
+			&version.CitySize,
+			&version.ValidFrom,
+			&version.ValidTo,
+		)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, version)
+	}
+	return versions, rows.Err()
+}
+
+func (repo *dbRepository) getAllCities() ([]CityData, error) {
+	rows, err := repo.db.Query("select " + cityColumns + " from city_tags.cities c order by c.city_id")
+	if err != nil {
+		return nil, err
+	}
+	return scanCities(rows)
+}
+
+func (repo *dbRepository) getAllTags() ([]TagsData, error) {
+	rows, err := repo.db.Query("select " + tagsColumns + " from city_tags.city_tags")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []TagsData{}
+	for rows.Next() {
+		tagsData, err := scanTags(rows)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, tagsData)
+	}
+	return tags, rows.Err()
+}
+
+// cityColumns are the columns of city_tags.cities in the order scanCity reads
+// them, queries must alias the table as c.
+const cityColumns = `c.city_id, c.city_name, c.continent, c.country_3_code,
+	c.latitude, c.longitude, c.timezone, c.population, c.alternate_names`
+
+const tagsColumns = `city_id, cloud_coverage_tag, humidity_tag, temp_tag, precipitation_tag,
+	air_quality_tag, daylight_hours_tag, city_size_tag`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanCities(rows pgx.Rows) ([]CityData, error) {
+	defer rows.Close()
+
+	cities := []CityData{}
+	for rows.Next() {
+		cityData, err := scanCity(rows)
+		if err != nil {
+			return nil, err
+		}
+		cities = append(cities, cityData)
+	}
+	return cities, rows.Err()
+}
+
+func scanCity(row rowScanner) (CityData, error) {
+	var cityData CityData
+	err := row.Scan(
+		&cityData.CityId,
+		&cityData.CityName,
+		&cityData.Continent,
+		&cityData.Country3Code,
+		&cityData.Latitude,
+		&cityData.Longitude,
+		&cityData.Timezone,
+		&cityData.Population,
+		&cityData.AlternateNames,
+	)
+	return cityData, err
+}
+
+func scanTags(row rowScanner) (TagsData, error) {
+	var tagsData TagsData
+	err := row.Scan(
+		&tagsData.CityId,
+		&tagsData.CloudCoverage,
+		&tagsData.Humidity,
+		&tagsData.Temp,
+		&tagsData.Precipitation,
+		&tagsData.AirQuality,
+		&tagsData.DaylightHours,
+		&tagsData.CitySize,
+	)
+	return tagsData, err
+}
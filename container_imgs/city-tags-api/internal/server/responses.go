@@ -1,6 +1,8 @@
 package server
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"log"
 	"net/http"
@@ -19,3 +21,14 @@ func respondWithJSON(w http.ResponseWriter, code int, payload any) {
 	w.WriteHeader(code)
 	w.Write(jsonPay)
 }
+
+// etag returns a strong entity tag derived from the JSON representation of
+// payload.
+func etag(payload any) string {
+	jsonPay, err := json.Marshal(payload)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(jsonPay)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
@@ -5,33 +5,82 @@ import (
 	"log"
 	"net/http"
 
-	"city-tags-api/internal/api_errors"
-
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/jwtauth/v5"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
+const compressMinSize = 1024
+
 func (api *Api) RegisterRoutes() *chi.Mux {
 	r := chi.NewRouter()
 
 	r.Use(middleware.Heartbeat("/ping"))
+	r.Use(middleware.RequestID)
 	r.Use(middleware.Logger)
+	r.Use(Versioned)
+	r.Use(Compress(compressMinSize))
 	r.Use(jwtauth.Verifier(api.tokenAuth))
 	r.Use(Authenticator(api.tokenAuth))
+	r.Use(Localize)
+
+	schema, err := newGraphQLSchema()
+	if err != nil {
+		log.Fatalf("Unable to build the GraphQL schema: %v", err)
+	}
 
 	r.Route("/v0", func(r chi.Router) {
 		r.Get("/swagger/*", httpSwagger.WrapHandler)
-
-		r.Get("/cities/{cityId}", NewHandler(api.getCity))
-		r.Get("/cities", NewHandler(api.getCities))
-		r.Get("/cities/{cityId}/tags", NewHandler(api.getTags))
+		r.Post("/graphql", NewHandler(api.graphql(schema)))
+		api.registerResources(r)
 	})
+	r.Route("/v1", api.registerResources)
 
 	return r
 }
 
+// registerResources registers the endpoints shared by every API version, the
+// contract of the responses depends on the version of the request.
+func (api *Api) registerResources(r chi.Router) {
+	r.Get("/cities/{cityId}", NewHandler(api.getCity))
+	r.Get("/cities", NewHandler(api.getCities))
+	r.Get("/cities/nearby", NewHandler(api.getNearbyCities))
+	r.Get("/cities/{cityId}/tags", NewHandler(api.getTags))
+	r.Get("/cities/{cityId}/tags/history", NewHandler(api.getTagsHistory))
+	r.Get("/cities/{cityId}/similar", NewHandler(api.getSimilar))
+	r.Get("/tags", NewHandler(api.getVocabulary))
+	r.Get("/countries", NewHandler(api.getCountries))
+	r.Get("/countries/{code}", NewHandler(api.getCountry))
+	r.Get("/countries/{code}/cities", NewHandler(api.getCountryCities))
+	r.Get("/continents", NewHandler(api.getContinents))
+	r.Get("/stats/tags", NewHandler(api.getTagStats))
+	r.Get("/changes", NewHandler(api.getChanges))
+	r.Get("/stream/changes", NewHandler(api.streamChanges))
+
+	r.Route("/admin", func(r chi.Router) {
+		r.Use(AdminOnly)
+
+		r.Post("/cities", NewHandler(api.createCity))
+		r.Put("/cities/{cityId}", NewHandler(api.replaceCity))
+		r.Patch("/cities/{cityId}", NewHandler(api.updateCity))
+		r.Delete("/cities/{cityId}", NewHandler(api.deleteCity))
+		r.Put("/cities/{cityId}/tags", NewHandler(api.replaceTags))
+		r.Patch("/cities/{cityId}/tags", NewHandler(api.updateTags))
+		r.Delete("/cities/{cityId}/tags", NewHandler(api.deleteTags))
+		r.Get("/cities/{cityId}/history", NewHandler(api.getCityHistory))
+
+		r.Get("/cache/stats", NewHandler(api.getCacheStats))
+
+		r.Post("/webhooks", NewHandler(api.createWebhook))
+		r.Get("/webhooks", NewHandler(api.listWebhooks))
+		r.Get("/webhooks/{subscriptionId}", NewHandler(api.getWebhook))
+		r.Delete("/webhooks/{subscriptionId}", NewHandler(api.deleteWebhook))
+		r.Get("/webhooks/{subscriptionId}/deliveries", NewHandler(api.listWebhookDeliveries))
+		r.Post("/webhooks/deliveries/{deliveryId}/replay", NewHandler(api.replayWebhookDelivery))
+	})
+}
+
 type CustomHandler func(w http.ResponseWriter, request *http.Request) error
 
 func NewHandler(customHandler CustomHandler) http.HandlerFunc {
@@ -39,16 +88,7 @@ func NewHandler(customHandler CustomHandler) http.HandlerFunc {
 		err := customHandler(w, r)
 		if err != nil {
 			log.Printf("Error: %s", err.Error())
-			if clientErr, ok := err.(*api_errors.ClientErr); ok {
-				respondWithJSON(w, clientErr.HttpCode, clientErr)
-			} else {
-				respondWithJSON(w, http.StatusInternalServerError,
-					api_errors.InternalErr{
-						HttpCode: http.StatusInternalServerError,
-						Message:  "internal server error",
-					},
-				)
-			}
+			respondWithError(w, r, err)
 		}
 	}
 }
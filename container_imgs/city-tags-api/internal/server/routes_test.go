@@ -6,7 +6,10 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/go-chi/jwtauth/v5"
 )
 
 func TestNewHandler(t *testing.T) {
@@ -80,3 +83,168 @@ func TestNewHandler(t *testing.T) {
 		)
 	}
 }
+
+// stubRepository serves a single city, the methods it doesn't override panic.
+type stubRepository struct {
+	Repository
+}
+
+var stubCity = CityData{CityId: 3838859, CityName: "Río Gallegos", Continent: "South America", Country3Code: "ARG"}
+
+func (stubRepository) GetCity(cityId int) (CityData, error) {
+	if cityId != stubCity.CityId {
+		return CityData{}, &api_errors.CityNotFoundErr
+	}
+	return stubCity, nil
+}
+
+func (stubRepository) GetCities(offset int, limit int) ([]CityData, error) {
+	if offset > 0 || limit == 0 {
+		return []CityData{}, nil
+	}
+	return []CityData{stubCity}, nil
+}
+
+func (stubRepository) GetTags(cityId int) (TagsData, error) {
+	return TagsData{CityId: cityId, Temp: "cold", CitySize: "small"}, nil
+}
+
+func (stubRepository) GetVocabulary() ([]TagCategory, error) {
+	return []TagCategory{{
+		Category: "temperature",
+		Label:    "Temperature",
+		Values:   []TagValue{{Value: "cold", Rank: 1, Label: "Cold"}},
+	}}, nil
+}
+
+func (stubRepository) GetTranslations(language string) (*Translations, error) {
+	return &Translations{}, nil
+}
+
+func serveVersioned(t *testing.T, target string) *httptest.ResponseRecorder {
+	tokenAuth := jwtauth.New("HS256", []byte("test_enc_key"), nil)
+	api := &Api{repo: stubRepository{}, tokenAuth: tokenAuth}
+
+	_, token, err := tokenAuth.Encode(map[string]interface{}{"sub": "test_user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest("GET", target, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	writer := httptest.NewRecorder()
+	api.RegisterRoutes().ServeHTTP(writer, req)
+	return writer
+}
+
+func TestRegisterRoutes_versions(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   string
+		code     int
+		expected []string
+	}{
+		{"v0 cities", "/v0/cities?limit=1", 200, []string{"cities", "offset"}},
+		{"v1 cities", "/v1/cities?limit=1", 200, []string{"cities", "page"}},
+		{"v0 tags", "/v0/cities/3838859/tags", 200, []string{"city_id", "temperature", "labels"}},
+		{"v1 tags", "/v1/cities/3838859/tags", 200, []string{"city_id", "tags"}},
+		{"v0 error", "/v0/cities/abc", 400, []string{"code", "message", "errors"}},
+		{"v1 error", "/v1/cities/abc", 400, []string{"type", "title", "status", "detail", "invalid_params"}},
+		{"v1 cache stats", "/v1/admin/cache/stats", 403, []string{"type", "title", "status"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writer := serveVersioned(t, tt.target)
+			if writer.Code != tt.code {
+				t.Fatalf("GET %s returned code %d; want %d: %s", tt.target, writer.Code, tt.code, writer.Body)
+			}
+
+			body := map[string]json.RawMessage{}
+			if err := json.Unmarshal(writer.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			for _, field := range tt.expected {
+				if _, ok := body[field]; !ok {
+					t.Errorf("GET %s returned %s; want field %s", tt.target, writer.Body, field)
+				}
+			}
+
+			deprecated := writer.Header().Get("Deprecation") != ""
+			if wantDeprecated := strings.HasPrefix(tt.target, "/v0/"); deprecated != wantDeprecated {
+				t.Errorf("GET %s Deprecation header %q; want it only on v0", tt.target, writer.Header().Get("Deprecation"))
+			}
+		})
+	}
+}
+
+func TestVersioned_deprecationHeaders(t *testing.T) {
+	writer := serveVersioned(t, "/v0/cities/3838859?lang=es")
+
+	expected := map[string]string{
+		"Deprecation": "@1792368000",
+		"Sunset":      "Mon, 19 Apr 2027 00:00:00 GMT",
+		"Link":        `</v1/cities/3838859>; rel="successor-version"`,
+	}
+	for header, value := range expected {
+		if got := writer.Header().Get(header); got != value {
+			t.Errorf("%s header = %q; want %q", header, got, value)
+		}
+	}
+}
+
+func TestRespondWithError_problem(t *testing.T) {
+	writer := serveVersioned(t, "/v1/cities/1")
+
+	if contentType := writer.Header().Get("Content-Type"); contentType != "application/problem+json" {
+		t.Errorf("Content-Type = %q; want application/problem+json", contentType)
+	}
+	problem := api_errors.Problem{}
+	if err := json.Unmarshal(writer.Body.Bytes(), &problem); err != nil {
+		t.Fatal(err)
+	}
+	if problem.Status != http.StatusNotFound || writer.Code != http.StatusNotFound {
+		t.Errorf("problem = %+v with code %d; want status 404", problem, writer.Code)
+	}
+}
+
+func TestNewPage(t *testing.T) {
+	tests := []struct {
+		name     string
+		offset   int
+		limit    int
+		count    int
+		expected *int
+	}{
+		{"full page", 0, 10, 10, ptr(10)},
+		{"last page", 10, 10, 3, nil},
+		{"empty page", 20, 10, 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := newPage(tt.offset, tt.limit, tt.count)
+			if !reflect.DeepEqual(page.NextOffset, tt.expected) {
+				t.Errorf("newPage(%d, %d, %d).NextOffset = %v; want %v", tt.offset, tt.limit, tt.count, page.NextOffset, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCityTags_v0(t *testing.T) {
+	cityTags := CityTags{
+		CityId: 1,
+		Tags: map[string]Tag{
+			"temperature": {Value: "cold", Rank: 1, Label: "Frío"},
+			"city_size":   {Value: "small", Label: "small"},
+		},
+	}
+
+	expected := LocalizedTags{
+		TagsData: TagsData{CityId: 1, Temp: "cold", CitySize: "small"},
+		Labels:   map[string]string{"temperature": "Frío", "city_size": "small"},
+	}
+	if got := cityTags.v0(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("CityTags.v0() = %+v; want %+v", got, expected)
+	}
+}
@@ -0,0 +1,252 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"city-tags-api/internal/api_errors"
+	"city-tags-api/internal/iso3166"
+	"city-tags-api/internal/similarity"
+	"city-tags-api/internal/vocabulary"
+)
+
+const (
+	defaultSimilarLimit = 10
+	maxSimilarLimit     = 100
+)
+
+type TagMatch struct {
+	Value      string  `json:"value"`
+	Similarity float64 `json:"similarity"`
+}
+
+type SimilarCity struct {
+	CityData
+	Score float64             `json:"score"`
+	Tags  map[string]TagMatch `json:"tags"`
+}
+
+type GetSimilarResp struct {
+	CityId  int           `json:"city_id"`
+	Similar []SimilarCity `json:"similar"`
+}
+
+func (repo *dbRepository) GetSimilarityIndex() (*similarity.Index, error) {
+	cities, err := repo.getAllCities()
+	if err != nil {
+		return nil, err
+	}
+	tags, err := repo.getAllTags()
+	if err != nil {
+		return nil, err
+	}
+
+	tagsByCity := make(map[int]TagsData, len(tags))
+	for _, tagsData := range tags {
+		tagsByCity[tagsData.CityId] = tagsData
+	}
+	return newSimilarityIndex(cities, tagsByCity), nil
+}
+
+// newSimilarityIndex builds the index of the cities with tags, the ones
+// without tags can't be compared.
+func newSimilarityIndex(cities []CityData, tags map[int]TagsData) *similarity.Index {
+	items := make([]similarity.Item, 0, len(tags))
+	for _, city := range cities {
+		tagsData, ok := tags[city.CityId]
+		if !ok {
+			continue
+		}
+
+		values := make([]string, len(vocabulary.Categories))
+		for index, category := range vocabulary.Categories {
+			values[index] = tagsData.byColumn(category.Column)
+		}
+		items = append(items, similarity.Item{
+			Id:        city.CityId,
+			Name:      city.CityName,
+			Continent: city.Continent,
+			Country:   city.Country3Code,
+			Values:    values,
+		})
+	}
+	return similarity.NewIndex(items)
+}
+
+type GetSimilarReq struct {
+	query similarity.Query
+}
+
+func (getSimR *GetSimilarReq) validate(r *http.Request) error {
+	cityId, err := parseCityId(r)
+	if err != nil {
+		return err
+	}
+
+	query := r.URL.Query()
+	errors := map[string]string{}
+
+	limit := defaultSimilarLimit
+	if limitParam := query.Get("limit"); limitParam != "" {
+		limit, err = strconv.Atoi(limitParam)
+		if err != nil || limit < 1 || limit > maxSimilarLimit {
+			errors["limit"] = fmt.Sprintf("Must be an integer between 1 and %d", maxSimilarLimit)
+		}
+	}
+
+	continent := query.Get("continent")
+	if continent != "" && !iso3166.IsContinent(continent) {
+		errors["continent"] = fmt.Sprintf("Must be one of: %s", strings.Join(iso3166.Continents, ", "))
+	}
+	country := query.Get("country_3_code")
+	if country != "" && !iso3166.IsAlpha3(country) {
+		errors["country_3_code"] = "Must be an ISO 3166-1 alpha-3 code"
+	}
+
+	weights, err := parseWeights(query.Get("weights"))
+	if err != nil {
+		errors["weights"] = err.Error()
+	}
+
+	if len(errors) > 0 {
+		return &api_errors.ClientErr{
+			HttpCode: http.StatusBadRequest,
+			Message:  "Parameters not present or invalid",
+			Errors:   errors,
+		}
+	}
+
+	getSimR.query = similarity.Query{
+		Id:        cityId,
+		Weights:   weights,
+		Continent: continent,
+		Country:   country,
+		Limit:     limit,
+	}
+	return nil
+}
+
+// parseWeights reads weights in the form "temperature:2,humidity:0.5", the
+// categories not present keep a weight of 1.
+func parseWeights(value string) ([]float64, error) {
+	weights := make([]float64, len(vocabulary.Categories))
+	for index := range weights {
+		weights[index] = 1
+	}
+	if value == "" {
+		return weights, nil
+	}
+
+	invalidErr := fmt.Errorf("Must be a list of category:weight pairs with finite non negative weights, e.g. temperature:2,humidity:0.5")
+	for _, pair := range strings.Split(value, ",") {
+		name, weightParam, found := strings.Cut(pair, ":")
+		if !found {
+			return nil, invalidErr
+		}
+		position := categoryPosition(name)
+		if position < 0 {
+			return nil, fmt.Errorf("Unknown category %s", name)
+		}
+		weight, err := strconv.ParseFloat(weightParam, 64)
+		if err != nil || math.IsNaN(weight) || math.IsInf(weight, 0) || weight < 0 {
+			return nil, invalidErr
+		}
+		weights[position] = weight
+	}
+
+	total := 0.0
+	for _, weight := range weights {
+		total += weight
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("At least one weight must be greater than 0")
+	}
+	if math.IsInf(total, 0) {
+		return nil, invalidErr
+	}
+	return weights, nil
+}
+
+func categoryPosition(name string) int {
+	for index, category := range vocabulary.Categories {
+		if category.Name == name {
+			return index
+		}
+	}
+	return -1
+}
+
+// @Summary		Get similar cities
+// @Description	Get the cities with the most similar tags to the given one, scored by the weighted distance between the ranks of every tag
+// @Accept			json
+// @Produce		json
+// @Param       limit			query int		false	"Number of cities to return, 10 by default and 100 at most"
+// @Param       continent		query string	false	"Only return cities of this continent"
+// @Param       country_3_code	query string	false	"Only return cities of this country"
+// @Param       weights			query string	false	"Weight of each tag category, e.g. temperature:2,humidity:0.5, 1 by default"
+// @Param       lang	query string	false	"Language of names and labels (en, es, de), Accept-Language is used if missing"
+// @Success		200 {object} GetSimilarResp
+// @Failure      400  {object} api_errors.ClientErr
+// @Failure      404  {object} api_errors.ClientErr
+// @Failure      500  {object} api_errors.ClientErr
+// @Router			/v0/cities/{cityId}/similar [get]
+func (api *Api) getSimilar(w http.ResponseWriter, r *http.Request) error {
+	similarReq := &GetSimilarReq{}
+	err := similarReq.validate(r)
+	if err != nil {
+		return err
+	}
+
+	index, err := api.repo.GetSimilarityIndex()
+	if err != nil {
+		return err
+	}
+	matches, ok := index.Similar(similarReq.query)
+	if !ok {
+		return &api_errors.CityNotFoundErr
+	}
+	localizer, err := api.localizer(r)
+	if err != nil {
+		return err
+	}
+
+	// The index only holds what the scoring needs, the cities are loaded
+	// whole, skipping the ones deleted since it was built.
+	cityIds := make([]int, 0, len(matches))
+	for _, match := range matches {
+		cityIds = append(cityIds, match.Id)
+	}
+	cities, err := api.repo.GetCitiesBatch(cityIds)
+	if err != nil {
+		return err
+	}
+
+	similar := make([]SimilarCity, 0, len(matches))
+	for _, match := range matches {
+		cityData, ok := cities[match.Id]
+		if !ok {
+			continue
+		}
+		tags := make(map[string]TagMatch, len(vocabulary.Categories))
+		for position, category := range vocabulary.Categories {
+			tags[category.Name] = TagMatch{
+				Value:      match.Values[position],
+				Similarity: match.Similarities[position],
+			}
+		}
+		similar = append(similar, SimilarCity{
+			CityData: localizer.city(cityData),
+			Score:    match.Score,
+			Tags:     tags,
+		})
+	}
+
+	respond(w, r, http.StatusOK, GetSimilarResp{
+		CityId:  similarReq.query.Id,
+		Similar: similar,
+	})
+	return nil
+}
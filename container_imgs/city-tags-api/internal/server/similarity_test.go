@@ -0,0 +1,160 @@
+package server
+
+import (
+	"city-tags-api/internal/similarity"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/go-chi/jwtauth/v5"
+)
+
+func TestParseWeights(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []float64
+		isError  bool
+	}{
+		{"default weights", "", []float64{1, 1, 1, 1, 1, 1, 1}, false},
+		{"some weights", "temperature:2,humidity:0.5", []float64{1, 0.5, 2, 1, 1, 1, 1}, false},
+		{"ignored category", "city_size:0", []float64{1, 1, 1, 1, 1, 1, 0}, false},
+		{"unknown category", "mood:2", nil, true},
+		{"negative weight", "temperature:-1", nil, true},
+		{"missing weight", "temperature", nil, true},
+		{"not a number", "temperature:NaN", nil, true},
+		{"infinite weight", "temperature:Inf", nil, true},
+		{"weights adding up to infinity", "temperature:1e308,humidity:1e308", nil, true},
+		{
+			"every weight is zero",
+			"cloud_coverage:0,humidity:0,temperature:0,precipitation:0,air_quality:0,daylight_hours:0,city_size:0",
+			nil,
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			weights, err := parseWeights(tt.input)
+			if tt.isError {
+				if err == nil {
+					t.Errorf("Expected error but none was received")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Didn't expect an error but received %v", err)
+			}
+			if !reflect.DeepEqual(weights, tt.expected) {
+				t.Errorf("parseWeights(%s) = %v; want %v", tt.input, weights, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGetSimilarReq_validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		isError bool
+	}{
+		{"defaults", "", false},
+		{"filters", "?continent=Europe&country_3_code=ESP&limit=5", false},
+		{"limit too big", "?limit=1000", true},
+		{"unknown continent", "?continent=Atlantis", true},
+		{"unknown country", "?country_3_code=XXX", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/v0/cities/{cityId}/similar"+tt.query, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.SetPathValue("cityId", "3838859")
+
+			similarReq := &GetSimilarReq{}
+			err = similarReq.validate(req)
+			if tt.isError != (err != nil) {
+				t.Errorf("GetSimilarReq.validate(%s) error = %v; want error %t", tt.query, err, tt.isError)
+			}
+			if !tt.isError && similarReq.query.Id != 3838859 {
+				t.Errorf("GetSimilarReq.validate(%s) city id = %d; want 3838859", tt.query, similarReq.query.Id)
+			}
+		})
+	}
+}
+
+func TestNewSimilarityIndex(t *testing.T) {
+	repo := newTestCatalogue()
+	delete(repo.catalogue.tags, 3430988)
+
+	cities := []CityData{}
+	for _, cityId := range repo.catalogue.order {
+		cities = append(cities, repo.catalogue.cities[cityId])
+	}
+
+	index := newSimilarityIndex(cities, repo.catalogue.tags)
+	if index.Len() != 2 {
+		t.Errorf("newSimilarityIndex() indexed %d cities; want 2", index.Len())
+	}
+	if _, ok := index.Similar(similarity.Query{Id: 3430988, Weights: []float64{1}, Limit: 1}); ok {
+		t.Errorf("newSimilarityIndex() indexed a city without tags")
+	}
+}
+
+// similarRepository indexes the cities of the test catalogue, and has
+// deleted 3430988 since.
+type similarRepository struct {
+	stubRepository
+}
+
+func (similarRepository) GetSimilarityIndex() (*similarity.Index, error) {
+	repo := newTestCatalogue()
+	cities := []CityData{}
+	for _, cityId := range repo.catalogue.order {
+		cities = append(cities, repo.catalogue.cities[cityId])
+	}
+	return newSimilarityIndex(cities, repo.catalogue.tags), nil
+}
+
+func (similarRepository) GetCitiesBatch(cityIds []int) (map[int]CityData, error) {
+	cities := map[int]CityData{}
+	for cityId, city := range newTestCatalogue().catalogue.cities {
+		if cityId != 3430988 {
+			city.Timezone = ptr("America/Argentina/Buenos_Aires")
+			city.AlternateNames = []string{"alternate name"}
+			cities[cityId] = city
+		}
+	}
+	return cities, nil
+}
+
+func TestGetSimilar_wholeCities(t *testing.T) {
+	tokenAuth := jwtauth.New("HS256", []byte("test_enc_key"), nil)
+	api := &Api{repo: similarRepository{}, tokenAuth: tokenAuth}
+	_, token, err := tokenAuth.Encode(map[string]interface{}{"sub": "test_user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest("GET", "/v0/cities/3838859/similar", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	writer := httptest.NewRecorder()
+	api.RegisterRoutes().ServeHTTP(writer, req)
+	if writer.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", writer.Code, writer.Body.String())
+	}
+	resp := GetSimilarResp{}
+	if err := json.Unmarshal(writer.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Similar) != 1 || resp.Similar[0].CityId != 3430443 {
+		t.Fatalf("expected only the city not deleted, got %+v", resp.Similar)
+	}
+	if city := resp.Similar[0].CityData; city.Timezone == nil || len(city.AlternateNames) != 1 || city.CityName != "Necochea" {
+		t.Errorf("expected the whole city, got %+v", city)
+	}
+}
@@ -0,0 +1,230 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+
+	"city-tags-api/internal/api_errors"
+	"city-tags-api/internal/vocabulary"
+)
+
+// TagDistribution has the number of cities with each value of every tag
+// category, keyed by category name and value.
+type TagDistribution map[string]map[string]int
+
+type TagValueStats struct {
+	Count      int     `json:"count"`
+	Percentage float64 `json:"percentage"`
+}
+
+type TagStatsGroup struct {
+	// Group is the continent or country code, empty when not grouping.
+	Group  string                              `json:"group,omitempty"`
+	Cities int                                 `json:"cities"`
+	Tags   map[string]map[string]TagValueStats `json:"tags"`
+}
+
+type GetTagStatsResp struct {
+	GroupBy string          `json:"group_by,omitempty"`
+	Groups  []TagStatsGroup `json:"groups"`
+}
+
+// groupByColumns maps the values of the group_by parameter to the column of
+// city_tags.cities the statistics are grouped by.
+var groupByColumns = map[string]string{
+	"continent": "continent",
+	"country":   "country_3_code",
+}
+
+// tagCountsQuery builds the query counting the annual tags of the cities
+// matching filter, grouped by groupBy if not empty.
+func tagCountsQuery(filter CityFilter, groupBy string) (string, []any) {
+	query, args := filterCitiesQuery(filter)
+	group := "''::varchar"
+	if groupBy != "" {
+		group = "fc." + groupByColumns[groupBy]
+	}
+	return `select ` + group + `, d.category, d.value, count(*) from (` + query + `) fc
+	join city_tags.city_tags ct on ct.city_id = fc.city_id
+	cross join lateral (values ` + tagValuesRows("ct") + `) d(category, value)
+	group by 1, d.category, d.value`, args
+}
+
+// tagValuesRows returns a values list with a (category, value) row per tag
+// column of the given table alias, to count every category in one pass.
+func tagValuesRows(alias string) string {
+	values := make([]string, 0, len(vocabulary.Categories))
+	for _, category := range vocabulary.Categories {
+		values = append(values, fmt.Sprintf("('%s', %s.%s)", category.Name, alias, category.Column))
+	}
+	return strings.Join(values, ", ")
+}
+
+// countTags returns the tag distribution of every group, every value of the
+// vocabulary is present even if no city has it.
+func (repo *dbRepository) countTags(filter CityFilter, groupBy string) (map[string]TagDistribution, error) {
+	query, args := tagCountsQuery(filter, groupBy)
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := map[string]TagDistribution{}
+	for rows.Next() {
+		var group, category, value string
+		var count int
+		if err := rows.Scan(&group, &category, &value, &count); err != nil {
+			return nil, err
+		}
+		distribution, ok := groups[group]
+		if !ok {
+			distribution = newTagDistribution()
+			groups[group] = distribution
+		}
+		if _, ok := distribution[category]; ok {
+			distribution[category][value] = count
+		}
+	}
+	return groups, rows.Err()
+}
+
+func (repo *dbRepository) getTagDistribution(filter CityFilter) (TagDistribution, error) {
+	groups, err := repo.countTags(filter, "")
+	if err != nil {
+		return nil, err
+	}
+	if distribution, ok := groups[""]; ok {
+		return distribution, nil
+	}
+	return newTagDistribution(), nil
+}
+
+func (repo *dbRepository) GetTagStats(filter CityFilter, groupBy string) ([]TagStatsGroup, error) {
+	if groupBy == "" {
+		distribution, err := repo.getTagDistribution(filter)
+		if err != nil {
+			return nil, err
+		}
+		return []TagStatsGroup{newTagStatsGroup("", distribution)}, nil
+	}
+
+	groups, err := repo.countTags(filter, groupBy)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	stats := make([]TagStatsGroup, 0, len(names))
+	for _, name := range names {
+		stats = append(stats, newTagStatsGroup(name, groups[name]))
+	}
+	return stats, nil
+}
+
+func newTagDistribution() TagDistribution {
+	distribution := make(TagDistribution, len(vocabulary.Categories))
+	for _, category := range vocabulary.Categories {
+		distribution[category.Name] = make(map[string]int, len(category.Values))
+		for _, value := range category.Values {
+			distribution[category.Name][value] = 0
+		}
+	}
+	return distribution
+}
+
+// newTagStatsGroup computes the percentages of a distribution over the cities
+// of the group, every city has exactly one value per category.
+func newTagStatsGroup(group string, distribution TagDistribution) TagStatsGroup {
+	cities := 0
+	for _, count := range distribution[vocabulary.Categories[0].Name] {
+		cities += count
+	}
+
+	tags := make(map[string]map[string]TagValueStats, len(distribution))
+	for category, counts := range distribution {
+		tags[category] = make(map[string]TagValueStats, len(counts))
+		for value, count := range counts {
+			percentage := 0.0
+			if cities > 0 {
+				percentage = math.Round(float64(count)*10000/float64(cities)) / 100
+			}
+			tags[category][value] = TagValueStats{Count: count, Percentage: percentage}
+		}
+	}
+	return TagStatsGroup{Group: group, Cities: cities, Tags: tags}
+}
+
+type GetTagStatsReq struct {
+	filter  CityFilter
+	groupBy string
+}
+
+func (getStatsR *GetTagStatsReq) validate(r *http.Request) error {
+	query := r.URL.Query()
+	filter, errors := parseCityFilter(query)
+
+	groupBy := query.Get("group_by")
+	if _, ok := groupByColumns[groupBy]; groupBy != "" && !ok {
+		errors["group_by"] = "Must be one of: continent, country"
+	}
+
+	if len(errors) > 0 {
+		return &api_errors.ClientErr{
+			HttpCode: http.StatusBadRequest,
+			Message:  "Parameters not present or invalid",
+			Errors:   errors,
+		}
+	}
+
+	getStatsR.filter = filter
+	getStatsR.groupBy = groupBy
+	return nil
+}
+
+// @Summary		Get tag statistics
+// @Description	Get the number and percentage of cities with each tag value, optionally grouped by continent or country and filtered like the cities listing
+// @Accept		json
+// @Produce		json
+// @Param       group_by		query string	false	"Group by continent or country"
+// @Param       cloud_coverage	query string	false	"Cloud coverage tag"
+// @Param       humidity		query string	false	"Humidity tag"
+// @Param       temperature		query string	false	"Temperature tag"
+// @Param       precipitation	query string	false	"Precipitation tag"
+// @Param       air_quality		query string	false	"Air quality tag"
+// @Param       daylight_hours	query string	false	"Daylight hours tag"
+// @Param       city_size		query string	false	"City size tag"
+// @Param       month			query int		false	"Month (1 to 12) the tag filters apply to"
+// @Param       season			query string	false	"Season (winter, spring, summer, autumn) the tag filters apply to"
+// @Param       bbox			query string	false	"Only cities inside the box minLon,minLat,maxLon,maxLat"
+// @Param       continent		query string	false	"Only cities of this continent"
+// @Param       country_3_code	query string	false	"Only cities of this country"
+// @Success		200 	{object} 	GetTagStatsResp
+// @Failure     400 	{object} 	api_errors.ClientErr
+// @Failure     500 	{object} 	api_errors.ClientErr
+// @Router		/v0/stats/tags [get]
+func (api *Api) getTagStats(w http.ResponseWriter, r *http.Request) error {
+	statsReq := &GetTagStatsReq{}
+	err := statsReq.validate(r)
+	if err != nil {
+		return err
+	}
+
+	groups, err := api.repo.GetTagStats(statsReq.filter, statsReq.groupBy)
+	if err != nil {
+		return err
+	}
+
+	respond(w, r, http.StatusOK, GetTagStatsResp{
+		GroupBy: statsReq.groupBy,
+		Groups:  groups,
+	})
+	return nil
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTagCountsQuery(t *testing.T) {
+	query, args := tagCountsQuery(CityFilter{Tags: map[string]string{"air_quality_tag": "good"}, Continent: "Europe"}, "country")
+
+	if !reflect.DeepEqual(args, []any{"good", "Europe"}) {
+		t.Errorf("tagCountsQuery() args = %v; want [good Europe]", args)
+	}
+	for _, expected := range []string{
+		"select fc.country_3_code, d.category, d.value, count(*)",
+		"where t.air_quality_tag = $1 and c.continent = $2) fc",
+		"('temperature', ct.temp_tag)",
+		"group by 1, d.category, d.value",
+	} {
+		if !strings.Contains(query, expected) {
+			t.Errorf("tagCountsQuery() = %s; want it to contain %s", query, expected)
+		}
+	}
+
+	query, _ = tagCountsQuery(CityFilter{}, "")
+	if !strings.HasPrefix(query, "select ''::varchar,") {
+		t.Errorf("tagCountsQuery() = %s; want a single empty group", query)
+	}
+}
+
+func TestNewTagStatsGroup(t *testing.T) {
+	distribution := newTagDistribution()
+	if len(distribution) != 7 || len(distribution["temperature"]) != 5 {
+		t.Fatalf("newTagDistribution() = %v; want every value of the 7 categories", distribution)
+	}
+	distribution["cloud_coverage"]["clear"] = 2
+	distribution["cloud_coverage"]["cloudy"] = 1
+	distribution["air_quality"]["good"] = 3
+
+	stats := newTagStatsGroup("Europe", distribution)
+	if stats.Group != "Europe" || stats.Cities != 3 {
+		t.Errorf("newTagStatsGroup() = %s with %d cities; want Europe with 3", stats.Group, stats.Cities)
+	}
+	expected := map[string]TagValueStats{
+		"clear":         {Count: 2, Percentage: 66.67},
+		"partly cloudy": {Count: 0, Percentage: 0},
+		"cloudy":        {Count: 1, Percentage: 33.33},
+		"overcast":      {Count: 0, Percentage: 0},
+	}
+	if !reflect.DeepEqual(stats.Tags["cloud_coverage"], expected) {
+		t.Errorf("newTagStatsGroup()[cloud_coverage] = %v; want %v", stats.Tags["cloud_coverage"], expected)
+	}
+	if stats.Tags["air_quality"]["good"].Percentage != 100 {
+		t.Errorf("newTagStatsGroup()[air_quality][good] = %v; want 100%%", stats.Tags["air_quality"]["good"])
+	}
+
+	if empty := newTagStatsGroup("", newTagDistribution()); empty.Cities != 0 || empty.Tags["temperature"]["hot"].Percentage != 0 {
+		t.Errorf("newTagStatsGroup() of no cities = %v; want zero counts", empty)
+	}
+}
+
+func TestGetTagStatsReq_validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		groupBy string
+		isError bool
+	}{
+		{"no parameters", "", "", false},
+		{"grouped and filtered", "?group_by=continent&air_quality=good&continent=Europe", "continent", false},
+		{"by country", "?group_by=country", "country", false},
+		{"unknown grouping", "?group_by=city", "", true},
+		{"invalid filter", "?temperature=freezing", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/v0/stats/tags"+tt.query, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			statsReq := &GetTagStatsReq{}
+			err = statsReq.validate(req)
+			if tt.isError != (err != nil) {
+				t.Errorf("GetTagStatsReq.validate(%s) error = %v; want error %t", tt.query, err, tt.isError)
+			}
+			if statsReq.groupBy != tt.groupBy {
+				t.Errorf("GetTagStatsReq.validate(%s) group by = %s; want %s", tt.query, statsReq.groupBy, tt.groupBy)
+			}
+		})
+	}
+}
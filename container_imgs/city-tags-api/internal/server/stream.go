@@ -0,0 +1,408 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"city-tags-api/internal/api_errors"
+	"city-tags-api/internal/iso3166"
+	"city-tags-api/internal/vocabulary"
+
+	"github.com/go-chi/jwtauth/v5"
+)
+
+const (
+	// streamBuffer is the number of changes a stream can fall behind before
+	// it is closed, the client resumes it with Last-Event-ID.
+	streamBuffer = 256
+	// streamRetry is the reconnection delay sent to the clients.
+	streamRetry = 3 * time.Second
+)
+
+type StreamConfig struct {
+	// MaxPerClient is the number of streams a client can have open at once.
+	MaxPerClient int
+	// Heartbeat is the time between two comments sent to keep an idle
+	// stream open.
+	Heartbeat time.Duration
+}
+
+func DefaultStreamConfig() StreamConfig {
+	return StreamConfig{MaxPerClient: 3, Heartbeat: 15 * time.Second}
+}
+
+func getStreamCfg() StreamConfig {
+	cfg := DefaultStreamConfig()
+
+	if maxPerClient := os.Getenv("STREAM_MAX_PER_CLIENT"); maxPerClient != "" {
+		value, err := strconv.Atoi(maxPerClient)
+		if err != nil {
+			log.Fatalf("STREAM_MAX_PER_CLIENT env variable error when parsing to integer %s", err.Error())
+		}
+		cfg.MaxPerClient = value
+	}
+
+	if heartbeat := os.Getenv("STREAM_HEARTBEAT_INTERVAL"); heartbeat != "" {
+		value, err := time.ParseDuration(heartbeat)
+		if err != nil {
+			log.Fatalf("STREAM_HEARTBEAT_INTERVAL env variable error when parsing to duration %s", err.Error())
+		}
+		cfg.Heartbeat = value
+	}
+	return cfg
+}
+
+// changeStream is a stream subscribed to the hub, closed is closed when it
+// falls too far behind.
+type changeStream struct {
+	client  string
+	changes chan Change
+	closed  chan struct{}
+}
+
+// ChangeHub reads the change log once for every open stream of this instance
+// and broadcasts the new changes to them. It only reads the log while there
+// are streams.
+type ChangeHub struct {
+	changeLog ChangeLog
+	cfg       StreamConfig
+
+	mu      sync.Mutex
+	cursor  ChangeToken
+	streams map[*changeStream]struct{}
+	clients map[string]int
+}
+
+func NewChangeHub(changeLog ChangeLog, cfg StreamConfig) *ChangeHub {
+	return &ChangeHub{
+		changeLog: changeLog,
+		cfg:       cfg,
+		streams:   map[*changeStream]struct{}{},
+		clients:   map[string]int{},
+	}
+}
+
+// Run broadcasts the changes when notified of a commit, and every
+// changesPollInterval for the changes held back by an older transaction,
+// until ctx is cancelled.
+func (hub *ChangeHub) Run(ctx context.Context, notified <-chan struct{}) {
+	ticker := time.NewTicker(changesPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-notified:
+		case <-ticker.C:
+		}
+		if err := hub.broadcast(); err != nil {
+			log.Printf("Error broadcasting changes: %s", err.Error())
+		}
+	}
+}
+
+// subscribe opens a stream for client, the hub starts reading the log from
+// the latest change when it is the first one.
+func (hub *ChangeHub) subscribe(client string) (*changeStream, error) {
+	latest, err := hub.changeLog.Latest()
+	if err != nil {
+		return nil, err
+	}
+
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	if hub.clients[client] >= hub.cfg.MaxPerClient {
+		return nil, &api_errors.TooManyStreamsErr
+	}
+	if len(hub.streams) == 0 {
+		hub.cursor = latest
+	}
+
+	stream := &changeStream{
+		client:  client,
+		changes: make(chan Change, streamBuffer),
+		closed:  make(chan struct{}),
+	}
+	hub.streams[stream] = struct{}{}
+	hub.clients[client]++
+	return stream, nil
+}
+
+func (hub *ChangeHub) unsubscribe(stream *changeStream) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	hub.remove(stream)
+}
+
+func (hub *ChangeHub) remove(stream *changeStream) {
+	if _, ok := hub.streams[stream]; !ok {
+		return
+	}
+	delete(hub.streams, stream)
+	close(stream.closed)
+	if hub.clients[stream.client]--; hub.clients[stream.client] == 0 {
+		delete(hub.clients, stream.client)
+	}
+}
+
+// broadcast sends the changes after the cursor to every stream, closing the
+// ones whose buffer is full. The log is read without holding the lock, the
+// changes are dropped and read again if the cursor moved meanwhile.
+func (hub *ChangeHub) broadcast() error {
+	for {
+		hub.mu.Lock()
+		cursor, open := hub.cursor, len(hub.streams) > 0
+		hub.mu.Unlock()
+		if !open {
+			return nil
+		}
+
+		changes, err := hub.changeLog.Changes(cursor, maxChangesLimit)
+		if err != nil {
+			return err
+		}
+		if len(changes) == 0 {
+			return nil
+		}
+		last, err := parseChangeToken(changes[len(changes)-1].Token)
+		if err != nil {
+			return err
+		}
+
+		hub.mu.Lock()
+		if hub.cursor != cursor {
+			hub.mu.Unlock()
+			continue
+		}
+		for _, change := range changes {
+			for stream := range hub.streams {
+				select {
+				case stream.changes <- change:
+				default:
+					hub.remove(stream)
+				}
+			}
+		}
+		hub.cursor = last
+		hub.mu.Unlock()
+		if len(changes) < maxChangesLimit {
+			return nil
+		}
+	}
+}
+
+// streamClient returns the client the streams of r are counted for: the id of
+// its token when there is one, and otherwise its subject from the address of
+// the client, as every public user shares the same token.
+func streamClient(r *http.Request) string {
+	_, claims, err := jwtauth.FromContext(r.Context())
+	if err == nil {
+		if jti, _ := claims["jti"].(string); jti != "" {
+			return "jti:" + jti
+		}
+	}
+	// Cloud Run appends the address of the client to X-Forwarded-For, the
+	// previous ones are sent by the client.
+	address, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		address = r.RemoteAddr
+	}
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		hops := strings.Split(forwarded, ",")
+		address = strings.TrimSpace(hops[len(hops)-1])
+	}
+	return fmt.Sprintf("sub:%s@%s", subject(r), address)
+}
+
+// ChangeFilter selects the changes sent to a stream.
+type ChangeFilter struct {
+	// Countries are ISO 3166-1 alpha-3 codes, every country if empty.
+	Countries []string
+	// Categories only selects the changes of these tag categories, every
+	// change if empty.
+	Categories []string
+}
+
+func (filter ChangeFilter) matches(change Change) bool {
+	if len(filter.Countries) > 0 && (change.Country3Code == nil || !slices.Contains(filter.Countries, *change.Country3Code)) {
+		return false
+	}
+	if len(filter.Categories) == 0 {
+		return true
+	}
+	for _, category := range change.Categories {
+		if slices.Contains(filter.Categories, category) {
+			return true
+		}
+	}
+	return false
+}
+
+type StreamChangesReq struct {
+	since  ChangeToken
+	resume bool
+	filter ChangeFilter
+}
+
+func (streamChR *StreamChangesReq) validate(r *http.Request) error {
+	errs := map[string]string{}
+	query := r.URL.Query()
+
+	if lastEventId := r.Header.Get("Last-Event-ID"); lastEventId != "" {
+		token, err := parseChangeToken(lastEventId)
+		if err != nil {
+			errs["Last-Event-ID"] = "Must be the id of an event of this stream"
+		}
+		streamChR.since = token
+		streamChR.resume = true
+	}
+
+	if countryParam := query.Get("country"); countryParam != "" {
+		for _, country := range strings.Split(countryParam, ",") {
+			if !iso3166.IsAlpha3(country) {
+				errs["country"] = "Must be ISO 3166-1 alpha-3 codes separated by commas"
+			}
+			streamChR.filter.Countries = append(streamChR.filter.Countries, country)
+		}
+	}
+
+	if categoryParam := query.Get("category"); categoryParam != "" {
+		names := make([]string, 0, len(vocabulary.Categories))
+		for _, category := range vocabulary.Categories {
+			names = append(names, category.Name)
+		}
+		for _, category := range strings.Split(categoryParam, ",") {
+			if !slices.Contains(names, category) {
+				errs["category"] = fmt.Sprintf("Must be some of: %s", strings.Join(names, ", "))
+			}
+			streamChR.filter.Categories = append(streamChR.filter.Categories, category)
+		}
+	}
+
+	if len(errs) > 0 {
+		return &api_errors.ClientErr{
+			HttpCode: http.StatusBadRequest,
+			Message:  "Parameters not present or invalid",
+			Errors:   errs,
+		}
+	}
+	return nil
+}
+
+// @Summary		Stream changes
+// @Description	Stream the changes of the cities and their tags as server-sent events as they are committed, each a change event whose data is a Change and whose id resumes the stream in the Last-Event-ID header. Without it the stream starts with the new changes. Idle streams receive a comment every 15 seconds, and a client, identified by the id of its token or else by its address, can have 3 streams open at once
+// @Produce		text/event-stream
+// @Param       Last-Event-ID	header	string	false	"Id of the last event received"
+// @Param       country		query	string	false	"Only the changes of these countries, ISO 3166-1 alpha-3 codes separated by commas"
+// @Param       category	query	string	false	"Only the changes of these tag categories, separated by commas"
+// @Success		200 	{object} 	Change
+// @Failure     400 	{object} 	api_errors.ClientErr
+// @Failure     429 	{object} 	api_errors.ClientErr
+// @Router		/v0/stream/changes [get]
+func (api *Api) streamChanges(w http.ResponseWriter, r *http.Request) error {
+	streamReq := &StreamChangesReq{}
+	if err := streamReq.validate(r); err != nil {
+		return err
+	}
+
+	stream, err := api.stream.subscribe(streamClient(r))
+	if err != nil {
+		return err
+	}
+	defer api.stream.unsubscribe(stream)
+
+	// The changes broadcast while catching up are buffered by the stream,
+	// the ones already sent are skipped.
+	since := streamReq.since
+	if !streamReq.resume {
+		if since, err = api.changes.Latest(); err != nil {
+			return err
+		}
+	}
+	changes, err := api.changes.Changes(since, maxChangesLimit)
+	if err != nil {
+		return err
+	}
+
+	controller := http.NewResponseController(w)
+	// Streams outlive the write timeout of the server.
+	controller.SetWriteDeadline(time.Time{})
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", streamRetry.Milliseconds())
+
+	send := func(change Change) error {
+		if !streamReq.filter.matches(change) {
+			return nil
+		}
+		data, err := json.Marshal(change)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "id: %s\nevent: change\ndata: %s\n\n", change.Token, data)
+		return err
+	}
+	// The backlog is written a page at a time, a client that can't be caught
+	// up resumes from the last event received.
+	for {
+		for _, change := range changes {
+			if err := send(change); err != nil {
+				return nil
+			}
+		}
+		if err := controller.Flush(); err != nil {
+			return nil
+		}
+		if len(changes) < maxChangesLimit {
+			break
+		}
+		since, _ = parseChangeToken(changes[len(changes)-1].Token)
+		if changes, err = api.changes.Changes(since, maxChangesLimit); err != nil {
+			log.Printf("Error catching up a stream: %s", err.Error())
+			return nil
+		}
+	}
+	if len(changes) > 0 {
+		since, _ = parseChangeToken(changes[len(changes)-1].Token)
+	}
+
+	heartbeat := time.NewTicker(api.stream.cfg.Heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return nil
+		case <-stream.closed:
+			return nil
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return nil
+			}
+		case change := <-stream.changes:
+			token, err := parseChangeToken(change.Token)
+			if err != nil || !since.Less(token) {
+				continue
+			}
+			since = token
+			if err := send(change); err != nil {
+				return nil
+			}
+		}
+		if err := controller.Flush(); err != nil {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/jwtauth/v5"
+)
+
+func newStreamServer(t *testing.T, changeLog ChangeLog, cfg StreamConfig) (*httptest.Server, *ChangeHub, string) {
+	tokenAuth := jwtauth.New("HS256", []byte("test_enc_key"), nil)
+	api := &Api{repo: stubRepository{}, changes: changeLog, tokenAuth: tokenAuth}
+	api.stream = NewChangeHub(changeLog, cfg)
+
+	_, token, err := tokenAuth.Encode(map[string]interface{}{"sub": "dashboard"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(api.RegisterRoutes())
+	t.Cleanup(server.Close)
+	return server, api.stream, token
+}
+
+func openStream(t *testing.T, server *httptest.Server, token string, target string, header map[string]string) *http.Response {
+	req, err := http.NewRequest("GET", server.URL+target, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	for name, value := range header {
+		req.Header.Set(name, value)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+// readEvent returns the fields of the next event, comments are returned as
+// the comment field.
+func readEvent(t *testing.T, reader *bufio.Reader) map[string]string {
+	fields := map[string]string{}
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("unexpected end of the stream: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return fields
+		}
+		name, value, _ := strings.Cut(line, ":")
+		if name == "" {
+			name = "comment"
+		}
+		fields[name] = strings.TrimPrefix(value, " ")
+	}
+}
+
+func TestChangeFilter_matches(t *testing.T) {
+	spain := Change{Resource: "tags", Country3Code: ptr("ESP"), Categories: []string{"humidity", "temperature"}}
+	tests := []struct {
+		name    string
+		filter  ChangeFilter
+		change  Change
+		matches bool
+	}{
+		{"no filter", ChangeFilter{}, spain, true},
+		{"country", ChangeFilter{Countries: []string{"FRA", "ESP"}}, spain, true},
+		{"other country", ChangeFilter{Countries: []string{"FRA"}}, spain, false},
+		{"unknown country", ChangeFilter{Countries: []string{"ESP"}}, Change{Resource: "tags"}, false},
+		{"category", ChangeFilter{Categories: []string{"temperature"}}, spain, true},
+		{"other category", ChangeFilter{Categories: []string{"city_size"}}, spain, false},
+		{"city with category", ChangeFilter{Categories: []string{"temperature"}}, Change{Resource: "city", Country3Code: ptr("ESP")}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if matches := tt.filter.matches(tt.change); matches != tt.matches {
+				t.Errorf("expected %v, got %v", tt.matches, matches)
+			}
+		})
+	}
+}
+
+func TestStreamChanges_resumesAndFilters(t *testing.T) {
+	changeLog := &memoryChangeLog{}
+	for _, change := range []Change{
+		{Resource: "tags", Operation: "update", CityId: 1, Country3Code: ptr("ESP"), Categories: []string{"temperature"}},
+		{Resource: "tags", Operation: "update", CityId: 2, Country3Code: ptr("FRA"), Categories: []string{"temperature"}},
+		{Resource: "tags", Operation: "update", CityId: 1, Country3Code: ptr("ESP"), Categories: []string{"city_size"}},
+		{Resource: "tags", Operation: "insert", CityId: 3, Country3Code: ptr("ESP"), Categories: []string{"humidity", "temperature"}},
+	} {
+		changeLog.append(change)
+	}
+	server, hub, token := newStreamServer(t, changeLog, DefaultStreamConfig())
+
+	resp := openStream(t, server, token, "/v0/stream/changes?country=ESP&category=temperature", map[string]string{"Last-Event-ID": changeLog.changes[0].Token})
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("expected an event stream, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	reader := bufio.NewReader(resp.Body)
+	if retry := readEvent(t, reader)["retry"]; retry != "3000" {
+		t.Errorf("expected a retry delay of 3000, got %q", retry)
+	}
+
+	// The backlog after the last event received, without the changes of
+	// France nor of the city size.
+	event := readEvent(t, reader)
+	change := Change{}
+	if err := json.Unmarshal([]byte(event["data"]), &change); err != nil {
+		t.Fatal(err)
+	}
+	if event["event"] != "change" || event["id"] != changeLog.changes[3].Token || change.CityId != 3 {
+		t.Errorf("unexpected event %v", event)
+	}
+
+	// The changes committed afterwards, without the ones already sent.
+	changeLog.append(Change{Resource: "tags", Operation: "update", CityId: 1, Country3Code: ptr("ESP"), Categories: []string{"temperature"}})
+	if err := hub.broadcast(); err != nil {
+		t.Fatal(err)
+	}
+	if event := readEvent(t, reader); event["id"] != changeLog.changes[4].Token {
+		t.Errorf("expected the new change, got %v", event)
+	}
+}
+
+func TestStreamChanges_backlogOverSeveralPages(t *testing.T) {
+	changeLog := &memoryChangeLog{}
+	for range maxChangesLimit + 10 {
+		changeLog.add("update")
+	}
+	server, _, token := newStreamServer(t, changeLog, DefaultStreamConfig())
+
+	resp := openStream(t, server, token, "/v1/stream/changes", map[string]string{"Last-Event-ID": ChangeToken{TxId: 1}.String()})
+	reader := bufio.NewReader(resp.Body)
+	readEvent(t, reader)
+	for index := range changeLog.changes {
+		if event := readEvent(t, reader); event["id"] != changeLog.changes[index].Token {
+			t.Fatalf("expected change %d, got %v", index, event)
+		}
+	}
+}
+
+func TestStreamChanges_limitsStreamsPerClient(t *testing.T) {
+	server, _, token := newStreamServer(t, &memoryChangeLog{}, StreamConfig{MaxPerClient: 1, Heartbeat: 10 * time.Millisecond})
+
+	first := openStream(t, server, token, "/v1/stream/changes", nil)
+	reader := bufio.NewReader(first.Body)
+	readEvent(t, reader)
+	if event := readEvent(t, reader); event["comment"] != "heartbeat" {
+		t.Errorf("expected a heartbeat, got %v", event)
+	}
+
+	if second := openStream(t, server, token, "/v1/stream/changes", nil); second.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("expected 429 for a second stream, got %d", second.StatusCode)
+	}
+
+	first.Body.Close()
+	for range 100 {
+		third := openStream(t, server, token, "/v1/stream/changes", nil)
+		if third.StatusCode == http.StatusOK {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Errorf("expected a stream to be allowed once the first is closed")
+}
+
+func TestStreamChanges_limitsStreamsPerAddress(t *testing.T) {
+	server, _, token := newStreamServer(t, &memoryChangeLog{}, StreamConfig{MaxPerClient: 1, Heartbeat: time.Minute})
+
+	first := openStream(t, server, token, "/v1/stream/changes", map[string]string{"X-Forwarded-For": "203.0.113.1"})
+	second := openStream(t, server, token, "/v1/stream/changes", map[string]string{"X-Forwarded-For": "203.0.113.1, 203.0.113.2"})
+	if first.StatusCode != http.StatusOK || second.StatusCode != http.StatusOK {
+		t.Errorf("expected the clients to share the subject but not the limit, got %d and %d", first.StatusCode, second.StatusCode)
+	}
+	if third := openStream(t, server, token, "/v1/stream/changes", map[string]string{"X-Forwarded-For": "203.0.113.2"}); third.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("expected 429 for a second stream of the same address, got %d", third.StatusCode)
+	}
+}
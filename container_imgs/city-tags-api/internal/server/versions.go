@@ -0,0 +1,124 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"city-tags-api/internal/api_errors"
+)
+
+const (
+	v0 = "v0"
+	v1 = "v1"
+)
+
+var (
+	// v0Deprecation is the release of v1, v0 is removed at v0Sunset.
+	v0Deprecation = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+	v0Sunset      = time.Date(2027, time.April, 19, 0, 0, 0, 0, time.UTC)
+)
+
+type versionKey struct{}
+
+// pathVersion returns the API version of the first segment of the path,
+// requests outside a versioned path get the v0 contracts.
+func pathVersion(path string) string {
+	if path == "/"+v1 || strings.HasPrefix(path, "/"+v1+"/") {
+		return v1
+	}
+	return v0
+}
+
+// Versioned stores the API version of the request so responses and errors are
+// written with its contracts, and announces the deprecation of v0 with the
+// Deprecation (RFC 9745) and Sunset (RFC 8594) headers.
+func Versioned(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		version := pathVersion(r.URL.Path)
+		if version == v0 && strings.HasPrefix(r.URL.Path, "/"+v0+"/") {
+			w.Header().Set("Deprecation", fmt.Sprintf("@%d", v0Deprecation.Unix()))
+			w.Header().Set("Sunset", v0Sunset.Format(http.TimeFormat))
+			successor := "/" + v1 + strings.TrimPrefix(r.URL.Path, "/"+v0)
+			w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, successor))
+		}
+
+		ctx := context.WithValue(r.Context(), versionKey{}, version)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func requestVersion(r *http.Request) string {
+	if version, ok := r.Context().Value(versionKey{}).(string); ok {
+		return version
+	}
+	return v0
+}
+
+// v0Adapter is implemented by the responses whose v1 contract differs from
+// the v0 one, v0 returns the payload in the v0 contract.
+type v0Adapter interface {
+	v0() any
+}
+
+// respond writes payload in the contract of the API version of the request.
+func respond(w http.ResponseWriter, r *http.Request, code int, payload any) {
+	if adapter, ok := payload.(v0Adapter); ok && requestVersion(r) == v0 {
+		payload = adapter.v0()
+	}
+	respondWithJSON(w, code, payload)
+}
+
+// respondWithError writes err as a ClientErr in v0 and as problem details
+// from v1 on.
+func respondWithError(w http.ResponseWriter, r *http.Request, err error) {
+	clientErr, ok := err.(*api_errors.ClientErr)
+	if requestVersion(r) == v0 {
+		if ok {
+			respondWithJSON(w, clientErr.HttpCode, clientErr)
+			return
+		}
+		respondWithJSON(w, http.StatusInternalServerError, internalErr)
+		return
+	}
+
+	problem := internalErr.Problem()
+	if ok {
+		problem = clientErr.Problem()
+	}
+	jsonPay, err := json.Marshal(problem)
+	if err != nil {
+		log.Printf("Error when marshaling JSON: %s", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(problem.Status)
+	w.Write(jsonPay)
+}
+
+var internalErr = &api_errors.InternalErr{
+	HttpCode: http.StatusInternalServerError,
+	Message:  "internal server error",
+}
+
+// Page describes the slice of a listing in a response. NextOffset is the
+// offset of the following page, null on the last one.
+type Page struct {
+	Offset     int  `json:"offset"`
+	Limit      int  `json:"limit"`
+	NextOffset *int `json:"next_offset"`
+}
+
+func newPage(offset int, limit int, count int) Page {
+	page := Page{Offset: offset, Limit: limit}
+	if count > 0 && count >= limit {
+		nextOffset := offset + count
+		page.NextOffset = &nextOffset
+	}
+	return page
+}
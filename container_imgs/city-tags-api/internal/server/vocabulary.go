@@ -0,0 +1,88 @@
+package server
+
+import (
+	"net/http"
+)
+
+type TagValue struct {
+	Value        string   `json:"value"`
+	Rank         int      `json:"rank"`
+	Label        string   `json:"label"`
+	MinThreshold *float64 `json:"min_threshold"`
+	MaxThreshold *float64 `json:"max_threshold"`
+}
+
+type TagCategory struct {
+	Category string     `json:"category"`
+	Label    string     `json:"label"`
+	Metric   string     `json:"metric"`
+	Unit     string     `json:"unit"`
+	Values   []TagValue `json:"values"`
+}
+
+type GetVocabularyResp struct {
+	Categories []TagCategory `json:"categories"`
+}
+
+func (repo *dbRepository) GetVocabulary() ([]TagCategory, error) {
+	rows, err := repo.db.Query(
+		`select c.category, c.label, c.metric, c.unit, v.value, v.rank, v.label, v.min_threshold, v.max_threshold
+		from city_tags.tag_categories c
+		join city_tags.tag_values v on v.category = c.category
+		order by c.category, v.rank`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []TagCategory{}
+	for rows.Next() {
+		var category TagCategory
+		var value TagValue
+		err := rows.Scan(
+			&category.Category,
+			&category.Label,
+			&category.Metric,
+			&category.Unit,
+			&value.Value,
+			&value.Rank,
+			&value.Label,
+			&value.MinThreshold,
+			&value.MaxThreshold,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		last := len(categories) - 1
+		if last < 0 || categories[last].Category != category.Category {
+			categories = append(categories, category)
+			last++
+		}
+		categories[last].Values = append(categories[last].Values, value)
+	}
+	return categories, rows.Err()
+}
+
+// @Summary		Get tag vocabulary
+// @Description	Get every tag category with its allowed values, ordered by rank, their labels and the thresholds used to derive them
+// @Accept			json
+// @Produce		json
+// @Param       lang	query string	false	"Language of names and labels (en, es, de), Accept-Language is used if missing"
+// @Success		200 {object} GetVocabularyResp
+// @Failure      500  {object} api_errors.ClientErr
+// @Router			/v0/tags [get]
+func (api *Api) getVocabulary(w http.ResponseWriter, r *http.Request) error {
+	categories, err := api.repo.GetVocabulary()
+	if err != nil {
+		return err
+	}
+	localizer, err := api.localizer(r)
+	if err != nil {
+		return err
+	}
+
+	respond(w, r, http.StatusOK, GetVocabularyResp{Categories: localizer.vocabulary(categories)})
+	return nil
+}
@@ -0,0 +1,297 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"city-tags-api/internal/api_errors"
+	"city-tags-api/internal/iso3166"
+	"city-tags-api/internal/webhooks"
+)
+
+func getWebhooksCfg() webhooks.Config {
+	cfg := webhooks.DefaultConfig()
+
+	if pollInterval := os.Getenv("WEBHOOKS_POLL_INTERVAL"); pollInterval != "" {
+		value, err := time.ParseDuration(pollInterval)
+		if err != nil {
+			log.Fatalf("WEBHOOKS_POLL_INTERVAL env variable error when parsing to duration %s", err.Error())
+		}
+		cfg.PollInterval = value
+	}
+
+	if maxAttempts := os.Getenv("WEBHOOKS_MAX_ATTEMPTS"); maxAttempts != "" {
+		value, err := strconv.Atoi(maxAttempts)
+		if err != nil {
+			log.Fatalf("WEBHOOKS_MAX_ATTEMPTS env variable error when parsing to integer %s", err.Error())
+		}
+		cfg.MaxAttempts = value
+	}
+	return cfg
+}
+
+type SubscriptionWriteReq struct {
+	Url *string `json:"url"`
+	// Secret signs the deliveries, one is generated if missing.
+	Secret *string `json:"secret"`
+	// EventTypes are every event type if missing.
+	EventTypes *[]string `json:"event_types"`
+	// Countries are ISO 3166-1 alpha-3 codes, every country if missing.
+	Countries *[]string `json:"countries"`
+}
+
+func (subW *SubscriptionWriteReq) validate() error {
+	errs := map[string]string{}
+
+	if subW.Url == nil {
+		errs["url"] = "Not present or invalid"
+	} else if parsed, err := url.Parse(*subW.Url); err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		errs["url"] = "Must be an absolute http or https URL"
+	}
+	if subW.Secret != nil && (len(*subW.Secret) < 16 || len(*subW.Secret) > 200) {
+		errs["secret"] = "Must have between 16 and 200 characters"
+	}
+	if subW.EventTypes != nil {
+		for _, eventType := range *subW.EventTypes {
+			if !slices.Contains(webhooks.EventTypes, eventType) {
+				errs["event_types"] = fmt.Sprintf("Must be some of: %s", strings.Join(webhooks.EventTypes, ", "))
+			}
+		}
+	}
+	if subW.Countries != nil {
+		for _, country := range *subW.Countries {
+			if !iso3166.IsAlpha3(country) {
+				errs["countries"] = "Must be ISO 3166-1 alpha-3 codes"
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return &api_errors.ClientErr{
+			HttpCode: http.StatusBadRequest,
+			Message:  "Parameters not present or invalid",
+			Errors:   errs,
+		}
+	}
+	return nil
+}
+
+func (subW *SubscriptionWriteReq) subscription() (webhooks.Subscription, error) {
+	subscription := webhooks.Subscription{
+		Url:        *subW.Url,
+		EventTypes: webhooks.EventTypes,
+		Countries:  []string{},
+	}
+	if subW.EventTypes != nil && len(*subW.EventTypes) > 0 {
+		subscription.EventTypes = *subW.EventTypes
+	}
+	if subW.Countries != nil {
+		subscription.Countries = *subW.Countries
+	}
+	if subW.Secret != nil {
+		subscription.Secret = *subW.Secret
+		return subscription, nil
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return subscription, err
+	}
+	subscription.Secret = hex.EncodeToString(secret)
+	return subscription, nil
+}
+
+func parseIdParam(r *http.Request, name string) (int64, error) {
+	id, err := strconv.ParseInt(r.PathValue(name), 10, 64)
+	if err != nil {
+		return 0, &api_errors.ClientErr{
+			HttpCode: http.StatusBadRequest,
+			Message:  "Parameters not present or invalid",
+			Errors: map[string]string{
+				name: "Not present or invalid",
+			},
+		}
+	}
+	return id, nil
+}
+
+type ListSubscriptionsResp struct {
+	Subscriptions []webhooks.Subscription `json:"subscriptions"`
+	Page          Page                    `json:"page"`
+}
+
+type ListDeliveriesResp struct {
+	Deliveries []webhooks.Delivery `json:"deliveries"`
+	Page       Page                `json:"page"`
+}
+
+// @Summary		Create webhook subscription
+// @Description	Subscribe a URL to the changes of the cities and their tags, requires a token with the admin role. The deliveries are signed with the secret, only returned here, in the X-Webhook-Signature header
+// @Accept		json
+// @Produce		json
+// @Param       subscription	body		SubscriptionWriteReq	true	"Subscription"
+// @Success		201 	{object} 	webhooks.Subscription
+// @Failure     400 	{object} 	api_errors.ClientErr
+// @Router		/v0/admin/webhooks [post]
+func (api *Api) createWebhook(w http.ResponseWriter, r *http.Request) error {
+	subW := &SubscriptionWriteReq{}
+	if err := decodeBody(r, subW); err != nil {
+		return err
+	}
+	if err := subW.validate(); err != nil {
+		return err
+	}
+
+	subscription, err := subW.subscription()
+	if err != nil {
+		return err
+	}
+	subscription, err = api.webhooks.CreateSubscription(subscription)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/%s/admin/webhooks/%d", requestVersion(r), subscription.SubscriptionId))
+	respond(w, r, http.StatusCreated, subscription)
+	return nil
+}
+
+// @Summary		List webhook subscriptions
+// @Description	List the webhook subscriptions, without their secrets. Requires a token with the admin role
+// @Produce		json
+// @Param       offset  query	int		false	"Offset for pagination"
+// @Param       limit   query	int		false	"Limit for pagination, 100 by default and 1000 at most"
+// @Success		200 	{object} 	ListSubscriptionsResp
+// @Failure     400 	{object} 	api_errors.ClientErr
+// @Router		/v0/admin/webhooks [get]
+func (api *Api) listWebhooks(w http.ResponseWriter, r *http.Request) error {
+	offset, limit, err := parsePagination(r.URL.Query())
+	if err != nil {
+		return err
+	}
+
+	subscriptions, err := api.webhooks.ListSubscriptions(offset, limit)
+	if err != nil {
+		return err
+	}
+	respond(w, r, http.StatusOK, ListSubscriptionsResp{
+		Subscriptions: append([]webhooks.Subscription{}, subscriptions...),
+		Page:          newPage(offset, limit, len(subscriptions)),
+	})
+	return nil
+}
+
+// @Summary		Get webhook subscription
+// @Description	Get a webhook subscription, without its secret. Requires a token with the admin role
+// @Produce		json
+// @Param       subscriptionId	path	int		true	"Subscription id"
+// @Success		200 	{object} 	webhooks.Subscription
+// @Failure     404 	{object} 	api_errors.ClientErr
+// @Router		/v0/admin/webhooks/{subscriptionId} [get]
+func (api *Api) getWebhook(w http.ResponseWriter, r *http.Request) error {
+	subscriptionId, err := parseIdParam(r, "subscriptionId")
+	if err != nil {
+		return err
+	}
+
+	subscription, err := api.webhooks.GetSubscription(subscriptionId)
+	if err != nil {
+		return err
+	}
+	respond(w, r, http.StatusOK, subscription)
+	return nil
+}
+
+// @Summary		Delete webhook subscription
+// @Description	Delete a webhook subscription and its deliveries, requires a token with the admin role
+// @Param       subscriptionId	path	int		true	"Subscription id"
+// @Success		204
+// @Failure     404 	{object} 	api_errors.ClientErr
+// @Router		/v0/admin/webhooks/{subscriptionId} [delete]
+func (api *Api) deleteWebhook(w http.ResponseWriter, r *http.Request) error {
+	subscriptionId, err := parseIdParam(r, "subscriptionId")
+	if err != nil {
+		return err
+	}
+
+	if err := api.webhooks.DeleteSubscription(subscriptionId); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// @Summary		List webhook deliveries
+// @Description	List the deliveries of a webhook subscription, most recent first. Requires a token with the admin role
+// @Produce		json
+// @Param       subscriptionId	path	int		true	"Subscription id"
+// @Param       status	query	string	false	"Only deliveries with this status (pending, delivered, dead)"
+// @Param       offset  query	int		false	"Offset for pagination"
+// @Param       limit   query	int		false	"Limit for pagination, 100 by default and 1000 at most"
+// @Success		200 	{object} 	ListDeliveriesResp
+// @Failure     400 	{object} 	api_errors.ClientErr
+// @Failure     404 	{object} 	api_errors.ClientErr
+// @Router		/v0/admin/webhooks/{subscriptionId}/deliveries [get]
+func (api *Api) listWebhookDeliveries(w http.ResponseWriter, r *http.Request) error {
+	subscriptionId, err := parseIdParam(r, "subscriptionId")
+	if err != nil {
+		return err
+	}
+	offset, limit, err := parsePagination(r.URL.Query())
+	if err != nil {
+		return err
+	}
+	status := r.URL.Query().Get("status")
+	if status != "" && !slices.Contains(webhooks.Statuses, status) {
+		return &api_errors.ClientErr{
+			HttpCode: http.StatusBadRequest,
+			Message:  "Parameters not present or invalid",
+			Errors: map[string]string{
+				"status": fmt.Sprintf("Must be one of: %s", strings.Join(webhooks.Statuses, ", ")),
+			},
+		}
+	}
+
+	if _, err := api.webhooks.GetSubscription(subscriptionId); err != nil {
+		return err
+	}
+	deliveries, err := api.webhooks.ListDeliveries(subscriptionId, status, offset, limit)
+	if err != nil {
+		return err
+	}
+	respond(w, r, http.StatusOK, ListDeliveriesResp{
+		Deliveries: append([]webhooks.Delivery{}, deliveries...),
+		Page:       newPage(offset, limit, len(deliveries)),
+	})
+	return nil
+}
+
+// @Summary		Replay webhook delivery
+// @Description	Send a delivery again, with every attempt available, whether it was delivered or is dead. Requires a token with the admin role
+// @Produce		json
+// @Param       deliveryId	path	int		true	"Delivery id"
+// @Success		202 	{object} 	webhooks.Delivery
+// @Failure     404 	{object} 	api_errors.ClientErr
+// @Router		/v0/admin/webhooks/deliveries/{deliveryId}/replay [post]
+func (api *Api) replayWebhookDelivery(w http.ResponseWriter, r *http.Request) error {
+	deliveryId, err := parseIdParam(r, "deliveryId")
+	if err != nil {
+		return err
+	}
+
+	delivery, err := api.webhooks.ReplayDelivery(deliveryId)
+	if err != nil {
+		return err
+	}
+	respond(w, r, http.StatusAccepted, delivery)
+	return nil
+}
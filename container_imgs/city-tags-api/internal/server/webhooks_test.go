@@ -0,0 +1,125 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"city-tags-api/internal/api_errors"
+	"city-tags-api/internal/webhooks"
+
+	"github.com/go-chi/jwtauth/v5"
+)
+
+func TestSubscriptionWriteReq_validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   SubscriptionWriteReq
+		isError bool
+	}{
+		{"url only", SubscriptionWriteReq{Url: ptr("https://partner.example.com/hooks")}, false},
+		{
+			"every field",
+			SubscriptionWriteReq{
+				Url:        ptr("https://partner.example.com/hooks"),
+				Secret:     ptr("0123456789abcdef"),
+				EventTypes: ptr([]string{webhooks.EventTagsUpdated}),
+				Countries:  ptr([]string{"ESP", "ARG"}),
+			},
+			false,
+		},
+		{"missing url", SubscriptionWriteReq{}, true},
+		{"relative url", SubscriptionWriteReq{Url: ptr("/hooks")}, true},
+		{"unsupported scheme", SubscriptionWriteReq{Url: ptr("ftp://partner.example.com")}, true},
+		{"short secret", SubscriptionWriteReq{Url: ptr("https://partner.example.com"), Secret: ptr("secret")}, true},
+		{"unknown event type", SubscriptionWriteReq{Url: ptr("https://partner.example.com"), EventTypes: ptr([]string{"city.moved"})}, true},
+		{"invalid country", SubscriptionWriteReq{Url: ptr("https://partner.example.com"), Countries: ptr([]string{"ES"})}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.input.validate()
+			if !tt.isError && err != nil {
+				t.Errorf("Didn't expect an error but one was received: %v", err)
+			}
+			if tt.isError && err == nil {
+				t.Errorf("Expected error but none was received")
+			}
+		})
+	}
+}
+
+// stubWebhooks stores the created subscriptions, the methods it doesn't
+// override panic.
+type stubWebhooks struct {
+	webhooks.Store
+	created []webhooks.Subscription
+}
+
+func (store *stubWebhooks) CreateSubscription(subscription webhooks.Subscription) (webhooks.Subscription, error) {
+	subscription.SubscriptionId = int64(len(store.created) + 1)
+	store.created = append(store.created, subscription)
+	return subscription, nil
+}
+
+func (store *stubWebhooks) ListSubscriptions(offset int, limit int) ([]webhooks.Subscription, error) {
+	return store.created, nil
+}
+
+func (store *stubWebhooks) ReplayDelivery(deliveryId int64) (webhooks.Delivery, error) {
+	return webhooks.Delivery{}, &api_errors.DeliveryNotFoundErr
+}
+
+func serveAdmin(t *testing.T, store webhooks.Store, method string, target string, body string) *httptest.ResponseRecorder {
+	tokenAuth := jwtauth.New("HS256", []byte("test_enc_key"), nil)
+	api := &Api{repo: stubRepository{}, webhooks: store, tokenAuth: tokenAuth}
+
+	_, token, err := tokenAuth.Encode(map[string]interface{}{"sub": "admin_user", "role": "admin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	writer := httptest.NewRecorder()
+	api.RegisterRoutes().ServeHTTP(writer, req)
+	return writer
+}
+
+func TestCreateWebhook(t *testing.T) {
+	store := &stubWebhooks{}
+	writer := serveAdmin(t, store, "POST", "/v1/admin/webhooks", `{"url": "https://partner.example.com/hooks", "countries": ["ESP"]}`)
+	if writer.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", writer.Code, writer.Body.String())
+	}
+	if location := writer.Header().Get("Location"); location != "/v1/admin/webhooks/1" {
+		t.Errorf("unexpected location %s", location)
+	}
+
+	subscription := webhooks.Subscription{}
+	if err := json.Unmarshal(writer.Body.Bytes(), &subscription); err != nil {
+		t.Fatal(err)
+	}
+	if len(subscription.Secret) != 64 || subscription.Secret != store.created[0].Secret {
+		t.Errorf("expected a generated secret to be returned, got %q", subscription.Secret)
+	}
+	if len(subscription.EventTypes) != len(webhooks.EventTypes) {
+		t.Errorf("expected every event type, got %v", subscription.EventTypes)
+	}
+}
+
+func TestReplayWebhookDelivery_notFound(t *testing.T) {
+	writer := serveAdmin(t, &stubWebhooks{}, "POST", "/v1/admin/webhooks/deliveries/7/replay", "")
+	if writer.Code != http.StatusNotFound || writer.Header().Get("Content-Type") != "application/problem+json" {
+		t.Errorf("expected a 404 problem, got %d %s", writer.Code, writer.Header().Get("Content-Type"))
+	}
+}
+
+func TestListWebhooks_onlyPagination(t *testing.T) {
+	writer := serveAdmin(t, &stubWebhooks{}, "GET", "/v1/admin/webhooks?limit=10&temperature=scorching", "")
+	if writer.Code != http.StatusOK {
+		t.Errorf("expected the filters of the cities to be ignored, got %d: %s", writer.Code, writer.Body.String())
+	}
+}
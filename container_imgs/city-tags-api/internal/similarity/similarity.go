@@ -0,0 +1,178 @@
+package similarity
+
+import (
+	"container/heap"
+	"math"
+
+	"city-tags-api/internal/vocabulary"
+)
+
+// Item is a city with its tag values in the same order as
+// vocabulary.Categories.
+type Item struct {
+	Id        int
+	Name      string
+	Continent string
+	Country   string
+	Values    []string
+}
+
+// Index holds the tags of every city as vectors of ranks normalized to [0, 1],
+// so the distance between two values of a category is the fraction of the
+// scale that separates them. It is immutable once built and safe for
+// concurrent use.
+type Index struct {
+	items     []Item
+	vectors   []float64
+	positions map[int]int
+}
+
+func NewIndex(items []Item) *Index {
+	dimensions := len(vocabulary.Categories)
+	index := &Index{
+		items:     items,
+		vectors:   make([]float64, len(items)*dimensions),
+		positions: make(map[int]int, len(items)),
+	}
+
+	for position, item := range items {
+		index.positions[item.Id] = position
+		for dimension, category := range vocabulary.Categories {
+			index.vectors[position*dimensions+dimension] = normalizedRank(category, item.Values[dimension])
+		}
+	}
+	return index
+}
+
+// normalizedRank maps a value to [0, 1], values out of the vocabulary are
+// placed in the middle of the scale.
+func normalizedRank(category vocabulary.Category, value string) float64 {
+	rank := category.Rank(value)
+	if rank < 0 || len(category.Values) < 2 {
+		return 0.5
+	}
+	return float64(rank) / float64(len(category.Values)-1)
+}
+
+func (index *Index) Len() int {
+	return len(index.items)
+}
+
+type Query struct {
+	Id int
+	// Weights has one weight per category in the order of
+	// vocabulary.Categories, a zero weight ignores the category.
+	Weights   []float64
+	Continent string
+	Country   string
+	Limit     int
+}
+
+type Match struct {
+	Item
+	// Score is the weighted mean of Similarities, 1 for identical tags.
+	Score float64
+	// Similarities has the similarity of every category in the order of
+	// vocabulary.Categories, 1 minus the normalized distance of the ranks.
+	Similarities []float64
+}
+
+// Similar scores every other city against the one in query, returning the
+// best Limit matches ordered by descending score. The second value is false if
+// the city is not in the index.
+func (index *Index) Similar(query Query) ([]Match, bool) {
+	position, ok := index.positions[query.Id]
+	if !ok {
+		return nil, false
+	}
+
+	dimensions := len(vocabulary.Categories)
+	target := index.vectors[position*dimensions : (position+1)*dimensions]
+	totalWeight := 0.0
+	for _, weight := range query.Weights {
+		totalWeight += weight
+	}
+	if totalWeight == 0 || query.Limit <= 0 {
+		return []Match{}, true
+	}
+
+	best := &scoreHeap{}
+	for candidate, item := range index.items {
+		if candidate == position ||
+			(query.Continent != "" && item.Continent != query.Continent) ||
+			(query.Country != "" && item.Country != query.Country) {
+			continue
+		}
+
+		vector := index.vectors[candidate*dimensions : (candidate+1)*dimensions]
+		score := 0.0
+		for dimension, weight := range query.Weights {
+			score += weight * (1 - math.Abs(target[dimension]-vector[dimension]))
+		}
+		score /= totalWeight
+
+		scored := scoredPosition{position: candidate, score: score, id: item.Id}
+		if best.Len() < query.Limit {
+			heap.Push(best, scored)
+		} else if scored.betterThan((*best)[0]) {
+			(*best)[0] = scored
+			heap.Fix(best, 0)
+		}
+	}
+
+	matches := make([]Match, best.Len())
+	for rank := len(matches) - 1; rank >= 0; rank-- {
+		scored := heap.Pop(best).(scoredPosition)
+		matches[rank] = index.match(scored, target)
+	}
+	return matches, true
+}
+
+func (index *Index) match(scored scoredPosition, target []float64) Match {
+	dimensions := len(vocabulary.Categories)
+	vector := index.vectors[scored.position*dimensions : (scored.position+1)*dimensions]
+
+	similarities := make([]float64, dimensions)
+	for dimension := range similarities {
+		similarities[dimension] = 1 - math.Abs(target[dimension]-vector[dimension])
+	}
+	return Match{
+		Item:         index.items[scored.position],
+		Score:        scored.score,
+		Similarities: similarities,
+	}
+}
+
+type scoredPosition struct {
+	position int
+	score    float64
+	id       int
+}
+
+// betterThan orders by descending score and ascending id on ties, so results
+// are deterministic.
+func (scored scoredPosition) betterThan(other scoredPosition) bool {
+	if scored.score != other.score {
+		return scored.score > other.score
+	}
+	return scored.id < other.id
+}
+
+// scoreHeap is a min-heap whose root is the worst of the best matches found
+// so far.
+type scoreHeap []scoredPosition
+
+func (h scoreHeap) Len() int           { return len(h) }
+func (h scoreHeap) Less(i, j int) bool { return h[j].betterThan(h[i]) }
+func (h scoreHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *scoreHeap) Push(value any) {
+	*h = append(*h, value.(scoredPosition))
+}
+
+func (h *scoreHeap) Pop() any {
+	old := *h
+	last := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return last
+}
@@ -0,0 +1,100 @@
+package similarity
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func newTestIndex() *Index {
+	return NewIndex([]Item{
+		{Id: 1, Name: "Target", Continent: "Europe", Country: "ESP", Values: []string{"clear", "dry", "hot", "dry", "good", "high", "big"}},
+		{Id: 2, Name: "Twin", Continent: "Europe", Country: "ESP", Values: []string{"clear", "dry", "hot", "dry", "good", "high", "big"}},
+		{Id: 3, Name: "Colder", Continent: "Europe", Country: "FRA", Values: []string{"clear", "dry", "warm", "dry", "good", "high", "big"}},
+		{Id: 4, Name: "Opposite", Continent: "Asia", Country: "JPN", Values: []string{"overcast", "very humid", "very cold", "very wet", "hazardous", "low", "small"}},
+		{Id: 5, Name: "Smaller", Continent: "Asia", Country: "JPN", Values: []string{"clear", "dry", "hot", "dry", "good", "high", "small"}},
+	})
+}
+
+func equalWeights() []float64 {
+	return []float64{1, 1, 1, 1, 1, 1, 1}
+}
+
+func matchIds(matches []Match) []int {
+	ids := []int{}
+	for _, match := range matches {
+		ids = append(ids, match.Id)
+	}
+	return ids
+}
+
+func TestSimilar(t *testing.T) {
+	index := newTestIndex()
+
+	tests := []struct {
+		name     string
+		query    Query
+		expected []int
+	}{
+		{"all cities", Query{Id: 1, Weights: equalWeights(), Limit: 10}, []int{2, 3, 5, 4}},
+		{"limit", Query{Id: 1, Weights: equalWeights(), Limit: 2}, []int{2, 3}},
+		{"continent", Query{Id: 1, Weights: equalWeights(), Continent: "Asia", Limit: 10}, []int{5, 4}},
+		{"country", Query{Id: 1, Weights: equalWeights(), Country: "FRA", Limit: 10}, []int{3}},
+		{
+			"only city size",
+			Query{Id: 1, Weights: []float64{0, 0, 0, 0, 0, 0, 1}, Limit: 3},
+			[]int{2, 3, 4},
+		},
+		{
+			"temperature outweighs city size",
+			Query{Id: 1, Weights: []float64{0, 0, 10, 0, 0, 0, 1}, Limit: 10},
+			[]int{2, 5, 3, 4},
+		},
+		{"no weights", Query{Id: 1, Weights: []float64{0, 0, 0, 0, 0, 0, 0}, Limit: 10}, []int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches, ok := index.Similar(tt.query)
+			if !ok {
+				t.Fatalf("Similar(%v) didn't find the city", tt.query)
+			}
+			if ids := matchIds(matches); !reflect.DeepEqual(ids, tt.expected) {
+				t.Errorf("Similar(%v) = %v; want %v", tt.query, ids, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSimilar_breakdown(t *testing.T) {
+	index := newTestIndex()
+
+	matches, _ := index.Similar(Query{Id: 1, Weights: equalWeights(), Country: "FRA", Limit: 1})
+	if len(matches) != 1 {
+		t.Fatalf("Similar() returned %d matches; want 1", len(matches))
+	}
+
+	// hot and warm are one step apart in a scale of five values.
+	expected := []float64{1, 1, 0.75, 1, 1, 1, 1}
+	for dimension, similarity := range matches[0].Similarities {
+		if math.Abs(similarity-expected[dimension]) > 1e-9 {
+			t.Errorf("Similarities[%d] = %f; want %f", dimension, similarity, expected[dimension])
+		}
+	}
+	if math.Abs(matches[0].Score-6.75/7) > 1e-9 {
+		t.Errorf("Score = %f; want %f", matches[0].Score, 6.75/7)
+	}
+
+	// Only city size is not at the opposite end of the scale, big is two
+	// steps away from small in a scale of four values.
+	opposite, _ := index.Similar(Query{Id: 1, Weights: equalWeights(), Country: "JPN", Limit: 2})
+	if opposite[1].Id != 4 || math.Abs(opposite[1].Score-(1.0/3)/7) > 1e-9 {
+		t.Errorf("Similar() = %v; want city 4 last with score %f", opposite, (1.0/3)/7)
+	}
+}
+
+func TestSimilar_notFound(t *testing.T) {
+	if _, ok := newTestIndex().Similar(Query{Id: 99, Weights: equalWeights(), Limit: 10}); ok {
+		t.Errorf("Similar() found a city that is not in the index")
+	}
+}
@@ -0,0 +1,136 @@
+package tagging
+
+import (
+	"city-tags-api/internal/database"
+)
+
+// Metrics are the raw values the tags are derived from, either for a single
+// month or aggregated over a year.
+type Metrics struct {
+	MeanTemperature  float64
+	RelativeHumidity float64
+	Precipitation    float64
+	CloudCover       float64
+	Aqi              float64
+	Daylight         float64
+	Population       float64
+}
+
+type MonthlyMetrics struct {
+	Month int
+	Metrics
+}
+
+// metricNames are the names the rules use to reference every metric, they
+// match the columns of city_tags.city_metrics.
+var metricNames = []string{
+	"mean_temperature",
+	"relative_humidity",
+	"precipitation",
+	"cloud_cover",
+	"aqi",
+	"daylight",
+	"population",
+}
+
+func isMetric(name string) bool {
+	for _, metricName := range metricNames {
+		if metricName == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (metrics Metrics) value(name string) float64 {
+	switch name {
+	case "mean_temperature":
+		return metrics.MeanTemperature
+	case "relative_humidity":
+		return metrics.RelativeHumidity
+	case "precipitation":
+		return metrics.Precipitation
+	case "cloud_cover":
+		return metrics.CloudCover
+	case "aqi":
+		return metrics.Aqi
+	case "daylight":
+		return metrics.Daylight
+	case "population":
+		return metrics.Population
+	}
+	return 0
+}
+
+// Aggregate returns the mean of every metric over the given months.
+func Aggregate(months []MonthlyMetrics) Metrics {
+	aggregated := Metrics{}
+	if len(months) == 0 {
+		return aggregated
+	}
+
+	for _, month := range months {
+		aggregated.MeanTemperature += month.MeanTemperature
+		aggregated.RelativeHumidity += month.RelativeHumidity
+		aggregated.Precipitation += month.Precipitation
+		aggregated.CloudCover += month.CloudCover
+		aggregated.Aqi += month.Aqi
+		aggregated.Daylight += month.Daylight
+		aggregated.Population += month.Population
+	}
+
+	count := float64(len(months))
+	aggregated.MeanTemperature /= count
+	aggregated.RelativeHumidity /= count
+	aggregated.Precipitation /= count
+	aggregated.CloudCover /= count
+	aggregated.Aqi /= count
+	aggregated.Daylight /= count
+	aggregated.Population /= count
+	return aggregated
+}
+
+// LoadMonthlyMetrics reads city_tags.city_metrics, returning the months of
+// every city ordered by month.
+func LoadMonthlyMetrics(db database.Service) (map[int][]MonthlyMetrics, error) {
+	rows, err := db.Query(
+		`select
+			city_id,
+			month,
+			mean_temperature::float8,
+			relative_humidity::float8,
+			precipitation::float8,
+			cloud_cover::float8,
+			aqi::float8,
+			daylight::float8,
+			population::float8
+		from city_tags.city_metrics
+		order by city_id, month`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	metrics := map[int][]MonthlyMetrics{}
+	for rows.Next() {
+		var cityId int
+		var month MonthlyMetrics
+		err := rows.Scan(
+			&cityId,
+			&month.Month,
+			&month.MeanTemperature,
+			&month.RelativeHumidity,
+			&month.Precipitation,
+			&month.CloudCover,
+			&month.Aqi,
+			&month.Daylight,
+			&month.Population,
+		)
+		if err != nil {
+			return nil, err
+		}
+		metrics[cityId] = append(metrics[cityId], month)
+	}
+	return metrics, rows.Err()
+}
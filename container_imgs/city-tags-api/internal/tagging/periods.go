@@ -0,0 +1,77 @@
+package tagging
+
+import (
+	"city-tags-api/internal/vocabulary"
+)
+
+// northernSeasons are the months of every season in the northern hemisphere,
+// in the southern one they are shifted by six months.
+var northernSeasons = map[string][]int{
+	"winter": {12, 1, 2},
+	"spring": {3, 4, 5},
+	"summer": {6, 7, 8},
+	"autumn": {9, 10, 11},
+}
+
+// IsNorthern guesses the hemisphere of a city from its metrics, northern
+// cities have longer days around June than around December.
+func IsNorthern(months []MonthlyMetrics) bool {
+	return meanDaylight(months, northernSeasons["summer"]) >= meanDaylight(months, northernSeasons["winter"])
+}
+
+func meanDaylight(months []MonthlyMetrics, selected []int) float64 {
+	total, count := 0.0, 0
+	for _, month := range months {
+		for _, selectedMonth := range selected {
+			if month.Month == selectedMonth {
+				total += month.Daylight
+				count++
+			}
+		}
+	}
+	if count == 0 {
+		return 0
+	}
+	return total / float64(count)
+}
+
+// SeasonMonths returns the months of the given season in the hemisphere of
+// the city.
+func SeasonMonths(season string, northern bool) []int {
+	months := northernSeasons[season]
+	if northern {
+		return months
+	}
+
+	shifted := make([]int, len(months))
+	for index, month := range months {
+		shifted[index] = (month+5)%12 + 1
+	}
+	return shifted
+}
+
+// DerivePeriods returns the tags of every month and season keyed by period and
+// then by tag column. Seasons are only derived when all their months are
+// present.
+func (rules *Rules) DerivePeriods(months []MonthlyMetrics) map[string]map[string]string {
+	periods := map[string]map[string]string{}
+	byMonth := map[int]MonthlyMetrics{}
+	for _, month := range months {
+		byMonth[month.Month] = month
+		periods[vocabulary.MonthPeriod(month.Month)] = rules.Derive(month.Metrics)
+	}
+
+	northern := IsNorthern(months)
+	for _, season := range vocabulary.Seasons {
+		seasonMonths := []MonthlyMetrics{}
+		for _, month := range SeasonMonths(season, northern) {
+			if metrics, ok := byMonth[month]; ok {
+				seasonMonths = append(seasonMonths, metrics)
+			}
+		}
+		if len(seasonMonths) == len(northernSeasons[season]) {
+			periods[season] = rules.Derive(Aggregate(seasonMonths))
+		}
+	}
+	return periods
+}
@@ -0,0 +1,188 @@
+package tagging
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+
+	"city-tags-api/internal/database"
+	"city-tags-api/internal/vocabulary"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed rules.yaml
+var defaultRules []byte
+
+type ValueRule struct {
+	Value string   `yaml:"value"`
+	Min   *float64 `yaml:"min"`
+	Max   *float64 `yaml:"max"`
+}
+
+type CategoryRule struct {
+	Category string      `yaml:"category"`
+	Column   string      `yaml:"column"`
+	Metric   string      `yaml:"metric"`
+	Values   []ValueRule `yaml:"values"`
+}
+
+type Rules struct {
+	Categories []CategoryRule `yaml:"categories"`
+}
+
+// DefaultRules returns the rules embedded in the binary.
+func DefaultRules() (*Rules, error) {
+	return ParseRules(defaultRules)
+}
+
+// LoadRules reads the rules from a YAML file, an empty path returns the
+// default ones.
+func LoadRules(path string) (*Rules, error) {
+	if path == "" {
+		return DefaultRules()
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := ParseRules(content)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return rules, nil
+}
+
+// LoadDBRules reads the rules from the thresholds of city_tags.tag_values,
+// which are the ones shown by the API.
+func LoadDBRules(db database.Service) (*Rules, error) {
+	rows, err := db.Query(
+		`select c.category, c.column_name, c.metric, v.value, v.min_threshold::float8, v.max_threshold::float8
+		from city_tags.tag_categories c
+		join city_tags.tag_values v on v.category = c.category
+		order by c.category, v.rank`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := &Rules{}
+	for rows.Next() {
+		var category CategoryRule
+		var value ValueRule
+		if err := rows.Scan(&category.Category, &category.Column, &category.Metric, &value.Value, &value.Min, &value.Max); err != nil {
+			return nil, err
+		}
+		last := len(rules.Categories) - 1
+		if last < 0 || rules.Categories[last].Category != category.Category {
+			rules.Categories = append(rules.Categories, category)
+			last++
+		}
+		rules.Categories[last].Values = append(rules.Categories[last].Values, value)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := rules.validate(); err != nil {
+		return nil, fmt.Errorf("city_tags.tag_values: %v", err)
+	}
+	return rules, nil
+}
+
+func ParseRules(content []byte) (*Rules, error) {
+	rules := &Rules{}
+	if err := yaml.Unmarshal(content, rules); err != nil {
+		return nil, err
+	}
+	if err := rules.validate(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// validate checks that there is a rule for every category of the vocabulary
+// and that the ranges of each one cover the whole real line without gaps nor
+// overlaps, so every metric value derives exactly one tag.
+func (rules *Rules) validate() error {
+	seen := map[string]bool{}
+	for _, rule := range rules.Categories {
+		category, ok := vocabulary.ByName(rule.Category)
+		if !ok {
+			return fmt.Errorf("unknown category %q", rule.Category)
+		}
+		if seen[rule.Category] {
+			return fmt.Errorf("category %q is defined more than once", rule.Category)
+		}
+		seen[rule.Category] = true
+
+		if rule.Column != category.Column {
+			return fmt.Errorf("category %q must use column %q, got %q", rule.Category, category.Column, rule.Column)
+		}
+		if !isMetric(rule.Metric) {
+			return fmt.Errorf("category %q uses unknown metric %q", rule.Category, rule.Metric)
+		}
+		if err := rule.validateValues(category); err != nil {
+			return fmt.Errorf("category %q: %v", rule.Category, err)
+		}
+	}
+
+	for _, category := range vocabulary.Categories {
+		if !seen[category.Name] {
+			return fmt.Errorf("missing rules for category %q", category.Name)
+		}
+	}
+	return nil
+}
+
+func (rule CategoryRule) validateValues(category vocabulary.Category) error {
+	if len(rule.Values) == 0 {
+		return fmt.Errorf("no values defined")
+	}
+
+	for index, value := range rule.Values {
+		if category.Rank(value.Value) < 0 {
+			return fmt.Errorf("unknown value %q", value.Value)
+		}
+
+		if index == 0 {
+			if value.Min != nil {
+				return fmt.Errorf("value %q is the lowest one and can't have min", value.Value)
+			}
+		} else {
+			previous := rule.Values[index-1]
+			if value.Min == nil || previous.Max == nil || *value.Min != *previous.Max {
+				return fmt.Errorf("min of %q must be equal to max of %q", value.Value, previous.Value)
+			}
+		}
+
+		if index == len(rule.Values)-1 {
+			if value.Max != nil {
+				return fmt.Errorf("value %q is the highest one and can't have max", value.Value)
+			}
+		} else if value.Max == nil || (value.Min != nil && *value.Max <= *value.Min) {
+			return fmt.Errorf("value %q must have a max greater than its min", value.Value)
+		}
+	}
+	return nil
+}
+
+// Derive returns the tags for the given metrics keyed by tag column.
+func (rules *Rules) Derive(metrics Metrics) map[string]string {
+	tags := make(map[string]string, len(rules.Categories))
+	for _, rule := range rules.Categories {
+		tags[rule.Column] = rule.derive(metrics.value(rule.Metric))
+	}
+	return tags
+}
+
+func (rule CategoryRule) derive(metric float64) string {
+	for _, value := range rule.Values {
+		if value.Max == nil || metric < *value.Max {
+			return value.Value
+		}
+	}
+	// Unreachable for validated rules, the last value is unbounded.
+	return rule.Values[len(rule.Values)-1].Value
+}
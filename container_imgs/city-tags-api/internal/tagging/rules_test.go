@@ -0,0 +1,424 @@
+package tagging
+
+import (
+	"encoding/csv"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const (
+	deriveFixture       = "testdata/derive.csv"
+	vocabularyMigration = "../../migrations/20261019140000_create_tag_vocabulary.sql"
+	cityTagsFixture     = "../../integration_tests/init_db/test_data/city_tags.csv"
+	periodTagsFixture   = "../../integration_tests/init_db/test_data/city_period_tags.csv"
+)
+
+func TestDefaultRules(t *testing.T) {
+	rules, err := DefaultRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules.Categories) != 7 {
+		t.Errorf("DefaultRules returned %d categories; want 7", len(rules.Categories))
+	}
+}
+
+func TestDerive(t *testing.T) {
+	rules, err := DefaultRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		metrics  Metrics
+		expected map[string]string
+	}{
+		{
+			"lower bounds are inclusive",
+			Metrics{
+				MeanTemperature:  10,
+				RelativeHumidity: 40,
+				Precipitation:    80,
+				CloudCover:       20,
+				Aqi:              50,
+				Daylight:         12,
+				Population:       100000,
+			},
+			map[string]string{
+				"temp_tag":           "mild",
+				"humidity_tag":       "moderate",
+				"precipitation_tag":  "moderately wet",
+				"cloud_coverage_tag": "partly cloudy",
+				"air_quality_tag":    "moderate",
+				"daylight_hours_tag": "high",
+				"city_size_tag":      "medium",
+			},
+		},
+		{
+			"lower bounds of the highest values",
+			Metrics{
+				MeanTemperature:  25,
+				RelativeHumidity: 80,
+				Precipitation:    200,
+				CloudCover:       80,
+				Aqi:              300,
+				Daylight:         12,
+				Population:       5000000,
+			},
+			map[string]string{
+				"temp_tag":           "hot",
+				"humidity_tag":       "very humid",
+				"precipitation_tag":  "very wet",
+				"cloud_coverage_tag": "overcast",
+				"air_quality_tag":    "hazardous",
+				"daylight_hours_tag": "high",
+				"city_size_tag":      "very big",
+			},
+		},
+		{
+			"unbounded extremes",
+			Metrics{
+				MeanTemperature:  -30,
+				RelativeHumidity: 100,
+				Precipitation:    0,
+				CloudCover:       100,
+				Aqi:              500,
+				Daylight:         0,
+				Population:       20000000,
+			},
+			map[string]string{
+				"temp_tag":           "very cold",
+				"humidity_tag":       "very humid",
+				"precipitation_tag":  "dry",
+				"cloud_coverage_tag": "overcast",
+				"air_quality_tag":    "hazardous",
+				"daylight_hours_tag": "low",
+				"city_size_tag":      "very big",
+			},
+		},
+		{
+			"just below upper bounds",
+			Metrics{
+				MeanTemperature:  24.99,
+				RelativeHumidity: 79.9,
+				Precipitation:    199.9,
+				CloudCover:       49.9,
+				Aqi:              299,
+				Daylight:         11.9,
+				Population:       999999,
+			},
+			map[string]string{
+				"temp_tag":           "warm",
+				"humidity_tag":       "humid",
+				"precipitation_tag":  "wet",
+				"cloud_coverage_tag": "partly cloudy",
+				"air_quality_tag":    "very unhealthy",
+				"daylight_hours_tag": "moderate",
+				"city_size_tag":      "medium",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tags := rules.Derive(tt.metrics)
+			for column, expected := range tt.expected {
+				if tags[column] != expected {
+					t.Errorf("Derive(%v)[%s] = %q; want %q", tt.metrics, column, tags[column], expected)
+				}
+			}
+		})
+	}
+}
+
+// TestDeriveFixtures derives the tags of the raw metrics of real cities, the
+// bounds of the ranges are covered by TestDerive.
+func TestDeriveFixtures(t *testing.T) {
+	rules, err := DefaultRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := os.Open(deriveFixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := records[0]
+	metricColumns := 8
+	for _, record := range records[1:] {
+		name := record[0]
+		metrics := Metrics{}
+		for index := 1; index < metricColumns; index++ {
+			value, err := strconv.ParseFloat(record[index], 64)
+			if err != nil {
+				t.Fatalf("%s: invalid %s: %v", name, header[index], err)
+			}
+			setMetric(&metrics, header[index], value)
+		}
+
+		tags := rules.Derive(metrics)
+		for index := metricColumns; index < len(header); index++ {
+			if tags[header[index]] != record[index] {
+				t.Errorf("%s: derived %s %q; want %q", name, header[index], tags[header[index]], record[index])
+			}
+		}
+	}
+}
+
+// TestFixtureTagsDerivable checks that the tags seeded for the integration tests
+// are values the rules can derive for their column.
+func TestFixtureTagsDerivable(t *testing.T) {
+	rules, err := DefaultRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := map[string]map[string]bool{}
+	for _, rule := range rules.Categories {
+		values[rule.Column] = map[string]bool{}
+		for _, value := range rule.Values {
+			values[rule.Column][value.Value] = true
+		}
+	}
+
+	for _, fixture := range []string{cityTagsFixture, periodTagsFixture} {
+		file, err := os.Open(fixture)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+
+		records, err := csv.NewReader(file).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		header := records[0]
+		for _, record := range records[1:] {
+			for index, column := range header {
+				if !strings.HasSuffix(column, "_tag") {
+					continue
+				}
+				if _, ok := values[column]; !ok {
+					t.Fatalf("%s: no rules for column %s", fixture, column)
+				}
+				if !values[column][record[index]] {
+					t.Errorf("%s: city %s has %s %q, which the rules don't derive", fixture, record[0], column, record[index])
+				}
+			}
+		}
+	}
+}
+
+func setMetric(metrics *Metrics, name string, value float64) {
+	switch name {
+	case "mean_temperature":
+		metrics.MeanTemperature = value
+	case "relative_humidity":
+		metrics.RelativeHumidity = value
+	case "precipitation":
+		metrics.Precipitation = value
+	case "cloud_cover":
+		metrics.CloudCover = value
+	case "aqi":
+		metrics.Aqi = value
+	case "daylight":
+		metrics.Daylight = value
+	case "population":
+		metrics.Population = value
+	}
+}
+
+// seedRules parses the rules of the seed of city_tags.tag_values.
+func seedRules(t *testing.T) map[string]CategoryRule {
+	content, err := os.ReadFile(vocabularyMigration)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sql := string(content)
+	section := func(table string) string {
+		start := strings.Index(sql, "INSERT INTO "+table+" ")
+		if start < 0 {
+			t.Fatalf("no seed of %s", table)
+		}
+		return sql[start : start+strings.Index(sql[start:], ";")]
+	}
+
+	rules := map[string]CategoryRule{}
+	categoryRow := regexp.MustCompile(`\('(\w+)', '[^']*', '(\w+)', '(\w+)', '[^']*'\)`)
+	for _, match := range categoryRow.FindAllStringSubmatch(section("city_tags.tag_categories"), -1) {
+		rules[match[1]] = CategoryRule{Category: match[1], Column: match[2], Metric: match[3]}
+	}
+
+	threshold := func(value string) *float64 {
+		if value == "NULL" {
+			return nil
+		}
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &parsed
+	}
+	valueRow := regexp.MustCompile(`\('(\w+)', '([^']+)', \d+, '[^']*', (NULL|[\d.-]+), (NULL|[\d.-]+)\)`)
+	// The rows of each category are seeded by rank.
+	for _, match := range valueRow.FindAllStringSubmatch(section("city_tags.tag_values"), -1) {
+		rule := rules[match[1]]
+		rule.Values = append(rule.Values, ValueRule{Value: match[2], Min: threshold(match[3]), Max: threshold(match[4])})
+		rules[match[1]] = rule
+	}
+	return rules
+}
+
+func TestRulesMatchTagValuesSeed(t *testing.T) {
+	rules, err := DefaultRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seed := seedRules(t)
+	if len(seed) != len(rules.Categories) {
+		t.Fatalf("the seed has %d categories; rules.yaml has %d", len(seed), len(rules.Categories))
+	}
+	for _, rule := range rules.Categories {
+		if !reflect.DeepEqual(rule, seed[rule.Category]) {
+			t.Errorf("rules.yaml has %s %+v; the seed of city_tags.tag_values has %+v", rule.Category, rule, seed[rule.Category])
+		}
+	}
+}
+
+func TestParseRulesInvalid(t *testing.T) {
+	valid := string(defaultRules)
+
+	tests := []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{
+			"unknown category",
+			strings.Replace(valid, "category: city_size", "category: city_mood", 1),
+			`unknown category "city_mood"`,
+		},
+		{
+			"wrong column",
+			strings.Replace(valid, "column: temp_tag", "column: temperature_tag", 1),
+			`must use column "temp_tag"`,
+		},
+		{
+			"unknown metric",
+			strings.Replace(valid, "metric: aqi", "metric: pm25", 1),
+			`unknown metric "pm25"`,
+		},
+		{
+			"unknown value",
+			strings.Replace(valid, "value: hot", "value: scorching", 1),
+			`unknown value "scorching"`,
+		},
+		{
+			"gap between values",
+			strings.Replace(valid, "min: 10\n        max: 18", "min: 11\n        max: 18", 1),
+			`min of "mild" must be equal to max of "cold"`,
+		},
+		{
+			"bounded highest value",
+			strings.Replace(valid, "min: 5000000", "min: 5000000\n        max: 50000000", 1),
+			`"very big" is the highest one and can't have max`,
+		},
+		{
+			"missing category",
+			valid[:strings.Index(valid, "  - category: city_size")],
+			`missing rules for category "city_size"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseRules([]byte(tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("ParseRules() error = %v; want it to contain %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	months := []MonthlyMetrics{
+		{Month: 1, Metrics: Metrics{MeanTemperature: -2, Precipitation: 30, Population: 1000}},
+		{Month: 7, Metrics: Metrics{MeanTemperature: 22, Precipitation: 90, Population: 1000}},
+	}
+
+	aggregated := Aggregate(months)
+	if aggregated.MeanTemperature != 10 || aggregated.Precipitation != 60 || aggregated.Population != 1000 {
+		t.Errorf("Aggregate() = %v; want mean temperature 10, precipitation 60 and population 1000", aggregated)
+	}
+	if (Aggregate(nil) != Metrics{}) {
+		t.Errorf("Aggregate(nil) = %v; want zero metrics", Aggregate(nil))
+	}
+}
+
+func TestSeasonMonths(t *testing.T) {
+	tests := []struct {
+		season   string
+		northern bool
+		expected []int
+	}{
+		{"winter", true, []int{12, 1, 2}},
+		{"winter", false, []int{6, 7, 8}},
+		{"summer", false, []int{12, 1, 2}},
+		{"autumn", false, []int{3, 4, 5}},
+	}
+
+	for _, tt := range tests {
+		months := SeasonMonths(tt.season, tt.northern)
+		if !reflect.DeepEqual(months, tt.expected) {
+			t.Errorf("SeasonMonths(%s, %t) = %v; want %v", tt.season, tt.northern, months, tt.expected)
+		}
+	}
+}
+
+func TestDerivePeriods(t *testing.T) {
+	rules, err := DefaultRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A southern hemisphere city, hot with long days around January.
+	months := make([]MonthlyMetrics, 0, 12)
+	for month := 1; month <= 12; month++ {
+		metrics := Metrics{MeanTemperature: 5, Daylight: 10}
+		if month <= 2 || month == 12 {
+			metrics = Metrics{MeanTemperature: 28, Daylight: 14}
+		}
+		months = append(months, MonthlyMetrics{Month: month, Metrics: metrics})
+	}
+
+	if IsNorthern(months) {
+		t.Fatalf("IsNorthern() = true; want false")
+	}
+
+	periods := rules.DerivePeriods(months)
+	if len(periods) != 16 {
+		t.Errorf("DerivePeriods() returned %d periods; want 16", len(periods))
+	}
+	expected := map[string]string{"1": "hot", "7": "cold", "summer": "hot", "winter": "cold"}
+	for period, temperature := range expected {
+		if periods[period]["temp_tag"] != temperature {
+			t.Errorf("DerivePeriods()[%s][temp_tag] = %q; want %q", period, periods[period]["temp_tag"], temperature)
+		}
+	}
+
+	partial := rules.DerivePeriods(months[:6])
+	if _, ok := partial["winter"]; ok {
+		t.Errorf("DerivePeriods() derived winter without all its months")
+	}
+}
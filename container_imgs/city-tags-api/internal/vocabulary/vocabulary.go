@@ -0,0 +1,85 @@
+package vocabulary
+
+import "strconv"
+
+type Category struct {
+	Name   string
+	Column string
+	Values []string
+}
+
+// Categories lists the allowed values of every tag category, ordered from the
+// lowest to the highest rank. It mirrors city_tags.tag_values, which enforces
+// them in the database, so requests can be validated before reaching it, and
+// TestCategoriesMatchTagValues fails when a migration changes one without the
+// other.
+var Categories = []Category{
+	{
+		Name:   "cloud_coverage",
+		Column: "cloud_coverage_tag",
+		Values: []string{"clear", "partly cloudy", "cloudy", "overcast"},
+	},
+	{
+		Name:   "humidity",
+		Column: "humidity_tag",
+		Values: []string{"dry", "moderate", "humid", "very humid"},
+	},
+	{
+		Name:   "temperature",
+		Column: "temp_tag",
+		Values: []string{"very cold", "cold", "mild", "warm", "hot"},
+	},
+	{
+		Name:   "precipitation",
+		Column: "precipitation_tag",
+		Values: []string{"dry", "moderate", "moderately wet", "wet", "very wet"},
+	},
+	{
+		Name:   "air_quality",
+		Column: "air_quality_tag",
+		Values: []string{"good", "moderate", "unhealthy", "very unhealthy", "hazardous"},
+	},
+	{
+		Name:   "daylight_hours",
+		Column: "daylight_hours_tag",
+		Values: []string{"low", "moderate", "high"},
+	},
+	{
+		Name:   "city_size",
+		Column: "city_size_tag",
+		Values: []string{"small", "medium", "big", "very big"},
+	},
+}
+
+func ByName(name string) (Category, bool) {
+	for _, category := range Categories {
+		if category.Name == name {
+			return category, true
+		}
+	}
+	return Category{}, false
+}
+
+// Rank returns the position of value within the category, or -1 if the value
+// is not part of the vocabulary.
+func (category Category) Rank(value string) int {
+	for index, allowed := range category.Values {
+		if allowed == value {
+			return index
+		}
+	}
+	return -1
+}
+
+func IsAllowed(name string, value string) bool {
+	category, ok := ByName(name)
+	return ok && category.Rank(value) >= 0
+}
+
+// Seasons are the periods tags can be requested for besides the months, which
+// are identified by their number from "1" to "12".
+var Seasons = []string{"winter", "spring", "summer", "autumn"}
+
+func MonthPeriod(month int) string {
+	return strconv.Itoa(month)
+}
@@ -0,0 +1,75 @@
+package vocabulary
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// migratedCategories returns the categories and values inserted by the up
+// migrations into city_tags.tag_categories and city_tags.tag_values, keyed by
+// category and with the values ordered by rank.
+func migratedCategories(t *testing.T) map[string]Category {
+	files, err := filepath.Glob("../../migrations/*.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	categoryRow := regexp.MustCompile(`\('(\w+)', '[^']*', '(\w+)', '\w+', '[^']*'\)`)
+	valueRow := regexp.MustCompile(`\('(\w+)', '([^']+)', (\d+), '[^']*', (?:NULL|[\d.-]+), (?:NULL|[\d.-]+)\)`)
+	categories := map[string]Category{}
+	ranks := map[string]map[int]string{}
+	// Files are globbed in the order they are applied.
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		up, _, _ := strings.Cut(string(content), "-- +goose Down")
+		for _, statement := range strings.Split(up, ";") {
+			switch {
+			case strings.Contains(statement, "INSERT INTO city_tags.tag_categories "):
+				for _, match := range categoryRow.FindAllStringSubmatch(statement, -1) {
+					categories[match[1]] = Category{Name: match[1], Column: match[2]}
+					ranks[match[1]] = map[int]string{}
+				}
+			case strings.Contains(statement, "INSERT INTO city_tags.tag_values "):
+				for _, match := range valueRow.FindAllStringSubmatch(statement, -1) {
+					rank, err := strconv.Atoi(match[3])
+					if err != nil {
+						t.Fatal(err)
+					}
+					ranks[match[1]][rank] = match[2]
+				}
+			}
+		}
+	}
+
+	for name, category := range categories {
+		for rank := range len(ranks[name]) {
+			value, ok := ranks[name][rank]
+			if !ok {
+				t.Fatalf("the values of %s skip rank %d", name, rank)
+			}
+			category.Values = append(category.Values, value)
+		}
+		categories[name] = category
+	}
+	return categories
+}
+
+func TestCategoriesMatchTagValues(t *testing.T) {
+	migrated := migratedCategories(t)
+	if len(migrated) != len(Categories) {
+		t.Errorf("the migrations have %d categories, Categories has %d", len(migrated), len(Categories))
+	}
+	for _, category := range Categories {
+		if expected := migrated[category.Name]; !reflect.DeepEqual(category, expected) {
+			t.Errorf("Categories has %+v, the migrations insert %+v", category, expected)
+		}
+	}
+}
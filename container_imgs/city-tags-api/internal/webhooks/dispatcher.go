@@ -0,0 +1,188 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type Config struct {
+	// PollInterval is the time between two checks of the outbox and the due
+	// deliveries.
+	PollInterval time.Duration
+	BatchSize    int
+	// MaxAttempts is the number of attempts of a delivery before it is dead.
+	MaxAttempts int
+	// The n-th retry waits BaseBackoff * 2^(n-1), up to MaxBackoff.
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// Timeout of each attempt.
+	Timeout time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		PollInterval: 5 * time.Second,
+		BatchSize:    50,
+		MaxAttempts:  10,
+		BaseBackoff:  30 * time.Second,
+		MaxBackoff:   6 * time.Hour,
+		Timeout:      10 * time.Second,
+	}
+}
+
+// Dispatcher fans out the events of the outbox and sends the due deliveries.
+// Several dispatchers can share a store, each delivery is claimed by one of
+// them at a time.
+type Dispatcher struct {
+	store      Store
+	cfg        Config
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+func NewDispatcher(store Store, cfg Config) *Dispatcher {
+	return &Dispatcher{
+		store:      store,
+		cfg:        cfg,
+		httpClient: &http.Client{Timeout: cfg.Timeout},
+		now:        time.Now,
+	}
+}
+
+// Run dispatches every PollInterval until ctx is cancelled.
+func (dispatcher *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(dispatcher.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		if err := dispatcher.Dispatch(ctx); err != nil {
+			log.Printf("Error dispatching webhooks: %s", err.Error())
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Dispatch fans out the pending events and sends the due deliveries, batch by
+// batch until none is left.
+func (dispatcher *Dispatcher) Dispatch(ctx context.Context) error {
+	for {
+		dispatched, err := dispatcher.store.FanOut(dispatcher.cfg.BatchSize)
+		if err != nil {
+			return err
+		}
+		if dispatched < dispatcher.cfg.BatchSize {
+			break
+		}
+	}
+
+	// Deliveries are leased for longer than an attempt can take so that
+	// another dispatcher does not send them at the same time.
+	lease := 2*dispatcher.cfg.Timeout + time.Minute
+	for ctx.Err() == nil {
+		attempts, err := dispatcher.store.ClaimDeliveries(dispatcher.cfg.BatchSize, lease)
+		if err != nil {
+			return err
+		}
+
+		var wg sync.WaitGroup
+		for _, attempt := range attempts {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				delivery := dispatcher.send(ctx, attempt)
+				if err := dispatcher.store.CompleteDelivery(delivery); err != nil {
+					log.Printf("Error completing webhook delivery %d: %s", delivery.DeliveryId, err.Error())
+				}
+			}()
+		}
+		wg.Wait()
+
+		if len(attempts) < dispatcher.cfg.BatchSize {
+			return nil
+		}
+	}
+	return ctx.Err()
+}
+
+// backoff returns the wait before the retry following the given number of
+// attempts.
+func (dispatcher *Dispatcher) backoff(attempts int) time.Duration {
+	wait := dispatcher.cfg.BaseBackoff
+	for i := 1; i < attempts && wait < dispatcher.cfg.MaxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, dispatcher.cfg.MaxBackoff)
+}
+
+// send makes an attempt of the delivery and returns it updated with the
+// outcome.
+func (dispatcher *Dispatcher) send(ctx context.Context, attempt Attempt) Delivery {
+	delivery := attempt.Delivery
+	delivery.Attempts++
+	statusCode, err := dispatcher.post(ctx, attempt)
+
+	now := dispatcher.now()
+	delivery.LastStatusCode = nil
+	if statusCode != 0 {
+		delivery.LastStatusCode = &statusCode
+	}
+	delivery.LastError = nil
+	delivery.NextAttemptAt = nil
+	switch {
+	case err == nil:
+		delivery.Status = StatusDelivered
+		delivery.DeliveredAt = &now
+	case delivery.Attempts >= dispatcher.cfg.MaxAttempts:
+		message := err.Error()
+		delivery.Status = StatusDead
+		delivery.LastError = &message
+	default:
+		message := err.Error()
+		nextAttemptAt := now.Add(dispatcher.backoff(delivery.Attempts))
+		delivery.Status = StatusPending
+		delivery.LastError = &message
+		delivery.NextAttemptAt = &nextAttemptAt
+	}
+	return delivery
+}
+
+// post sends the event to the subscription and returns the status code of the
+// response, 0 if there is none.
+func (dispatcher *Dispatcher) post(ctx context.Context, attempt Attempt) (int, error) {
+	body, err := json.Marshal(attempt.Event)
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, attempt.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "city-tags-api-webhooks")
+	req.Header.Set(EventHeader, attempt.Event.Type)
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(attempt.Delivery.DeliveryId, 10))
+	req.Header.Set(SignatureHeader, Sign(attempt.Secret, dispatcher.now(), body))
+
+	resp, err := dispatcher.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
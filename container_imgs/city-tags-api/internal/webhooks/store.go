@@ -0,0 +1,294 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"city-tags-api/internal/api_errors"
+	"city-tags-api/internal/database"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Attempt is a delivery claimed by the dispatcher, with what it needs to send
+// it.
+type Attempt struct {
+	Delivery Delivery
+	Url      string
+	Secret   string
+	Event    Event
+}
+
+type Store interface {
+	CreateSubscription(subscription Subscription) (Subscription, error)
+	ListSubscriptions(offset int, limit int) ([]Subscription, error)
+	GetSubscription(subscriptionId int64) (Subscription, error)
+	DeleteSubscription(subscriptionId int64) error
+	// ListDeliveries returns the deliveries of a subscription, most recent
+	// first, only the ones with status if it is not empty.
+	ListDeliveries(subscriptionId int64, status string, offset int, limit int) ([]Delivery, error)
+	// ReplayDelivery makes a delivery pending again, with every attempt
+	// available, whatever its status.
+	ReplayDelivery(deliveryId int64) (Delivery, error)
+
+	// FanOut creates the deliveries of up to limit events of the outbox and
+	// returns the number of events dispatched.
+	FanOut(limit int) (int, error)
+	// ClaimDeliveries returns up to limit due deliveries and postpones them by
+	// lease, so that other dispatchers do not claim them while they are sent.
+	ClaimDeliveries(limit int, lease time.Duration) ([]Attempt, error)
+	// CompleteDelivery stores the status and attempts of a delivery.
+	CompleteDelivery(delivery Delivery) error
+}
+
+// Enqueue writes event in the outbox in tx, so that it is only delivered if
+// the change is committed.
+func Enqueue(tx pgx.Tx, event Event) error {
+	payload, err := json.Marshal(event.Data)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(
+		context.Background(),
+		`insert into city_tags.webhook_outbox (event_type, city_id, country_3_code, payload)
+		values ($1, $2, $3, $4)`,
+		event.Type, event.CityId, event.Country3Code, payload,
+	)
+	return err
+}
+
+type dbStore struct {
+	db database.Service
+}
+
+func NewStore(db database.Service) Store {
+	return &dbStore{db: db}
+}
+
+const subscriptionColumns = "subscription_id, url, event_types, countries, created_at"
+
+func scanSubscription(row pgx.Row) (Subscription, error) {
+	subscription := Subscription{}
+	err := row.Scan(
+		&subscription.SubscriptionId,
+		&subscription.Url,
+		&subscription.EventTypes,
+		&subscription.Countries,
+		&subscription.CreatedAt,
+	)
+	return subscription, err
+}
+
+func (store *dbStore) CreateSubscription(subscription Subscription) (Subscription, error) {
+	rows, err := store.db.Query(
+		`insert into city_tags.webhook_subscriptions (url, secret, event_types, countries)
+		values ($1, $2, $3, $4) returning `+subscriptionColumns,
+		subscription.Url, subscription.Secret, subscription.EventTypes, subscription.Countries,
+	)
+	if err != nil {
+		return Subscription{}, err
+	}
+	created, err := pgx.CollectExactlyOneRow(rows, func(row pgx.CollectableRow) (Subscription, error) {
+		return scanSubscription(row)
+	})
+	created.Secret = subscription.Secret
+	return created, err
+}
+
+func (store *dbStore) ListSubscriptions(offset int, limit int) ([]Subscription, error) {
+	rows, err := store.db.Query(
+		`select `+subscriptionColumns+` from city_tags.webhook_subscriptions
+		order by subscription_id limit $1 offset $2`,
+		limit, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (Subscription, error) {
+		return scanSubscription(row)
+	})
+}
+
+func (store *dbStore) GetSubscription(subscriptionId int64) (Subscription, error) {
+	rows, err := store.db.Query(
+		`select `+subscriptionColumns+` from city_tags.webhook_subscriptions where subscription_id = $1`,
+		subscriptionId,
+	)
+	if err != nil {
+		return Subscription{}, err
+	}
+	subscription, err := pgx.CollectExactlyOneRow(rows, func(row pgx.CollectableRow) (Subscription, error) {
+		return scanSubscription(row)
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Subscription{}, &api_errors.SubscriptionNotFoundErr
+	}
+	return subscription, err
+}
+
+func (store *dbStore) DeleteSubscription(subscriptionId int64) error {
+	tag, err := store.db.Exec("delete from city_tags.webhook_subscriptions where subscription_id = $1", subscriptionId)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return &api_errors.SubscriptionNotFoundErr
+	}
+	return nil
+}
+
+const deliveryColumns = `d.delivery_id, d.subscription_id, d.event_id, o.event_type, d.status, d.attempts,
+	d.next_attempt_at, d.last_status_code, d.last_error, d.created_at, d.delivered_at`
+
+func scanDelivery(row pgx.Row) (Delivery, error) {
+	delivery := Delivery{}
+	err := row.Scan(
+		&delivery.DeliveryId,
+		&delivery.SubscriptionId,
+		&delivery.EventId,
+		&delivery.EventType,
+		&delivery.Status,
+		&delivery.Attempts,
+		&delivery.NextAttemptAt,
+		&delivery.LastStatusCode,
+		&delivery.LastError,
+		&delivery.CreatedAt,
+		&delivery.DeliveredAt,
+	)
+	return delivery, err
+}
+
+func (store *dbStore) ListDeliveries(subscriptionId int64, status string, offset int, limit int) ([]Delivery, error) {
+	rows, err := store.db.Query(
+		`select `+deliveryColumns+` from city_tags.webhook_deliveries d
+		join city_tags.webhook_outbox o on o.event_id = d.event_id
+		where d.subscription_id = $1 and ($2 = '' or d.status = $2)
+		order by d.delivery_id desc limit $3 offset $4`,
+		subscriptionId, status, limit, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (Delivery, error) {
+		return scanDelivery(row)
+	})
+}
+
+func (store *dbStore) ReplayDelivery(deliveryId int64) (Delivery, error) {
+	rows, err := store.db.Query(
+		`with replayed as (
+			update city_tags.webhook_deliveries
+			set status = 'pending', attempts = 0, next_attempt_at = now(), delivered_at = null
+			where delivery_id = $1
+			returning *
+		)
+		select `+deliveryColumns+` from replayed d
+		join city_tags.webhook_outbox o on o.event_id = d.event_id`,
+		deliveryId,
+	)
+	if err != nil {
+		return Delivery{}, err
+	}
+	delivery, err := pgx.CollectExactlyOneRow(rows, func(row pgx.CollectableRow) (Delivery, error) {
+		return scanDelivery(row)
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Delivery{}, &api_errors.DeliveryNotFoundErr
+	}
+	return delivery, err
+}
+
+// FanOut locks the events it dispatches, so that concurrent dispatchers skip
+// them instead of creating their deliveries twice.
+func (store *dbStore) FanOut(limit int) (int, error) {
+	tag, err := store.db.Exec(
+		`with events as (
+			select event_id, event_type, country_3_code from city_tags.webhook_outbox
+			where dispatched_at is null
+			order by event_id limit $1
+			for update skip locked
+		), deliveries as (
+			insert into city_tags.webhook_deliveries (subscription_id, event_id)
+			select s.subscription_id, e.event_id from events e
+			join city_tags.webhook_subscriptions s on e.event_type = any(s.event_types)
+				and (cardinality(s.countries) = 0 or e.country_3_code = any(s.countries))
+			on conflict (subscription_id, event_id) do nothing
+		)
+		update city_tags.webhook_outbox set dispatched_at = now()
+		where event_id in (select event_id from events)`,
+		limit,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+func (store *dbStore) ClaimDeliveries(limit int, lease time.Duration) ([]Attempt, error) {
+	rows, err := store.db.Query(
+		`with claimed as (
+			update city_tags.webhook_deliveries set next_attempt_at = now() + make_interval(secs => $2)
+			where delivery_id in (
+				select delivery_id from city_tags.webhook_deliveries
+				where status = 'pending' and next_attempt_at <= now()
+				order by next_attempt_at limit $1
+				for update skip locked
+			)
+			returning *
+		)
+		select `+deliveryColumns+`, s.url, s.secret, o.city_id, o.country_3_code, o.created_at, o.payload
+		from claimed d
+		join city_tags.webhook_subscriptions s on s.subscription_id = d.subscription_id
+		join city_tags.webhook_outbox o on o.event_id = d.event_id`,
+		limit, lease.Seconds(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (Attempt, error) {
+		attempt := Attempt{}
+		delivery := &attempt.Delivery
+		var payload json.RawMessage
+		err := row.Scan(
+			&delivery.DeliveryId,
+			&delivery.SubscriptionId,
+			&delivery.EventId,
+			&delivery.EventType,
+			&delivery.Status,
+			&delivery.Attempts,
+			&delivery.NextAttemptAt,
+			&delivery.LastStatusCode,
+			&delivery.LastError,
+			&delivery.CreatedAt,
+			&delivery.DeliveredAt,
+			&attempt.Url,
+			&attempt.Secret,
+			&attempt.Event.CityId,
+			&attempt.Event.Country3Code,
+			&attempt.Event.OccurredAt,
+			&payload,
+		)
+		attempt.Event.EventId = delivery.EventId
+		attempt.Event.Type = delivery.EventType
+		attempt.Event.Data = payload
+		return attempt, err
+	})
+}
+
+func (store *dbStore) CompleteDelivery(delivery Delivery) error {
+	_, err := store.db.Exec(
+		`update city_tags.webhook_deliveries
+		set status = $2, attempts = $3, next_attempt_at = $4, last_status_code = $5, last_error = $6, delivered_at = $7
+		where delivery_id = $1`,
+		delivery.DeliveryId,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.NextAttemptAt,
+		delivery.LastStatusCode,
+		delivery.LastError,
+		delivery.DeliveredAt,
+	)
+	return err
+}
@@ -0,0 +1,138 @@
+// Package webhooks notifies partners of the changes of the cities and their
+// tags. The admin writes enqueue events in an outbox table, in their own
+// transaction, and the Dispatcher delivers them to the matching subscriptions
+// as HMAC signed POST requests, retrying them with exponential backoff.
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	EventCityCreated = "city.created"
+	EventCityUpdated = "city.updated"
+	EventCityDeleted = "city.deleted"
+	EventTagsUpdated = "tags.updated"
+	EventTagsDeleted = "tags.deleted"
+)
+
+// EventTypes are the types of events a subscription can receive.
+var EventTypes = []string{EventCityCreated, EventCityUpdated, EventCityDeleted, EventTagsUpdated, EventTagsDeleted}
+
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusDead      = "dead"
+)
+
+// Statuses are the statuses of a delivery.
+var Statuses = []string{StatusPending, StatusDelivered, StatusDead}
+
+// Headers of the deliveries.
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+)
+
+// Event is the body of the deliveries. Data is the city or tags after the
+// change, or before it when they are deleted.
+type Event struct {
+	EventId      int64     `json:"event_id"`
+	Type         string    `json:"type"`
+	CityId       int       `json:"city_id"`
+	Country3Code string    `json:"country_3_code"`
+	OccurredAt   time.Time `json:"occurred_at"`
+	Data         any       `json:"data" swaggertype:"object"`
+}
+
+type Subscription struct {
+	SubscriptionId int64    `json:"subscription_id"`
+	Url            string   `json:"url"`
+	EventTypes     []string `json:"event_types"`
+	Countries      []string `json:"countries"`
+	// Secret is only returned when the subscription is created.
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Matches returns whether the subscription receives event.
+func (subscription Subscription) Matches(event Event) bool {
+	if !slices.Contains(subscription.EventTypes, event.Type) {
+		return false
+	}
+	return len(subscription.Countries) == 0 || slices.Contains(subscription.Countries, event.Country3Code)
+}
+
+type Delivery struct {
+	DeliveryId     int64      `json:"delivery_id"`
+	SubscriptionId int64      `json:"subscription_id"`
+	EventId        int64      `json:"event_id"`
+	EventType      string     `json:"event_type"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at"`
+	LastStatusCode *int       `json:"last_status_code"`
+	LastError      *string    `json:"last_error"`
+	CreatedAt      time.Time  `json:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+}
+
+// Sign returns the value of the signature header of a delivery of body sent
+// at timestamp: "t=<unix timestamp>,v1=<hex HMAC-SHA256 of timestamp.body>".
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	unix := strconv.FormatInt(timestamp.Unix(), 10)
+	return fmt.Sprintf("t=%s,v1=%s", unix, hex.EncodeToString(mac(secret, unix, body)))
+}
+
+func mac(secret string, unix string, body []byte) []byte {
+	hash := hmac.New(sha256.New, []byte(secret))
+	hash.Write([]byte(unix))
+	hash.Write([]byte("."))
+	hash.Write(body)
+	return hash.Sum(nil)
+}
+
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// Verify checks the signature header of a delivery received at now, rejecting
+// the ones signed more than tolerance ago so they cannot be replayed. It is
+// meant for the receivers.
+func Verify(secret string, header string, body []byte, now time.Time, tolerance time.Duration) error {
+	var unix string
+	var signatures [][]byte
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			unix = value
+		case "v1":
+			if signature, err := hex.DecodeString(value); err == nil {
+				signatures = append(signatures, signature)
+			}
+		}
+	}
+
+	timestamp, err := strconv.ParseInt(unix, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return ErrInvalidSignature
+	}
+	if age := now.Sub(time.Unix(timestamp, 0)); age > tolerance || age < -tolerance {
+		return ErrInvalidSignature
+	}
+	expected := mac(secret, unix, body)
+	for _, signature := range signatures {
+		if hmac.Equal(signature, expected) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
@@ -0,0 +1,213 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryStore implements the dispatcher side of Store, the other methods
+// panic.
+type memoryStore struct {
+	Store
+	mu            sync.Mutex
+	now           func() time.Time
+	subscriptions []Subscription
+	outbox        []Event
+	dispatched    int
+	deliveries    []Delivery
+}
+
+func (store *memoryStore) FanOut(limit int) (int, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	events := store.outbox[store.dispatched:min(store.dispatched+limit, len(store.outbox))]
+	for _, event := range events {
+		for _, subscription := range store.subscriptions {
+			if !subscription.Matches(event) {
+				continue
+			}
+			now := store.now()
+			store.deliveries = append(store.deliveries, Delivery{
+				DeliveryId:     int64(len(store.deliveries) + 1),
+				SubscriptionId: subscription.SubscriptionId,
+				EventId:        event.EventId,
+				EventType:      event.Type,
+				Status:         StatusPending,
+				NextAttemptAt:  &now,
+			})
+		}
+	}
+	store.dispatched += len(events)
+	return len(events), nil
+}
+
+func (store *memoryStore) ClaimDeliveries(limit int, lease time.Duration) ([]Attempt, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	var attempts []Attempt
+	for index := range store.deliveries {
+		delivery := &store.deliveries[index]
+		if len(attempts) == limit || delivery.Status != StatusPending || delivery.NextAttemptAt.After(store.now()) {
+			continue
+		}
+		attempt := Attempt{Delivery: *delivery}
+		leasedUntil := store.now().Add(lease)
+		delivery.NextAttemptAt = &leasedUntil
+		for _, subscription := range store.subscriptions {
+			if subscription.SubscriptionId == delivery.SubscriptionId {
+				attempt.Url, attempt.Secret = subscription.Url, subscription.Secret
+			}
+		}
+		for _, event := range store.outbox {
+			if event.EventId == delivery.EventId {
+				attempt.Event = event
+			}
+		}
+		attempts = append(attempts, attempt)
+	}
+	return attempts, nil
+}
+
+func (store *memoryStore) CompleteDelivery(delivery Delivery) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	store.deliveries[delivery.DeliveryId-1] = delivery
+	return nil
+}
+
+func newTestDispatcher(store *memoryStore) *Dispatcher {
+	cfg := DefaultConfig()
+	cfg.MaxAttempts = 3
+	cfg.BaseBackoff = time.Minute
+	dispatcher := NewDispatcher(store, cfg)
+	dispatcher.now = store.now
+	return dispatcher
+}
+
+func TestSignAndVerify(t *testing.T) {
+	now := time.Unix(1760000000, 0)
+	body := []byte(`{"event_id":1}`)
+	header := Sign("secret", now, body)
+
+	if err := Verify("secret", header, body, now.Add(time.Minute), 5*time.Minute); err != nil {
+		t.Errorf("expected a valid signature, got %v", err)
+	}
+	if err := Verify("other secret", header, body, now, 5*time.Minute); err == nil {
+		t.Errorf("expected a signature with another secret to be invalid")
+	}
+	if err := Verify("secret", header, []byte(`{"event_id":2}`), now, 5*time.Minute); err == nil {
+		t.Errorf("expected a signature of another body to be invalid")
+	}
+	if err := Verify("secret", header, body, now.Add(time.Hour), 5*time.Minute); err == nil {
+		t.Errorf("expected an old signature to be invalid")
+	}
+}
+
+func TestDispatcher_delivers(t *testing.T) {
+	received := make(chan Event, 10)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := Verify("spain secret", r.Header.Get(SignatureHeader), body, time.Now(), time.Minute); err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		event := Event{}
+		json.Unmarshal(body, &event)
+		if r.Header.Get(EventHeader) != event.Type {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received <- event
+	}))
+	defer receiver.Close()
+
+	store := &memoryStore{
+		now: time.Now,
+		subscriptions: []Subscription{
+			{SubscriptionId: 1, Url: receiver.URL, Secret: "spain secret", EventTypes: []string{EventTagsUpdated}, Countries: []string{"ESP"}},
+			{SubscriptionId: 2, Url: receiver.URL, Secret: "france secret", EventTypes: EventTypes, Countries: []string{"FRA"}},
+		},
+		outbox: []Event{
+			{EventId: 1, Type: EventTagsUpdated, CityId: 3117735, Country3Code: "ESP", Data: map[string]string{"temperature": "hot"}},
+			{EventId: 2, Type: EventCityUpdated, CityId: 3117735, Country3Code: "ESP"},
+		},
+	}
+	if err := newTestDispatcher(store).Dispatch(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(store.deliveries) != 1 || store.deliveries[0].Status != StatusDelivered || store.deliveries[0].Attempts != 1 {
+		t.Fatalf("expected one delivered delivery, got %+v", store.deliveries)
+	}
+	event := <-received
+	if event.EventId != 1 || event.CityId != 3117735 {
+		t.Errorf("unexpected event %+v", event)
+	}
+}
+
+func TestDispatcher_retriesUntilDead(t *testing.T) {
+	calls := 0
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer receiver.Close()
+
+	now := time.Now()
+	store := &memoryStore{
+		now:           func() time.Time { return now },
+		subscriptions: []Subscription{{SubscriptionId: 1, Url: receiver.URL, Secret: "secret", EventTypes: EventTypes}},
+		outbox:        []Event{{EventId: 1, Type: EventCityDeleted, CityId: 1, Country3Code: "ARG"}},
+	}
+	dispatcher := newTestDispatcher(store)
+
+	for attempt, wait := range []time.Duration{time.Minute, 2 * time.Minute} {
+		if err := dispatcher.Dispatch(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		delivery := store.deliveries[0]
+		if delivery.Status != StatusPending || delivery.Attempts != attempt+1 || *delivery.LastStatusCode != 503 {
+			t.Fatalf("attempt %d: unexpected delivery %+v", attempt+1, delivery)
+		}
+		if !delivery.NextAttemptAt.Equal(now.Add(wait)) {
+			t.Errorf("attempt %d: next attempt at %s, expected %s later", attempt+1, delivery.NextAttemptAt, wait)
+		}
+
+		// Nothing is sent before the next attempt is due.
+		dispatcher.Dispatch(context.Background())
+		if calls != attempt+1 {
+			t.Errorf("attempt %d: %d calls before the retry is due", attempt+1, calls)
+		}
+		now = now.Add(wait)
+	}
+
+	if err := dispatcher.Dispatch(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	delivery := store.deliveries[0]
+	if delivery.Status != StatusDead || delivery.Attempts != 3 || delivery.NextAttemptAt != nil || delivery.LastError == nil {
+		t.Errorf("expected a dead delivery, got %+v", delivery)
+	}
+}
+
+func TestDispatcher_backoff(t *testing.T) {
+	dispatcher := NewDispatcher(nil, DefaultConfig())
+	for attempts, expected := range map[int]time.Duration{
+		1:  30 * time.Second,
+		2:  time.Minute,
+		5:  8 * time.Minute,
+		20: 6 * time.Hour,
+	} {
+		if actual := dispatcher.backoff(attempts); actual != expected {
+			t.Errorf("backoff after %d attempts = %s, expected %s", attempts, actual, expected)
+		}
+	}
+}
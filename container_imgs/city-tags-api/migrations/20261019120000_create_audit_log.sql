@@ -0,0 +1,60 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE city_tags.audit_log (
+    audit_id BIGSERIAL PRIMARY KEY,
+    table_name VARCHAR(30) NOT NULL,
+    action VARCHAR(10) NOT NULL,
+    city_id INT NOT NULL,
+    actor VARCHAR(100) NOT NULL,
+    request_id VARCHAR(100),
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    before JSONB,
+    after JSONB
+);
+
+CREATE INDEX audit_log_city_id_idx ON city_tags.audit_log (city_id, changed_at);
+
+-- The actor and request id are read from transaction settings set by the
+-- writer, changes made without them are attributed to the database user.
+CREATE FUNCTION city_tags.record_audit() RETURNS TRIGGER AS $$
+DECLARE
+    before_row JSONB;
+    after_row JSONB;
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        before_row := to_jsonb(OLD);
+    END IF;
+    IF TG_OP <> 'DELETE' THEN
+        after_row := to_jsonb(NEW);
+    END IF;
+
+    INSERT INTO city_tags.audit_log (table_name, action, city_id, actor, request_id, before, after)
+    VALUES (
+        TG_TABLE_NAME,
+        lower(TG_OP),
+        (COALESCE(after_row, before_row)->>'city_id')::INT,
+        COALESCE(NULLIF(current_setting('city_tags.actor', true), ''), current_user),
+        NULLIF(current_setting('city_tags.request_id', true), ''),
+        before_row,
+        after_row
+    );
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER cities_audit
+AFTER INSERT OR UPDATE OR DELETE ON city_tags.cities
+FOR EACH ROW EXECUTE FUNCTION city_tags.record_audit();
+
+CREATE TRIGGER city_tags_audit
+AFTER INSERT OR UPDATE OR DELETE ON city_tags.city_tags
+FOR EACH ROW EXECUTE FUNCTION city_tags.record_audit();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER city_tags_audit ON city_tags.city_tags;
+DROP TRIGGER cities_audit ON city_tags.cities;
+DROP FUNCTION city_tags.record_audit();
+DROP TABLE city_tags.audit_log;
+-- +goose StatementEnd
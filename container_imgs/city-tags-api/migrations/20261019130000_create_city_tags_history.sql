@@ -0,0 +1,74 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+-- Every version of the tags of a city, city_tags.city_tags keeps only the
+-- current one so the default reads stay as fast as before.
+CREATE TABLE city_tags.city_tags_history (
+    city_id INT NOT NULL,
+    cloud_coverage_tag VARCHAR(30) NOT NULL,
+    humidity_tag VARCHAR(30) NOT NULL,
+    temp_tag VARCHAR(30) NOT NULL,
+    precipitation_tag VARCHAR(30) NOT NULL,
+    air_quality_tag VARCHAR(30) NOT NULL,
+    daylight_hours_tag VARCHAR(30) NOT NULL,
+    city_size_tag VARCHAR(30) NOT NULL,
+    valid_from TIMESTAMPTZ NOT NULL,
+    valid_to TIMESTAMPTZ,
+    CHECK (valid_to IS NULL OR valid_to >= valid_from),
+    EXCLUDE USING gist (city_id WITH =, tstzrange(valid_from, valid_to) WITH &&)
+);
+
+CREATE INDEX city_tags_history_city_id_idx ON city_tags.city_tags_history (city_id, valid_from);
+
+-- Tags that existed before versioning was introduced are considered valid
+-- since the epoch.
+INSERT INTO city_tags.city_tags_history
+SELECT *, '1970-01-01T00:00:00Z', NULL FROM city_tags.city_tags;
+
+CREATE FUNCTION city_tags.record_tags_history() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND NEW IS NOT DISTINCT FROM OLD THEN
+        RETURN NULL;
+    END IF;
+
+    IF TG_OP <> 'INSERT' THEN
+        -- A version opened in this same transaction is replaced instead of
+        -- being closed with an empty validity range.
+        DELETE FROM city_tags.city_tags_history
+        WHERE city_id = OLD.city_id AND valid_to IS NULL AND valid_from = now();
+
+        UPDATE city_tags.city_tags_history SET valid_to = now()
+        WHERE city_id = OLD.city_id AND valid_to IS NULL;
+    END IF;
+
+    IF TG_OP <> 'DELETE' THEN
+        INSERT INTO city_tags.city_tags_history
+        VALUES (
+            NEW.city_id,
+            NEW.cloud_coverage_tag,
+            NEW.humidity_tag,
+            NEW.temp_tag,
+            NEW.precipitation_tag,
+            NEW.air_quality_tag,
+            NEW.daylight_hours_tag,
+            NEW.city_size_tag,
+            now(),
+            NULL
+        );
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER city_tags_history
+AFTER INSERT OR UPDATE OR DELETE ON city_tags.city_tags
+FOR EACH ROW EXECUTE FUNCTION city_tags.record_tags_history();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER city_tags_history ON city_tags.city_tags;
+DROP FUNCTION city_tags.record_tags_history();
+DROP TABLE city_tags.city_tags_history;
+-- +goose StatementEnd
@@ -0,0 +1,97 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE city_tags.tag_categories (
+    category VARCHAR(30) PRIMARY KEY,
+    label VARCHAR(50) NOT NULL,
+    column_name VARCHAR(30) NOT NULL UNIQUE,
+    metric VARCHAR(50) NOT NULL,
+    unit VARCHAR(20) NOT NULL
+);
+
+-- Thresholds are the range of the metric of the category that derives each
+-- value, min_threshold is inclusive, max_threshold exclusive and NULL means
+-- unbounded.
+CREATE TABLE city_tags.tag_values (
+    category VARCHAR(30) NOT NULL REFERENCES city_tags.tag_categories(category),
+    value VARCHAR(30) NOT NULL,
+    rank INT NOT NULL,
+    label VARCHAR(50) NOT NULL,
+    min_threshold NUMERIC,
+    max_threshold NUMERIC,
+    PRIMARY KEY (category, value),
+    UNIQUE (category, rank)
+);
+
+INSERT INTO city_tags.tag_categories (category, label, column_name, metric, unit) VALUES
+    ('cloud_coverage', 'Cloud coverage', 'cloud_coverage_tag', 'cloud_cover', '%'),
+    ('humidity', 'Humidity', 'humidity_tag', 'relative_humidity', '%'),
+    ('temperature', 'Temperature', 'temp_tag', 'mean_temperature', '°C'),
+    ('precipitation', 'Precipitation', 'precipitation_tag', 'precipitation', 'mm/month'),
+    ('air_quality', 'Air quality', 'air_quality_tag', 'aqi', 'AQI'),
+    ('daylight_hours', 'Daylight hours', 'daylight_hours_tag', 'daylight', 'h/day'),
+    ('city_size', 'City size', 'city_size_tag', 'population', 'inhabitants');
+
+INSERT INTO city_tags.tag_values (category, value, rank, label, min_threshold, max_threshold) VALUES
+    ('cloud_coverage', 'clear', 0, 'Clear', NULL, 20),
+    ('cloud_coverage', 'partly cloudy', 1, 'Partly cloudy', 20, 50),
+    ('cloud_coverage', 'cloudy', 2, 'Cloudy', 50, 80),
+    ('cloud_coverage', 'overcast', 3, 'Overcast', 80, NULL),
+    ('humidity', 'dry', 0, 'Dry', NULL, 40),
+    ('humidity', 'moderate', 1, 'Moderate', 40, 65),
+    ('humidity', 'humid', 2, 'Humid', 65, 80),
+    ('humidity', 'very humid', 3, 'Very humid', 80, NULL),
+    ('temperature', 'very cold', 0, 'Very cold', NULL, 0),
+    ('temperature', 'cold', 1, 'Cold', 0, 10),
+    ('temperature', 'mild', 2, 'Mild', 10, 18),
+    ('temperature', 'warm', 3, 'Warm', 18, 25),
+    ('temperature', 'hot', 4, 'Hot', 25, NULL),
+    ('precipitation', 'dry', 0, 'Dry', NULL, 20),
+    ('precipitation', 'moderate', 1, 'Moderate', 20, 80),
+    ('precipitation', 'moderately wet', 2, 'Moderately wet', 80, 120),
+    ('precipitation', 'wet', 3, 'Wet', 120, 200),
+    ('precipitation', 'very wet', 4, 'Very wet', 200, NULL),
+    ('air_quality', 'good', 0, 'Good', NULL, 50),
+    ('air_quality', 'moderate', 1, 'Moderate', 50, 100),
+    ('air_quality', 'unhealthy', 2, 'Unhealthy', 100, 200),
+    ('air_quality', 'very unhealthy', 3, 'Very unhealthy', 200, 300),
+    ('air_quality', 'hazardous', 4, 'Hazardous', 300, NULL),
+    ('daylight_hours', 'low', 0, 'Low', NULL, 11),
+    ('daylight_hours', 'moderate', 1, 'Moderate', 11, 12),
+    ('daylight_hours', 'high', 2, 'High', 12, NULL),
+    ('city_size', 'small', 0, 'Small', NULL, 100000),
+    ('city_size', 'medium', 1, 'Medium', 100000, 1000000),
+    ('city_size', 'big', 2, 'Big', 1000000, 5000000),
+    ('city_size', 'very big', 3, 'Very big', 5000000, NULL);
+
+-- A foreign key to tag_values needs the category on every row, so each tag
+-- column gets a constant generated column with its category.
+ALTER TABLE city_tags.city_tags
+    ADD COLUMN cloud_coverage_category VARCHAR(30) GENERATED ALWAYS AS ('cloud_coverage') STORED,
+    ADD COLUMN humidity_category VARCHAR(30) GENERATED ALWAYS AS ('humidity') STORED,
+    ADD COLUMN temp_category VARCHAR(30) GENERATED ALWAYS AS ('temperature') STORED,
+    ADD COLUMN precipitation_category VARCHAR(30) GENERATED ALWAYS AS ('precipitation') STORED,
+    ADD COLUMN air_quality_category VARCHAR(30) GENERATED ALWAYS AS ('air_quality') STORED,
+    ADD COLUMN daylight_hours_category VARCHAR(30) GENERATED ALWAYS AS ('daylight_hours') STORED,
+    ADD COLUMN city_size_category VARCHAR(30) GENERATED ALWAYS AS ('city_size') STORED,
+    ADD FOREIGN KEY (cloud_coverage_category, cloud_coverage_tag) REFERENCES city_tags.tag_values (category, value),
+    ADD FOREIGN KEY (humidity_category, humidity_tag) REFERENCES city_tags.tag_values (category, value),
+    ADD FOREIGN KEY (temp_category, temp_tag) REFERENCES city_tags.tag_values (category, value),
+    ADD FOREIGN KEY (precipitation_category, precipitation_tag) REFERENCES city_tags.tag_values (category, value),
+    ADD FOREIGN KEY (air_quality_category, air_quality_tag) REFERENCES city_tags.tag_values (category, value),
+    ADD FOREIGN KEY (daylight_hours_category, daylight_hours_tag) REFERENCES city_tags.tag_values (category, value),
+    ADD FOREIGN KEY (city_size_category, city_size_tag) REFERENCES city_tags.tag_values (category, value);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE city_tags.city_tags
+    DROP COLUMN cloud_coverage_category,
+    DROP COLUMN humidity_category,
+    DROP COLUMN temp_category,
+    DROP COLUMN precipitation_category,
+    DROP COLUMN air_quality_category,
+    DROP COLUMN daylight_hours_category,
+    DROP COLUMN city_size_category;
+DROP TABLE city_tags.tag_values;
+DROP TABLE city_tags.tag_categories;
+-- +goose StatementEnd
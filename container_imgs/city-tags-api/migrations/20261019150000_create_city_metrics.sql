@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Raw monthly climate and demographic metrics the tags are derived from, the
+-- column names match city_tags.tag_categories.metric.
+CREATE TABLE city_tags.city_metrics (
+    city_id INT NOT NULL,
+    month SMALLINT NOT NULL CHECK (month BETWEEN 1 AND 12),
+    mean_temperature NUMERIC NOT NULL,
+    relative_humidity NUMERIC NOT NULL CHECK (relative_humidity BETWEEN 0 AND 100),
+    precipitation NUMERIC NOT NULL CHECK (precipitation >= 0),
+    cloud_cover NUMERIC NOT NULL CHECK (cloud_cover BETWEEN 0 AND 100),
+    aqi NUMERIC NOT NULL CHECK (aqi >= 0),
+    daylight NUMERIC NOT NULL CHECK (daylight BETWEEN 0 AND 24),
+    population BIGINT NOT NULL CHECK (population >= 0),
+    PRIMARY KEY (city_id, month),
+    FOREIGN KEY (city_id) REFERENCES city_tags.cities(city_id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE city_tags.city_metrics;
+-- +goose StatementEnd
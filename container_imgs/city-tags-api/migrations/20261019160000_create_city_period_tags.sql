@@ -0,0 +1,42 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Tags of a city for a single month ('1' to '12') or season, city_tags.city_tags
+-- keeps the annual ones. Seasons follow the hemisphere of each city.
+CREATE TABLE city_tags.city_period_tags (
+    city_id INT NOT NULL,
+    period VARCHAR(10) NOT NULL CHECK (
+        period IN ('1', '2', '3', '4', '5', '6', '7', '8', '9', '10', '11', '12', 'winter', 'spring', 'summer', 'autumn')
+    ),
+    cloud_coverage_tag VARCHAR(30) NOT NULL,
+    humidity_tag VARCHAR(30) NOT NULL,
+    temp_tag VARCHAR(30) NOT NULL,
+    precipitation_tag VARCHAR(30) NOT NULL,
+    air_quality_tag VARCHAR(30) NOT NULL,
+    daylight_hours_tag VARCHAR(30) NOT NULL,
+    city_size_tag VARCHAR(30) NOT NULL,
+    cloud_coverage_category VARCHAR(30) GENERATED ALWAYS AS ('cloud_coverage') STORED,
+    humidity_category VARCHAR(30) GENERATED ALWAYS AS ('humidity') STORED,
+    temp_category VARCHAR(30) GENERATED ALWAYS AS ('temperature') STORED,
+    precipitation_category VARCHAR(30) GENERATED ALWAYS AS ('precipitation') STORED,
+    air_quality_category VARCHAR(30) GENERATED ALWAYS AS ('air_quality') STORED,
+    daylight_hours_category VARCHAR(30) GENERATED ALWAYS AS ('daylight_hours') STORED,
+    city_size_category VARCHAR(30) GENERATED ALWAYS AS ('city_size') STORED,
+    PRIMARY KEY (city_id, period),
+    FOREIGN KEY (city_id) REFERENCES city_tags.cities(city_id) ON DELETE CASCADE ON UPDATE CASCADE,
+    FOREIGN KEY (cloud_coverage_category, cloud_coverage_tag) REFERENCES city_tags.tag_values (category, value),
+    FOREIGN KEY (humidity_category, humidity_tag) REFERENCES city_tags.tag_values (category, value),
+    FOREIGN KEY (temp_category, temp_tag) REFERENCES city_tags.tag_values (category, value),
+    FOREIGN KEY (precipitation_category, precipitation_tag) REFERENCES city_tags.tag_values (category, value),
+    FOREIGN KEY (air_quality_category, air_quality_tag) REFERENCES city_tags.tag_values (category, value),
+    FOREIGN KEY (daylight_hours_category, daylight_hours_tag) REFERENCES city_tags.tag_values (category, value),
+    FOREIGN KEY (city_size_category, city_size_tag) REFERENCES city_tags.tag_values (category, value)
+);
+
+-- Listing filters scan every city of a single period.
+CREATE INDEX city_period_tags_period_idx ON city_tags.city_period_tags (period);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE city_tags.city_period_tags;
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE city_tags.cities
+    ADD COLUMN latitude DOUBLE PRECISION CHECK (latitude BETWEEN -90 AND 90),
+    ADD COLUMN longitude DOUBLE PRECISION CHECK (longitude BETWEEN -180 AND 180),
+    ADD COLUMN timezone VARCHAR(64),
+    ADD COLUMN population BIGINT CHECK (population >= 0),
+    ADD COLUMN alternate_names TEXT[] NOT NULL DEFAULT '{}',
+    ADD CHECK ((latitude IS NULL) = (longitude IS NULL));
+
+-- Nearby and bounding box queries first narrow the candidates with a range on
+-- the latitude and then compute the exact distance, PostGIS is not available
+-- in every environment.
+CREATE INDEX cities_latitude_longitude_idx ON city_tags.cities (latitude, longitude)
+WHERE latitude IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX city_tags.cities_latitude_longitude_idx;
+ALTER TABLE city_tags.cities
+    DROP COLUMN latitude,
+    DROP COLUMN longitude,
+    DROP COLUMN timezone,
+    DROP COLUMN population,
+    DROP COLUMN alternate_names;
+-- +goose StatementEnd
@@ -0,0 +1,293 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE city_tags.continents (
+    name VARCHAR(50) PRIMARY KEY
+);
+
+CREATE TABLE city_tags.countries (
+    alpha_3_code VARCHAR(3) PRIMARY KEY,
+    alpha_2_code VARCHAR(2) NOT NULL UNIQUE,
+    name VARCHAR(100) NOT NULL,
+    continent VARCHAR(50) NOT NULL REFERENCES city_tags.continents(name)
+);
+
+CREATE INDEX countries_continent_idx ON city_tags.countries (continent);
+
+-- Seeded from internal/iso3166, which the importer and the admin endpoints
+-- validate against, both lists must be kept in sync.
+INSERT INTO city_tags.continents (name) VALUES
+    ('Africa'),
+    ('Antarctica'),
+    ('Asia'),
+    ('Europe'),
+    ('North America'),
+    ('Oceania'),
+    ('South America');
+
+INSERT INTO city_tags.countries (alpha_3_code, alpha_2_code, name, continent) VALUES
+    ('AFG', 'AF', 'Afghanistan', 'Asia'),
+    ('ALA', 'AX', 'Åland Islands', 'Europe'),
+    ('ALB', 'AL', 'Albania', 'Europe'),
+    ('DZA', 'DZ', 'Algeria', 'Africa'),
+    ('ASM', 'AS', 'American Samoa', 'Oceania'),
+    ('AND', 'AD', 'Andorra', 'Europe'),
+    ('AGO', 'AO', 'Angola', 'Africa'),
+    ('AIA', 'AI', 'Anguilla', 'North America'),
+    ('ATA', 'AQ', 'Antarctica', 'Antarctica'),
+    ('ATG', 'AG', 'Antigua and Barbuda', 'North America'),
+    ('ARG', 'AR', 'Argentina', 'South America'),
+    ('ARM', 'AM', 'Armenia', 'Asia'),
+    ('ABW', 'AW', 'Aruba', 'North America'),
+    ('AUS', 'AU', 'Australia', 'Oceania'),
+    ('AUT', 'AT', 'Austria', 'Europe'),
+    ('AZE', 'AZ', 'Azerbaijan', 'Asia'),
+    ('BHS', 'BS', 'Bahamas', 'North America'),
+    ('BHR', 'BH', 'Bahrain', 'Asia'),
+    ('BGD', 'BD', 'Bangladesh', 'Asia'),
+    ('BRB', 'BB', 'Barbados', 'North America'),
+    ('BLR', 'BY', 'Belarus', 'Europe'),
+    ('BEL', 'BE', 'Belgium', 'Europe'),
+    ('BLZ', 'BZ', 'Belize', 'North America'),
+    ('BEN', 'BJ', 'Benin', 'Africa'),
+    ('BMU', 'BM', 'Bermuda', 'North America'),
+    ('BTN', 'BT', 'Bhutan', 'Asia'),
+    ('BOL', 'BO', 'Bolivia', 'South America'),
+    ('BES', 'BQ', 'Bonaire, Sint Eustatius and Saba', 'North America'),
+    ('BIH', 'BA', 'Bosnia and Herzegovina', 'Europe'),
+    ('BWA', 'BW', 'Botswana', 'Africa'),
+    ('BVT', 'BV', 'Bouvet Island', 'Antarctica'),
+    ('BRA', 'BR', 'Brazil', 'South America'),
+    ('IOT', 'IO', 'British Indian Ocean Territory', 'Asia'),
+    ('BRN', 'BN', 'Brunei Darussalam', 'Asia'),
+    ('BGR', 'BG', 'Bulgaria', 'Europe'),
+    ('BFA', 'BF', 'Burkina Faso', 'Africa'),
+    ('BDI', 'BI', 'Burundi', 'Africa'),
+    ('CPV', 'CV', 'Cabo Verde', 'Africa'),
+    ('KHM', 'KH', 'Cambodia', 'Asia'),
+    ('CMR', 'CM', 'Cameroon', 'Africa'),
+    ('CAN', 'CA', 'Canada', 'North America'),
+    ('CYM', 'KY', 'Cayman Islands', 'North America'),
+    ('CAF', 'CF', 'Central African Republic', 'Africa'),
+    ('TCD', 'TD', 'Chad', 'Africa'),
+    ('CHL', 'CL', 'Chile', 'South America'),
+    ('CHN', 'CN', 'China', 'Asia'),
+    ('CXR', 'CX', 'Christmas Island', 'Asia'),
+    ('CCK', 'CC', 'Cocos (Keeling) Islands', 'Asia'),
+    ('COL', 'CO', 'Colombia', 'South America'),
+    ('COM', 'KM', 'Comoros', 'Africa'),
+    ('COG', 'CG', 'Congo', 'Africa'),
+    ('COD', 'CD', 'Congo, Democratic Republic of the', 'Africa'),
+    ('COK', 'CK', 'Cook Islands', 'Oceania'),
+    ('CRI', 'CR', 'Costa Rica', 'North America'),
+    ('CIV', 'CI', 'Côte d''Ivoire', 'Africa'),
+    ('HRV', 'HR', 'Croatia', 'Europe'),
+    ('CUB', 'CU', 'Cuba', 'North America'),
+    ('CUW', 'CW', 'Curaçao', 'North America'),
+    ('CYP', 'CY', 'Cyprus', 'Asia'),
+    ('CZE', 'CZ', 'Czechia', 'Europe'),
+    ('DNK', 'DK', 'Denmark', 'Europe'),
+    ('DJI', 'DJ', 'Djibouti', 'Africa'),
+    ('DMA', 'DM', 'Dominica', 'North America'),
+    ('DOM', 'DO', 'Dominican Republic', 'North America'),
+    ('ECU', 'EC', 'Ecuador', 'South America'),
+    ('EGY', 'EG', 'Egypt', 'Africa'),
+    ('SLV', 'SV', 'El Salvador', 'North America'),
+    ('GNQ', 'GQ', 'Equatorial Guinea', 'Africa'),
+    ('ERI', 'ER', 'Eritrea', 'Africa'),
+    ('EST', 'EE', 'Estonia', 'Europe'),
+    ('SWZ', 'SZ', 'Eswatini', 'Africa'),
+    ('ETH', 'ET', 'Ethiopia', 'Africa'),
+    ('FLK', 'FK', 'Falkland Islands (Malvinas)', 'South America'),
+    ('FRO', 'FO', 'Faroe Islands', 'Europe'),
+    ('FJI', 'FJ', 'Fiji', 'Oceania'),
+    ('FIN', 'FI', 'Finland', 'Europe'),
+    ('FRA', 'FR', 'France', 'Europe'),
+    ('GUF', 'GF', 'French Guiana', 'South America'),
+    ('PYF', 'PF', 'French Polynesia', 'Oceania'),
+    ('ATF', 'TF', 'French Southern Territories', 'Antarctica'),
+    ('GAB', 'GA', 'Gabon', 'Africa'),
+    ('GMB', 'GM', 'Gambia', 'Africa'),
+    ('GEO', 'GE', 'Georgia', 'Asia'),
+    ('DEU', 'DE', 'Germany', 'Europe'),
+    ('GHA', 'GH', 'Ghana', 'Africa'),
+    ('GIB', 'GI', 'Gibraltar', 'Europe'),
+    ('GRC', 'GR', 'Greece', 'Europe'),
+    ('GRL', 'GL', 'Greenland', 'North America'),
+    ('GRD', 'GD', 'Grenada', 'North America'),
+    ('GLP', 'GP', 'Guadeloupe', 'North America'),
+    ('GUM', 'GU', 'Guam', 'Oceania'),
+    ('GTM', 'GT', 'Guatemala', 'North America'),
+    ('GGY', 'GG', 'Guernsey', 'Europe'),
+    ('GIN', 'GN', 'Guinea', 'Africa'),
+    ('GNB', 'GW', 'Guinea-Bissau', 'Africa'),
+    ('GUY', 'GY', 'Guyana', 'South America'),
+    ('HTI', 'HT', 'Haiti', 'North America'),
+    ('HMD', 'HM', 'Heard Island and McDonald Islands', 'Antarctica'),
+    ('VAT', 'VA', 'Holy See', 'Europe'),
+    ('HND', 'HN', 'Honduras', 'North America'),
+    ('HKG', 'HK', 'Hong Kong', 'Asia'),
+    ('HUN', 'HU', 'Hungary', 'Europe'),
+    ('ISL', 'IS', 'Iceland', 'Europe'),
+    ('IND', 'IN', 'India', 'Asia'),
+    ('IDN', 'ID', 'Indonesia', 'Asia'),
+    ('IRN', 'IR', 'Iran', 'Asia'),
+    ('IRQ', 'IQ', 'Iraq', 'Asia'),
+    ('IRL', 'IE', 'Ireland', 'Europe'),
+    ('IMN', 'IM', 'Isle of Man', 'Europe'),
+    ('ISR', 'IL', 'Israel', 'Asia'),
+    ('ITA', 'IT', 'Italy', 'Europe'),
+    ('JAM', 'JM', 'Jamaica', 'North America'),
+    ('JPN', 'JP', 'Japan', 'Asia'),
+    ('JEY', 'JE', 'Jersey', 'Europe'),
+    ('JOR', 'JO', 'Jordan', 'Asia'),
+    ('KAZ', 'KZ', 'Kazakhstan', 'Asia'),
+    ('KEN', 'KE', 'Kenya', 'Africa'),
+    ('KIR', 'KI', 'Kiribati', 'Oceania'),
+    ('PRK', 'KP', 'Korea, Democratic People''s Republic of', 'Asia'),
+    ('KOR', 'KR', 'Korea, Republic of', 'Asia'),
+    ('KWT', 'KW', 'Kuwait', 'Asia'),
+    ('KGZ', 'KG', 'Kyrgyzstan', 'Asia'),
+    ('LAO', 'LA', 'Lao People''s Democratic Republic', 'Asia'),
+    ('LVA', 'LV', 'Latvia', 'Europe'),
+    ('LBN', 'LB', 'Lebanon', 'Asia'),
+    ('LSO', 'LS', 'Lesotho', 'Africa'),
+    ('LBR', 'LR', 'Liberia', 'Africa'),
+    ('LBY', 'LY', 'Libya', 'Africa'),
+    ('LIE', 'LI', 'Liechtenstein', 'Europe'),
+    ('LTU', 'LT', 'Lithuania', 'Europe'),
+    ('LUX', 'LU', 'Luxembourg', 'Europe'),
+    ('MAC', 'MO', 'Macao', 'Asia'),
+    ('MDG', 'MG', 'Madagascar', 'Africa'),
+    ('MWI', 'MW', 'Malawi', 'Africa'),
+    ('MYS', 'MY', 'Malaysia', 'Asia'),
+    ('MDV', 'MV', 'Maldives', 'Asia'),
+    ('MLI', 'ML', 'Mali', 'Africa'),
+    ('MLT', 'MT', 'Malta', 'Europe'),
+    ('MHL', 'MH', 'Marshall Islands', 'Oceania'),
+    ('MTQ', 'MQ', 'Martinique', 'North America'),
+    ('MRT', 'MR', 'Mauritania', 'Africa'),
+    ('MUS', 'MU', 'Mauritius', 'Africa'),
+    ('MYT', 'YT', 'Mayotte', 'Africa'),
+    ('MEX', 'MX', 'Mexico', 'North America'),
+    ('FSM', 'FM', 'Micronesia', 'Oceania'),
+    ('MDA', 'MD', 'Moldova', 'Europe'),
+    ('MCO', 'MC', 'Monaco', 'Europe'),
+    ('MNG', 'MN', 'Mongolia', 'Asia'),
+    ('MNE', 'ME', 'Montenegro', 'Europe'),
+    ('MSR', 'MS', 'Montserrat', 'North America'),
+    ('MAR', 'MA', 'Morocco', 'Africa'),
+    ('MOZ', 'MZ', 'Mozambique', 'Africa'),
+    ('MMR', 'MM', 'Myanmar', 'Asia'),
+    ('NAM', 'NA', 'Namibia', 'Africa'),
+    ('NRU', 'NR', 'Nauru', 'Oceania'),
+    ('NPL', 'NP', 'Nepal', 'Asia'),
+    ('NLD', 'NL', 'Netherlands', 'Europe'),
+    ('NCL', 'NC', 'New Caledonia', 'Oceania'),
+    ('NZL', 'NZ', 'New Zealand', 'Oceania'),
+    ('NIC', 'NI', 'Nicaragua', 'North America'),
+    ('NER', 'NE', 'Niger', 'Africa'),
+    ('NGA', 'NG', 'Nigeria', 'Africa'),
+    ('NIU', 'NU', 'Niue', 'Oceania'),
+    ('NFK', 'NF', 'Norfolk Island', 'Oceania'),
+    ('MKD', 'MK', 'North Macedonia', 'Europe'),
+    ('MNP', 'MP', 'Northern Mariana Islands', 'Oceania'),
+    ('NOR', 'NO', 'Norway', 'Europe'),
+    ('OMN', 'OM', 'Oman', 'Asia'),
+    ('PAK', 'PK', 'Pakistan', 'Asia'),
+    ('PLW', 'PW', 'Palau', 'Oceania'),
+    ('PSE', 'PS', 'Palestine, State of', 'Asia'),
+    ('PAN', 'PA', 'Panama', 'North America'),
+    ('PNG', 'PG', 'Papua New Guinea', 'Oceania'),
+    ('PRY', 'PY', 'Paraguay', 'South America'),
+    ('PER', 'PE', 'Peru', 'South America'),
+    ('PHL', 'PH', 'Philippines', 'Asia'),
+    ('PCN', 'PN', 'Pitcairn', 'Oceania'),
+    ('POL', 'PL', 'Poland', 'Europe'),
+    ('PRT', 'PT', 'Portugal', 'Europe'),
+    ('PRI', 'PR', 'Puerto Rico', 'North America'),
+    ('QAT', 'QA', 'Qatar', 'Asia'),
+    ('REU', 'RE', 'Réunion', 'Africa'),
+    ('ROU', 'RO', 'Romania', 'Europe'),
+    ('RUS', 'RU', 'Russian Federation', 'Europe'),
+    ('RWA', 'RW', 'Rwanda', 'Africa'),
+    ('BLM', 'BL', 'Saint Barthélemy', 'North America'),
+    ('SHN', 'SH', 'Saint Helena, Ascension and Tristan da Cunha', 'Africa'),
+    ('KNA', 'KN', 'Saint Kitts and Nevis', 'North America'),
+    ('LCA', 'LC', 'Saint Lucia', 'North America'),
+    ('MAF', 'MF', 'Saint Martin (French part)', 'North America'),
+    ('SPM', 'PM', 'Saint Pierre and Miquelon', 'North America'),
+    ('VCT', 'VC', 'Saint Vincent and the Grenadines', 'North America'),
+    ('WSM', 'WS', 'Samoa', 'Oceania'),
+    ('SMR', 'SM', 'San Marino', 'Europe'),
+    ('STP', 'ST', 'Sao Tome and Principe', 'Africa'),
+    ('SAU', 'SA', 'Saudi Arabia', 'Asia'),
+    ('SEN', 'SN', 'Senegal', 'Africa'),
+    ('SRB', 'RS', 'Serbia', 'Europe'),
+    ('SYC', 'SC', 'Seychelles', 'Africa'),
+    ('SLE', 'SL', 'Sierra Leone', 'Africa'),
+    ('SGP', 'SG', 'Singapore', 'Asia'),
+    ('SXM', 'SX', 'Sint Maarten (Dutch part)', 'North America'),
+    ('SVK', 'SK', 'Slovakia', 'Europe'),
+    ('SVN', 'SI', 'Slovenia', 'Europe'),
+    ('SLB', 'SB', 'Solomon Islands', 'Oceania'),
+    ('SOM', 'SO', 'Somalia', 'Africa'),
+    ('ZAF', 'ZA', 'South Africa', 'Africa'),
+    ('SGS', 'GS', 'South Georgia and the South Sandwich Islands', 'Antarctica'),
+    ('SSD', 'SS', 'South Sudan', 'Africa'),
+    ('ESP', 'ES', 'Spain', 'Europe'),
+    ('LKA', 'LK', 'Sri Lanka', 'Asia'),
+    ('SDN', 'SD', 'Sudan', 'Africa'),
+    ('SUR', 'SR', 'Suriname', 'South America'),
+    ('SJM', 'SJ', 'Svalbard and Jan Mayen', 'Europe'),
+    ('SWE', 'SE', 'Sweden', 'Europe'),
+    ('CHE', 'CH', 'Switzerland', 'Europe'),
+    ('SYR', 'SY', 'Syrian Arab Republic', 'Asia'),
+    ('TWN', 'TW', 'Taiwan', 'Asia'),
+    ('TJK', 'TJ', 'Tajikistan', 'Asia'),
+    ('TZA', 'TZ', 'Tanzania', 'Africa'),
+    ('THA', 'TH', 'Thailand', 'Asia'),
+    ('TLS', 'TL', 'Timor-Leste', 'Asia'),
+    ('TGO', 'TG', 'Togo', 'Africa'),
+    ('TKL', 'TK', 'Tokelau', 'Oceania'),
+    ('TON', 'TO', 'Tonga', 'Oceania'),
+    ('TTO', 'TT', 'Trinidad and Tobago', 'North America'),
+    ('TUN', 'TN', 'Tunisia', 'Africa'),
+    ('TUR', 'TR', 'Türkiye', 'Asia'),
+    ('TKM', 'TM', 'Turkmenistan', 'Asia'),
+    ('TCA', 'TC', 'Turks and Caicos Islands', 'North America'),
+    ('TUV', 'TV', 'Tuvalu', 'Oceania'),
+    ('UGA', 'UG', 'Uganda', 'Africa'),
+    ('UKR', 'UA', 'Ukraine', 'Europe'),
+    ('ARE', 'AE', 'United Arab Emirates', 'Asia'),
+    ('GBR', 'GB', 'United Kingdom', 'Europe'),
+    ('USA', 'US', 'United States of America', 'North America'),
+    ('UMI', 'UM', 'United States Minor Outlying Islands', 'Oceania'),
+    ('URY', 'UY', 'Uruguay', 'South America'),
+    ('UZB', 'UZ', 'Uzbekistan', 'Asia'),
+    ('VUT', 'VU', 'Vanuatu', 'Oceania'),
+    ('VEN', 'VE', 'Venezuela', 'South America'),
+    ('VNM', 'VN', 'Viet Nam', 'Asia'),
+    ('VGB', 'VG', 'Virgin Islands (British)', 'North America'),
+    ('VIR', 'VI', 'Virgin Islands (U.S.)', 'North America'),
+    ('WLF', 'WF', 'Wallis and Futuna', 'Oceania'),
+    ('ESH', 'EH', 'Western Sahara', 'Africa'),
+    ('YEM', 'YE', 'Yemen', 'Asia'),
+    ('ZMB', 'ZM', 'Zambia', 'Africa'),
+    ('ZWE', 'ZW', 'Zimbabwe', 'Africa');
+
+ALTER TABLE city_tags.cities
+    ADD FOREIGN KEY (continent) REFERENCES city_tags.continents(name),
+    ADD FOREIGN KEY (country_3_code) REFERENCES city_tags.countries(alpha_3_code);
+
+CREATE INDEX cities_country_3_code_idx ON city_tags.cities (country_3_code);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX city_tags.cities_country_3_code_idx;
+ALTER TABLE city_tags.cities
+    DROP CONSTRAINT cities_continent_fkey,
+    DROP CONSTRAINT cities_country_3_code_fkey;
+DROP TABLE city_tags.countries;
+DROP TABLE city_tags.continents;
+-- +goose StatementEnd
@@ -0,0 +1,116 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Translations of the names of the cities and the labels of the vocabulary,
+-- the ones in city_tags.cities and city_tags.tag_values are the default
+-- language. Languages are ISO 639-1 codes without region.
+CREATE TABLE city_tags.city_names (
+    city_id INT NOT NULL,
+    language VARCHAR(3) NOT NULL CHECK (language ~ '^[a-z]{2,3}$'),
+    name VARCHAR(100) NOT NULL,
+    PRIMARY KEY (city_id, language),
+    FOREIGN KEY (city_id) REFERENCES city_tags.cities(city_id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX city_names_language_idx ON city_tags.city_names (language);
+
+CREATE TABLE city_tags.tag_category_labels (
+    category VARCHAR(30) NOT NULL REFERENCES city_tags.tag_categories(category),
+    language VARCHAR(3) NOT NULL CHECK (language ~ '^[a-z]{2,3}$'),
+    label VARCHAR(50) NOT NULL,
+    PRIMARY KEY (category, language)
+);
+
+CREATE TABLE city_tags.tag_value_labels (
+    category VARCHAR(30) NOT NULL,
+    value VARCHAR(30) NOT NULL,
+    language VARCHAR(3) NOT NULL CHECK (language ~ '^[a-z]{2,3}$'),
+    label VARCHAR(50) NOT NULL,
+    PRIMARY KEY (category, value, language),
+    FOREIGN KEY (category, value) REFERENCES city_tags.tag_values (category, value)
+);
+
+INSERT INTO city_tags.tag_category_labels (category, language, label) VALUES
+    ('cloud_coverage', 'es', 'Nubosidad'),
+    ('cloud_coverage', 'de', 'Bewölkung'),
+    ('humidity', 'es', 'Humedad'),
+    ('humidity', 'de', 'Luftfeuchtigkeit'),
+    ('temperature', 'es', 'Temperatura'),
+    ('temperature', 'de', 'Temperatur'),
+    ('precipitation', 'es', 'Precipitación'),
+    ('precipitation', 'de', 'Niederschlag'),
+    ('air_quality', 'es', 'Calidad del aire'),
+    ('air_quality', 'de', 'Luftqualität'),
+    ('daylight_hours', 'es', 'Horas de luz'),
+    ('daylight_hours', 'de', 'Tageslichtstunden'),
+    ('city_size', 'es', 'Tamaño de la ciudad'),
+    ('city_size', 'de', 'Stadtgröße');
+
+INSERT INTO city_tags.tag_value_labels (category, value, language, label) VALUES
+    ('cloud_coverage', 'clear', 'es', 'Despejado'),
+    ('cloud_coverage', 'clear', 'de', 'Klar'),
+    ('cloud_coverage', 'partly cloudy', 'es', 'Parcialmente nublado'),
+    ('cloud_coverage', 'partly cloudy', 'de', 'Teilweise bewölkt'),
+    ('cloud_coverage', 'cloudy', 'es', 'Nublado'),
+    ('cloud_coverage', 'cloudy', 'de', 'Bewölkt'),
+    ('cloud_coverage', 'overcast', 'es', 'Cubierto'),
+    ('cloud_coverage', 'overcast', 'de', 'Bedeckt'),
+    ('humidity', 'dry', 'es', 'Seca'),
+    ('humidity', 'dry', 'de', 'Trocken'),
+    ('humidity', 'moderate', 'es', 'Moderada'),
+    ('humidity', 'moderate', 'de', 'Mäßig'),
+    ('humidity', 'humid', 'es', 'Húmeda'),
+    ('humidity', 'humid', 'de', 'Feucht'),
+    ('humidity', 'very humid', 'es', 'Muy húmeda'),
+    ('humidity', 'very humid', 'de', 'Sehr feucht'),
+    ('temperature', 'very cold', 'es', 'Muy frío'),
+    ('temperature', 'very cold', 'de', 'Sehr kalt'),
+    ('temperature', 'cold', 'es', 'Frío'),
+    ('temperature', 'cold', 'de', 'Kalt'),
+    ('temperature', 'mild', 'es', 'Templado'),
+    ('temperature', 'mild', 'de', 'Mild'),
+    ('temperature', 'warm', 'es', 'Cálido'),
+    ('temperature', 'warm', 'de', 'Warm'),
+    ('temperature', 'hot', 'es', 'Caluroso'),
+    ('temperature', 'hot', 'de', 'Heiß'),
+    ('precipitation', 'dry', 'es', 'Seco'),
+    ('precipitation', 'dry', 'de', 'Trocken'),
+    ('precipitation', 'moderate', 'es', 'Moderado'),
+    ('precipitation', 'moderate', 'de', 'Mäßig'),
+    ('precipitation', 'moderately wet', 'es', 'Algo lluvioso'),
+    ('precipitation', 'moderately wet', 'de', 'Mäßig nass'),
+    ('precipitation', 'wet', 'es', 'Lluvioso'),
+    ('precipitation', 'wet', 'de', 'Nass'),
+    ('precipitation', 'very wet', 'es', 'Muy lluvioso'),
+    ('precipitation', 'very wet', 'de', 'Sehr nass'),
+    ('air_quality', 'good', 'es', 'Buena'),
+    ('air_quality', 'good', 'de', 'Gut'),
+    ('air_quality', 'moderate', 'es', 'Moderada'),
+    ('air_quality', 'moderate', 'de', 'Mäßig'),
+    ('air_quality', 'unhealthy', 'es', 'Insalubre'),
+    ('air_quality', 'unhealthy', 'de', 'Ungesund'),
+    ('air_quality', 'very unhealthy', 'es', 'Muy insalubre'),
+    ('air_quality', 'very unhealthy', 'de', 'Sehr ungesund'),
+    ('air_quality', 'hazardous', 'es', 'Peligrosa'),
+    ('air_quality', 'hazardous', 'de', 'Gefährlich'),
+    ('daylight_hours', 'low', 'es', 'Pocas'),
+    ('daylight_hours', 'low', 'de', 'Wenig'),
+    ('daylight_hours', 'moderate', 'es', 'Moderadas'),
+    ('daylight_hours', 'moderate', 'de', 'Mittel'),
+    ('daylight_hours', 'high', 'es', 'Muchas'),
+    ('daylight_hours', 'high', 'de', 'Viel'),
+    ('city_size', 'small', 'es', 'Pequeña'),
+    ('city_size', 'small', 'de', 'Klein'),
+    ('city_size', 'medium', 'es', 'Mediana'),
+    ('city_size', 'medium', 'de', 'Mittelgroß'),
+    ('city_size', 'big', 'es', 'Grande'),
+    ('city_size', 'big', 'de', 'Groß'),
+    ('city_size', 'very big', 'es', 'Muy grande'),
+    ('city_size', 'very big', 'de', 'Sehr groß');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE city_tags.tag_value_labels;
+DROP TABLE city_tags.tag_category_labels;
+DROP TABLE city_tags.city_names;
+-- +goose StatementEnd
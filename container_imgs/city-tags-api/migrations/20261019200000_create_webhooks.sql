@@ -0,0 +1,55 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Partners subscribe to the changes of the cities and their tags. An empty
+-- countries array matches the cities of every country.
+CREATE TABLE city_tags.webhook_subscriptions (
+    subscription_id BIGSERIAL PRIMARY KEY,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    event_types VARCHAR(30)[] NOT NULL,
+    countries VARCHAR(3)[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- The events are written in the transaction of the admin write that causes
+-- them and fanned out to the matching subscriptions by the dispatcher, which
+-- sets dispatched_at.
+CREATE TABLE city_tags.webhook_outbox (
+    event_id BIGSERIAL PRIMARY KEY,
+    event_type VARCHAR(30) NOT NULL,
+    city_id INT NOT NULL,
+    country_3_code VARCHAR(3) NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    dispatched_at TIMESTAMPTZ
+);
+
+CREATE INDEX webhook_outbox_pending_idx ON city_tags.webhook_outbox (event_id) WHERE dispatched_at IS NULL;
+
+-- Deliveries are pending until the receiver answers with a 2xx status code,
+-- or dead once every attempt failed. next_attempt_at is only set while
+-- pending.
+CREATE TABLE city_tags.webhook_deliveries (
+    delivery_id BIGSERIAL PRIMARY KEY,
+    subscription_id BIGINT NOT NULL REFERENCES city_tags.webhook_subscriptions(subscription_id) ON DELETE CASCADE,
+    event_id BIGINT NOT NULL REFERENCES city_tags.webhook_outbox(event_id),
+    status VARCHAR(10) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'delivered', 'dead')),
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ DEFAULT now(),
+    last_status_code INT,
+    last_error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    delivered_at TIMESTAMPTZ,
+    UNIQUE (subscription_id, event_id)
+);
+
+CREATE INDEX webhook_deliveries_due_idx ON city_tags.webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX webhook_deliveries_subscription_idx ON city_tags.webhook_deliveries (subscription_id, delivery_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE city_tags.webhook_deliveries;
+DROP TABLE city_tags.webhook_outbox;
+DROP TABLE city_tags.webhook_subscriptions;
+-- +goose StatementEnd
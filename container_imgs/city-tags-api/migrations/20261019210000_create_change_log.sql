@@ -0,0 +1,70 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Every change of the cities and their tags, with the data in the contract of
+-- the API, for the clients mirroring the dataset. tx_id is the transaction of
+-- the change: a change is only read once every transaction started before it
+-- is finished, so that a reader never skips changes committed late.
+CREATE TABLE city_tags.change_log (
+    change_id BIGSERIAL PRIMARY KEY,
+    tx_id XID8 NOT NULL DEFAULT pg_current_xact_id(),
+    resource VARCHAR(10) NOT NULL,
+    operation VARCHAR(10) NOT NULL,
+    city_id INT NOT NULL,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    data JSONB NOT NULL
+);
+
+CREATE INDEX change_log_tx_id_change_id_idx ON city_tags.change_log (tx_id, change_id);
+
+-- data is the row after the change, or before it when it is deleted.
+CREATE FUNCTION city_tags.record_change() RETURNS TRIGGER AS $$
+DECLARE
+    changed RECORD;
+    change_resource VARCHAR(10);
+    change_data JSONB;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        changed := OLD;
+    ELSE
+        changed := NEW;
+    END IF;
+
+    IF TG_TABLE_NAME = 'cities' THEN
+        change_resource := 'city';
+        change_data := to_jsonb(changed);
+    ELSE
+        change_resource := 'tags';
+        change_data := jsonb_build_object(
+            'city_id', changed.city_id,
+            'cloud_coverage', changed.cloud_coverage_tag,
+            'humidity', changed.humidity_tag,
+            'temperature', changed.temp_tag,
+            'precipitation', changed.precipitation_tag,
+            'air_quality', changed.air_quality_tag,
+            'daylight_hours', changed.daylight_hours_tag,
+            'city_size', changed.city_size_tag
+        );
+    END IF;
+
+    INSERT INTO city_tags.change_log (resource, operation, city_id, data)
+    VALUES (change_resource, lower(TG_OP), changed.city_id, change_data);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER cities_change_log
+AFTER INSERT OR UPDATE OR DELETE ON city_tags.cities
+FOR EACH ROW EXECUTE FUNCTION city_tags.record_change();
+
+CREATE TRIGGER city_tags_change_log
+AFTER INSERT OR UPDATE OR DELETE ON city_tags.city_tags
+FOR EACH ROW EXECUTE FUNCTION city_tags.record_change();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER city_tags_change_log ON city_tags.city_tags;
+DROP TRIGGER cities_change_log ON city_tags.cities;
+DROP FUNCTION city_tags.record_change();
+DROP TABLE city_tags.change_log;
+-- +goose StatementEnd
@@ -0,0 +1,104 @@
+-- +goose Up
+-- +goose StatementBegin
+-- country_3_code and categories let the readers of the change log filter it
+-- without decoding data. country_3_code is NULL for the tags deleted along
+-- with their city, categories lists the tag categories changed.
+ALTER TABLE city_tags.change_log
+    ADD COLUMN country_3_code CHAR(3),
+    ADD COLUMN categories TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE FUNCTION city_tags.tags_to_jsonb(tags city_tags.city_tags) RETURNS JSONB AS $$
+    SELECT jsonb_build_object(
+        'city_id', tags.city_id,
+        'cloud_coverage', tags.cloud_coverage_tag,
+        'humidity', tags.humidity_tag,
+        'temperature', tags.temp_tag,
+        'precipitation', tags.precipitation_tag,
+        'air_quality', tags.air_quality_tag,
+        'daylight_hours', tags.daylight_hours_tag,
+        'city_size', tags.city_size_tag
+    );
+$$ LANGUAGE sql IMMUTABLE;
+
+CREATE OR REPLACE FUNCTION city_tags.record_change() RETURNS TRIGGER AS $$
+DECLARE
+    changed RECORD;
+    change_resource VARCHAR(10);
+    change_data JSONB;
+    change_country CHAR(3);
+    change_categories TEXT[] := '{}';
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        changed := OLD;
+    ELSE
+        changed := NEW;
+    END IF;
+
+    IF TG_TABLE_NAME = 'cities' THEN
+        change_resource := 'city';
+        change_data := to_jsonb(changed);
+        change_country := changed.country_3_code;
+    ELSE
+        change_resource := 'tags';
+        IF TG_OP = 'DELETE' THEN
+            change_data := city_tags.tags_to_jsonb(OLD);
+        ELSE
+            change_data := city_tags.tags_to_jsonb(NEW);
+        END IF;
+        SELECT country_3_code INTO change_country
+        FROM city_tags.cities WHERE city_id = changed.city_id;
+        SELECT coalesce(array_agg(category ORDER BY category), '{}') INTO change_categories
+        FROM jsonb_each(change_data) AS tag(category, value)
+        WHERE category <> 'city_id'
+        AND (TG_OP <> 'UPDATE' OR city_tags.tags_to_jsonb(OLD) -> category IS DISTINCT FROM value);
+    END IF;
+
+    INSERT INTO city_tags.change_log (resource, operation, city_id, data, country_3_code, categories)
+    VALUES (change_resource, lower(TG_OP), changed.city_id, change_data, change_country, change_categories);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION city_tags.record_change() RETURNS TRIGGER AS $$
+DECLARE
+    changed RECORD;
+    change_resource VARCHAR(10);
+    change_data JSONB;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        changed := OLD;
+    ELSE
+        changed := NEW;
+    END IF;
+
+    IF TG_TABLE_NAME = 'cities' THEN
+        change_resource := 'city';
+        change_data := to_jsonb(changed);
+    ELSE
+        change_resource := 'tags';
+        change_data := jsonb_build_object(
+            'city_id', changed.city_id,
+            'cloud_coverage', changed.cloud_coverage_tag,
+            'humidity', changed.humidity_tag,
+            'temperature', changed.temp_tag,
+            'precipitation', changed.precipitation_tag,
+            'air_quality', changed.air_quality_tag,
+            'daylight_hours', changed.daylight_hours_tag,
+            'city_size', changed.city_size_tag
+        );
+    END IF;
+
+    INSERT INTO city_tags.change_log (resource, operation, city_id, data)
+    VALUES (change_resource, lower(TG_OP), changed.city_id, change_data);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP FUNCTION city_tags.tags_to_jsonb(city_tags.city_tags);
+ALTER TABLE city_tags.change_log
+    DROP COLUMN categories,
+    DROP COLUMN country_3_code;
+-- +goose StatementEnd
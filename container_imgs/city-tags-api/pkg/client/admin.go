@@ -0,0 +1,75 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"city-tags-api/internal/server"
+)
+
+// The admin methods require a token with the admin role.
+
+func (client *Client) CreateCity(ctx context.Context, city CityWriteReq) (CityData, error) {
+	cityData := CityData{}
+	err := client.do(ctx, http.MethodPost, "/admin/cities", nil, city, &cityData)
+	return cityData, err
+}
+
+// ReplaceCity replaces every field of a city, the missing ones are rejected.
+func (client *Client) ReplaceCity(ctx context.Context, cityId int, city CityWriteReq) (CityData, error) {
+	cityData := CityData{}
+	err := client.do(ctx, http.MethodPut, fmt.Sprintf("/admin/cities/%d", cityId), nil, city, &cityData)
+	return cityData, err
+}
+
+// UpdateCity only updates the fields of city that are set.
+func (client *Client) UpdateCity(ctx context.Context, cityId int, city CityWriteReq) (CityData, error) {
+	cityData := CityData{}
+	err := client.do(ctx, http.MethodPatch, fmt.Sprintf("/admin/cities/%d", cityId), nil, city, &cityData)
+	return cityData, err
+}
+
+// DeleteCity deletes a city and its tags.
+func (client *Client) DeleteCity(ctx context.Context, cityId int) error {
+	return client.do(ctx, http.MethodDelete, fmt.Sprintf("/admin/cities/%d", cityId), nil, nil, nil)
+}
+
+func (client *Client) writeTags(ctx context.Context, method string, cityId int, tags TagsWriteReq) (TagsData, error) {
+	cityTags := server.CityTags{}
+	if err := client.do(ctx, method, fmt.Sprintf("/admin/cities/%d/tags", cityId), nil, tags, &cityTags); err != nil {
+		return TagsData{}, err
+	}
+	return cityTags.TagsData(), nil
+}
+
+// ReplaceTags creates or replaces every tag of a city.
+func (client *Client) ReplaceTags(ctx context.Context, cityId int, tags TagsWriteReq) (TagsData, error) {
+	return client.writeTags(ctx, http.MethodPut, cityId, tags)
+}
+
+// UpdateTags only updates the tags that are set.
+func (client *Client) UpdateTags(ctx context.Context, cityId int, tags TagsWriteReq) (TagsData, error) {
+	return client.writeTags(ctx, http.MethodPatch, cityId, tags)
+}
+
+func (client *Client) DeleteTags(ctx context.Context, cityId int) error {
+	return client.do(ctx, http.MethodDelete, fmt.Sprintf("/admin/cities/%d/tags", cityId), nil, nil, nil)
+}
+
+// CityHistory iterates over the changes made to a city and its tags, most
+// recent first.
+func (client *Client) CityHistory(ctx context.Context, cityId int) *Iterator[AuditEntry] {
+	path := fmt.Sprintf("/admin/cities/%d/history", cityId)
+	return paginate(ctx, client, path, nil, 0, func(resp server.ListCityHistoryResp) ([]AuditEntry, Page) {
+		return resp.History, resp.Page
+	})
+}
+
+// CacheStats returns the statistics of the caches of the server keyed by
+// cache name.
+func (client *Client) CacheStats(ctx context.Context) (map[string]CacheStats, error) {
+	stats := map[string]CacheStats{}
+	err := client.do(ctx, http.MethodGet, "/admin/cache/stats", nil, nil, &stats)
+	return stats, err
+}
@@ -0,0 +1,198 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"city-tags-api/internal/server"
+)
+
+// CitiesQuery filters the cities listings, the zero value selects every city.
+type CitiesQuery struct {
+	// Tags are the tag values the cities must have keyed by category, e.g.
+	// "temperature": "mild".
+	Tags map[string]string
+	// Month (1 to 12) or Season the tag filters apply to, the annual tags
+	// are matched if both are empty.
+	Month  int
+	Season string
+	// BBox only selects the cities inside minLon,minLat,maxLon,maxLat.
+	BBox         string
+	Continent    string
+	Country3Code string
+	// Offset is the position of the first city and PageSize the number of
+	// cities requested at once, 100 if 0.
+	Offset   int
+	PageSize int
+}
+
+func (query CitiesQuery) values() url.Values {
+	values := url.Values{}
+	for category, value := range query.Tags {
+		values.Set(category, value)
+	}
+	if query.Month != 0 {
+		values.Set("month", strconv.Itoa(query.Month))
+	}
+	params := map[string]string{
+		"season":         query.Season,
+		"bbox":           query.BBox,
+		"continent":      query.Continent,
+		"country_3_code": query.Country3Code,
+	}
+	for param, value := range params {
+		if value != "" {
+			values.Set(param, value)
+		}
+	}
+	if query.PageSize != 0 {
+		values.Set("limit", strconv.Itoa(query.PageSize))
+	}
+	return values
+}
+
+func citiesPage(resp server.ListCitiesResp) ([]CityData, Page) {
+	return resp.Cities, resp.Page
+}
+
+func (client *Client) GetCity(ctx context.Context, cityId int) (CityData, error) {
+	cityData := CityData{}
+	err := client.do(ctx, http.MethodGet, fmt.Sprintf("/cities/%d", cityId), nil, nil, &cityData)
+	return cityData, err
+}
+
+// Cities iterates over the cities matching query, ordered by city id.
+func (client *Client) Cities(ctx context.Context, query CitiesQuery) *Iterator[CityData] {
+	return paginate(ctx, client, "/cities", query.values(), query.Offset, citiesPage)
+}
+
+type NearbyQuery struct {
+	Lat float64
+	Lon float64
+	// RadiusKm is 50 and Limit 100 if 0.
+	RadiusKm float64
+	Limit    int
+}
+
+// NearbyCities returns the cities around a point, closest first.
+func (client *Client) NearbyCities(ctx context.Context, query NearbyQuery) ([]NearbyCity, error) {
+	values := url.Values{}
+	values.Set("lat", strconv.FormatFloat(query.Lat, 'f', -1, 64))
+	values.Set("lon", strconv.FormatFloat(query.Lon, 'f', -1, 64))
+	if query.RadiusKm != 0 {
+		values.Set("radius_km", strconv.FormatFloat(query.RadiusKm, 'f', -1, 64))
+	}
+	if query.Limit != 0 {
+		values.Set("limit", strconv.Itoa(query.Limit))
+	}
+
+	resp := server.GetNearbyResp{}
+	err := client.do(ctx, http.MethodGet, "/cities/nearby", values, nil, &resp)
+	return resp.Cities, err
+}
+
+type SimilarQuery struct {
+	// Limit is 10 if 0.
+	Limit        int
+	Continent    string
+	Country3Code string
+	// Weights of the tag categories keyed by name, 1 for the missing ones.
+	Weights map[string]float64
+}
+
+// SimilarCities returns the cities with the most similar tags to cityId.
+func (client *Client) SimilarCities(ctx context.Context, cityId int, query SimilarQuery) ([]SimilarCity, error) {
+	values := url.Values{}
+	if query.Limit != 0 {
+		values.Set("limit", strconv.Itoa(query.Limit))
+	}
+	if query.Continent != "" {
+		values.Set("continent", query.Continent)
+	}
+	if query.Country3Code != "" {
+		values.Set("country_3_code", query.Country3Code)
+	}
+	if len(query.Weights) > 0 {
+		weights := make([]string, 0, len(query.Weights))
+		for category, weight := range query.Weights {
+			weights = append(weights, category+":"+strconv.FormatFloat(weight, 'f', -1, 64))
+		}
+		sort.Strings(weights)
+		values.Set("weights", strings.Join(weights, ","))
+	}
+
+	resp := server.GetSimilarResp{}
+	err := client.do(ctx, http.MethodGet, fmt.Sprintf("/cities/%d/similar", cityId), values, nil, &resp)
+	return resp.Similar, err
+}
+
+// TagsQuery selects the tags of a month or season, or the ones valid at AsOf,
+// instead of the current annual tags.
+type TagsQuery struct {
+	Month  int
+	Season string
+	AsOf   time.Time
+}
+
+func (client *Client) GetTags(ctx context.Context, cityId int, query TagsQuery) (TagsData, error) {
+	values := url.Values{}
+	if query.Month != 0 {
+		values.Set("month", strconv.Itoa(query.Month))
+	}
+	if query.Season != "" {
+		values.Set("season", query.Season)
+	}
+	if !query.AsOf.IsZero() {
+		values.Set("as_of", query.AsOf.Format(time.RFC3339))
+	}
+
+	cityTags := server.CityTags{}
+	if err := client.do(ctx, http.MethodGet, fmt.Sprintf("/cities/%d/tags", cityId), values, nil, &cityTags); err != nil {
+		return TagsData{}, err
+	}
+	return cityTags.TagsData(), nil
+}
+
+// TagsHistory iterates over every version of the tags of a city, oldest first.
+func (client *Client) TagsHistory(ctx context.Context, cityId int) *Iterator[TagsVersion] {
+	path := fmt.Sprintf("/cities/%d/tags/history", cityId)
+	return paginate(ctx, client, path, nil, 0, func(resp server.ListTagsHistoryResp) ([]TagsVersion, Page) {
+		history := make([]TagsVersion, len(resp.History))
+		for index, revision := range resp.History {
+			history[index] = TagsVersion{
+				TagsData:  revision.TagsData(),
+				ValidFrom: revision.ValidFrom,
+				ValidTo:   revision.ValidTo,
+			}
+		}
+		return history, resp.Page
+	})
+}
+
+// Vocabulary returns the tag categories with their allowed values.
+func (client *Client) Vocabulary(ctx context.Context) ([]TagCategory, error) {
+	resp := server.GetVocabularyResp{}
+	err := client.do(ctx, http.MethodGet, "/tags", nil, nil, &resp)
+	return resp.Categories, err
+}
+
+// TagStats returns how many cities matching query have each tag value,
+// grouped by "continent" or "country" unless groupBy is empty. The offset and
+// page size of query are ignored.
+func (client *Client) TagStats(ctx context.Context, query CitiesQuery, groupBy string) ([]TagStatsGroup, error) {
+	values := query.values()
+	values.Del("limit")
+	if groupBy != "" {
+		values.Set("group_by", groupBy)
+	}
+
+	resp := server.GetTagStatsResp{}
+	err := client.do(ctx, http.MethodGet, "/stats/tags", values, nil, &resp)
+	return resp.Groups, err
+}
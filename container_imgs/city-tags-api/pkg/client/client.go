@@ -0,0 +1,273 @@
+// Package client is the Go client of the city tags API. It talks to the v1
+// endpoints and returns the types of the server, retrying the requests
+// rejected with 429 or 5xx.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"city-tags-api/internal/api_errors"
+	"city-tags-api/internal/cache"
+	"city-tags-api/internal/server"
+)
+
+// The types returned by the client are the ones of the server, aliased so
+// they can be named outside of this module.
+type (
+	CityData      = server.CityData
+	TagsData      = server.TagsData
+	TagsVersion   = server.TagsVersion
+	NearbyCity    = server.NearbyCity
+	SimilarCity   = server.SimilarCity
+	TagCategory   = server.TagCategory
+	CountryData   = server.CountryData
+	CountryDetail = server.CountryDetail
+	ContinentData = server.ContinentData
+	TagStatsGroup = server.TagStatsGroup
+	AuditEntry    = server.AuditEntry
+	CityWriteReq  = server.CityWriteReq
+	TagsWriteReq  = server.TagsWriteReq
+	Page          = server.Page
+	CacheStats    = cache.Stats
+)
+
+// Error is returned for every response with an error status, with the
+// invalid parameters of the request in Errors.
+type Error = api_errors.ClientErr
+
+const (
+	defaultRetries = 3
+	defaultBackoff = 500 * time.Millisecond
+	maxBackoff     = 30 * time.Second
+	apiVersion     = "v1"
+)
+
+type Client struct {
+	endpoint   string
+	token      string
+	language   string
+	httpClient *http.Client
+	retries    int
+	backoff    time.Duration
+}
+
+type Option func(*Client)
+
+// WithToken authenticates every request with the JWT token.
+func WithToken(token string) Option {
+	return func(client *Client) {
+		client.token = token
+	}
+}
+
+// WithLanguage requests the names and labels in language, e.g. "es".
+func WithLanguage(language string) Option {
+	return func(client *Client) {
+		client.language = language
+	}
+}
+
+// WithHTTPClient sends the requests with httpClient instead of
+// http.DefaultClient.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(client *Client) {
+		client.httpClient = httpClient
+	}
+}
+
+// WithRetries sets how many times a request is retried and the delay before
+// the first retry, which doubles on every attempt.
+func WithRetries(retries int, backoff time.Duration) Option {
+	return func(client *Client) {
+		client.retries = retries
+		client.backoff = backoff
+	}
+}
+
+// New returns a client of the API served at endpoint, e.g.
+// "https://city-tags-api.com".
+func New(endpoint string, options ...Option) *Client {
+	client := &Client{
+		endpoint:   strings.TrimSuffix(endpoint, "/"),
+		httpClient: http.DefaultClient,
+		retries:    defaultRetries,
+		backoff:    defaultBackoff,
+	}
+	for _, option := range options {
+		option(client)
+	}
+	return client
+}
+
+// retryable reports whether a request can be sent again after the status
+// code. Requests rejected with 429 were not processed, the rest are only
+// retried if they are idempotent.
+func retryable(method string, code int) bool {
+	if code == http.StatusTooManyRequests {
+		return true
+	}
+	return code >= 500 && idempotent(method)
+}
+
+func idempotent(method string) bool {
+	return method != http.MethodPost && method != http.MethodPatch
+}
+
+// delay returns the wait before the given retry, the one requested by the
+// Retry-After header if any or an exponential backoff with jitter.
+func (client *Client) delay(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		retryAfter := resp.Header.Get("Retry-After")
+		if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds >= 0 {
+			return min(time.Duration(seconds)*time.Second, maxBackoff)
+		}
+		if date, err := http.ParseTime(retryAfter); err == nil {
+			return min(max(time.Until(date), 0), maxBackoff)
+		}
+	}
+
+	backoff := min(client.backoff<<attempt, maxBackoff)
+	if backoff <= 0 {
+		return 0
+	}
+	return backoff/2 + rand.N(backoff/2+1)
+}
+
+// do sends a request to the path of the API version and decodes the response
+// into target, if not nil.
+func (client *Client) do(ctx context.Context, method string, path string, query url.Values, body any, target any) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("failed to encode request body: %v", err)
+		}
+	}
+	requestURL := fmt.Sprintf("%s/%s%s", client.endpoint, apiVersion, path)
+	if len(query) > 0 {
+		requestURL += "?" + query.Encode()
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := client.send(ctx, method, requestURL, payload)
+		if err != nil && (ctx.Err() != nil || !idempotent(method)) {
+			return err
+		}
+		if err == nil && !retryable(method, resp.StatusCode) {
+			defer resp.Body.Close()
+			return decodeResponse(resp, target)
+		}
+		if attempt == client.retries {
+			if err != nil {
+				return err
+			}
+			defer resp.Body.Close()
+			return decodeResponse(resp, target)
+		}
+
+		wait := client.delay(attempt, resp)
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (client *Client) send(ctx context.Context, method string, requestURL string, payload []byte) (*http.Response, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, requestURL, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if client.token != "" {
+		req.Header.Set("Authorization", "Bearer "+client.token)
+	}
+	if client.language != "" {
+		req.Header.Set("Accept-Language", client.language)
+	}
+
+	resp, err := client.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make %s request: %w", method, err)
+	}
+	return resp, nil
+}
+
+func decodeResponse(resp *http.Response, target any) error {
+	if resp.StatusCode >= 300 {
+		return decodeError(resp)
+	}
+	if target == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return fmt.Errorf("failed to decode response body: %v", err)
+	}
+	return nil
+}
+
+// decodeError reads the problem details of the response into an Error. The
+// bodies that aren't problem details, like the ones of proxies, are kept as
+// the message.
+func decodeError(resp *http.Response) error {
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %v", err)
+	}
+
+	clientErr := &Error{HttpCode: resp.StatusCode, Message: http.StatusText(resp.StatusCode)}
+	problem := api_errors.Problem{}
+	if err := json.Unmarshal(body, &problem); err != nil || problem.Status == 0 {
+		if message := strings.TrimSpace(string(body)); message != "" {
+			clientErr.Message = message
+		}
+		return clientErr
+	}
+
+	if problem.Detail != "" {
+		clientErr.Message = problem.Detail
+	}
+	if len(problem.InvalidParams) > 0 {
+		clientErr.Errors = make(map[string]string, len(problem.InvalidParams))
+		for _, param := range problem.InvalidParams {
+			clientErr.Errors[param.Name] = param.Reason
+		}
+	}
+	return clientErr
+}
+
+// StatusCode returns the status code of the response that caused err, 0 if
+// it isn't an Error.
+func StatusCode(err error) int {
+	var clientErr *Error
+	if errors.As(err, &clientErr) {
+		return clientErr.HttpCode
+	}
+	return 0
+}
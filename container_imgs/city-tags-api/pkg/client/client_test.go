@@ -0,0 +1,332 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"city-tags-api/internal/api_errors"
+	"city-tags-api/internal/server"
+
+	"github.com/go-chi/jwtauth/v5"
+)
+
+// fakeRepository serves the cities 1 to 250, the even ones are cold and the
+// odd ones mild. The methods it doesn't override panic.
+type fakeRepository struct {
+	server.Repository
+}
+
+func fakeTags(cityId int) TagsData {
+	temperature := "mild"
+	if cityId%2 == 0 {
+		temperature = "cold"
+	}
+	return TagsData{CityId: cityId, Temp: temperature, CitySize: "small"}
+}
+
+func (fakeRepository) GetCity(cityId int) (CityData, error) {
+	if cityId < 1 || cityId > 250 {
+		return CityData{}, &api_errors.CityNotFoundErr
+	}
+	return CityData{CityId: cityId, CityName: "City", Continent: "Europe", Country3Code: "ESP"}, nil
+}
+
+func (repo fakeRepository) GetCities(offset int, limit int) ([]CityData, error) {
+	cities := []CityData{}
+	for cityId := offset + 1; cityId <= 250 && len(cities) < limit; cityId++ {
+		cityData, _ := repo.GetCity(cityId)
+		cities = append(cities, cityData)
+	}
+	return cities, nil
+}
+
+func (repo fakeRepository) FilterCities(filter server.CityFilter, offset int, limit int) ([]CityData, error) {
+	cities := []CityData{}
+	for cityId := 1; cityId <= 250 && len(cities) < limit; cityId++ {
+		if fakeTags(cityId).Temp != filter.Tags["temp_tag"] {
+			continue
+		}
+		if offset > 0 {
+			offset--
+			continue
+		}
+		cityData, _ := repo.GetCity(cityId)
+		cities = append(cities, cityData)
+	}
+	return cities, nil
+}
+
+func (repo fakeRepository) GetTags(cityId int) (TagsData, error) {
+	if _, err := repo.GetCity(cityId); err != nil {
+		return TagsData{}, err
+	}
+	return fakeTags(cityId), nil
+}
+
+func (fakeRepository) GetVocabulary() ([]TagCategory, error) {
+	return []TagCategory{{
+		Category: "temperature",
+		Label:    "Temperature",
+		Values:   []server.TagValue{{Value: "cold", Rank: 1, Label: "Cold"}, {Value: "mild", Rank: 2, Label: "Mild"}},
+	}}, nil
+}
+
+func (fakeRepository) GetTranslations(language string) (*server.Translations, error) {
+	return &server.Translations{}, nil
+}
+
+// newTestClient serves the API with the real router behind handler, which
+// wraps it, and returns an authenticated client without backoff.
+func newTestClient(t *testing.T, handler func(next http.Handler) http.Handler) *Client {
+	tokenAuth := jwtauth.New("HS256", []byte("test_enc_key"), nil)
+	api := server.NewApi(nil, fakeRepository{}, tokenAuth, server.DefaultStreamConfig())
+
+	testServer := httptest.NewServer(handler(api.RegisterRoutes()))
+	t.Cleanup(testServer.Close)
+
+	_, token, err := tokenAuth.Encode(map[string]interface{}{"sub": "test_user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return New(testServer.URL, WithToken(token), WithHTTPClient(testServer.Client()), WithRetries(2, time.Millisecond))
+}
+
+func passThrough(next http.Handler) http.Handler {
+	return next
+}
+
+func TestClient_GetCity(t *testing.T) {
+	client := newTestClient(t, passThrough)
+
+	cityData, err := client.GetCity(context.Background(), 7)
+	if err != nil || cityData.CityId != 7 || cityData.CityName != "City" {
+		t.Errorf("GetCity(7) = %v, %v; want city 7", cityData, err)
+	}
+
+	_, err = client.GetCity(context.Background(), 999)
+	var clientErr *Error
+	if !errors.As(err, &clientErr) {
+		t.Fatalf("GetCity(999) returned %v; want an Error", err)
+	}
+	if clientErr.HttpCode != http.StatusNotFound || clientErr.Message != api_errors.CityNotFoundErr.Message {
+		t.Errorf("GetCity(999) returned %v; want %v", clientErr, api_errors.CityNotFoundErr)
+	}
+}
+
+func TestClient_GetTags(t *testing.T) {
+	client := newTestClient(t, passThrough)
+
+	tagsData, err := client.GetTags(context.Background(), 8, TagsQuery{})
+	expected := TagsData{CityId: 8, Temp: "cold", CitySize: "small"}
+	if err != nil || tagsData != expected {
+		t.Errorf("GetTags(8) = %v, %v; want %v", tagsData, err, expected)
+	}
+
+	_, err = client.GetTags(context.Background(), 8, TagsQuery{Month: 13})
+	var clientErr *Error
+	if !errors.As(err, &clientErr) || clientErr.HttpCode != http.StatusBadRequest || clientErr.Errors["month"] == "" {
+		t.Errorf("GetTags(8) of month 13 returned %v; want a bad request of month", err)
+	}
+}
+
+func TestClient_Cities(t *testing.T) {
+	var requests atomic.Int32
+	client := newTestClient(t, func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			next.ServeHTTP(w, r)
+		})
+	})
+
+	tests := []struct {
+		name     string
+		query    CitiesQuery
+		count    int
+		first    int
+		requests int32
+	}{
+		{"every city", CitiesQuery{}, 250, 1, 3},
+		{"offset and page size", CitiesQuery{Offset: 10, PageSize: 60}, 240, 11, 5},
+		{"filtered", CitiesQuery{Tags: map[string]string{"temperature": "cold"}, PageSize: 50}, 125, 2, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests.Store(0)
+			cities, err := client.Cities(context.Background(), tt.query).All()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(cities) != tt.count || cities[0].CityId != tt.first {
+				t.Errorf("Cities(%+v) returned %d cities from %d; want %d from %d", tt.query, len(cities), cities[0].CityId, tt.count, tt.first)
+			}
+			if requests.Load() != tt.requests {
+				t.Errorf("Cities(%+v) sent %d requests; want %d", tt.query, requests.Load(), tt.requests)
+			}
+		})
+	}
+}
+
+func TestClient_CitiesInvalid(t *testing.T) {
+	client := newTestClient(t, passThrough)
+
+	cities := client.Cities(context.Background(), CitiesQuery{Tags: map[string]string{"temperature": "boiling"}})
+	if cities.Next() {
+		t.Fatalf("Cities returned %v; want an error", cities.Value())
+	}
+
+	var clientErr *Error
+	if !errors.As(cities.Err(), &clientErr) {
+		t.Fatalf("Cities returned %v; want an Error", cities.Err())
+	}
+	if _, ok := clientErr.Errors["temperature"]; clientErr.HttpCode != http.StatusBadRequest || !ok {
+		t.Errorf("Cities returned %v; want a bad request of temperature", clientErr)
+	}
+}
+
+func TestClient_Unauthorized(t *testing.T) {
+	authenticated := newTestClient(t, passThrough)
+	client := New(authenticated.endpoint, WithHTTPClient(authenticated.httpClient))
+
+	_, err := client.GetCity(context.Background(), 7)
+	if StatusCode(err) != http.StatusUnauthorized {
+		t.Errorf("GetCity without token returned %v; want %d", err, http.StatusUnauthorized)
+	}
+}
+
+// failing returns a handler that answers the first failures requests with
+// code and the rest with the router.
+func failing(failures int32, code int, requests *atomic.Int32) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if requests.Add(1) <= failures {
+				http.Error(w, http.StatusText(code), code)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func TestClient_retries(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int32
+		code     int
+		status   int
+		requests int32
+	}{
+		{"recovers after 503", 2, http.StatusServiceUnavailable, 0, 3},
+		{"recovers after 429", 1, http.StatusTooManyRequests, 0, 2},
+		{"gives up after the retries", 5, http.StatusBadGateway, http.StatusBadGateway, 3},
+		{"doesn't retry client errors", 1, http.StatusConflict, http.StatusConflict, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			client := newTestClient(t, failing(tt.failures, tt.code, &requests))
+
+			_, err := client.GetCity(context.Background(), 7)
+			if StatusCode(err) != tt.status || (tt.status == 0 && err != nil) {
+				t.Errorf("GetCity(7) returned %v; want status %d", err, tt.status)
+			}
+			if requests.Load() != tt.requests {
+				t.Errorf("GetCity(7) sent %d requests; want %d", requests.Load(), tt.requests)
+			}
+		})
+	}
+}
+
+func TestClient_retriesCanceled(t *testing.T) {
+	var requests atomic.Int32
+	client := newTestClient(t, failing(5, http.StatusServiceUnavailable, &requests))
+	client.retries, client.backoff = 5, time.Minute
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := client.GetCity(ctx, 7)
+	if !errors.Is(err, context.DeadlineExceeded) || requests.Load() != 1 {
+		t.Errorf("GetCity(7) returned %v after %d requests; want the deadline after 1", err, requests.Load())
+	}
+}
+
+func TestClient_delay(t *testing.T) {
+	client := New("", WithRetries(3, time.Second))
+
+	tests := []struct {
+		name       string
+		attempt    int
+		retryAfter string
+		min        time.Duration
+		max        time.Duration
+	}{
+		{"first retry", 0, "", 500 * time.Millisecond, time.Second},
+		{"third retry", 2, "", 2 * time.Second, 4 * time.Second},
+		{"capped", 10, "", maxBackoff / 2, maxBackoff},
+		{"retry after seconds", 0, "3", 3 * time.Second, 3 * time.Second},
+		{"retry after too long", 0, "3600", maxBackoff, maxBackoff},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{Header: http.Header{}}
+			if tt.retryAfter != "" {
+				resp.Header.Set("Retry-After", tt.retryAfter)
+			}
+			delay := client.delay(tt.attempt, resp)
+			if delay < tt.min || delay > tt.max {
+				t.Errorf("delay(%d, %q) = %s; want between %s and %s", tt.attempt, tt.retryAfter, delay, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		expected    *Error
+	}{
+		{
+			"problem details",
+			"application/problem+json",
+			`{"type":"about:blank","title":"Bad Request","status":400,"detail":"Parameters not present or invalid","invalid_params":[{"name":"limit","reason":"Not present or invalid"}]}`,
+			&Error{HttpCode: http.StatusBadRequest, Message: "Parameters not present or invalid", Errors: map[string]string{"limit": "Not present or invalid"}},
+		},
+		{
+			"plain text",
+			"text/plain",
+			"upstream connect error\n",
+			&Error{HttpCode: http.StatusBadRequest, Message: "upstream connect error"},
+		},
+		{
+			"empty body",
+			"",
+			"",
+			&Error{HttpCode: http.StatusBadRequest, Message: "Bad Request"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writer := httptest.NewRecorder()
+			writer.Header().Set("Content-Type", tt.contentType)
+			writer.WriteHeader(http.StatusBadRequest)
+			writer.WriteString(tt.body)
+
+			err := decodeError(writer.Result())
+			if !reflect.DeepEqual(err, tt.expected) {
+				t.Errorf("decodeError(%s) = %v; want %v", tt.body, err, tt.expected)
+			}
+		})
+	}
+}
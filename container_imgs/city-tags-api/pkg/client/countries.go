@@ -0,0 +1,43 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"city-tags-api/internal/server"
+)
+
+// Countries iterates over the countries of continent, or every country if
+// empty, ordered by alpha-3 code.
+func (client *Client) Countries(ctx context.Context, continent string) *Iterator[CountryData] {
+	values := url.Values{}
+	if continent != "" {
+		values.Set("continent", continent)
+	}
+	return paginate(ctx, client, "/countries", values, 0, func(resp server.ListCountriesResp) ([]CountryData, Page) {
+		return resp.Countries, resp.Page
+	})
+}
+
+// GetCountry returns a country by its ISO 3166-1 alpha-2 or alpha-3 code.
+func (client *Client) GetCountry(ctx context.Context, code string) (CountryDetail, error) {
+	countryDetail := CountryDetail{}
+	err := client.do(ctx, http.MethodGet, "/countries/"+url.PathEscape(code), nil, nil, &countryDetail)
+	return countryDetail, err
+}
+
+// CountryCities iterates over the cities of a country matching query, its
+// Country3Code is replaced by the one of code.
+func (client *Client) CountryCities(ctx context.Context, code string, query CitiesQuery) *Iterator[CityData] {
+	values := query.values()
+	values.Del("country_3_code")
+	path := "/countries/" + url.PathEscape(code) + "/cities"
+	return paginate(ctx, client, path, values, query.Offset, citiesPage)
+}
+
+func (client *Client) Continents(ctx context.Context) ([]ContinentData, error) {
+	resp := server.GetContinentsResp{}
+	err := client.do(ctx, http.MethodGet, "/continents", nil, nil, &resp)
+	return resp.Continents, err
+}
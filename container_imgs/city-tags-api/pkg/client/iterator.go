@@ -0,0 +1,86 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Iterator walks through every item of a listing, requesting the next page
+// when the current one is exhausted:
+//
+//	cities := client.Cities(ctx, CitiesQuery{})
+//	for cities.Next() {
+//		city := cities.Value()
+//	}
+//	if err := cities.Err(); err != nil {
+//		...
+//	}
+type Iterator[T any] struct {
+	ctx   context.Context
+	fetch func(ctx context.Context, offset int) ([]T, Page, error)
+	items []T
+	next  *int
+	value T
+	err   error
+}
+
+// Next advances to the next item, it returns false at the end of the listing
+// or when a page fails, see Err.
+func (it *Iterator[T]) Next() bool {
+	for len(it.items) == 0 {
+		if it.err != nil || it.next == nil {
+			return false
+		}
+		items, page, err := it.fetch(it.ctx, *it.next)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.items, it.next = items, page.NextOffset
+	}
+
+	it.value, it.items = it.items[0], it.items[1:]
+	return true
+}
+
+// Value returns the current item.
+func (it *Iterator[T]) Value() T {
+	return it.value
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+// All returns the remaining items of the listing.
+func (it *Iterator[T]) All() ([]T, error) {
+	items := []T{}
+	for it.Next() {
+		items = append(items, it.Value())
+	}
+	return items, it.err
+}
+
+// paginate returns an iterator over the listing at path from offset, page
+// extracts the items and the page of each response.
+func paginate[T any, R any](ctx context.Context, client *Client, path string, query url.Values, offset int, page func(resp R) ([]T, Page)) *Iterator[T] {
+	if query == nil {
+		query = url.Values{}
+	}
+	return &Iterator[T]{
+		ctx:  ctx,
+		next: &offset,
+		fetch: func(ctx context.Context, offset int) ([]T, Page, error) {
+			query.Set("offset", strconv.Itoa(offset))
+			var resp R
+			if err := client.do(ctx, http.MethodGet, path, query, nil, &resp); err != nil {
+				return nil, Page{}, err
+			}
+			items, respPage := page(resp)
+			return items, respPage, nil
+		},
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// maxBodySize is the part of the response body the assertions look at.
+const maxBodySize = 1 << 20
+
+// Result is the outcome of the last attempt of a target.
+type Result struct {
+	Target   string
+	Attempts int
+	Latency  time.Duration
+	Err      error
+}
+
+// check pings target until an attempt succeeds or its retries run out.
+func check(ctx context.Context, httpClient *http.Client, target Target) Result {
+	result := Result{Target: target.Name}
+	backoff := time.Duration(target.Backoff)
+	for attempt := 0; attempt <= target.Retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return result
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+
+		result.Attempts++
+		attemptCtx, cancel := context.WithTimeout(ctx, time.Duration(target.Timeout))
+		start := time.Now()
+		if target.Type == targetPostgres {
+			result.Err = pingPostgres(attemptCtx, target)
+		} else {
+			result.Err = pingHttp(attemptCtx, httpClient, target)
+		}
+		result.Latency = time.Since(start)
+		cancel()
+
+		if result.Err == nil && target.MaxLatency > 0 && result.Latency > time.Duration(target.MaxLatency) {
+			result.Err = fmt.Errorf("latency %s is over %s", result.Latency.Round(time.Millisecond), time.Duration(target.MaxLatency))
+		}
+		if result.Err == nil {
+			return result
+		}
+	}
+	return result
+}
+
+func pingHttp(ctx context.Context, httpClient *http.Client, target Target) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.Url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	if target.Token != "" {
+		req.Header.Add("Authorization", "Bearer "+target.Token)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make GET request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %v", err)
+	}
+	if resp.StatusCode != target.ExpectStatus {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	if target.BodyContains != "" && !strings.Contains(string(body), target.BodyContains) {
+		return fmt.Errorf("response body does not contain %q", target.BodyContains)
+	}
+	return nil
+}
+
+func pingPostgres(ctx context.Context, target Target) error {
+	conn, err := pgx.Connect(ctx, target.Url)
+	if err != nil {
+		return fmt.Errorf("failed to connect: %v", err)
+	}
+	defer conn.Close(context.Background())
+
+	if err := conn.Ping(ctx); err != nil {
+		return fmt.Errorf("failed to ping: %v", err)
+	}
+	return nil
+}
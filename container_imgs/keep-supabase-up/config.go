@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	targetHttp     = "http"
+	targetPostgres = "postgres"
+)
+
+// Duration is a time.Duration read from JSON as a string like "5s".
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("duration must be a string like \"5s\": %w", err)
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// Target is a service pinged by the heartbeat. HTTP targets succeed when the
+// response has the expected status code, contains BodyContains and arrives
+// within MaxLatency, postgres targets when the database answers a ping.
+type Target struct {
+	Name string `json:"name"`
+	// Type is http or postgres, http by default.
+	Type string `json:"type"`
+	// Url is the URL of an http target or the connection string of a postgres
+	// one.
+	Url          string   `json:"url"`
+	Token        string   `json:"token"`
+	ExpectStatus int      `json:"expect_status"`
+	BodyContains string   `json:"body_contains"`
+	MaxLatency   Duration `json:"max_latency"`
+	Timeout      Duration `json:"timeout"`
+	// Retries are the attempts made after the first one fails, waiting Backoff
+	// before the first retry and twice as long before each next one.
+	Retries int      `json:"retries"`
+	Backoff Duration `json:"backoff"`
+}
+
+// Config is read from the JSON file at TARGETS_FILE or from the JSON of
+// TARGETS. Without them, the single HTTP target of ENDPOINT and JWT is pinged.
+type Config struct {
+	Targets []Target `json:"targets"`
+	// WebhookUrl receives a Slack compatible message when a target fails
+	// FailureThreshold runs in a row and when it recovers.
+	WebhookUrl       string `json:"webhook_url"`
+	FailureThreshold int    `json:"failure_threshold"`
+	// StateDatabaseUrl is the connection string of the Postgres database
+	// keeping the consecutive failures of every target between runs. Cloud
+	// Run jobs lose their files after each run, so it is the state to use
+	// there.
+	StateDatabaseUrl string `json:"state_database_url"`
+	// StateFile keeps the consecutive failures in a local file instead, for
+	// the runs on a persistent disk.
+	StateFile string `json:"state_file"`
+}
+
+func loadConfig(getenv func(string) string) (*Config, error) {
+	config := &Config{}
+	switch {
+	case getenv("TARGETS_FILE") != "":
+		data, err := os.ReadFile(getenv("TARGETS_FILE"))
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, config); err != nil {
+			return nil, fmt.Errorf("invalid TARGETS_FILE: %w", err)
+		}
+	case getenv("TARGETS") != "":
+		if err := json.Unmarshal([]byte(getenv("TARGETS")), &config.Targets); err != nil {
+			return nil, fmt.Errorf("invalid TARGETS: %w", err)
+		}
+	case getenv("ENDPOINT") != "":
+		config.Targets = []Target{{Name: "api", Url: getenv("ENDPOINT"), Token: getenv("JWT")}}
+	default:
+		return nil, errors.New("TARGETS_FILE, TARGETS or ENDPOINT environment variable must be set")
+	}
+
+	if value := getenv("WEBHOOK_URL"); value != "" {
+		config.WebhookUrl = value
+	}
+	if value := getenv("FAILURE_THRESHOLD"); value != "" {
+		threshold, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid FAILURE_THRESHOLD %s", value)
+		}
+		config.FailureThreshold = threshold
+	}
+	if value := getenv("STATE_DATABASE_URL"); value != "" {
+		config.StateDatabaseUrl = value
+	}
+	if value := getenv("STATE_FILE"); value != "" {
+		config.StateFile = value
+	}
+	return config, config.validate()
+}
+
+// validate checks the config and sets the defaults.
+func (config *Config) validate() error {
+	if len(config.Targets) == 0 {
+		return errors.New("no target to ping")
+	}
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = 1
+	}
+	// Without a state each failed run counts as the first failure, and a
+	// higher threshold would never be reached.
+	if config.FailureThreshold > 1 && config.StateDatabaseUrl == "" && config.StateFile == "" {
+		return errors.New("a failure threshold above 1 requires STATE_DATABASE_URL or STATE_FILE")
+	}
+
+	names := map[string]bool{}
+	for index := range config.Targets {
+		target := &config.Targets[index]
+		if target.Name == "" {
+			target.Name = fmt.Sprintf("target-%d", index+1)
+		}
+		if names[target.Name] {
+			return fmt.Errorf("duplicated target name %s", target.Name)
+		}
+		names[target.Name] = true
+
+		if target.Type == "" {
+			target.Type = targetHttp
+		}
+		if target.Type != targetHttp && target.Type != targetPostgres {
+			return fmt.Errorf("target %s: unknown type %s, must be %s or %s", target.Name, target.Type, targetHttp, targetPostgres)
+		}
+		if target.Url == "" {
+			return fmt.Errorf("target %s: url is required", target.Name)
+		}
+		if target.Retries < 0 {
+			return fmt.Errorf("target %s: retries must not be negative", target.Name)
+		}
+		if target.ExpectStatus == 0 {
+			target.ExpectStatus = 200
+		}
+		if target.Timeout <= 0 {
+			target.Timeout = Duration(10 * time.Second)
+		}
+		if target.Backoff <= 0 {
+			target.Backoff = Duration(time.Second)
+		}
+	}
+	return nil
+}
@@ -1,56 +1,74 @@
+// Command keep-supabase-up pings the targets of its config, HTTP endpoints and
+// Postgres databases, so that they are not paused for inactivity, and notifies
+// a webhook when a target keeps failing. See Config for its environment.
 package main
 
 import (
-	"fmt"
-	"io"
+	"context"
 	"log"
 	"net/http"
 	"os"
+	"sync"
+	"time"
 )
 
-func main() {
-	endpoint := os.Getenv("ENDPOINT")
-	if endpoint == "" {
-		log.Fatal("ENDPOINT environment variable is not set")
-	}
-
-	token := os.Getenv("JWT")
-	if token == "" {
-		log.Fatal("JWT environment variable is not set")
-	}
+// Exit codes of the job, configuration errors exit with 1.
+const (
+	exitPartialFailure = 2
+	exitTotalFailure   = 3
+)
 
-	err := makeRequest(endpoint, token)
+func main() {
+	config, err := loadConfig(os.Getenv)
 	if err != nil {
-		log.Fatalf("Error making request: %v\n", err)
+		log.Fatal(err)
 	}
-}
-
-func makeRequest(url, token string) error {
-	req, err := http.NewRequest("GET", url, nil)
+	ctx := context.Background()
+	store := newStateStore(config)
+	state, err := store.load(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %v", err)
+		log.Fatalf("Error loading state: %v", err)
 	}
 
-	req.Header.Add("Authorization", "Bearer "+token)
+	httpClient := &http.Client{}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to make GET request: %v", err)
+	results := make([]Result, len(config.Targets))
+	var wg sync.WaitGroup
+	for index, target := range config.Targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[index] = check(ctx, httpClient, target)
+		}()
 	}
-	defer resp.Body.Close()
+	wg.Wait()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-	}
+	failed := 0
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+			log.Printf("%s failed after %d attempts: %v", result.Target, result.Attempts, result.Err)
+		} else {
+			log.Printf("%s is up, %s", result.Target, result.Latency.Round(time.Millisecond))
+		}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read response body: %v", err)
+		message := state.update(result, config.FailureThreshold)
+		if message == "" || config.WebhookUrl == "" {
+			continue
+		}
+		if err := notify(ctx, httpClient, config.WebhookUrl, message); err != nil {
+			log.Printf("Error notifying %s: %v", result.Target, err)
+		}
+	}
+	if err := store.save(ctx, state); err != nil {
+		log.Printf("Error saving state: %v", err)
 	}
 
-	log.Println("Request successful")
-	log.Println("Response body:", string(body))
-
-	return nil
+	switch failed {
+	case 0:
+	case len(results):
+		os.Exit(exitTotalFailure)
+	default:
+		os.Exit(exitPartialFailure)
+	}
 }
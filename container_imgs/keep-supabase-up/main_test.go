@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheck(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"city_id": 1}`))
+	}))
+	defer server.Close()
+
+	target := Target{Name: "api", Url: server.URL, Token: "token", BodyContains: "city_id", Retries: 2}
+	config := &Config{Targets: []Target{target}}
+	if err := config.validate(); err != nil {
+		t.Fatal(err)
+	}
+	target = config.Targets[0]
+	target.Backoff = Duration(time.Millisecond)
+
+	result := check(context.Background(), server.Client(), target)
+	if result.Err != nil || result.Attempts != 3 {
+		t.Errorf("expected success at the third attempt, got %d attempts and %v", result.Attempts, result.Err)
+	}
+
+	calls = 0
+	target.Retries = 1
+	result = check(context.Background(), server.Client(), target)
+	if result.Err == nil || !strings.Contains(result.Err.Error(), "503") {
+		t.Errorf("expected a status code error, got %v", result.Err)
+	}
+
+	target.BodyContains = "country"
+	result = check(context.Background(), server.Client(), target)
+	if result.Err == nil || !strings.Contains(result.Err.Error(), "country") {
+		t.Errorf("expected a body assertion error, got %v", result.Err)
+	}
+}
+
+func TestStateUpdate(t *testing.T) {
+	state := State{}
+	failure := Result{Target: "api", Err: errors.New("unexpected status code: 500")}
+
+	for run, expectMessage := range []bool{false, true, false} {
+		message := state.update(failure, 2)
+		if (message != "") != expectMessage {
+			t.Errorf("run %d: unexpected message %q", run+1, message)
+		}
+	}
+	if message := state.update(Result{Target: "api"}, 2); !strings.Contains(message, "recovered after 3") {
+		t.Errorf("expected a recovery message, got %q", message)
+	}
+	if _, ok := state["api"]; ok {
+		t.Errorf("expected the failures to be reset")
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	env := map[string]string{
+		"TARGETS":            `[{"url": "https://city-tags-api.com/v0/cities", "timeout": "5s"}, {"name": "db", "type": "postgres", "url": "postgres://localhost/db"}]`,
+		"FAILURE_THRESHOLD":  "3",
+		"STATE_DATABASE_URL": "postgres://localhost/state",
+	}
+	config, err := loadConfig(func(key string) string { return env[key] })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.FailureThreshold != 3 || len(config.Targets) != 2 {
+		t.Fatalf("unexpected config %+v", config)
+	}
+	api := config.Targets[0]
+	if api.Name != "target-1" || api.Type != targetHttp || api.Timeout != Duration(5*time.Second) || api.ExpectStatus != 200 {
+		t.Errorf("unexpected defaults %+v", api)
+	}
+
+	delete(env, "STATE_DATABASE_URL")
+	if _, err := loadConfig(func(key string) string { return env[key] }); err == nil {
+		t.Errorf("expected an error for a threshold without state")
+	}
+
+	env["TARGETS"] = `[{"type": "ftp", "url": "ftp://localhost"}]`
+	if _, err := loadConfig(func(key string) string { return env[key] }); err == nil {
+		t.Errorf("expected an error for an unknown type")
+	}
+}
+
+func TestFileState(t *testing.T) {
+	store := fileState(filepath.Join(t.TempDir(), "state.json"))
+	state, err := store.load(context.Background())
+	if err != nil || len(state) != 0 {
+		t.Fatalf("expected an empty state, got %v %v", state, err)
+	}
+
+	if err := store.save(context.Background(), State{"api": 2}); err != nil {
+		t.Fatal(err)
+	}
+	if state, err := store.load(context.Background()); err != nil || state["api"] != 2 {
+		t.Errorf("expected the saved state, got %v %v", state, err)
+	}
+}
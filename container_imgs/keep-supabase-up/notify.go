@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// State are the consecutive failed runs of every target.
+type State map[string]int
+
+// stateStore keeps the State between runs.
+type stateStore interface {
+	load(ctx context.Context) (State, error)
+	save(ctx context.Context, state State) error
+}
+
+func newStateStore(config *Config) stateStore {
+	switch {
+	case config.StateDatabaseUrl != "":
+		return postgresState(config.StateDatabaseUrl)
+	case config.StateFile != "":
+		return fileState(config.StateFile)
+	default:
+		return noState{}
+	}
+}
+
+// noState forgets the failures after each run.
+type noState struct{}
+
+func (noState) load(ctx context.Context) (State, error) {
+	return State{}, nil
+}
+
+func (noState) save(ctx context.Context, state State) error {
+	return nil
+}
+
+// fileState is the path of a JSON file.
+type fileState string
+
+func (path fileState) load(ctx context.Context) (State, error) {
+	state := State{}
+	data, err := os.ReadFile(string(path))
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return state, json.Unmarshal(data, &state)
+}
+
+func (path fileState) save(ctx context.Context, state State) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(string(path), data, 0o644)
+}
+
+// postgresState is the connection string of a database keeping the failures
+// in the keep_supabase_up_state table, created on the first run.
+type postgresState string
+
+func (url postgresState) connect(ctx context.Context) (*pgx.Conn, error) {
+	conn, err := pgx.Connect(ctx, string(url))
+	if err != nil {
+		return nil, err
+	}
+	_, err = conn.Exec(ctx, `create table if not exists keep_supabase_up_state (
+		target text primary key,
+		failures int not null
+	)`)
+	if err != nil {
+		conn.Close(ctx)
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (url postgresState) load(ctx context.Context) (State, error) {
+	conn, err := url.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close(ctx)
+
+	rows, err := conn.Query(ctx, "select target, failures from keep_supabase_up_state")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	state := State{}
+	for rows.Next() {
+		var target string
+		var failures int
+		if err := rows.Scan(&target, &failures); err != nil {
+			return nil, err
+		}
+		state[target] = failures
+	}
+	return state, rows.Err()
+}
+
+func (url postgresState) save(ctx context.Context, state State) error {
+	conn, err := url.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(ctx)
+
+	return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, "delete from keep_supabase_up_state"); err != nil {
+			return err
+		}
+		for target, failures := range state {
+			_, err := tx.Exec(ctx, "insert into keep_supabase_up_state (target, failures) values ($1, $2)", target, failures)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// update counts the result in the failures of its target and returns the
+// message to notify, empty when the target neither reaches the threshold nor
+// recovers after reaching it.
+func (state State) update(result Result, threshold int) string {
+	failures := state[result.Target]
+	if result.Err == nil {
+		delete(state, result.Target)
+		if failures >= threshold {
+			return fmt.Sprintf(":white_check_mark: %s recovered after %d failed runs", result.Target, failures)
+		}
+		return ""
+	}
+
+	failures++
+	state[result.Target] = failures
+	if failures == threshold {
+		return fmt.Sprintf(":rotating_light: %s failed %d runs in a row: %v", result.Target, failures, result.Err)
+	}
+	return ""
+}
+
+// notify posts the message as the text of a Slack compatible JSON payload.
+func notify(ctx context.Context, httpClient *http.Client, webhookUrl, message string) error {
+	payload, err := json.Marshal(map[string]string{"text": message})
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookUrl, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post webhook: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected webhook status code: %d", resp.StatusCode)
+	}
+	return nil
+}
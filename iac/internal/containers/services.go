@@ -110,9 +110,7 @@ func (service *service) createService(sa *serviceaccount.Account) {
 							Envs:     service.parseEnvs(),
 							Commands: pulumi.ToStringArray(service.cfg.Entrypoint),
 							Ports: cloudrun.ServiceTemplateSpecContainerPortArray{
-								&cloudrun.ServiceTemplateSpecContainerPortArgs{
-									ContainerPort: pulumi.Int(service.cfg.ContainerPort),
-								},
+								service.parsePort(),
 							},
 							Resources: &cloudrun.ServiceTemplateSpecContainerResourcesArgs{
 								Limits: pulumi.StringMap{
@@ -133,10 +131,7 @@ func (service *service) createService(sa *serviceaccount.Account) {
 					},
 				},
 				Metadata: &cloudrun.ServiceTemplateMetadataArgs{
-					Annotations: pulumi.StringMap{
-						"autoscaling.knative.dev/maxScale": pulumi.Sprintf("%d", service.cfg.MaxCount),
-						"autoscaling.knative.dev/minScale": pulumi.Sprintf("%d", service.cfg.MinCount),
-					},
+					Annotations: service.parseAnnotations(),
 				},
 			},
 		},
@@ -232,6 +227,29 @@ func (service *service) createDNSRecords(name string, ipAddress pulumi.StringOut
 	}
 }
 
+func (service *service) parseAnnotations() pulumi.StringMap {
+	annotations := pulumi.StringMap{
+		"autoscaling.knative.dev/maxScale": pulumi.Sprintf("%d", service.cfg.MaxCount),
+		"autoscaling.knative.dev/minScale": pulumi.Sprintf("%d", service.cfg.MinCount),
+	}
+	// Background work between requests needs the CPU, which is otherwise
+	// throttled outside of them.
+	if service.cfg.AlwaysOnCpu {
+		annotations["run.googleapis.com/cpu-throttling"] = pulumi.String("false")
+	}
+	return annotations
+}
+
+func (service *service) parsePort() *cloudrun.ServiceTemplateSpecContainerPortArgs {
+	port := &cloudrun.ServiceTemplateSpecContainerPortArgs{
+		ContainerPort: pulumi.Int(service.cfg.ContainerPort),
+	}
+	if service.cfg.PortName != "" {
+		port.Name = pulumi.String(service.cfg.PortName)
+	}
+	return port
+}
+
 func (service *service) parseEnvs() cloudrun.ServiceTemplateSpecContainerEnvArray {
 	var envs cloudrun.ServiceTemplateSpecContainerEnvArray
 	for _, env := range service.cfg.EnvVars {
@@ -9,8 +9,10 @@ type ServiceCfg struct {
 	Memory        string   `json:"memory"`
 	MinCount      int      `json:"min_count"`
 	MaxCount      int      `json:"max_count"`
+	AlwaysOnCpu   bool     `json:"always_on_cpu"`
 	LbPort        int      `json:"lb_port"`
 	ContainerPort int      `json:"container_port"`
+	PortName      string   `json:"port_name"`
 	EnvVars       []EnvVar `json:"env_vars"`
 	Entrypoint    []string `json:"entrypoint"`
 }